@@ -0,0 +1,103 @@
+// Perintah migrasi file upload antar backend storage.
+//
+// Contoh (dari root project, .env dibaca otomatis):
+//
+//	go run ./cmd/migrasistorage -dari local -ke s3
+//	go run ./cmd/migrasistorage -dari s3 -ke local -prefix tugas/ -dry-run
+//
+// Key file (misal tugas/<nama file>) sama di semua backend, sehingga URL /uploads/... di database
+// tidak perlu diubah. Setelah selesai, ganti STORAGE_BACKEND dan restart server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+
+	"Pasti/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dari := flag.String("dari", "local", "backend sumber (local|s3)")
+	ke := flag.String("ke", "s3", "backend tujuan (local|s3)")
+	prefix := flag.String("prefix", "", "hanya salin key dengan awalan ini (kosong = semua key: tugas/, karantina/, thumb/, profil/)")
+	timpa := flag.Bool("timpa", false, "salin ulang meskipun file dengan ukuran sama sudah ada di tujuan")
+	dryRun := flag.Bool("dry-run", false, "hanya tampilkan file yang akan disalin")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("⚠️ .env tidak ditemukan, memakai environment yang ada")
+	}
+
+	if *dari == *ke {
+		log.Fatalf("❌ Backend sumber dan tujuan tidak boleh sama")
+	}
+	sumber, err := storage.NewFromEnv(*dari)
+	if err != nil {
+		log.Fatalf("❌ Storage sumber: %v", err)
+	}
+	tujuan, err := storage.NewFromEnv(*ke)
+	if err != nil {
+		log.Fatalf("❌ Storage tujuan: %v", err)
+	}
+
+	ctx := context.Background()
+	var disalin, dilewati, gagal int
+	var totalByte int64
+
+	err = sumber.List(ctx, *prefix, func(info storage.Info) error {
+		if !*timpa {
+			if ada, err := tujuan.Stat(ctx, info.Key); err == nil && ada.Size == info.Size {
+				dilewati++
+				return nil
+			} else if err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("❌ %s: gagal cek tujuan: %v", info.Key, err)
+				gagal++
+				return nil
+			}
+		}
+
+		if *dryRun {
+			log.Printf("📄 %s (%d bytes)", info.Key, info.Size)
+			disalin++
+			totalByte += info.Size
+			return nil
+		}
+
+		if err := salin(ctx, sumber, tujuan, info); err != nil {
+			log.Printf("❌ %s: %v", info.Key, err)
+			gagal++
+			return nil
+		}
+		log.Printf("✅ %s (%d bytes)", info.Key, info.Size)
+		disalin++
+		totalByte += info.Size
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("❌ Gagal membaca daftar file sumber: %v", err)
+	}
+
+	aksi := "Disalin"
+	if *dryRun {
+		aksi = "Akan disalin"
+	}
+	log.Printf("🎉 Selesai. %s: %d file (%d bytes), dilewati: %d, gagal: %d", aksi, disalin, totalByte, dilewati, gagal)
+	if gagal > 0 {
+		log.Fatalf("❌ Sebagian file gagal disalin, jalankan ulang perintah untuk mencoba lagi")
+	}
+}
+
+// salin - Stream satu file dari sumber ke tujuan
+func salin(ctx context.Context, sumber, tujuan storage.Storage, info storage.Info) error {
+	file, detail, err := sumber.Get(ctx, info.Key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tujuan.Put(ctx, info.Key, file, detail.Size, detail.ContentType)
+}
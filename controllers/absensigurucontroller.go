@@ -8,6 +8,9 @@ import (
 	"strconv"
 )
 
+// queryPertemuanPengganti - Pertemuan jadwal_mengajar_guru yang digantikan guru (disetujui)
+const queryPertemuanPengganti = `select 1 from pertemuan p where p.id_jadwal = jadwal_mengajar_guru.jadwal_id and p.guru_pengganti_id = ? and p.pengganti_disetujui_pada is not null`
+
 func GetDaftarMengajar(w http.ResponseWriter, r *http.Request) {
 	guruInfo := r.Context().Value("guruinfo")
 	if guruInfo == nil {
@@ -21,9 +24,10 @@ func GetDaftarMengajar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Termasuk jadwal yang salah satu pertemuannya digantikan guru ini dan sudah disetujui
 	var dataMengajar []models.JadwalGuru
-	query  := `select * from jadwal_mengajar_guru where guru_id = ?`
-	if err := config.DB.Raw(query, guru.ID).Scan(&dataMengajar).Error; err != nil {
+	query  := `select * from jadwal_mengajar_guru where guru_id = ? or exists (` + queryPertemuanPengganti + `)`
+	if err := config.DB.Raw(query, guru.ID, guru.ID).Scan(&dataMengajar).Error; err != nil {
 		helpers.Response(w, 500, "Gagal mengambil data", nil)
 		return
 	}
@@ -66,20 +70,26 @@ func GetAbsensiSiswaPertemuan(w http.ResponseWriter, r *http.Request) {
 		Where("jadwal_id = ? AND guru_id = ?", jadwalID, guru.ID).
 		Count(&count)
 
-	if count == 0 {
-		helpers.Response(w, 404, "Jadwal tidak ditemukan atau tidak sesuai dengan guru", nil)
-		return
-	}
-
 	var data []models.ResponseAbsensiSiswa
 
 	query := `SELECT * FROM jadwal_pertemuan_guru_pelajaran WHERE jadwal_id = ?`
+	args := []interface{}{jadwalID}
+	if count == 0 {
+		// Guru pengganti hanya melihat pertemuan yang digantikannya
+		query += ` AND id_pertemuan IN (SELECT id_pertemuan FROM pertemuan WHERE id_jadwal = ? AND guru_pengganti_id = ? AND pengganti_disetujui_pada IS NOT NULL)`
+		args = append(args, jadwalID, guru.ID)
+	}
 
-	if err := config.DB.Raw(query, jadwalID).Scan(&data).Error; err != nil {
+	if err := config.DB.Raw(query, args...).Scan(&data).Error; err != nil {
 		helpers.Response(w, 500, "Gagal mengambil data absensi siswa", nil)
 		return
 	}
 
+	if count == 0 && len(data) == 0 {
+		helpers.Response(w, 404, "Jadwal tidak ditemukan atau tidak sesuai dengan guru", nil)
+		return
+	}
+
 	helpers.Response(w, 200, "Daftar Absensi Siswa Per Pertemuan", data)
 
 }
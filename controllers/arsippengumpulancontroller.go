@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+	"Pasti/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// karakterNamaArsip - Karakter yang tidak aman untuk nama file di dalam ZIP
+var karakterNamaArsip = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// DownloadArsipPengumpulan - Stream ZIP berisi seluruh file jawaban siswa untuk sebuah tugas
+// beserta manifest.csv (siapa yang mengumpulkan, kapan, terlambat atau belum mengumpulkan).
+// ZIP ditulis langsung ke response tanpa file sementara di disk
+func DownloadArsipPengumpulan(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	var siswaList []models.Siswa
+	if err := config.DB.Where("kelas_id = ?", tugas.JadwalPelajaran.KelasID).
+		Order("nama_lengkap ASC").
+		Find(&siswaList).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch siswa list", nil)
+		return
+	}
+
+	var pengumpulanList []models.PengumpulanTugas
+	if err := config.DB.Where("tugas_id = ?", tugasID).Find(&pengumpulanList).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch pengumpulan", nil)
+		return
+	}
+	pengumpulanMap := make(map[int]models.PengumpulanTugas)
+	kunciList := []string{}
+	for _, p := range pengumpulanList {
+		pengumpulanMap[p.SiswaID] = p
+		if p.FileJawabanSiswa != "" {
+			kunciList = append(kunciList, kunciFileTugas(p.FileJawabanSiswa))
+		}
+	}
+
+	// Nama asli file dari registry upload; file lama tanpa catatan memakai nama file di storage
+	namaAsli := make(map[string]string)
+	if len(kunciList) > 0 {
+		var uploads []models.FileUpload
+		config.DB.Select("kunci, nama_asli").Where("kunci IN ?", kunciList).Find(&uploads)
+		for _, u := range uploads {
+			namaAsli[u.Kunci] = u.NamaAsli
+		}
+	}
+
+	filename := fmt.Sprintf("pengumpulan_tugas_%d_%s.zip", tugas.TugasID, time.Now().Format("20060102_150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	archive := zip.NewWriter(w)
+	defer archive.Close()
+
+	// Baris manifest dikumpulkan selama file ditulis, manifest ditulis terakhir
+	manifest := [][]string{{"nis", "nama_lengkap", "status_pengumpulan", "tanggal_pengumpulan", "deadline_efektif", "terlambat", "file_dalam_arsip", "keterangan"}}
+	// File kelompok dipakai bersama anggota, cukup dimasukkan sekali
+	fileTertulis := make(map[string]string)
+
+	for _, siswa := range siswaList {
+		deadline, _ := batasPengumpulanSiswa(tugas, siswa.SiswaID)
+		pengumpulan, exists := pengumpulanMap[siswa.SiswaID]
+		if !exists {
+			manifest = append(manifest, []string{siswa.NIS, siswa.NamaLengkap, "Belum Mengerjakan", "", deadline.Format("2006-01-02 15:04"), "", "", "Belum mengumpulkan"})
+			continue
+		}
+
+		terlambat := "tidak"
+		if pengumpulan.TanggalPengumpulan.After(deadline) {
+			terlambat = "ya"
+		}
+
+		namaArsip, keterangan := "", ""
+		if pengumpulan.FileJawabanSiswa == "" {
+			keterangan = "Tidak ada file"
+		} else if tertulis, ada := fileTertulis[pengumpulan.FileJawabanSiswa]; ada {
+			namaArsip, keterangan = tertulis, "File kelompok"
+		} else {
+			namaArsip = namaFileArsip(siswa, pengumpulan.FileJawabanSiswa, namaAsli[kunciFileTugas(pengumpulan.FileJawabanSiswa)])
+			if err := tambahFileKeArsip(r.Context(), archive, pengumpulan.FileJawabanSiswa, namaArsip); err != nil {
+				log.Printf("❌ Failed to add %s to archive: %v", pengumpulan.FileJawabanSiswa, err)
+				namaArsip, keterangan = "", "File tidak ditemukan di server"
+			} else {
+				fileTertulis[pengumpulan.FileJawabanSiswa] = namaArsip
+			}
+		}
+
+		manifest = append(manifest, []string{
+			siswa.NIS,
+			siswa.NamaLengkap,
+			pengumpulan.StatusPengumpulan,
+			pengumpulan.TanggalPengumpulan.Format("2006-01-02 15:04:05"),
+			deadline.Format("2006-01-02 15:04"),
+			terlambat,
+			namaArsip,
+			keterangan,
+		})
+	}
+
+	manifestWriter, err := archive.Create("manifest.csv")
+	if err != nil {
+		log.Printf("❌ Failed to create manifest: %v", err)
+		return
+	}
+	writer := csv.NewWriter(manifestWriter)
+	writer.WriteAll(manifest)
+}
+
+// namaFileArsip - Nama file di dalam ZIP: NIS_Nama_namafileasli.ext. Setiap bagian dibersihkan
+// terpisah sehingga pemisah path di nama siswa atau nama file tidak membuat folder di dalam ZIP
+func namaFileArsip(siswa models.Siswa, fileURL, namaAsli string) string {
+	// Nama dari browser lama bisa berisi path lengkap (C:\fakepath\jawaban.pdf)
+	namaAsli = path.Base(strings.ReplaceAll(namaAsli, "\\", "/"))
+	if namaAsli == "." || namaAsli == "/" {
+		namaAsli = path.Base(fileURL)
+	}
+	bagian := []string{siswa.NIS, siswa.NamaLengkap, namaAsli}
+	for i, b := range bagian {
+		bagian[i] = strings.Trim(karakterNamaArsip.ReplaceAllString(b, "_"), "_")
+	}
+	return strings.Join(bagian, "_")
+}
+
+// tambahFileKeArsip - Menyalin file jawaban dari storage ke dalam ZIP
+func tambahFileKeArsip(ctx context.Context, archive *zip.Writer, fileURL, namaArsip string) error {
+	file, info, err := storage.Default().Get(ctx, kunciFileTugas(fileURL))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header := &zip.FileHeader{
+		Name:   namaArsip,
+		Method: zip.Deflate,
+	}
+	header.Modified = info.ModTime
+
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(entry, file)
+	return err
+}
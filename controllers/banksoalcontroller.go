@@ -0,0 +1,525 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// bankSoalInput - Format JSON soal bank (juga format import/export JSON)
+type bankSoalInput struct {
+	soalKuisInput
+	Topik            string `json:"topik"`
+	TingkatKesulitan string `json:"tingkat_kesulitan"`
+	LevelBloom       string `json:"level_bloom"`
+}
+
+// aturanSampel - Ambil sejumlah soal acak dari bank yang cocok dengan tag
+type aturanSampel struct {
+	Topik            string `json:"topik"`
+	TingkatKesulitan string `json:"tingkat_kesulitan"`
+	LevelBloom       string `json:"level_bloom"`
+	Jumlah           int    `json:"jumlah"`
+}
+
+var tingkatKesulitanValid = map[string]bool{"": true, "Mudah": true, "Sedang": true, "Sulit": true}
+
+var levelBloomValid = map[string]bool{"": true, "C1": true, "C2": true, "C3": true, "C4": true, "C5": true, "C6": true}
+
+// guruMengajarMapel - Bank soal hanya dapat diakses guru yang mengampu mapel tersebut
+func guruMengajarMapel(guruID, mapelID int) bool {
+	var jumlah int64
+	config.DB.Model(&models.JadwalPelajaran{}).
+		Where("guru_id = ? AND mapel_id = ?", guruID, mapelID).
+		Count(&jumlah)
+	return jumlah > 0
+}
+
+// buatBankSoal - Validasi input (aturan sama dengan soal kuis) dan mengubahnya menjadi model bank soal
+func buatBankSoal(mapelID, guruID int, input []bankSoalInput) ([]models.BankSoal, error) {
+	soalInput := make([]soalKuisInput, len(input))
+	for i, in := range input {
+		if !tingkatKesulitanValid[in.TingkatKesulitan] {
+			return nil, fmt.Errorf("soal %d: tingkat kesulitan harus Mudah, Sedang atau Sulit", i+1)
+		}
+		if !levelBloomValid[in.LevelBloom] {
+			return nil, fmt.Errorf("soal %d: level bloom harus C1 sampai C6", i+1)
+		}
+		soalInput[i] = in.soalKuisInput
+	}
+
+	soalList, _, err := buatSoalKuis(soalInput)
+	if err != nil {
+		return nil, err
+	}
+
+	bankList := make([]models.BankSoal, len(soalList))
+	for i, soal := range soalList {
+		bank := models.BankSoal{
+			MapelID:          mapelID,
+			GuruID:           guruID,
+			TipeSoal:         soal.TipeSoal,
+			Pertanyaan:       soal.Pertanyaan,
+			Poin:             soal.Poin,
+			Topik:            strings.TrimSpace(input[i].Topik),
+			TingkatKesulitan: input[i].TingkatKesulitan,
+			LevelBloom:       input[i].LevelBloom,
+		}
+		for _, opsi := range soal.Opsi {
+			bank.Opsi = append(bank.Opsi, models.OpsiBankSoal{Teks: opsi.Teks, IsBenar: opsi.IsBenar, Urutan: opsi.Urutan})
+		}
+		bankList[i] = bank
+	}
+
+	return bankList, nil
+}
+
+// inputDariBankSoal - Kebalikan buatBankSoal, dipakai untuk export dan menyalin soal ke kuis
+func inputDariBankSoal(bank models.BankSoal) bankSoalInput {
+	in := bankSoalInput{
+		soalKuisInput:    soalKuisInput{TipeSoal: bank.TipeSoal, Pertanyaan: bank.Pertanyaan, Poin: bank.Poin},
+		Topik:            bank.Topik,
+		TingkatKesulitan: bank.TingkatKesulitan,
+		LevelBloom:       bank.LevelBloom,
+	}
+
+	switch bank.TipeSoal {
+	case "BenarSalah":
+		benar := false
+		for _, opsi := range bank.Opsi {
+			if opsi.Teks == "Benar" && opsi.IsBenar {
+				benar = true
+			}
+		}
+		in.JawabanBenar = &benar
+	case "IsianSingkat":
+		for _, opsi := range bank.Opsi {
+			in.JawabanDiterima = append(in.JawabanDiterima, opsi.Teks)
+		}
+	default:
+		for _, opsi := range bank.Opsi {
+			in.Opsi = append(in.Opsi, opsiSoalInput{Teks: opsi.Teks, IsBenar: opsi.IsBenar})
+		}
+	}
+
+	return in
+}
+
+// queryBankSoal - Query bank soal sebuah mapel dengan filter tag opsional
+func queryBankSoal(mapelID int, topik, tingkatKesulitan, levelBloom string) *gorm.DB {
+	query := config.DB.Model(&models.BankSoal{}).Where("mapel_id = ?", mapelID)
+	if topik != "" {
+		query = query.Where("topik = ?", topik)
+	}
+	if tingkatKesulitan != "" {
+		query = query.Where("tingkat_kesulitan = ?", tingkatKesulitan)
+	}
+	if levelBloom != "" {
+		query = query.Where("level_bloom = ?", levelBloom)
+	}
+	return query
+}
+
+// sampelBankSoal - Mengambil N soal acak per aturan tag; soal yang sama tidak terambil dua kali
+func sampelBankSoal(mapelID int, aturan []aturanSampel) ([]soalKuisInput, error) {
+	var hasil []soalKuisInput
+	terpakai := []int{0}
+
+	for i, a := range aturan {
+		if a.Jumlah <= 0 {
+			return nil, fmt.Errorf("aturan sampel %d: jumlah harus lebih dari 0", i+1)
+		}
+
+		var bankList []models.BankSoal
+		if err := queryBankSoal(mapelID, a.Topik, a.TingkatKesulitan, a.LevelBloom).
+			Preload("Opsi", func(db *gorm.DB) *gorm.DB {
+				return db.Order("urutan ASC, opsi_bank_id ASC")
+			}).
+			Where("bank_soal_id NOT IN ?", terpakai).
+			Order("RAND()").
+			Limit(a.Jumlah).
+			Find(&bankList).Error; err != nil {
+			return nil, err
+		}
+
+		if len(bankList) < a.Jumlah {
+			return nil, fmt.Errorf("aturan sampel %d: hanya tersedia %d soal (topik '%s', kesulitan '%s', bloom '%s')",
+				i+1, len(bankList), a.Topik, a.TingkatKesulitan, a.LevelBloom)
+		}
+
+		for _, bank := range bankList {
+			terpakai = append(terpakai, bank.BankSoalID)
+			hasil = append(hasil, inputDariBankSoal(bank).soalKuisInput)
+		}
+	}
+
+	return hasil, nil
+}
+
+// GetBankSoal - Daftar soal bank sebuah mapel, dapat difilter topik/tingkat_kesulitan/level_bloom
+func GetBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	mapelID, err := strconv.Atoi(vars["mapel_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid mapel ID", nil)
+		return
+	}
+
+	if !guruMengajarMapel(guru.ID, mapelID) {
+		helpers.Response(w, 403, "Anda tidak mengampu mata pelajaran ini", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	var bankList []models.BankSoal
+	if err := queryBankSoal(mapelID, q.Get("topik"), q.Get("tingkat_kesulitan"), q.Get("level_bloom")).
+		Preload("Opsi", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, opsi_bank_id ASC")
+		}).
+		Order("topik ASC, bank_soal_id ASC").
+		Find(&bankList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	// Ringkasan jumlah soal per tag untuk membantu menyusun aturan sampel
+	var ringkasan []struct {
+		Topik            string `json:"topik"`
+		TingkatKesulitan string `json:"tingkat_kesulitan"`
+		LevelBloom       string `json:"level_bloom"`
+		Jumlah           int    `json:"jumlah"`
+	}
+	config.DB.Model(&models.BankSoal{}).
+		Select("COALESCE(topik, '') AS topik, COALESCE(tingkat_kesulitan, '') AS tingkat_kesulitan, COALESCE(level_bloom, '') AS level_bloom, COUNT(*) AS jumlah").
+		Where("mapel_id = ?", mapelID).
+		Group("topik, tingkat_kesulitan, level_bloom").
+		Order("topik ASC").
+		Scan(&ringkasan)
+
+	helpers.Response(w, 200, "Bank soal berhasil diambil", map[string]interface{}{
+		"soal":      bankList,
+		"ringkasan": ringkasan,
+	})
+}
+
+// CreateBankSoal - Menambah satu atau beberapa soal ke bank mapel
+func CreateBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	mapelID, err := strconv.Atoi(vars["mapel_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid mapel ID", nil)
+		return
+	}
+
+	var input struct {
+		Soal []bankSoalInput `json:"soal"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if !guruMengajarMapel(guru.ID, mapelID) {
+		helpers.Response(w, 403, "Anda tidak mengampu mata pelajaran ini", nil)
+		return
+	}
+
+	bankList, err := buatBankSoal(mapelID, guru.ID, input.Soal)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	if err := config.DB.Create(&bankList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 201, fmt.Sprintf("%d soal berhasil ditambahkan ke bank soal", len(bankList)), bankList)
+}
+
+// UpdateBankSoal - Mengubah soal bank (hanya pembuat soal)
+func UpdateBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bankSoalID, err := strconv.Atoi(vars["bank_soal_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid bank soal ID", nil)
+		return
+	}
+
+	var input bankSoalInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	var lama models.BankSoal
+	if err := config.DB.Where("bank_soal_id = ? AND guru_id = ?", bankSoalID, guru.ID).First(&lama).Error; err != nil {
+		helpers.Response(w, 404, "Soal tidak ditemukan atau bukan buatan Anda", nil)
+		return
+	}
+
+	bankList, err := buatBankSoal(lama.MapelID, guru.ID, []bankSoalInput{input})
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+	bank := bankList[0]
+	bank.BankSoalID = lama.BankSoalID
+	bank.CreatedAt = lama.CreatedAt
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bank_soal_id = ?", bank.BankSoalID).Delete(&models.OpsiBankSoal{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&bank).Error
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Soal berhasil diperbarui", bank)
+}
+
+// DeleteBankSoal - Menghapus soal bank (hanya pembuat soal). Kuis yang sudah memakai soal tidak terpengaruh
+func DeleteBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bankSoalID, err := strconv.Atoi(vars["bank_soal_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid bank soal ID", nil)
+		return
+	}
+
+	result := config.DB.Where("bank_soal_id = ? AND guru_id = ?", bankSoalID, guru.ID).Delete(&models.BankSoal{})
+	if result.Error != nil {
+		helpers.Response(w, 500, "Database error: "+result.Error.Error(), nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		helpers.Response(w, 404, "Soal tidak ditemukan atau bukan buatan Anda", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Soal berhasil dihapus", nil)
+}
+
+// ExportBankSoal - Export bank soal mapel dalam format json (default), gift atau xml (Moodle XML)
+func ExportBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	mapelID, err := strconv.Atoi(vars["mapel_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid mapel ID", nil)
+		return
+	}
+
+	if !guruMengajarMapel(guru.ID, mapelID) {
+		helpers.Response(w, 403, "Anda tidak mengampu mata pelajaran ini", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	var bankList []models.BankSoal
+	if err := queryBankSoal(mapelID, q.Get("topik"), q.Get("tingkat_kesulitan"), q.Get("level_bloom")).
+		Preload("Opsi", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, opsi_bank_id ASC")
+		}).
+		Order("topik ASC, bank_soal_id ASC").
+		Find(&bankList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	soalList := make([]bankSoalInput, len(bankList))
+	for i, bank := range bankList {
+		soalList[i] = inputDariBankSoal(bank)
+	}
+
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	var body []byte
+	var contentType string
+	switch format {
+	case "json":
+		body, err = json.MarshalIndent(map[string]interface{}{"soal": soalList}, "", "  ")
+		contentType = "application/json"
+	case "gift":
+		body, err = []byte(tulisGIFT(soalList)), nil
+		contentType = "text/plain; charset=utf-8"
+	case "xml":
+		body, err = tulisMoodleXML(soalList)
+		contentType = "application/xml"
+	default:
+		helpers.Response(w, 400, "Format harus json, gift atau xml", nil)
+		return
+	}
+	if err != nil {
+		helpers.Response(w, 500, "Gagal membuat file export: "+err.Error(), nil)
+		return
+	}
+
+	ekstensi := map[string]string{"json": "json", "gift": "gift.txt", "xml": "xml"}[format]
+	filename := fmt.Sprintf("bank_soal_mapel_%d_%s.%s", mapelID, time.Now().Format("20060102"), ekstensi)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Write(body)
+}
+
+// ImportBankSoal - Import soal ke bank mapel dari json, gift atau xml (Moodle XML).
+// Isi dapat dikirim sebagai body langsung atau file multipart "file"; semua soal disimpan atau tidak sama sekali
+func ImportBankSoal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	mapelID, err := strconv.Atoi(vars["mapel_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid mapel ID", nil)
+		return
+	}
+
+	if !guruMengajarMapel(guru.ID, mapelID) {
+		helpers.Response(w, 403, "Anda tidak mengampu mata pelajaran ini", nil)
+		return
+	}
+
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			helpers.Response(w, 400, "Failed to parse form data", nil)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			helpers.Response(w, 400, "No file uploaded", nil)
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, 10<<20))
+	if err != nil {
+		helpers.Response(w, 400, "Gagal membaca file import", nil)
+		return
+	}
+
+	var soalList []bankSoalInput
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		var wrapper struct {
+			Soal []bankSoalInput `json:"soal"`
+		}
+		err = json.Unmarshal(data, &wrapper)
+		soalList = wrapper.Soal
+	case "gift":
+		soalList, err = bacaGIFT(string(data))
+	case "xml":
+		soalList, err = bacaMoodleXML(data)
+	default:
+		helpers.Response(w, 400, "Format harus json, gift atau xml", nil)
+		return
+	}
+	if err != nil {
+		helpers.Response(w, 400, "Format file tidak valid: "+err.Error(), nil)
+		return
+	}
+
+	bankList, err := buatBankSoal(mapelID, guru.ID, soalList)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	if err := config.DB.Create(&bankList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 201, fmt.Sprintf("%d soal berhasil diimpor", len(bankList)), map[string]interface{}{
+		"total_imported": len(bankList),
+	})
+}
@@ -0,0 +1,453 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format import/export bank soal. Dokumentasi lengkap ada di BANK_SOAL_DOCS.md.
+// Tag bank soal (topik, tingkat kesulitan, level bloom) dan poin disimpan sebagai tag Moodle
+// berbentuk "kunci=nilai": topik=..., kesulitan=..., bloom=..., poin=...
+
+// ==================== GIFT ====================
+
+// polaTagGIFT - Tag Moodle di komentar GIFT: // [tag:topik=Aljabar]
+var polaTagGIFT = regexp.MustCompile(`\[tag:([^\]]+)\]`)
+
+// polaBobotGIFT - Bobot jawaban GIFT: ~%50%jawaban
+var polaBobotGIFT = regexp.MustCompile(`^%(-?[0-9.]+)%`)
+
+// escapeGIFT - Karakter khusus GIFT di-escape dengan backslash
+func escapeGIFT(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `~`, `\~`, `=`, `\=`, `#`, `\#`, `{`, `\{`, `}`, `\}`, `:`, `\:`, "\n", `\n`).Replace(s)
+}
+
+// unescapeGIFT - Kebalikan escapeGIFT
+func unescapeGIFT(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\~`, `~`, `\=`, `=`, `\#`, `#`, `\{`, `{`, `\}`, `}`, `\:`, `:`, `\n`, "\n").Replace(s)
+}
+
+// tagBankSoal - Tag kunci=nilai untuk GIFT dan Moodle XML
+func tagBankSoal(soal bankSoalInput) []string {
+	tags := []string{fmt.Sprintf("poin=%d", soal.Poin)}
+	if soal.Topik != "" {
+		tags = append(tags, "topik="+soal.Topik)
+	}
+	if soal.TingkatKesulitan != "" {
+		tags = append(tags, "kesulitan="+soal.TingkatKesulitan)
+	}
+	if soal.LevelBloom != "" {
+		tags = append(tags, "bloom="+soal.LevelBloom)
+	}
+	return tags
+}
+
+// terapkanTagBankSoal - Mengisi tag bank soal dari tag kunci=nilai
+func terapkanTagBankSoal(soal *bankSoalInput, tag string) {
+	kunci, nilai, ada := strings.Cut(strings.TrimSpace(tag), "=")
+	if !ada {
+		return
+	}
+	nilai = strings.TrimSpace(nilai)
+	switch strings.ToLower(strings.TrimSpace(kunci)) {
+	case "topik":
+		soal.Topik = nilai
+	case "kesulitan":
+		soal.TingkatKesulitan = nilai
+	case "bloom":
+		soal.LevelBloom = nilai
+	case "poin":
+		if poin, err := strconv.Atoi(nilai); err == nil {
+			soal.Poin = poin
+		}
+	}
+}
+
+// tulisGIFT - Export soal ke format GIFT
+func tulisGIFT(soalList []bankSoalInput) string {
+	var b strings.Builder
+	for i, soal := range soalList {
+		b.WriteString("// ")
+		for _, tag := range tagBankSoal(soal) {
+			b.WriteString("[tag:" + tag + "] ")
+		}
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "::Soal %d:: %s {", i+1, escapeGIFT(soal.Pertanyaan))
+
+		switch soal.TipeSoal {
+		case "BenarSalah":
+			if soal.JawabanBenar != nil && *soal.JawabanBenar {
+				b.WriteString("T")
+			} else {
+				b.WriteString("F")
+			}
+		case "IsianSingkat":
+			for _, jawaban := range soal.JawabanDiterima {
+				b.WriteString("\n\t=" + escapeGIFT(jawaban))
+			}
+			b.WriteString("\n")
+		case "PilihanJamak":
+			// Bobot benar dibagi rata, pilihan salah bernilai -100%
+			jumlahBenar := 0
+			for _, opsi := range soal.Opsi {
+				if opsi.IsBenar {
+					jumlahBenar++
+				}
+			}
+			for _, opsi := range soal.Opsi {
+				bobot := "-100"
+				if opsi.IsBenar {
+					bobot = strconv.FormatFloat(100/float64(jumlahBenar), 'f', -1, 64)
+				}
+				b.WriteString("\n\t~%" + bobot + "%" + escapeGIFT(opsi.Teks))
+			}
+			b.WriteString("\n")
+		default:
+			for _, opsi := range soal.Opsi {
+				if opsi.IsBenar {
+					b.WriteString("\n\t=" + escapeGIFT(opsi.Teks))
+				} else {
+					b.WriteString("\n\t~" + escapeGIFT(opsi.Teks))
+				}
+			}
+			b.WriteString("\n")
+		}
+
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+// pisahGIFT - Memisahkan teks pada karakter pemisah yang tidak di-escape
+func pisahGIFT(s string, pemisah func(byte) bool) []string {
+	var bagian []string
+	mulai := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if pemisah(s[i]) {
+			bagian = append(bagian, s[mulai:i])
+			mulai = i
+		}
+	}
+	return append(bagian, s[mulai:])
+}
+
+// indeksGIFT - Posisi karakter pertama yang tidak di-escape, -1 jika tidak ada
+func indeksGIFT(s string, c byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// bacaGIFT - Import soal dari format GIFT. Mendukung pilihan ganda (=/~), pilihan jamak (~%n%),
+// benar/salah ({T}/{F}) dan isian singkat (hanya =). Baris $CATEGORY dipakai sebagai topik default
+func bacaGIFT(teks string) ([]bankSoalInput, error) {
+	var hasil []bankSoalInput
+	var tags []string
+	var baris []string
+	kategori := ""
+	nomor := 0
+
+	proses := func() error {
+		blok := strings.TrimSpace(strings.Join(baris, "\n"))
+		baris = nil
+		if blok == "" {
+			tags = nil
+			return nil
+		}
+		nomor++
+
+		soal := bankSoalInput{Topik: kategori}
+		for _, tag := range tags {
+			terapkanTagBankSoal(&soal, tag)
+		}
+		tags = nil
+
+		// Judul ::...:: diabaikan
+		if strings.HasPrefix(blok, "::") {
+			if akhir := strings.Index(blok[2:], "::"); akhir >= 0 {
+				blok = strings.TrimSpace(blok[akhir+4:])
+			}
+		}
+
+		buka := indeksGIFT(blok, '{')
+		if buka < 0 {
+			return fmt.Errorf("soal GIFT %d: blok jawaban {...} tidak ditemukan", nomor)
+		}
+		tutupRelatif := indeksGIFT(blok[buka+1:], '}')
+		if tutupRelatif < 0 {
+			return fmt.Errorf("soal GIFT %d: blok jawaban tidak ditutup", nomor)
+		}
+		tutup := buka + 1 + tutupRelatif
+
+		soal.Pertanyaan = strings.TrimSpace(unescapeGIFT(blok[:buka] + " " + blok[tutup+1:]))
+		jawaban := strings.TrimSpace(blok[buka+1 : tutup])
+
+		switch strings.ToUpper(jawaban) {
+		case "T", "TRUE":
+			benar := true
+			soal.TipeSoal, soal.JawabanBenar = "BenarSalah", &benar
+			hasil = append(hasil, soal)
+			return nil
+		case "F", "FALSE":
+			benar := false
+			soal.TipeSoal, soal.JawabanBenar = "BenarSalah", &benar
+			hasil = append(hasil, soal)
+			return nil
+		}
+
+		adaSalah, adaBobot := false, false
+		for _, bagian := range pisahGIFT(jawaban, func(c byte) bool { return c == '=' || c == '~' }) {
+			bagian = strings.TrimSpace(bagian)
+			if bagian == "" {
+				continue
+			}
+			penanda, isi := bagian[0], strings.TrimSpace(bagian[1:])
+			if penanda != '=' && penanda != '~' {
+				continue
+			}
+
+			// Feedback setelah # tidak disimpan
+			if pagar := indeksGIFT(isi, '#'); pagar >= 0 {
+				isi = strings.TrimSpace(isi[:pagar])
+			}
+
+			benar := penanda == '='
+			if m := polaBobotGIFT.FindStringSubmatch(isi); m != nil {
+				adaBobot = true
+				bobot, _ := strconv.ParseFloat(m[1], 64)
+				benar = bobot > 0
+				isi = strings.TrimSpace(isi[len(m[0]):])
+			}
+			if !benar {
+				adaSalah = true
+			}
+
+			soal.Opsi = append(soal.Opsi, opsiSoalInput{Teks: unescapeGIFT(isi), IsBenar: benar})
+		}
+
+		switch {
+		case !adaSalah && !adaBobot:
+			soal.TipeSoal = "IsianSingkat"
+			for _, opsi := range soal.Opsi {
+				soal.JawabanDiterima = append(soal.JawabanDiterima, opsi.Teks)
+			}
+			soal.Opsi = nil
+		case adaBobot:
+			soal.TipeSoal = "PilihanJamak"
+		default:
+			soal.TipeSoal = "PilihanGanda"
+		}
+
+		hasil = append(hasil, soal)
+		return nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(teks, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			if err := proses(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(trimmed, "//"):
+			for _, m := range polaTagGIFT.FindAllStringSubmatch(trimmed, -1) {
+				tags = append(tags, m[1])
+			}
+		case strings.HasPrefix(trimmed, "$CATEGORY:"):
+			kategori = strings.TrimSpace(strings.TrimPrefix(trimmed, "$CATEGORY:"))
+			if idx := strings.LastIndex(kategori, "/"); idx >= 0 {
+				kategori = kategori[idx+1:]
+			}
+		default:
+			baris = append(baris, line)
+		}
+	}
+	if err := proses(); err != nil {
+		return nil, err
+	}
+
+	return hasil, nil
+}
+
+// ==================== Moodle XML ====================
+
+type moodleTeks struct {
+	Text string `xml:"text"`
+}
+
+type moodleJawaban struct {
+	Fraction string `xml:"fraction,attr"`
+	Format   string `xml:"format,attr,omitempty"`
+	Text     string `xml:"text"`
+}
+
+type moodleSoal struct {
+	Type         string     `xml:"type,attr"`
+	Name         moodleTeks `xml:"name"`
+	QuestionText struct {
+		Format string `xml:"format,attr"`
+		Text   string `xml:"text"`
+	} `xml:"questiontext"`
+	DefaultGrade string          `xml:"defaultgrade,omitempty"`
+	Single       string          `xml:"single,omitempty"`
+	Category     *moodleTeks     `xml:"category,omitempty"`
+	Answers      []moodleJawaban `xml:"answer"`
+	Tags         []moodleTeks    `xml:"tags>tag"`
+}
+
+type moodleQuiz struct {
+	XMLName   xml.Name     `xml:"quiz"`
+	Questions []moodleSoal `xml:"question"`
+}
+
+// tulisMoodleXML - Export soal ke Moodle XML
+func tulisMoodleXML(soalList []bankSoalInput) ([]byte, error) {
+	var quiz moodleQuiz
+	for i, soal := range soalList {
+		q := moodleSoal{Name: moodleTeks{Text: fmt.Sprintf("Soal %d", i+1)}, DefaultGrade: strconv.Itoa(soal.Poin)}
+		q.QuestionText.Format = "plain_text"
+		q.QuestionText.Text = soal.Pertanyaan
+		for _, tag := range tagBankSoal(soal) {
+			q.Tags = append(q.Tags, moodleTeks{Text: tag})
+		}
+
+		switch soal.TipeSoal {
+		case "BenarSalah":
+			q.Type = "truefalse"
+			benar := soal.JawabanBenar != nil && *soal.JawabanBenar
+			fraksi := map[bool]string{true: "100", false: "0"}
+			q.Answers = []moodleJawaban{
+				{Fraction: fraksi[benar], Format: "moodle_auto_format", Text: "true"},
+				{Fraction: fraksi[!benar], Format: "moodle_auto_format", Text: "false"},
+			}
+		case "IsianSingkat":
+			q.Type = "shortanswer"
+			for _, jawaban := range soal.JawabanDiterima {
+				q.Answers = append(q.Answers, moodleJawaban{Fraction: "100", Format: "plain_text", Text: jawaban})
+			}
+		default:
+			q.Type = "multichoice"
+			q.Single = strconv.FormatBool(soal.TipeSoal == "PilihanGanda")
+			jumlahBenar := 0
+			for _, opsi := range soal.Opsi {
+				if opsi.IsBenar {
+					jumlahBenar++
+				}
+			}
+			for _, opsi := range soal.Opsi {
+				fraksi := "0"
+				if opsi.IsBenar {
+					fraksi = strconv.FormatFloat(100/float64(jumlahBenar), 'f', 5, 64)
+				}
+				q.Answers = append(q.Answers, moodleJawaban{Fraction: fraksi, Format: "plain_text", Text: opsi.Teks})
+			}
+		}
+
+		quiz.Questions = append(quiz.Questions, q)
+	}
+
+	body, err := xml.MarshalIndent(quiz, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// polaHTML - Tag HTML pada teks Moodle dibuang saat import
+var polaHTML = regexp.MustCompile(`<[^>]*>`)
+
+// teksMoodle - Teks Moodle sebagai teks biasa. Teks berformat html/moodle_auto_format dibuang tag HTML-nya
+// dan entitasnya di-decode; teks plain_text/markdown dipakai apa adanya
+func teksMoodle(format, teks string) string {
+	switch format {
+	case "html", "moodle_auto_format":
+		teks = html.UnescapeString(polaHTML.ReplaceAllString(teks, ""))
+	}
+	return strings.TrimSpace(teks)
+}
+
+// bacaMoodleXML - Import soal dari Moodle XML (multichoice, truefalse, shortanswer).
+// Soal bertipe category dipakai sebagai topik default; tipe lain diabaikan
+func bacaMoodleXML(data []byte) ([]bankSoalInput, error) {
+	var quiz moodleQuiz
+	if err := xml.Unmarshal(data, &quiz); err != nil {
+		return nil, err
+	}
+
+	var hasil []bankSoalInput
+	kategori := ""
+	for _, q := range quiz.Questions {
+		if q.Type == "category" {
+			if q.Category != nil {
+				kategori = q.Category.Text
+				if idx := strings.LastIndex(kategori, "/"); idx >= 0 {
+					kategori = kategori[idx+1:]
+				}
+			}
+			continue
+		}
+
+		soal := bankSoalInput{Topik: kategori}
+		// Teks soal tanpa atribut format berformat html (default Moodle)
+		formatSoal := q.QuestionText.Format
+		if formatSoal == "" {
+			formatSoal = "html"
+		}
+		soal.Pertanyaan = teksMoodle(formatSoal, q.QuestionText.Text)
+		if grade, err := strconv.ParseFloat(q.DefaultGrade, 64); err == nil && grade > 0 {
+			soal.Poin = int(grade + 0.5)
+		}
+		for _, tag := range q.Tags {
+			terapkanTagBankSoal(&soal, tag.Text)
+		}
+
+		switch q.Type {
+		case "truefalse":
+			benar := false
+			for _, a := range q.Answers {
+				fraksi, _ := strconv.ParseFloat(a.Fraction, 64)
+				if strings.EqualFold(teksMoodle(a.Format, a.Text), "true") && fraksi > 0 {
+					benar = true
+				}
+			}
+			soal.TipeSoal, soal.JawabanBenar = "BenarSalah", &benar
+		case "shortanswer":
+			soal.TipeSoal = "IsianSingkat"
+			for _, a := range q.Answers {
+				if fraksi, _ := strconv.ParseFloat(a.Fraction, 64); fraksi > 0 {
+					soal.JawabanDiterima = append(soal.JawabanDiterima, teksMoodle(a.Format, a.Text))
+				}
+			}
+		case "multichoice":
+			soal.TipeSoal = "PilihanGanda"
+			if q.Single == "false" || q.Single == "0" {
+				soal.TipeSoal = "PilihanJamak"
+			}
+			for _, a := range q.Answers {
+				fraksi, _ := strconv.ParseFloat(a.Fraction, 64)
+				soal.Opsi = append(soal.Opsi, opsiSoalInput{Teks: teksMoodle(a.Format, a.Text), IsBenar: fraksi > 0})
+			}
+		default:
+			continue
+		}
+
+		hasil = append(hasil, soal)
+	}
+
+	return hasil, nil
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func benarSalah(b bool) *bool {
+	return &b
+}
+
+// contohBankSoal - Satu soal untuk setiap tipe, dengan karakter khusus GIFT, XML dan HTML di teksnya
+func contohBankSoal() []bankSoalInput {
+	return []bankSoalInput{
+		{
+			soalKuisInput: soalKuisInput{
+				TipeSoal:   "PilihanGanda",
+				Pertanyaan: "Jika x = 2 {dan} y ~ 3, berapa x + y?",
+				Poin:       2,
+				Opsi: []opsiSoalInput{
+					{Teks: "5", IsBenar: true},
+					{Teks: "a < b & c > d", IsBenar: false},
+					{Teks: "<b>6</b>", IsBenar: false},
+				},
+			},
+			Topik:            "Aljabar",
+			TingkatKesulitan: "Mudah",
+			LevelBloom:       "C1",
+		},
+		{
+			soalKuisInput: soalKuisInput{
+				TipeSoal:   "PilihanJamak",
+				Pertanyaan: "Pilih bilangan prima: #1",
+				Poin:       3,
+				Opsi: []opsiSoalInput{
+					{Teks: "2", IsBenar: true},
+					{Teks: "3", IsBenar: true},
+					{Teks: "4", IsBenar: false},
+				},
+			},
+			Topik: "Bilangan",
+		},
+		{
+			soalKuisInput: soalKuisInput{
+				TipeSoal:     "BenarSalah",
+				Pertanyaan:   "Air mendidih pada 100°C (tekanan 1 atm)",
+				Poin:         1,
+				JawabanBenar: benarSalah(true),
+			},
+			LevelBloom: "C2",
+		},
+		{
+			soalKuisInput: soalKuisInput{
+				TipeSoal:     "BenarSalah",
+				Pertanyaan:   "5 < 3",
+				Poin:         1,
+				JawabanBenar: benarSalah(false),
+			},
+		},
+		{
+			soalKuisInput: soalKuisInput{
+				TipeSoal:        "IsianSingkat",
+				Pertanyaan:      "Ibu kota Indonesia: ...",
+				Poin:            4,
+				JawabanDiterima: []string{"Jakarta", "DKI Jakarta"},
+			},
+			TingkatKesulitan: "Sedang",
+		},
+	}
+}
+
+func TestBankSoalRoundTrip(t *testing.T) {
+	tests := []struct {
+		nama string
+		ubah func([]bankSoalInput) ([]byte, error)
+		baca func([]byte) ([]bankSoalInput, error)
+	}{
+		{
+			nama: "GIFT",
+			ubah: func(s []bankSoalInput) ([]byte, error) { return []byte(tulisGIFT(s)), nil },
+			baca: func(b []byte) ([]bankSoalInput, error) { return bacaGIFT(string(b)) },
+		},
+		{
+			nama: "MoodleXML",
+			ubah: tulisMoodleXML,
+			baca: bacaMoodleXML,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			for _, soal := range contohBankSoal() {
+				t.Run(soal.TipeSoal, func(t *testing.T) {
+					data, err := tt.ubah([]bankSoalInput{soal})
+					if err != nil {
+						t.Fatalf("export: %v", err)
+					}
+					hasil, err := tt.baca(data)
+					if err != nil {
+						t.Fatalf("import: %v\n%s", err, data)
+					}
+					if len(hasil) != 1 || !reflect.DeepEqual(hasil[0], soal) {
+						t.Fatalf("round trip\n got: %+v\nwant: %+v\n%s", hasil, soal, data)
+					}
+				})
+			}
+
+			// Semua soal sekaligus tetap terpisah dan berurutan
+			semua := contohBankSoal()
+			data, err := tt.ubah(semua)
+			if err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			hasil, err := tt.baca(data)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if !reflect.DeepEqual(hasil, semua) {
+				t.Fatalf("round trip %d soal\n got: %+v\nwant: %+v", len(semua), hasil, semua)
+			}
+		})
+	}
+}
+
+func TestTulisMoodleXMLTeksBiasa(t *testing.T) {
+	data, err := tulisMoodleXML(contohBankSoal()[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(data)
+	if strings.Contains(xml, `format="html"`) {
+		t.Fatalf("teks biasa diekspor sebagai html:\n%s", xml)
+	}
+	if !strings.Contains(xml, "a &lt; b &amp; c &gt; d") {
+		t.Fatalf("karakter khusus tidak di-escape:\n%s", xml)
+	}
+}
+
+func TestBacaMoodleXMLFormatHTML(t *testing.T) {
+	data := `<quiz>
+  <question type="multichoice">
+    <questiontext><text><![CDATA[<p>Hasil dari 1 &lt; 2?</p>]]></text></questiontext>
+    <single>true</single>
+    <answer fraction="100" format="html"><text><![CDATA[<b>Benar</b> &amp; tepat]]></text></answer>
+    <answer fraction="0" format="plain_text"><text>&lt;salah&gt;</text></answer>
+  </question>
+</quiz>`
+	hasil, err := bacaMoodleXML([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hasil) != 1 {
+		t.Fatalf("hasil = %+v", hasil)
+	}
+	if hasil[0].Pertanyaan != "Hasil dari 1 < 2?" {
+		t.Errorf("pertanyaan = %q", hasil[0].Pertanyaan)
+	}
+	want := []opsiSoalInput{{Teks: "Benar & tepat", IsBenar: true}, {Teks: "<salah>", IsBenar: false}}
+	if !reflect.DeepEqual(hasil[0].Opsi, want) {
+		t.Errorf("opsi = %+v, want %+v", hasil[0].Opsi, want)
+	}
+}
+
+func TestBacaGIFTError(t *testing.T) {
+	tests := []struct {
+		nama string
+		teks string
+	}{
+		{"tanpa blok jawaban", "Soal tanpa jawaban"},
+		{"blok tidak ditutup", "Soal {=a ~b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if _, err := bacaGIFT(tt.teks); err == nil {
+				t.Fatal("error tidak dikembalikan")
+			}
+		})
+	}
+}
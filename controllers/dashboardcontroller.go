@@ -165,6 +165,7 @@ func GetTugasMendekatiDeadline(w http.ResponseWriter, r *http.Request) {
 		WHERE s.siswa_id = ?
 			AND t.deadline_pengumpulan >= NOW()
 			AND t.deadline_pengumpulan <= DATE_ADD(NOW(), INTERVAL 7 DAY)
+			AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())
 		ORDER BY t.deadline_pengumpulan ASC
 		LIMIT 10
 	`
@@ -254,6 +255,7 @@ func GetDashboardSummary(w http.ResponseWriter, r *http.Request) {
 		WHERE s.siswa_id = ?
 			AND t.deadline_pengumpulan >= NOW()
 			AND t.deadline_pengumpulan <= DATE_ADD(NOW(), INTERVAL 7 DAY)
+			AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())
 		ORDER BY t.deadline_pengumpulan ASC
 		LIMIT 5
 	`
@@ -290,6 +292,7 @@ func GetDashboardSummary(w http.ResponseWriter, r *http.Request) {
 		WHERE s.siswa_id = ?
 			AND pt.pengumpulan_id IS NULL
 			AND t.deadline_pengumpulan >= NOW()
+			AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())
 	`, siswaID).Scan(&totalTugasBelumSelesai)
 	dashboardData["total_tugas_belum_selesai"] = totalTugasBelumSelesai
 
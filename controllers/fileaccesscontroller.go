@@ -1,138 +1,304 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"Pasti/config"
 	"Pasti/helpers"
-	"Pasti/models"
+	"Pasti/storage"
 
 	"github.com/gorilla/mux"
 )
 
-// ServeProtectedFile - Melayani file dengan akses kontrol
+// ServeProtectedFile - Melayani file dengan akses kontrol.
+// Akses melalui header Authorization (siswa/guru/admin) atau tautan bertanda tangan (?exp=&sig=)
 func ServeProtectedFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
-	
+
 	log.Printf("🔍 File access request:")
 	log.Printf("   - filename: %s", filename)
 	log.Printf("   - URL path: %s", r.URL.Path)
 	log.Printf("   - method: %s", r.Method)
-	
+
 	// Validasi path untuk mencegah directory traversal
 	if strings.Contains(filename, "..") || strings.Contains(filename, "/") || strings.Contains(filename, "\\") {
 		log.Printf("❌ Invalid file path detected: %s", filename)
 		http.Error(w, "Invalid file path", http.StatusBadRequest)
 		return
 	}
-	// Tentukan path file berdasarkan kategori
-	var filePath string
+	// Tentukan key storage berdasarkan kategori
+	var key string
 	category := strings.Split(r.URL.Path, "/")[2] // uploads/tugas/file.ext -> ambil "tugas"
-	
+
 	log.Printf("   - category detected: %s", category)
-	
+
 	switch category {
-	case "tugas":
-		filePath = filepath.Join("uploads", "tugas", filename)
+	case "tugas", "thumb", "profil":
+		key = category + "/" + filename
 	default:
 		log.Printf("❌ Invalid file category: %s", category)
 		http.Error(w, "Invalid file category", http.StatusBadRequest)
 		return
 	}
-	
-	log.Printf("   - full file path: %s", filePath)	// Cek apakah file ada
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Printf("❌ File not found: %s", filePath)
-		http.Error(w, "File not found", http.StatusNotFound)
+
+	log.Printf("   - storage key: %s", key)
+
+	// Tautan bertanda tangan sudah diperiksa haknya saat dibuat, cukup verifikasi tanda tangan
+	query := r.URL.Query()
+	if sig := query.Get("sig"); sig != "" {
+		if !helpers.VerifikasiTautanFile(r.URL.Path, query.Get("exp"), sig) {
+			log.Printf("❌ Invalid or expired signed URL")
+			http.Error(w, "Tautan tidak valid atau sudah kedaluwarsa", http.StatusForbidden)
+			return
+		}
+		log.Printf("✅ Signed URL valid")
+	} else {
+		user, err := helpers.ValidateTokenPengguna(r.Header.Get("Authorization"))
+		if err != nil {
+			log.Printf("❌ Token validation failed: %v", err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !hasFileAccess(user, filename, category) {
+			http.Error(w, "Anda tidak memiliki akses ke file ini", http.StatusForbidden)
+			return
+		}
+	}
+
+	file, info, err := storage.Default().Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("❌ File not found: %s", key)
+			http.Error(w, "File not found", http.StatusNotFound)
+		} else {
+			log.Printf("❌ Storage error for %s: %v", key, err)
+			http.Error(w, "Gagal membaca file", http.StatusInternalServerError)
+		}
 		return
 	}
-	
-	log.Printf("✅ File exists: %s", filePath)
-	log.Printf("✅ Serving file without authentication check (public access)")
+	defer file.Close()
+
+	log.Printf("✅ Serving file: %s", key)
 
-	// Serve file tanpa validasi akses
-	http.ServeFile(w, r, filePath)
+	w.Header().Set("Cache-Control", "private, no-store")
+	// File lokal mendukung Range/If-Modified-Since, file dari S3 di-stream apa adanya
+	if rs, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, filename, info.ModTime, rs)
+		return
+	}
+	if info.ContentType != "" {
+		w.Header().Set("Content-Type", info.ContentType)
+	}
+	if info.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	io.Copy(w, file)
+}
+
+// BuatTautanFile - Membuat tautan unduhan bertanda tangan untuk file yang boleh diakses user.
+// Body: {"urls": ["/uploads/tugas/...", "/uploads/thumb/...", "/uploads/profil/..."]}; file tanpa akses dikembalikan dengan pesan error
+func BuatTautanFile(w http.ResponseWriter, r *http.Request) {
+	user := penggunaDariContext(r)
+	if user == nil {
+		helpers.Response(w, 401, "unauthorized", nil)
+		return
+	}
+
+	var input struct {
+		URLs []string `json:"urls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if len(input.URLs) == 0 || len(input.URLs) > 200 {
+		helpers.Response(w, 400, "Jumlah url harus antara 1 dan 200", nil)
+		return
+	}
+
+	durasi := helpers.DurasiTautanFile()
+	kedaluwarsa := time.Now().Add(durasi)
+
+	hasil := make([]map[string]interface{}, 0, len(input.URLs))
+	for _, raw := range input.URLs {
+		item := map[string]interface{}{"url": raw}
+
+		u, err := url.Parse(raw)
+		if err != nil || strings.Count(u.Path, "/") != 3 || !kategoriTautanFile[strings.Split(u.Path, "/")[2]] ||
+			!strings.HasPrefix(u.Path, "/uploads/") {
+			item["error"] = "URL file tidak valid"
+			hasil = append(hasil, item)
+			continue
+		}
+
+		filename := path.Base(u.Path)
+		category := strings.Split(u.Path, "/")[2]
+		if !hasFileAccess(user, filename, category) {
+			item["error"] = "Anda tidak memiliki akses ke file ini"
+			hasil = append(hasil, item)
+			continue
+		}
+
+		tautan, err := storage.Default().SignedURL(r.Context(), category+"/"+filename, durasi)
+		if err != nil {
+			item["error"] = "Gagal membuat tautan: " + err.Error()
+			hasil = append(hasil, item)
+			continue
+		}
+		item["tautan"] = tautan
+		item["kedaluwarsa"] = kedaluwarsa
+		hasil = append(hasil, item)
+	}
+
+	helpers.Response(w, 200, "Tautan file berhasil dibuat", hasil)
+}
+
+// kategoriTautanFile - Kategori /uploads/<kategori>/ yang dapat dibuatkan tautan bertanda tangan
+var kategoriTautanFile = map[string]bool{"tugas": true, "thumb": true, "profil": true}
+
+// penggunaDariContext - Claims siswa/guru/admin yang disimpan middleware AuthPengguna
+func penggunaDariContext(r *http.Request) any {
+	for _, key := range []string{"siswainfo", "guruinfo", "admininfo"} {
+		if user := r.Context().Value(key); user != nil {
+			return user
+		}
+	}
+	return nil
 }
 
 // hasFileAccess - Mengecek akses file berdasarkan role dan kepemilikan
-func hasFileAccess(r *http.Request, filename, category string) bool {
+func hasFileAccess(user any, filename, category string) bool {
 	log.Printf("🔍 Checking file access:")
 	log.Printf("   - filename: %s", filename)
 	log.Printf("   - category: %s", category)
-	
-	// Cek apakah user adalah guru
-	if guruInfo := r.Context().Value("guruinfo"); guruInfo != nil {
-		log.Printf("   - user type: GURU")
-		guru := guruInfo.(*helpers.GuruCustomClaims)
-		log.Printf("   - guru ID: %d", guru.ID)
-		log.Printf("   - access granted: true (guru has full access)")
-		return true // Guru bisa akses semua file
+
+	switch category {
+	case "profil":
+		// Foto profil boleh dilihat semua pengguna yang login
+		log.Printf("   - access granted: %t (profile photo)", user != nil)
+		return user != nil
+	case "thumb":
+		// Thumbnail mengikuti hak akses file jawaban aslinya (thumb/<file asli>.jpg)
+		filename, category = strings.TrimSuffix(filename, ".jpg"), "tugas"
 	}
 
-	// Cek apakah user adalah siswa
-	if siswaInfo := r.Context().Value("siswainfo"); siswaInfo != nil {
+	switch u := user.(type) {
+	case *helpers.AdminCustomClaims:
+		log.Printf("   - user type: ADMIN (%s)", u.Username)
+		log.Printf("   - access granted: true (admin has full access)")
+		return true
+	case *helpers.GuruCustomClaims:
+		log.Printf("   - user type: GURU")
+		return hasGuruFileAccess(u, filename, category)
+	case *helpers.MyCustomClaims:
 		log.Printf("   - user type: SISWA")
-		siswa := siswaInfo.(*helpers.MyCustomClaims)
-		log.Printf("   - siswa ID: %d", siswa.ID)
-		log.Printf("   - access granted: true (siswa has access)")
-		return true // Siswa juga bisa akses semua file
+		return hasSiswaFileAccess(u, filename, category)
 	}
 
 	log.Printf("❌ No valid user context found")
 	return false
 }
 
-// hasGuruFileAccess - Validasi akses file untuk guru
+// adaFile - Menjalankan query COUNT dan mengembalikan true jika ada baris yang cocok
+func adaFile(query string, args ...interface{}) bool {
+	var jumlah int64
+	if err := config.DB.Raw(query, args...).Scan(&jumlah).Error; err != nil {
+		log.Printf("   - query error: %v", err)
+		return false
+	}
+	return jumlah > 0
+}
+
+// polaNamaFile - Pola LIKE (dengan ESCAPE '!') untuk URL yang berakhiran /filename. % dan _ pada nama file
+// di-escape agar tidak cocok dengan file lain
+func polaNamaFile(filename string) string {
+	return "%/" + strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(filename)
+}
+
+// hasGuruFileAccess - Validasi akses file untuk guru: file jawaban, lampiran tugas, lampiran komentar
+// dan materi pada jadwal yang diajarnya
 func hasGuruFileAccess(guru *helpers.GuruCustomClaims, filename, category string) bool {
 	log.Printf("🔍 Checking guru file access:")
 	log.Printf("   - guru ID: %d", guru.ID)
 	log.Printf("   - filename: %s", filename)
 	log.Printf("   - category: %s", category)
-	
-	if category == "tugas" {
-		// Guru bisa akses semua file tugas dari kelas yang diajarnya
-		var pengumpulan models.PengumpulanTugas
-		result := config.DB.
-			Joins("JOIN tugas ON pengumpulan_tugas.tugas_id = tugas.tugas_id").
-			Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
-			Where("pengumpulan_tugas.file_jawaban_siswa LIKE ? AND jadwalpelajaran.guru_id = ?", "%"+filename, guru.ID).
-			First(&pengumpulan)
-		
-		log.Printf("   - query result error: %v", result.Error)
-		log.Printf("   - access granted: %t", result.Error == nil)
-		
-		return result.Error == nil
-	}
-	
-	log.Printf("   - category not supported for guru")
-	return false
+
+	if category != "tugas" {
+		log.Printf("   - category not supported for guru")
+		return false
+	}
+
+	pola := polaNamaFile(filename)
+	granted := adaFile(`
+		SELECT COUNT(*) FROM tugas t
+		JOIN jadwalpelajaran jp ON t.jadwal_id = jp.jadwal_id
+		WHERE jp.guru_id = ? AND (
+			t.file_tugas_guru LIKE ? ESCAPE '!'
+			OR EXISTS (SELECT 1 FROM pengumpulantugas pt WHERE pt.tugas_id = t.tugas_id AND pt.file_jawaban_siswa LIKE ? ESCAPE '!')
+			OR EXISTS (
+				SELECT 1 FROM versipengumpulan vp
+				JOIN pengumpulantugas pt ON pt.pengumpulan_id = vp.pengumpulan_id
+				WHERE pt.tugas_id = t.tugas_id AND vp.file_jawaban_siswa LIKE ? ESCAPE '!')
+			OR EXISTS (
+				SELECT 1 FROM komentarpengumpulan kp
+				JOIN pengumpulantugas pt ON pt.pengumpulan_id = kp.pengumpulan_id
+				WHERE pt.tugas_id = t.tugas_id AND kp.file_lampiran LIKE ? ESCAPE '!')
+		)`, guru.ID, pola, pola, pola, pola) ||
+		adaFile(`
+		SELECT COUNT(*) FROM itemmateri im
+		JOIN seksimateri sm ON sm.seksi_id = im.seksi_id
+		JOIN pertemuan ON pertemuan.id_pertemuan = sm.pertemuan_id
+		JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id
+		WHERE im.url LIKE ? ESCAPE '!' AND `+kondisiGuruPertemuan, pola, guru.ID, guru.ID)
+
+	log.Printf("   - access granted: %t", granted)
+	return granted
 }
 
-// hasSiswaFileAccess - Validasi akses file untuk siswa
+// hasSiswaFileAccess - Validasi akses file untuk siswa: jawaban dan lampiran komentar miliknya sendiri,
+// serta file tugas dan materi dari guru di kelasnya
 func hasSiswaFileAccess(siswa *helpers.MyCustomClaims, filename, category string) bool {
 	log.Printf("🔍 Checking siswa file access:")
 	log.Printf("   - siswa ID: %d", siswa.ID)
 	log.Printf("   - filename: %s", filename)
 	log.Printf("   - category: %s", category)
-	
-	if category == "tugas" {
-		// Siswa hanya bisa akses file yang diupload sendiri
-		var pengumpulan models.PengumpulanTugas
-		result := config.DB.
-			Where("siswa_id = ? AND file_jawaban_siswa LIKE ?", siswa.ID, "%"+filename).
-			First(&pengumpulan)
-		
-		log.Printf("   - query result error: %v", result.Error)
-		log.Printf("   - access granted: %t", result.Error == nil)
-		
-		return result.Error == nil
-	}
-	
-	log.Printf("   - category not supported for siswa")
-	return false
+
+	if category != "tugas" {
+		log.Printf("   - category not supported for siswa")
+		return false
+	}
+
+	pola := polaNamaFile(filename)
+	granted := adaFile(`
+		SELECT COUNT(*) FROM pengumpulantugas pt
+		WHERE pt.siswa_id = ? AND (
+			pt.file_jawaban_siswa LIKE ? ESCAPE '!'
+			OR EXISTS (SELECT 1 FROM versipengumpulan vp WHERE vp.pengumpulan_id = pt.pengumpulan_id AND vp.file_jawaban_siswa LIKE ? ESCAPE '!')
+			OR EXISTS (SELECT 1 FROM komentarpengumpulan kp WHERE kp.pengumpulan_id = pt.pengumpulan_id AND kp.file_lampiran LIKE ? ESCAPE '!')
+		)`, siswa.ID, pola, pola, pola) ||
+		adaFile(`
+		SELECT COUNT(*) FROM tugas
+		JOIN jadwalpelajaran jp ON tugas.jadwal_id = jp.jadwal_id
+		JOIN siswa s ON s.kelas_id = jp.kelas_id
+		WHERE s.siswa_id = ? AND tugas.file_tugas_guru LIKE ? ESCAPE '!' AND `+kondisiTugasTerbit, siswa.ID, pola) ||
+		adaFile(`
+		SELECT COUNT(*) FROM itemmateri im
+		JOIN seksimateri sm ON sm.seksi_id = im.seksi_id
+		JOIN pertemuan p ON p.id_pertemuan = sm.pertemuan_id
+		JOIN jadwalpelajaran jp ON p.id_jadwal = jp.jadwal_id
+		JOIN siswa s ON s.kelas_id = jp.kelas_id
+		WHERE s.siswa_id = ? AND im.url LIKE ? ESCAPE '!'`, siswa.ID, pola)
+
+	log.Printf("   - access granted: %t", granted)
+	return granted
 }
@@ -0,0 +1,262 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+	"Pasti/storage"
+
+	"github.com/google/uuid"
+)
+
+// Foto profil siswa dan thumbnail jawaban berupa gambar.
+// Foto profil disimpan di "profil/<siswa_id>_<uuid>_<ukuran>.jpg" untuk setiap ukuran avatar,
+// thumbnail jawaban di "thumb/<nama file asli>.jpg". Semua hasil di-encode ulang tanpa EXIF.
+
+// ukuranAvatar - Sisi avatar (px) yang dibuat; foto_profil menyimpan URL ukuran terbesar
+var ukuranAvatar = []int{256, 128, 64}
+
+const (
+	maksUkuranFotoProfil = 5 << 20
+	minSisiFotoProfil    = 64
+	sisiThumbnail        = 320
+	// Gambar lebih besar dari ini tidak dibuatkan thumbnail (seluruh file dibaca ke memori)
+	maksUkuranGambarThumbnail = 20 << 20
+)
+
+// ekstensiGambar - Ekstensi file jawaban yang dibuatkan thumbnail
+var ekstensiGambar = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// UploadFotoProfilSiswa - Siswa mengganti foto profil (multipart field "foto", JPG/PNG maks 5MB).
+// Foto dipotong persegi di tengah lalu disimpan dalam beberapa ukuran avatar
+func UploadFotoProfilSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	r.Body = http.MaxBytesReader(w, r.Body, maksUkuranFotoProfil+(1<<20))
+	if err := r.ParseMultipartForm(maksUkuranFotoProfil); err != nil {
+		helpers.Response(w, 400, "File terlalu besar (maksimal 5MB)", nil)
+		return
+	}
+	file, handler, err := r.FormFile("foto")
+	if err != nil {
+		helpers.Response(w, 400, "Error retrieving the file", nil)
+		return
+	}
+	defer file.Close()
+
+	if handler.Size > maksUkuranFotoProfil {
+		helpers.Response(w, 400, "File terlalu besar (maksimal 5MB)", nil)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		helpers.Response(w, 400, "Error retrieving the file", nil)
+		return
+	}
+	if tipe := helpers.DeteksiTipeFile(data); tipe != "jpeg" && tipe != "png" {
+		helpers.Response(w, 400, "Foto profil harus berformat JPG atau PNG", nil)
+		return
+	}
+
+	img, err := helpers.BacaGambar(data)
+	if err != nil {
+		helpers.Response(w, 400, "Gambar tidak dapat dibaca: "+err.Error(), nil)
+		return
+	}
+	if img.Bounds().Dx() < minSisiFotoProfil || img.Bounds().Dy() < minSisiFotoProfil {
+		helpers.Response(w, 400, fmt.Sprintf("Foto profil minimal %dx%d piksel", minSisiFotoProfil, minSisiFotoProfil), nil)
+		return
+	}
+
+	var current models.Siswa
+	if err := config.DB.Where("siswa_id = ?", siswa.ID).First(&current).Error; err != nil {
+		helpers.Response(w, 404, "Siswa tidak ditemukan", nil)
+		return
+	}
+
+	persegi := helpers.PotongPersegiTengah(img)
+	dasar := fmt.Sprintf("profil/%d_%s", siswa.ID, uuid.New().String())
+	var tersimpan []string
+	for _, ukuran := range ukuranAvatar {
+		hasil, err := helpers.EncodeJPEG(helpers.UbahUkuran(persegi, ukuran, ukuran), 85)
+		if err == nil {
+			key := fmt.Sprintf("%s_%d.jpg", dasar, ukuran)
+			err = storage.Default().Put(r.Context(), key, bytes.NewReader(hasil), int64(len(hasil)), "image/jpeg")
+			tersimpan = append(tersimpan, key)
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store avatar for siswa %d: %v", siswa.ID, err)
+			for _, key := range tersimpan {
+				storage.Default().Delete(context.Background(), key)
+			}
+			helpers.Response(w, 500, "Gagal menyimpan foto profil", nil)
+			return
+		}
+	}
+
+	fotoLama := current.FotoProfil
+	fotoBaru := fmt.Sprintf("/uploads/%s_%d.jpg", dasar, ukuranAvatar[0])
+	if err := config.DB.Model(&current).Update("foto_profil", fotoBaru).Error; err != nil {
+		for _, key := range tersimpan {
+			storage.Default().Delete(context.Background(), key)
+		}
+		helpers.Response(w, 500, "Gagal mengupdate profil", nil)
+		return
+	}
+	hapusFotoProfil(r.Context(), fotoLama)
+
+	helpers.Response(w, 200, "Foto profil berhasil diupdate", map[string]interface{}{
+		"foto_profil":        fotoBaru,
+		"foto_profil_ukuran": urlAvatarSiswa(fotoBaru),
+	})
+}
+
+// DeleteFotoProfilSiswa - Siswa menghapus foto profilnya
+func DeleteFotoProfilSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	var current models.Siswa
+	if err := config.DB.Where("siswa_id = ?", siswa.ID).First(&current).Error; err != nil {
+		helpers.Response(w, 404, "Siswa tidak ditemukan", nil)
+		return
+	}
+	if err := config.DB.Model(&current).Update("foto_profil", "").Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengupdate profil", nil)
+		return
+	}
+	hapusFotoProfil(r.Context(), current.FotoProfil)
+
+	helpers.Response(w, 200, "Foto profil berhasil dihapus", nil)
+}
+
+// urlAvatarSiswa - URL setiap ukuran avatar dari foto_profil ("256": ..., "128": ..., "64": ...).
+// Foto lama yang bukan hasil upload avatar dikembalikan apa adanya untuk semua ukuran
+func urlAvatarSiswa(foto string) map[string]string {
+	if foto == "" {
+		return nil
+	}
+	hasil := make(map[string]string, len(ukuranAvatar))
+	akhiran := fmt.Sprintf("_%d.jpg", ukuranAvatar[0])
+	for _, ukuran := range ukuranAvatar {
+		if strings.HasPrefix(foto, "/uploads/profil/") && strings.HasSuffix(foto, akhiran) {
+			hasil[strconv.Itoa(ukuran)] = strings.TrimSuffix(foto, akhiran) + fmt.Sprintf("_%d.jpg", ukuran)
+		} else {
+			hasil[strconv.Itoa(ukuran)] = foto
+		}
+	}
+	return hasil
+}
+
+// hapusFotoProfil - Menghapus semua ukuran avatar dari storage (foto bukan hasil upload diabaikan)
+func hapusFotoProfil(ctx context.Context, foto string) {
+	if !strings.HasPrefix(foto, "/uploads/profil/") {
+		return
+	}
+	for _, url := range urlAvatarSiswa(foto) {
+		key := strings.TrimPrefix(url, "/uploads/")
+		if err := storage.Default().Delete(ctx, key); err != nil {
+			log.Printf("⚠️ Failed to delete old avatar %s: %v", key, err)
+		}
+	}
+}
+
+// kunciThumbnail - Key thumbnail untuk file jawaban, kosong jika file bukan gambar
+func kunciThumbnail(fileURL string) string {
+	nama := path.Base(strings.Split(fileURL, "?")[0])
+	if !ekstensiGambar[strings.ToLower(path.Ext(nama))] {
+		return ""
+	}
+	return "thumb/" + nama + ".jpg"
+}
+
+// buatThumbnail - Membuat dan menyimpan thumbnail (maks 320px) dari isi file gambar
+func buatThumbnail(ctx context.Context, data []byte, key string) error {
+	img, err := helpers.BacaGambar(data)
+	if err != nil {
+		return err
+	}
+	w, h := helpers.UkuranMuat(img.Bounds().Dx(), img.Bounds().Dy(), sisiThumbnail)
+	hasil, err := helpers.EncodeJPEG(helpers.UbahUkuran(img, w, h), 80)
+	if err != nil {
+		return err
+	}
+	return storage.Default().Put(ctx, key, bytes.NewReader(hasil), int64(len(hasil)), "image/jpeg")
+}
+
+// thumbnailPengumpulan - URL thumbnail file jawaban gambar jika sudah ada. Thumbnail dibuat saat upload;
+// file lama yang belum punya thumbnail dijadwalkan ke background dan URL-nya kosong sampai selesai dibuat
+func thumbnailPengumpulan(ctx context.Context, fileURL string) string {
+	key := kunciThumbnail(fileURL)
+	if key == "" {
+		return ""
+	}
+
+	if _, err := storage.Default().Stat(ctx, key); err == nil {
+		return "/uploads/" + key
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ Failed to stat thumbnail %s: %v", key, err)
+		return ""
+	}
+	jadwalkanThumbnail(fileURL)
+	return ""
+}
+
+// Antrean thumbnail untuk file lama, dikerjakan satu per satu oleh satu goroutine agar daftar pengumpulan
+// kelas besar tidak men-decode banyak gambar sekaligus. File yang sudah diantrekan tidak diantrekan lagi
+var (
+	antreanThumbnail     = make(chan string, 256)
+	thumbnailDiantrekan  sync.Map
+	mulaiWorkerThumbnail sync.Once
+)
+
+// jadwalkanThumbnail - Mengantrekan pembuatan thumbnail; diabaikan jika antrean penuh (dicoba lagi saat
+// daftar pengumpulan dibuka berikutnya)
+func jadwalkanThumbnail(fileURL string) {
+	mulaiWorkerThumbnail.Do(func() { go workerThumbnail() })
+	if _, ada := thumbnailDiantrekan.LoadOrStore(fileURL, struct{}{}); ada {
+		return
+	}
+	select {
+	case antreanThumbnail <- fileURL:
+	default:
+		thumbnailDiantrekan.Delete(fileURL)
+	}
+}
+
+func workerThumbnail() {
+	for fileURL := range antreanThumbnail {
+		buatThumbnailFileLama(context.Background(), fileURL)
+		thumbnailDiantrekan.Delete(fileURL)
+	}
+}
+
+// buatThumbnailFileLama - Membuat thumbnail file jawaban yang belum punya (gambar terlalu besar dilewati)
+func buatThumbnailFileLama(ctx context.Context, fileURL string) {
+	key := kunciThumbnail(fileURL)
+	if _, err := storage.Default().Stat(ctx, key); err == nil {
+		return
+	}
+	if info, err := storage.Default().Stat(ctx, kunciFileTugas(fileURL)); err != nil || info.Size > maksUkuranGambarThumbnail {
+		return
+	}
+	data, err := bacaFileTugas(fileURL)
+	if err != nil {
+		log.Printf("⚠️ Failed to read image %s for thumbnail: %v", fileURL, err)
+		return
+	}
+	if err := buatThumbnail(ctx, data, key); err != nil {
+		log.Printf("⚠️ Failed to create thumbnail %s: %v", key, err)
+	}
+}
@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+	"Pasti/storage"
+)
+
+// Pembersihan file upload yatim: file di storage "tugas/" yang tidak dirujuk oleh tugas, template,
+// pengumpulan (termasuk versi lama), komentar atau materi dan sudah lebih tua dari masa retensi.
+
+// gcUploadMu - Mencegah dua pembersihan berjalan bersamaan
+var gcUploadMu sync.Mutex
+
+// uploadYatim - Satu file yang tidak dirujuk di mana pun
+type uploadYatim struct {
+	Kunci     string    `json:"kunci"`
+	URL       string    `json:"url"`
+	Ukuran    int64     `json:"ukuran"`
+	Diupload  time.Time `json:"diupload"`
+	NamaAsli  string    `json:"nama_asli,omitempty"`
+	TipeUser  string    `json:"tipe_user,omitempty"`
+	UserID    int       `json:"user_id,omitempty"`
+	Tujuan    string    `json:"tujuan,omitempty"`
+	TugasID   *int      `json:"tugas_id,omitempty"`
+	AdaFile   bool      `json:"ada_file"`
+	Terdaftar bool      `json:"terdaftar"`
+}
+
+// laporanUploadYatim - Ringkasan hasil pencarian file yatim
+type laporanUploadYatim struct {
+	RetensiHari int           `json:"retensi_hari"`
+	BatasWaktu  time.Time     `json:"batas_waktu"`
+	Jumlah      int           `json:"jumlah"`
+	TotalUkuran int64         `json:"total_ukuran"`
+	File        []uploadYatim `json:"file"`
+}
+
+// retensiUploadHari - UPLOAD_RETENSI_HARI (default 7): umur minimal file yatim sebelum dihapus
+func retensiUploadHari() int {
+	if v, err := strconv.Atoi(os.Getenv("UPLOAD_RETENSI_HARI")); err == nil && v > 0 {
+		return v
+	}
+	return 7
+}
+
+// referensiFileUpload - Nama file dari semua kolom yang menyimpan URL /uploads/tugas/...
+func referensiFileUpload() (map[string]bool, error) {
+	var urls []string
+	err := config.DB.Raw(`
+		SELECT file_tugas_guru FROM tugas WHERE file_tugas_guru LIKE '%/uploads/tugas/%'
+		UNION SELECT file_tugas_guru FROM templatetugas WHERE file_tugas_guru LIKE '%/uploads/tugas/%'
+		UNION SELECT file_jawaban_siswa FROM pengumpulantugas WHERE file_jawaban_siswa LIKE '%/uploads/tugas/%'
+		UNION SELECT file_jawaban_siswa FROM versipengumpulan WHERE file_jawaban_siswa LIKE '%/uploads/tugas/%'
+		UNION SELECT file_lampiran FROM komentarpengumpulan WHERE file_lampiran LIKE '%/uploads/tugas/%'
+		UNION SELECT url FROM itemmateri WHERE url LIKE '%/uploads/tugas/%'`).Scan(&urls).Error
+	if err != nil {
+		return nil, err
+	}
+
+	dirujuk := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		dirujuk[path.Base(strings.Split(u, "?")[0])] = true
+	}
+	return dirujuk, nil
+}
+
+// cariUploadYatim - File tugas/ yang tidak dirujuk dan lebih tua dari batas waktu, ditambah catatan
+// registry yang filenya sudah tidak ada di storage
+func cariUploadYatim(ctx context.Context, batas time.Time) ([]uploadYatim, error) {
+	dirujuk, err := referensiFileUpload()
+	if err != nil {
+		return nil, err
+	}
+
+	var catatan []models.FileUpload
+	if err := config.DB.Where("status = ? AND kunci LIKE ?", "Aktif", "tugas/%").Find(&catatan).Error; err != nil {
+		return nil, err
+	}
+	registry := make(map[string]models.FileUpload, len(catatan))
+	for _, c := range catatan {
+		registry[c.Kunci] = c
+	}
+
+	var hasil []uploadYatim
+	adaDiStorage := make(map[string]bool)
+	err = storage.Default().List(ctx, "tugas/", func(info storage.Info) error {
+		adaDiStorage[info.Key] = true
+		if dirujuk[path.Base(info.Key)] {
+			return nil
+		}
+
+		item := uploadYatim{Kunci: info.Key, URL: "/uploads/" + info.Key, Ukuran: info.Size, Diupload: info.ModTime, AdaFile: true}
+		// Waktu upload dari registry lebih akurat daripada ModTime (misal setelah migrasi storage)
+		if c, ok := registry[info.Key]; ok {
+			item.Diupload = c.CreatedAt
+			item.NamaAsli = c.NamaAsli
+			item.TipeUser = c.TipeUser
+			item.UserID = c.UserID
+			item.Tujuan = c.Tujuan
+			item.TugasID = c.TugasID
+			item.Terdaftar = true
+		}
+		if item.Diupload.Before(batas) {
+			hasil = append(hasil, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range catatan {
+		if adaDiStorage[c.Kunci] || dirujuk[path.Base(c.Kunci)] || !c.CreatedAt.Before(batas) {
+			continue
+		}
+		hasil = append(hasil, uploadYatim{
+			Kunci:     c.Kunci,
+			URL:       "/uploads/" + c.Kunci,
+			Ukuran:    c.Ukuran,
+			Diupload:  c.CreatedAt,
+			NamaAsli:  c.NamaAsli,
+			TipeUser:  c.TipeUser,
+			UserID:    c.UserID,
+			Tujuan:    c.Tujuan,
+			TugasID:   c.TugasID,
+			Terdaftar: true,
+		})
+	}
+	return hasil, nil
+}
+
+// buatLaporanUploadYatim - Daftar file yatim untuk masa retensi tertentu
+func buatLaporanUploadYatim(ctx context.Context, retensiHari int) (laporanUploadYatim, error) {
+	laporan := laporanUploadYatim{
+		RetensiHari: retensiHari,
+		BatasWaktu:  time.Now().AddDate(0, 0, -retensiHari),
+		File:        []uploadYatim{},
+	}
+	file, err := cariUploadYatim(ctx, laporan.BatasWaktu)
+	if err != nil {
+		return laporan, err
+	}
+	for _, f := range file {
+		if f.AdaFile {
+			laporan.TotalUkuran += f.Ukuran
+		}
+	}
+	laporan.File = append(laporan.File, file...)
+	laporan.Jumlah = len(file)
+	return laporan, nil
+}
+
+// GetLaporanUploadYatim - Admin melihat file yang akan dihapus pembersihan berikutnya (dry run).
+// Query opsional: retensi_hari (default UPLOAD_RETENSI_HARI)
+func GetLaporanUploadYatim(w http.ResponseWriter, r *http.Request) {
+	retensi := retensiUploadHari()
+	if v := r.URL.Query().Get("retensi_hari"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			helpers.Response(w, 400, "retensi_hari harus bilangan bulat minimal 1", nil)
+			return
+		}
+		retensi = n
+	}
+
+	laporan, err := buatLaporanUploadYatim(r.Context(), retensi)
+	if err != nil {
+		log.Printf("❌ Failed to find orphaned uploads: %v", err)
+		helpers.Response(w, 500, "Gagal mencari file yatim", nil)
+		return
+	}
+	helpers.Response(w, 200, "Laporan file upload yatim (dry run)", laporan)
+}
+
+// RunGCUploadCron - Menghapus file upload yatim yang melewati masa retensi beserta catatan registry-nya
+func RunGCUploadCron() {
+	if !gcUploadMu.TryLock() {
+		log.Println("⏭️ Orphaned upload cleanup already running, skipped")
+		return
+	}
+	defer gcUploadMu.Unlock()
+
+	ctx := context.Background()
+	laporan, err := buatLaporanUploadYatim(ctx, retensiUploadHari())
+	if err != nil {
+		log.Printf("❌ Failed to find orphaned uploads: %v", err)
+		return
+	}
+
+	var dihapus int
+	var dibebaskan int64
+	for _, f := range laporan.File {
+		if f.AdaFile {
+			if err := storage.Default().Delete(ctx, f.Kunci); err != nil {
+				log.Printf("⚠️ Failed to delete orphaned upload %s: %v", f.Kunci, err)
+				continue
+			}
+			dibebaskan += f.Ukuran
+			if thumb := kunciThumbnail(f.Kunci); thumb != "" {
+				storage.Default().Delete(ctx, thumb)
+			}
+		}
+		if f.Terdaftar {
+			if err := config.DB.Where("kunci = ?", f.Kunci).Delete(&models.FileUpload{}).Error; err != nil {
+				log.Printf("⚠️ Failed to delete upload record %s: %v", f.Kunci, err)
+			}
+		}
+		dihapus++
+	}
+
+	log.Printf("🧹 Orphaned upload cleanup: %d file dihapus, %d byte dibebaskan", dihapus, dibebaskan)
+}
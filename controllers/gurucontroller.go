@@ -105,13 +105,8 @@ func UpdatePertemuan(w http.ResponseWriter, r *http.Request) {
 	if request.Materi == "" || request.Tanggal == "" {
 		helpers.Response(w, 400, "Materi dan tanggal diperlukan", nil)
 		return
-	}	// Verify that the pertemuan belongs to the guru
-	var pertemuan models.Pertemuan
-	err := config.DB.
-		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
-		Where("pertemuan.id_pertemuan = ? AND jp.guru_id = ?", pertemuanID, guru.ID).
-		First(&pertemuan).Error
-
+	}	// Verify that the pertemuan belongs to the guru (or its approved substitute)
+	pertemuan, err := findPertemuanGuru(pertemuanID, guru.ID)
 	if err != nil {
 		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
 		return
@@ -167,13 +162,8 @@ func UpdateStatusPertemuan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify that the pertemuan belongs to the guru
-	var pertemuan models.Pertemuan
-	err := config.DB.
-		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
-		Where("pertemuan.id_pertemuan = ? AND jp.guru_id = ?", pertemuanID, guru.ID).
-		First(&pertemuan).Error
-	
+	// Verify that the pertemuan belongs to the guru (or its approved substitute)
+	pertemuan, err := findPertemuanGuru(pertemuanID, guru.ID)
 	if err != nil {
 		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
 		return
@@ -241,24 +231,27 @@ func UpdateStatusAbsensi(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify that the absensi belongs to the guru's pertemuan
+	// Verify that the absensi belongs to the guru's pertemuan (or one they substitute)
 	var absensi models.Absensi
-	err := config.DB.
-		Joins("JOIN pertemuan p ON absensi.id_pertemuan = p.id_pertemuan").
-		Joins("JOIN jadwalpelajaran jp ON p.id_jadwal = jp.jadwal_id").
-		Where("absensi.id_absensi = ? AND jp.guru_id = ?", absensiID, guru.ID).
-		First(&absensi).Error
-	
-	if err != nil {
+	if err := config.DB.Where("id_absensi = ?", absensiID).First(&absensi).Error; err != nil {
+		helpers.Response(w, 404, "Absensi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if _, err := findPertemuanGuru(absensi.IDPertemuan, guru.ID); err != nil {
 		helpers.Response(w, 404, "Absensi tidak ditemukan atau Anda tidak memiliki akses", nil)
 		return
 	}
 	// Update status kehadiran
-	err = config.DB.Model(&absensi).Update("status", request.StatusKehadiran).Error
+	statusLama := absensi.Status
+	err := config.DB.Model(&absensi).Update("status", request.StatusKehadiran).Error
 	if err != nil {
 		helpers.Response(w, 500, "Gagal mengubah status kehadiran", nil)
 		return
 	}
+	if statusLama != request.StatusKehadiran {
+		go notifikasiAbsensi(absensi.IDPertemuan, absensi.IDSiswa, request.StatusKehadiran)
+	}
 
 	// Return updated data
 	config.DB.First(&absensi, absensiID)
@@ -317,13 +310,8 @@ func CreateManualAbsensi(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify that the pertemuan belongs to the guru
-	var pertemuan models.Pertemuan
-	err := config.DB.
-		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
-		Where("pertemuan.id_pertemuan = ? AND jp.guru_id = ?", request.IDPertemuan, guru.ID).
-		First(&pertemuan).Error
-	
+	// Verify that the pertemuan belongs to the guru (or its approved substitute)
+	_, err := findPertemuanGuru(request.IDPertemuan, guru.ID)
 	if err != nil {
 		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
 		return
@@ -333,6 +321,7 @@ func CreateManualAbsensi(w http.ResponseWriter, r *http.Request) {
 	err = config.DB.Where("id_pertemuan = ? AND id_siswa = ?", request.IDPertemuan, request.IDSiswa).First(&existingAbsensi).Error
 	if err == nil {
 		// Absensi sudah ada, update statusnya
+		statusLama := existingAbsensi.Status
 		existingAbsensi.Status = request.StatusKehadiran
 		existingAbsensi.WaktuAbsen = time.Now() // Update waktu jika diperlukan
 		
@@ -340,6 +329,9 @@ func CreateManualAbsensi(w http.ResponseWriter, r *http.Request) {
 			helpers.Response(w, 500, "Gagal memperbarui absensi", nil)
 			return
 		}
+		if statusLama != request.StatusKehadiran {
+			go notifikasiAbsensi(existingAbsensi.IDPertemuan, existingAbsensi.IDSiswa, existingAbsensi.Status)
+		}
 		
 		helpers.Response(w, 200, "Absensi berhasil diperbarui", map[string]interface{}{
 			"id_absensi":   existingAbsensi.IDAbsensi,
@@ -362,6 +354,7 @@ func CreateManualAbsensi(w http.ResponseWriter, r *http.Request) {
 		helpers.Response(w, 500, "Gagal membuat absensi manual", nil)
 		return
 	}
+	go notifikasiAbsensi(newAbsensi.IDPertemuan, newAbsensi.IDSiswa, newAbsensi.Status)
 
 	helpers.Response(w, 201, "Absensi manual berhasil dibuat", map[string]interface{}{
 		"id_absensi":   newAbsensi.IDAbsensi,
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+)
+
+// Kotak masuk notifikasi in-app (tabel notifikasi) untuk siswa dan guru, beserta stream SSE
+// yang mendorong notifikasi baru secara real time.
+
+// intervalPollingStream - NOTIFIKASI_STREAM_POLL_DETIK (default 15): interval cek database dan keep-alive stream
+func intervalPollingStream() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIKASI_STREAM_POLL_DETIK")); err == nil && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return 15 * time.Second
+}
+
+// penerimaInbox - Tipe user dan ID pemilik kotak masuk; menulis respon 403 untuk admin
+func penerimaInbox(w http.ResponseWriter, r *http.Request) (string, int, bool) {
+	tipeUser, userID := penerimaNotifikasi(r)
+	if tipeUser == "" {
+		helpers.Response(w, 403, "Notifikasi hanya tersedia untuk siswa dan guru", nil)
+		return "", 0, false
+	}
+	return tipeUser, userID, true
+}
+
+// jumlahBelumDibaca - Jumlah notifikasi yang belum dibaca pengguna
+func jumlahBelumDibaca(tipeUser string, userID int) int64 {
+	var jumlah int64
+	config.DB.Model(&models.Notifikasi{}).
+		Where("tipe_user = ? AND user_id = ? AND status_baca = ?", tipeUser, userID, false).
+		Count(&jumlah)
+	return jumlah
+}
+
+// GetNotifikasi - Daftar notifikasi terbaru dengan paginasi.
+// Query: page (default 1), limit (default 20, maks 100), status=belum_dibaca|dibaca
+func GetNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := config.DB.Model(&models.Notifikasi{}).Where("tipe_user = ? AND user_id = ?", tipeUser, userID)
+	switch r.URL.Query().Get("status") {
+	case "":
+	case "belum_dibaca":
+		query = query.Where("status_baca = ?", false)
+	case "dibaca":
+		query = query.Where("status_baca = ?", true)
+	default:
+		helpers.Response(w, 400, "status harus belum_dibaca atau dibaca", nil)
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil notifikasi", nil)
+		return
+	}
+	notifikasi := []models.Notifikasi{}
+	if err := query.Order("notifikasi_id DESC").Limit(limit).Offset((page - 1) * limit).Find(&notifikasi).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil notifikasi", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Notifikasi berhasil diambil", map[string]interface{}{
+		"notifikasi":   notifikasi,
+		"belum_dibaca": jumlahBelumDibaca(tipeUser, userID),
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetJumlahNotifikasiBelumDibaca - Jumlah notifikasi belum dibaca (untuk badge)
+func GetJumlahNotifikasiBelumDibaca(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+	helpers.Response(w, 200, "Jumlah notifikasi belum dibaca", map[string]interface{}{
+		"belum_dibaca": jumlahBelumDibaca(tipeUser, userID),
+	})
+}
+
+// notifikasiMilikPengguna - Notifikasi berdasarkan ID di URL yang dimiliki pengguna; menulis respon error jika tidak ada
+func notifikasiMilikPengguna(w http.ResponseWriter, r *http.Request, tipeUser string, userID int) (models.Notifikasi, bool) {
+	var notif models.Notifikasi
+	notifikasiID, err := strconv.Atoi(mux.Vars(r)["notifikasi_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid notifikasi ID", nil)
+		return notif, false
+	}
+	if err := config.DB.Where("notifikasi_id = ? AND tipe_user = ? AND user_id = ?", notifikasiID, tipeUser, userID).First(&notif).Error; err != nil {
+		helpers.Response(w, 404, "Notifikasi tidak ditemukan", nil)
+		return notif, false
+	}
+	return notif, true
+}
+
+// BacaNotifikasi - Menandai satu notifikasi sudah dibaca
+func BacaNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+	notif, ok := notifikasiMilikPengguna(w, r, tipeUser, userID)
+	if !ok {
+		return
+	}
+
+	if !notif.StatusBaca {
+		if err := config.DB.Model(&notif).Update("status_baca", true).Error; err != nil {
+			helpers.Response(w, 500, "Gagal menandai notifikasi", nil)
+			return
+		}
+	}
+	helpers.Response(w, 200, "Notifikasi ditandai sudah dibaca", map[string]interface{}{
+		"notifikasi_id": notif.NotifikasiID,
+		"belum_dibaca":  jumlahBelumDibaca(tipeUser, userID),
+	})
+}
+
+// BacaSemuaNotifikasi - Menandai semua notifikasi pengguna sudah dibaca
+func BacaSemuaNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+
+	hasil := config.DB.Model(&models.Notifikasi{}).
+		Where("tipe_user = ? AND user_id = ? AND status_baca = ?", tipeUser, userID, false).
+		Update("status_baca", true)
+	if hasil.Error != nil {
+		helpers.Response(w, 500, "Gagal menandai notifikasi", nil)
+		return
+	}
+	helpers.Response(w, 200, "Semua notifikasi ditandai sudah dibaca", map[string]interface{}{
+		"jumlah_ditandai": hasil.RowsAffected,
+	})
+}
+
+// DeleteNotifikasi - Menghapus satu notifikasi dari kotak masuk
+func DeleteNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+	notif, ok := notifikasiMilikPengguna(w, r, tipeUser, userID)
+	if !ok {
+		return
+	}
+
+	if err := config.DB.Delete(&notif).Error; err != nil {
+		helpers.Response(w, 500, "Gagal menghapus notifikasi", nil)
+		return
+	}
+	helpers.Response(w, 200, "Notifikasi berhasil dihapus", nil)
+}
+
+// pathStreamNotifikasi - Path stream bertanda tangan untuk satu pengguna
+func pathStreamNotifikasi(tipeUser string, userID int) string {
+	return fmt.Sprintf("/api/notifikasi/stream/%s/%d", tipeUser, userID)
+}
+
+// BuatTautanStreamNotifikasi - Tautan stream bertanda tangan untuk EventSource di browser yang tidak
+// bisa mengirim header Authorization. Tautan hanya diperiksa saat koneksi dibuka
+func BuatTautanStreamNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID, ok := penerimaInbox(w, r)
+	if !ok {
+		return
+	}
+	durasi := helpers.DurasiTautanFile()
+	helpers.Response(w, 200, "Tautan stream notifikasi", map[string]interface{}{
+		"url":        helpers.TautanFileBertandaTangan(pathStreamNotifikasi(tipeUser, userID), durasi),
+		"expires_at": time.Now().Add(durasi),
+	})
+}
+
+// StreamNotifikasi - Server-Sent Events berisi notifikasi baru (event "notifikasi", id = notifikasi_id)
+// dan jumlah belum dibaca (event "belum_dibaca"). Autentikasi lewat header Authorization atau tautan
+// dari BuatTautanStreamNotifikasi. Header Last-Event-ID melanjutkan dari notifikasi terakhir yang diterima
+func StreamNotifikasi(w http.ResponseWriter, r *http.Request) {
+	var tipeUser string
+	var userID int
+	if vars := mux.Vars(r); vars["tipe_user"] != "" {
+		if !helpers.VerifikasiTautanFile(r.URL.Path, r.URL.Query().Get("exp"), r.URL.Query().Get("sig")) {
+			helpers.Response(w, 403, "Tautan stream tidak valid atau kedaluwarsa", nil)
+			return
+		}
+		tipeUser = vars["tipe_user"]
+		userID, _ = strconv.Atoi(vars["user_id"])
+	} else {
+		var ok bool
+		if tipeUser, userID, ok = penerimaInbox(w, r); !ok {
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		helpers.Response(w, 500, "Streaming tidak didukung", nil)
+		return
+	}
+
+	// Tanpa Last-Event-ID hanya notifikasi yang dibuat setelah koneksi dibuka yang dikirim
+	lastID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if lastID <= 0 {
+		config.DB.Model(&models.Notifikasi{}).
+			Where("tipe_user = ? AND user_id = ?", tipeUser, userID).
+			Select("COALESCE(MAX(notifikasi_id), 0)").Scan(&lastID)
+	}
+
+	baru, berhenti := helpers.BerlanggananNotifikasi(tipeUser, userID)
+	defer berhenti()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	kirimJumlah := func() {
+		data, _ := json.Marshal(map[string]int64{"belum_dibaca": jumlahBelumDibaca(tipeUser, userID)})
+		fmt.Fprintf(w, "event: belum_dibaca\ndata: %s\n\n", data)
+	}
+	// kirimBaru - Mengambil dari database agar urutan terjaga dan notifikasi dari instance lain ikut terkirim
+	kirimBaru := func() {
+		var daftar []models.Notifikasi
+		if err := config.DB.Where("tipe_user = ? AND user_id = ? AND notifikasi_id > ?", tipeUser, userID, lastID).
+			Order("notifikasi_id ASC").Limit(100).Find(&daftar).Error; err != nil {
+			log.Printf("⚠️ Failed to poll notifications for %s %d: %v", tipeUser, userID, err)
+			return
+		}
+		for _, n := range daftar {
+			data, _ := json.Marshal(n)
+			fmt.Fprintf(w, "id: %d\nevent: notifikasi\ndata: %s\n\n", n.NotifikasiID, data)
+			lastID = n.NotifikasiID
+		}
+		if len(daftar) > 0 {
+			kirimJumlah()
+		}
+	}
+
+	fmt.Fprintf(w, "retry: 5000\n\n")
+	kirimBaru()
+	kirimJumlah()
+	flusher.Flush()
+
+	ticker := time.NewTicker(intervalPollingStream())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-baru:
+			kirimBaru()
+		case <-ticker.C:
+			kirimBaru()
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
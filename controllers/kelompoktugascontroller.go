@@ -0,0 +1,537 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetKelompokTugas - Daftar kelompok beserta anggotanya untuk satu tugas (guru)
+func GetKelompokTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	var kelompokList []models.KelompokTugas
+	if err := config.DB.
+		Preload("Anggota").
+		Preload("Anggota.Siswa").
+		Where("tugas_id = ?", tugasID).
+		Order("kelompok_id ASC").
+		Find(&kelompokList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Kelompok tugas berhasil diambil", kelompokList)
+}
+
+// SetKelompokTugas - Guru menyusun kelompok secara manual (mengganti kelompok yang ada)
+func SetKelompokTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var request struct {
+		Kelompok []struct {
+			NamaKelompok string `json:"nama_kelompok"`
+			SiswaIDs     []int  `json:"siswa_ids"`
+		} `json:"kelompok"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if len(request.Kelompok) == 0 {
+		helpers.Response(w, 400, "Minimal satu kelompok harus diisi", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if tugas.TipeTugas != "Kelompok" {
+		helpers.Response(w, 400, "Tugas ini bukan tugas kelompok", nil)
+		return
+	}
+
+	// Validasi anggota: harus siswa di kelas tugas dan tidak boleh ganda
+	siswaKelas, err := siswaKelasTugas(tugas)
+	if err != nil {
+		helpers.Response(w, 500, "Failed to fetch siswa list", nil)
+		return
+	}
+
+	dipakai := make(map[int]bool)
+	var nama []string
+	var anggota [][]int
+	for i, k := range request.Kelompok {
+		if len(k.SiswaIDs) == 0 {
+			helpers.Response(w, 400, fmt.Sprintf("Kelompok ke-%d tidak memiliki anggota", i+1), nil)
+			return
+		}
+		for _, siswaID := range k.SiswaIDs {
+			if !siswaKelas[siswaID] {
+				helpers.Response(w, 400, fmt.Sprintf("Siswa %d bukan anggota kelas tugas ini", siswaID), nil)
+				return
+			}
+			if dipakai[siswaID] {
+				helpers.Response(w, 400, fmt.Sprintf("Siswa %d terdaftar di lebih dari satu kelompok", siswaID), nil)
+				return
+			}
+			dipakai[siswaID] = true
+		}
+
+		namaKelompok := k.NamaKelompok
+		if namaKelompok == "" {
+			namaKelompok = fmt.Sprintf("Kelompok %d", i+1)
+		}
+		nama = append(nama, namaKelompok)
+		anggota = append(anggota, k.SiswaIDs)
+	}
+
+	kelompokList, err := simpanKelompokTugas(tugasID, nama, anggota)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 201, "Kelompok tugas berhasil disimpan", kelompokList)
+}
+
+// GenerateKelompokAcak - Membagi siswa kelas ke kelompok secara acak
+func GenerateKelompokAcak(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	// Isi salah satu: jumlah_kelompok atau anggota_per_kelompok
+	var request struct {
+		JumlahKelompok     int `json:"jumlah_kelompok"`
+		AnggotaPerKelompok int `json:"anggota_per_kelompok"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if tugas.TipeTugas != "Kelompok" {
+		helpers.Response(w, 400, "Tugas ini bukan tugas kelompok", nil)
+		return
+	}
+
+	siswaKelas, err := siswaKelasTugas(tugas)
+	if err != nil {
+		helpers.Response(w, 500, "Failed to fetch siswa list", nil)
+		return
+	}
+	siswaIDs := make([]int, 0, len(siswaKelas))
+	for siswaID := range siswaKelas {
+		siswaIDs = append(siswaIDs, siswaID)
+	}
+
+	if len(siswaIDs) == 0 {
+		helpers.Response(w, 400, "Tidak ada siswa di kelas ini", nil)
+		return
+	}
+
+	jumlah := request.JumlahKelompok
+	if jumlah <= 0 && request.AnggotaPerKelompok > 0 {
+		jumlah = (len(siswaIDs) + request.AnggotaPerKelompok - 1) / request.AnggotaPerKelompok
+	}
+	if jumlah <= 0 {
+		helpers.Response(w, 400, "jumlah_kelompok atau anggota_per_kelompok harus diisi", nil)
+		return
+	}
+	if jumlah > len(siswaIDs) {
+		jumlah = len(siswaIDs)
+	}
+
+	// Acak urutan siswa lalu bagikan bergiliran agar ukuran kelompok seimbang
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rng.Shuffle(len(siswaIDs), func(i, j int) {
+		siswaIDs[i], siswaIDs[j] = siswaIDs[j], siswaIDs[i]
+	})
+
+	nama := make([]string, jumlah)
+	anggota := make([][]int, jumlah)
+	for i := range nama {
+		nama[i] = fmt.Sprintf("Kelompok %d", i+1)
+	}
+	for i, siswaID := range siswaIDs {
+		anggota[i%jumlah] = append(anggota[i%jumlah], siswaID)
+	}
+
+	kelompokList, err := simpanKelompokTugas(tugasID, nama, anggota)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 201, "Kelompok acak berhasil dibuat", kelompokList)
+}
+
+// NilaiKelompok - Memberi nilai satu kelompok sekaligus dengan penyesuaian per anggota
+func NilaiKelompok(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	kelompokID, err := strconv.Atoi(vars["kelompok_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kelompok ID", nil)
+		return
+	}
+
+	var request struct {
+		PoinKelompok      int    `json:"poin_kelompok"`
+		CatatanGuru       string `json:"catatan_guru"`
+		StatusPengumpulan string `json:"status_pengumpulan"`
+		Penyesuaian       []struct {
+			SiswaID         int `json:"siswa_id"`
+			PenyesuaianPoin int `json:"penyesuaian_poin"`
+		} `json:"penyesuaian"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid JSON format", nil)
+		return
+	}
+
+	if request.StatusPengumpulan == "" {
+		request.StatusPengumpulan = "Dinilai"
+	}
+
+	var kelompok models.KelompokTugas
+	if err := config.DB.First(&kelompok, "kelompok_id = ?", kelompokID).Error; err != nil {
+		helpers.Response(w, 404, "Kelompok tidak ditemukan", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(kelompok.TugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 403, "Access denied - not your class", nil)
+		return
+	}
+
+	if request.PoinKelompok < 0 || request.PoinKelompok > tugas.PoinMaksimal {
+		helpers.Response(w, 400, "Poin melebihi poin maksimal tugas", nil)
+		return
+	}
+
+	var anggota []int
+	if err := config.DB.Model(&models.AnggotaKelompok{}).Where("kelompok_id = ?", kelompokID).
+		Pluck("siswa_id", &anggota).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch anggota kelompok", nil)
+		return
+	}
+	anggotaKelompok := make(map[int]bool, len(anggota))
+	for _, siswaID := range anggota {
+		anggotaKelompok[siswaID] = true
+	}
+
+	penyesuaian := make(map[int]int)
+	for _, p := range request.Penyesuaian {
+		if !anggotaKelompok[p.SiswaID] {
+			helpers.Response(w, 400, fmt.Sprintf("Siswa %d bukan anggota kelompok ini", p.SiswaID), nil)
+			return
+		}
+		penyesuaian[p.SiswaID] = p.PenyesuaianPoin
+	}
+
+	var pengumpulanList []models.PengumpulanTugas
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tugas_id = ? AND kelompok_id = ?", tugas.TugasID, kelompokID).
+			Find(&pengumpulanList).Error; err != nil {
+			return err
+		}
+		if len(pengumpulanList) == 0 {
+			return fmt.Errorf("kelompok belum mengumpulkan tugas")
+		}
+
+		if err := tx.Model(&kelompok).Updates(map[string]interface{}{
+			"poin_kelompok": request.PoinKelompok,
+			"catatan_guru":  request.CatatanGuru,
+		}).Error; err != nil {
+			return err
+		}
+
+		for i := range pengumpulanList {
+			p := &pengumpulanList[i]
+			if adj, ok := penyesuaian[p.SiswaID]; ok {
+				p.PenyesuaianPoin = adj
+			}
+			poinMentah := hitungPoinAnggota(request.PoinKelompok, p.PenyesuaianPoin, tugas.PoinMaksimal)
+			waktuKumpul, _ := pilihVersiDinilai(p, 0)
+			terapkanPoinPengumpulan(tugas, p, poinMentah, waktuKumpul)
+			p.CatatanGuru = request.CatatanGuru
+			p.StatusPengumpulan = request.StatusPengumpulan
+			if err := tx.Save(p).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		helpers.Response(w, 400, "Gagal menilai kelompok: "+err.Error(), nil)
+		return
+	}
+
+	go notifikasiNilai(tugas, pengumpulanList...)
+
+	helpers.Response(w, 200, "Nilai kelompok berhasil disimpan", map[string]interface{}{
+		"kelompok":    kelompok,
+		"pengumpulan": pengumpulanList,
+	})
+}
+
+// GetKelompokSaya - Siswa melihat kelompoknya pada sebuah tugas
+func GetKelompokSaya(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	kelompok, err := findKelompokSiswa(tugasID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Anda belum tergabung dalam kelompok untuk tugas ini", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Kelompok tugas berhasil diambil", kelompok)
+}
+
+// submitTugasKelompok - Satu pengumpulan dipakai bersama oleh seluruh anggota kelompok
+func submitTugasKelompok(tugas models.Tugas, siswaID int, fileJawaban, catatan, status string, now time.Time) (models.PengumpulanTugas, error) {
+	var hasil models.PengumpulanTugas
+
+	kelompok, err := findKelompokSiswa(tugas.TugasID, siswaID)
+	if err != nil {
+		return hasil, fmt.Errorf("anda belum tergabung dalam kelompok untuk tugas ini")
+	}
+
+	// Kebijakan pengumpulan ulang dicek terhadap pengumpulan kelompok yang sudah ada
+	var existing models.PengumpulanTugas
+	if err := config.DB.Where("tugas_id = ? AND kelompok_id = ?", tugas.TugasID, kelompok.KelompokID).
+		Limit(1).Find(&existing).Error; err != nil {
+		return hasil, err
+	}
+	if existing.PengumpulanID != 0 {
+		if err := cekKebijakanPengumpulanUlang(tugas, existing); err != nil {
+			return hasil, err
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, anggota := range kelompok.Anggota {
+			var pengumpulan models.PengumpulanTugas
+			res := tx.Where("tugas_id = ? AND siswa_id = ?", tugas.TugasID, anggota.SiswaID).Limit(1).Find(&pengumpulan)
+			if res.Error != nil {
+				return res.Error
+			}
+
+			pengumpulan.TugasID = tugas.TugasID
+			pengumpulan.SiswaID = anggota.SiswaID
+			pengumpulan.KelompokID = &kelompok.KelompokID
+			pengumpulan.FileJawabanSiswa = fileJawaban
+			pengumpulan.CatatanSiswa = catatan
+			pengumpulan.TanggalPengumpulan = now
+			pengumpulan.StatusPengumpulan = status
+
+			if err := tx.Save(&pengumpulan).Error; err != nil {
+				return err
+			}
+			if err := catatVersiPengumpulan(tx, &pengumpulan); err != nil {
+				return err
+			}
+			if anggota.SiswaID == siswaID {
+				hasil = pengumpulan
+			}
+		}
+		return nil
+	})
+
+	return hasil, err
+}
+
+// findKelompokSiswa - Kelompok (beserta anggota) tempat siswa tergabung pada sebuah tugas
+func findKelompokSiswa(tugasID, siswaID int) (models.KelompokTugas, error) {
+	var kelompok models.KelompokTugas
+	err := config.DB.
+		Preload("Anggota").
+		Preload("Anggota.Siswa").
+		Joins("JOIN anggotakelompok ak ON ak.kelompok_id = kelompoktugas.kelompok_id").
+		Where("kelompoktugas.tugas_id = ? AND ak.siswa_id = ?", tugasID, siswaID).
+		First(&kelompok).Error
+
+	return kelompok, err
+}
+
+// simpanKelompokTugas - Mengganti seluruh kelompok tugas dalam satu transaksi
+func simpanKelompokTugas(tugasID int, nama []string, anggota [][]int) ([]models.KelompokTugas, error) {
+	var kelompokList []models.KelompokTugas
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Kelompok tidak boleh diubah setelah ada pengumpulan kelompok
+		var terkumpul int64
+		if err := tx.Model(&models.PengumpulanTugas{}).
+			Where("tugas_id = ? AND kelompok_id IS NOT NULL", tugasID).
+			Count(&terkumpul).Error; err != nil {
+			return err
+		}
+		if terkumpul > 0 {
+			return fmt.Errorf("kelompok tidak dapat diubah karena sudah ada pengumpulan")
+		}
+
+		if err := tx.Where("kelompok_id IN (?)",
+			tx.Model(&models.KelompokTugas{}).Select("kelompok_id").Where("tugas_id = ?", tugasID),
+		).Delete(&models.AnggotaKelompok{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tugas_id = ?", tugasID).Delete(&models.KelompokTugas{}).Error; err != nil {
+			return err
+		}
+
+		for i := range nama {
+			kelompok := models.KelompokTugas{
+				TugasID:      tugasID,
+				NamaKelompok: nama[i],
+			}
+			for _, siswaID := range anggota[i] {
+				kelompok.Anggota = append(kelompok.Anggota, models.AnggotaKelompok{SiswaID: siswaID})
+			}
+			if err := tx.Create(&kelompok).Error; err != nil {
+				return err
+			}
+			kelompokList = append(kelompokList, kelompok)
+		}
+		return nil
+	})
+
+	return kelompokList, err
+}
+
+// anggotaKelompokSiswa - Seluruh anggota kelompok siswa pada sebuah tugas (kosong jika belum berkelompok)
+func anggotaKelompokSiswa(tugasID, siswaID int) []int {
+	var anggota []int
+	config.DB.Raw(`
+		SELECT ak2.siswa_id
+		FROM anggotakelompok ak
+		JOIN kelompoktugas kt ON kt.kelompok_id = ak.kelompok_id AND kt.tugas_id = ?
+		JOIN anggotakelompok ak2 ON ak2.kelompok_id = ak.kelompok_id
+		WHERE ak.siswa_id = ?`, tugasID, siswaID).Scan(&anggota)
+	return anggota
+}
+
+// siswaKelasTugas - Set siswa_id yang berada di kelas tugas
+func siswaKelasTugas(tugas models.Tugas) (map[int]bool, error) {
+	var siswaIDs []int
+	if err := config.DB.Model(&models.Siswa{}).
+		Where("kelas_id = ?", tugas.JadwalPelajaran.KelasID).
+		Pluck("siswa_id", &siswaIDs).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int]bool, len(siswaIDs))
+	for _, id := range siswaIDs {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// hitungPoinAnggota - Poin kelompok ditambah penyesuaian individu, dibatasi 0..poin maksimal
+func hitungPoinAnggota(poinKelompok, penyesuaian, poinMaksimal int) int {
+	poin := poinKelompok + penyesuaian
+	if poin < 0 {
+		return 0
+	}
+	if poin > poinMaksimal {
+		return poinMaksimal
+	}
+	return poin
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+)
+
+// GetPerpanjanganDeadline - Daftar perpanjangan deadline siswa untuk sebuah tugas
+func GetPerpanjanganDeadline(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	var perpanjanganList []models.PerpanjanganDeadline
+	if err := config.DB.Preload("Siswa").
+		Where("tugas_id = ?", tugasID).
+		Order("deadline_baru ASC").
+		Find(&perpanjanganList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Perpanjangan deadline berhasil diambil", perpanjanganList)
+}
+
+// SetPerpanjanganDeadline - Guru memberi (atau mengubah) perpanjangan deadline untuk satu siswa
+func SetPerpanjanganDeadline(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var input struct {
+		SiswaID      int    `json:"siswa_id"`
+		DeadlineBaru string `json:"deadline_baru"`
+		Alasan       string `json:"alasan"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if input.SiswaID == 0 || input.DeadlineBaru == "" {
+		helpers.Response(w, 400, "Missing required fields", nil)
+		return
+	}
+
+	deadlineBaru, err := time.Parse("2006-01-02T15:04", input.DeadlineBaru)
+	if err != nil {
+		helpers.Response(w, 400, "Format deadline tidak valid", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if !deadlineBaru.After(tugas.DeadlinePengumpulan) {
+		helpers.Response(w, 400, "Deadline baru harus setelah deadline tugas", nil)
+		return
+	}
+
+	siswaKelas, err := siswaKelasTugas(tugas)
+	if err != nil {
+		helpers.Response(w, 500, "Failed to fetch siswa list", nil)
+		return
+	}
+	if !siswaKelas[input.SiswaID] {
+		helpers.Response(w, 400, "Siswa bukan anggota kelas tugas ini", nil)
+		return
+	}
+
+	var perpanjangan models.PerpanjanganDeadline
+	if err := config.DB.Where("tugas_id = ? AND siswa_id = ?", tugasID, input.SiswaID).
+		Limit(1).Find(&perpanjangan).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	perpanjangan.TugasID = tugasID
+	perpanjangan.SiswaID = input.SiswaID
+	perpanjangan.DeadlineBaru = deadlineBaru
+	perpanjangan.Alasan = input.Alasan
+	perpanjangan.DiberikanOleh = guru.ID
+
+	if err := config.DB.Save(&perpanjangan).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Perpanjangan deadline berhasil disimpan", perpanjangan)
+}
+
+// DeletePerpanjanganDeadline - Mencabut perpanjangan deadline siswa
+func DeletePerpanjanganDeadline(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+	siswaID, err := strconv.Atoi(vars["siswa_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid siswa ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	result := config.DB.Where("tugas_id = ? AND siswa_id = ?", tugasID, siswaID).Delete(&models.PerpanjanganDeadline{})
+	if result.Error != nil {
+		helpers.Response(w, 500, "Database error: "+result.Error.Error(), nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		helpers.Response(w, 404, "Perpanjangan deadline tidak ditemukan", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Perpanjangan deadline berhasil dihapus", nil)
+}
+
+// batasPengumpulanSiswa - Deadline efektif siswa (termasuk perpanjangan dan masa tenggang)
+// serta batas akhir mutlak pengumpulan (nil jika tugas tidak pernah ditutup). Pada tugas kelompok
+// dipakai deadline bersama seluruh anggota kelompok siswa
+func batasPengumpulanSiswa(tugas models.Tugas, siswaID int) (time.Time, *time.Time) {
+	deadline := tugas.DeadlinePengumpulan
+	tutup := tugas.TanggalTutup
+
+	siswaIDs := []int{siswaID}
+	if tugas.TipeTugas == "Kelompok" {
+		if anggota := anggotaKelompokSiswa(tugas.TugasID, siswaID); len(anggota) > 0 {
+			siswaIDs = anggota
+		}
+	}
+
+	// Perpanjangan hanya berlaku jika dimiliki semua anggota; yang paling awal menjadi deadline bersama
+	var perpanjangan []models.PerpanjanganDeadline
+	config.DB.Where("tugas_id = ? AND siswa_id IN ?", tugas.TugasID, siswaIDs).Find(&perpanjangan)
+	if len(perpanjangan) == len(siswaIDs) {
+		baru := perpanjangan[0].DeadlineBaru
+		for _, p := range perpanjangan[1:] {
+			if p.DeadlineBaru.Before(baru) {
+				baru = p.DeadlineBaru
+			}
+		}
+		if baru.After(deadline) {
+			deadline = baru
+		}
+	}
+
+	deadline = deadline.Add(time.Duration(tugas.GracePeriodMenit) * time.Minute)
+	// Perpanjangan dan masa tenggang yang melewati tanggal tutup ikut memundurkan tanggal tutup siswa
+	// tersebut, sehingga masa tenggang tetap berlaku jika tanggal tutup sama dengan deadline
+	if tutup != nil && deadline.After(*tutup) {
+		tutupSiswa := deadline
+		tutup = &tutupSiswa
+	}
+	return deadline, tutup
+}
+
+// hitungPenaltiTerlambat - Persentase penalti dan jumlah hari terlambat untuk waktu pengumpulan tertentu
+func hitungPenaltiTerlambat(tugas models.Tugas, siswaID int, waktuKumpul time.Time) (float64, int) {
+	deadline, _ := batasPengumpulanSiswa(tugas, siswaID)
+	if !waktuKumpul.After(deadline) {
+		return 0, 0
+	}
+
+	// Setiap bagian hari keterlambatan dihitung satu hari penuh
+	hari := int(math.Ceil(waktuKumpul.Sub(deadline).Hours() / 24))
+	persen := float64(hari) * tugas.PenaltiPersenPerHari
+
+	maksimal := tugas.PenaltiMaksimalPersen
+	if maksimal <= 0 || maksimal > 100 {
+		maksimal = 100
+	}
+	if persen > maksimal {
+		persen = maksimal
+	}
+
+	return persen, hari
+}
+
+// terapkanPenalti - Poin setelah dikurangi penalti keterlambatan
+func terapkanPenalti(poinMentah int, persenPenalti float64) int {
+	return int(math.Round(float64(poinMentah) * (100 - persenPenalti) / 100))
+}
+
+// terapkanPoinPengumpulan - Menyimpan poin mentah dan poin setelah penalti keterlambatan
+func terapkanPoinPengumpulan(tugas models.Tugas, pengumpulan *models.PengumpulanTugas, poinMentah int, waktuKumpul time.Time) {
+	persenPenalti, hariTerlambat := hitungPenaltiTerlambat(tugas, pengumpulan.SiswaID, waktuKumpul)
+	pengumpulan.PoinMentah = &poinMentah
+	pengumpulan.PersenPenalti = persenPenalti
+	pengumpulan.HariTerlambat = hariTerlambat
+	pengumpulan.PoinDidapat = terapkanPenalti(poinMentah, persenPenalti)
+
+	// Nilai dalam skala 0-100 dari poin maksimal tugas
+	if tugas.PoinMaksimal > 0 {
+		nilai := math.Round(float64(pengumpulan.PoinDidapat)/float64(tugas.PoinMaksimal)*10000) / 100
+		pengumpulan.Nilai = &nilai
+	}
+}
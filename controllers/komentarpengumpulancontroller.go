@@ -0,0 +1,469 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// komentarInput - Isi request komentar baru. Lampiran diunggah dulu lewat /api/upload/tugas
+type komentarInput struct {
+	Isi          string `json:"isi"`
+	FileLampiran string `json:"file_lampiran"`
+	NamaLampiran string `json:"nama_lampiran"`
+}
+
+// validasi - Komentar minimal berisi teks atau lampiran, lampiran harus file hasil upload pengirim sendiri
+func (k *komentarInput) validasi(tipeUser string, userID int) error {
+	k.Isi = strings.TrimSpace(k.Isi)
+	if k.Isi == "" && k.FileLampiran == "" {
+		return fmt.Errorf("Komentar atau lampiran wajib diisi")
+	}
+	if k.FileLampiran != "" && !strings.HasPrefix(k.FileLampiran, "/uploads/tugas/") {
+		return fmt.Errorf("Lampiran harus file yang diunggah melalui /api/upload/tugas")
+	}
+	if k.FileLampiran != "" && !fileUploadMilik(k.FileLampiran, tipeUser, userID) {
+		return fmt.Errorf("Lampiran harus file yang Anda unggah sendiri")
+	}
+	return nil
+}
+
+// findPengumpulanGuru - Mengambil pengumpulan jika tugasnya berada pada jadwal milik guru
+func findPengumpulanGuru(pengumpulanID, guruID int) (models.PengumpulanTugas, error) {
+	var pengumpulan models.PengumpulanTugas
+	err := config.DB.Preload("Tugas").
+		Joins("JOIN tugas ON tugas.tugas_id = pengumpulantugas.tugas_id").
+		Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
+		Where("pengumpulantugas.pengumpulan_id = ? AND jadwalpelajaran.guru_id = ?", pengumpulanID, guruID).
+		First(&pengumpulan).Error
+
+	return pengumpulan, err
+}
+
+// ambilThreadKomentar - Seluruh komentar sebuah pengumpulan (terlama dulu) beserta nama pengirim
+func ambilThreadKomentar(pengumpulanID int) ([]models.KomentarPengumpulan, error) {
+	var komentarList []models.KomentarPengumpulan
+	if err := config.DB.Where("pengumpulan_id = ?", pengumpulanID).
+		Order("komentar_id ASC").
+		Find(&komentarList).Error; err != nil {
+		return nil, err
+	}
+
+	var guruIDs, siswaIDs []int
+	for _, k := range komentarList {
+		if k.TipePengirim == "Guru" {
+			guruIDs = append(guruIDs, k.PengirimID)
+		} else {
+			siswaIDs = append(siswaIDs, k.PengirimID)
+		}
+	}
+
+	namaGuru := make(map[int]string)
+	if len(guruIDs) > 0 {
+		var guruList []models.Guru
+		config.DB.Select("guru_id, nama_lengkap").Where("guru_id IN ?", guruIDs).Find(&guruList)
+		for _, g := range guruList {
+			namaGuru[g.GuruID] = g.NamaLengkap
+		}
+	}
+	namaSiswa := make(map[int]string)
+	if len(siswaIDs) > 0 {
+		var siswaList []models.Siswa
+		config.DB.Select("siswa_id, nama_lengkap").Where("siswa_id IN ?", siswaIDs).Find(&siswaList)
+		for _, s := range siswaList {
+			namaSiswa[s.SiswaID] = s.NamaLengkap
+		}
+	}
+
+	for i := range komentarList {
+		if komentarList[i].TipePengirim == "Guru" {
+			komentarList[i].NamaPengirim = namaGuru[komentarList[i].PengirimID]
+		} else {
+			komentarList[i].NamaPengirim = namaSiswa[komentarList[i].PengirimID]
+		}
+	}
+
+	return komentarList, nil
+}
+
+// tandaiKomentarDibaca - Menyimpan komentar terakhir yang sudah dibaca user (tidak pernah mundur)
+func tandaiKomentarDibaca(pengumpulanID int, tipeUser string, userID, komentarID int) {
+	if komentarID == 0 {
+		return
+	}
+	config.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"terakhir_dibaca_id": gorm.Expr("GREATEST(terakhir_dibaca_id, ?)", komentarID),
+		}),
+	}).Create(&models.BacaKomentar{
+		PengumpulanID:    pengumpulanID,
+		TipeUser:         tipeUser,
+		UserID:           userID,
+		TerakhirDibacaID: komentarID,
+	})
+}
+
+// queryKomentarBelumDibaca - Komentar dari pihak lain yang lebih baru dari penanda baca user
+func queryKomentarBelumDibaca(tipeUser string, userID int) *gorm.DB {
+	return config.DB.Table("komentarpengumpulan").
+		Joins("LEFT JOIN bacakomentar ON bacakomentar.pengumpulan_id = komentarpengumpulan.pengumpulan_id AND bacakomentar.tipe_user = ? AND bacakomentar.user_id = ?", tipeUser, userID).
+		Where("komentarpengumpulan.tipe_pengirim <> ?", tipeUser).
+		Where("komentarpengumpulan.komentar_id > COALESCE(bacakomentar.terakhir_dibaca_id, 0)")
+}
+
+// hitungKomentarBelumDibaca - Jumlah komentar belum dibaca per pengumpulan
+func hitungKomentarBelumDibaca(pengumpulanIDs []int, tipeUser string, userID int) map[int]int {
+	hasil := make(map[int]int)
+	if len(pengumpulanIDs) == 0 {
+		return hasil
+	}
+
+	var rows []struct {
+		PengumpulanID int
+		Jumlah        int
+	}
+	queryKomentarBelumDibaca(tipeUser, userID).
+		Select("komentarpengumpulan.pengumpulan_id, COUNT(*) AS jumlah").
+		Where("komentarpengumpulan.pengumpulan_id IN ?", pengumpulanIDs).
+		Group("komentarpengumpulan.pengumpulan_id").
+		Scan(&rows)
+	for _, row := range rows {
+		hasil[row.PengumpulanID] = row.Jumlah
+	}
+	return hasil
+}
+
+// ringkasanKomentarBelumDibaca - Daftar thread dengan komentar belum dibaca, dibatasi query tambahan (kepemilikan)
+func ringkasanKomentarBelumDibaca(tipeUser string, userID int, filter func(*gorm.DB) *gorm.DB) ([]map[string]interface{}, int, error) {
+	var rows []struct {
+		PengumpulanID int
+		TugasID       int
+		JudulTugas    string
+		SiswaID       int
+		NamaSiswa     string
+		Jumlah        int
+	}
+	err := filter(queryKomentarBelumDibaca(tipeUser, userID).
+		Select("komentarpengumpulan.pengumpulan_id, tugas.tugas_id, tugas.judul_tugas, siswa.siswa_id, siswa.nama_lengkap AS nama_siswa, COUNT(*) AS jumlah").
+		Joins("JOIN pengumpulantugas ON pengumpulantugas.pengumpulan_id = komentarpengumpulan.pengumpulan_id").
+		Joins("JOIN tugas ON tugas.tugas_id = pengumpulantugas.tugas_id").
+		Joins("JOIN siswa ON siswa.siswa_id = pengumpulantugas.siswa_id")).
+		Group("komentarpengumpulan.pengumpulan_id, tugas.tugas_id, tugas.judul_tugas, siswa.siswa_id, siswa.nama_lengkap").
+		Order("MAX(komentarpengumpulan.komentar_id) DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := 0
+	daftar := []map[string]interface{}{}
+	for _, row := range rows {
+		total += row.Jumlah
+		daftar = append(daftar, map[string]interface{}{
+			"pengumpulan_id": row.PengumpulanID,
+			"tugas_id":       row.TugasID,
+			"judul_tugas":    row.JudulTugas,
+			"siswa_id":       row.SiswaID,
+			"nama_siswa":     row.NamaSiswa,
+			"belum_dibaca":   row.Jumlah,
+		})
+	}
+	return daftar, total, nil
+}
+
+// simpanKomentar - Menyimpan komentar baru; pengirim otomatis dianggap sudah membaca thread
+func simpanKomentar(pengumpulanID, pengirimID int, tipePengirim string, input komentarInput) (models.KomentarPengumpulan, error) {
+	komentar := models.KomentarPengumpulan{
+		PengumpulanID: pengumpulanID,
+		PengirimID:    pengirimID,
+		TipePengirim:  tipePengirim,
+		Isi:           input.Isi,
+		FileLampiran:  input.FileLampiran,
+		NamaLampiran:  input.NamaLampiran,
+	}
+	if err := config.DB.Create(&komentar).Error; err != nil {
+		return komentar, err
+	}
+	tandaiKomentarDibaca(pengumpulanID, tipePengirim, pengirimID, komentar.KomentarID)
+	return komentar, nil
+}
+
+// cuplikanKomentar - Potongan isi komentar untuk pesan notifikasi
+func cuplikanKomentar(komentar models.KomentarPengumpulan) string {
+	isi := []rune(komentar.Isi)
+	if len(isi) > 100 {
+		return string(isi[:100]) + "..."
+	}
+	if len(isi) == 0 {
+		return "(lampiran " + komentar.NamaLampiran + ")"
+	}
+	return string(isi)
+}
+
+// hapusKomentar - Pengirim menghapus komentarnya sendiri
+func hapusKomentar(w http.ResponseWriter, r *http.Request, tipeUser string, userID int) {
+	vars := mux.Vars(r)
+	komentarID, err := strconv.Atoi(vars["komentar_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid komentar ID", nil)
+		return
+	}
+
+	result := config.DB.Where("komentar_id = ? AND pengirim_id = ? AND tipe_pengirim = ?", komentarID, userID, tipeUser).
+		Delete(&models.KomentarPengumpulan{})
+	if result.Error != nil {
+		helpers.Response(w, 500, "Database error: "+result.Error.Error(), nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		helpers.Response(w, 404, "Komentar tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Komentar berhasil dihapus", nil)
+}
+
+// ==================== Guru ====================
+
+// GetKomentarPengumpulanGuru - Thread komentar sebuah pengumpulan; seluruh komentar ditandai sudah dibaca guru
+func GetKomentarPengumpulanGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pengumpulanID, err := strconv.Atoi(vars["pengumpulan_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pengumpulan ID", nil)
+		return
+	}
+
+	if _, err := findPengumpulanGuru(pengumpulanID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Pengumpulan tidak ditemukan atau bukan milik kelas Anda", nil)
+		return
+	}
+
+	komentarList, err := ambilThreadKomentar(pengumpulanID)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+	if len(komentarList) > 0 {
+		tandaiKomentarDibaca(pengumpulanID, "Guru", guru.ID, komentarList[len(komentarList)-1].KomentarID)
+	}
+
+	helpers.Response(w, 200, "Komentar berhasil diambil", komentarList)
+}
+
+// KirimKomentarGuru - Guru menambahkan komentar pada pengumpulan siswa; siswa mendapat notifikasi
+func KirimKomentarGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pengumpulanID, err := strconv.Atoi(vars["pengumpulan_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pengumpulan ID", nil)
+		return
+	}
+
+	var input komentarInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if err := input.validasi("Guru", guru.ID); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	pengumpulan, err := findPengumpulanGuru(pengumpulanID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Pengumpulan tidak ditemukan atau bukan milik kelas Anda", nil)
+		return
+	}
+
+	komentar, err := simpanKomentar(pengumpulanID, guru.ID, "Guru", input)
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan komentar", nil)
+		return
+	}
+	komentar.NamaPengirim = guru.Name
+
+	go notifikasiSiswa(pengumpulan.SiswaID, "Komentar baru dari guru",
+		fmt.Sprintf("%s mengomentari tugas \"%s\": %s", guru.Name, pengumpulan.Tugas.JudulTugas, cuplikanKomentar(komentar)),
+		"/tugas")
+
+	helpers.Response(w, 201, "Komentar berhasil dikirim", komentar)
+}
+
+// DeleteKomentarGuru - Guru menghapus komentarnya sendiri
+func DeleteKomentarGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	hapusKomentar(w, r, "Guru", guru.ID)
+}
+
+// GetKomentarBelumDibacaGuru - Thread pada kelas guru yang memiliki komentar siswa belum dibaca
+func GetKomentarBelumDibacaGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	daftar, total, err := ringkasanKomentarBelumDibaca("Guru", guru.ID, func(db *gorm.DB) *gorm.DB {
+		return db.Joins("JOIN jadwalpelajaran ON jadwalpelajaran.jadwal_id = tugas.jadwal_id").
+			Where("jadwalpelajaran.guru_id = ?", guru.ID)
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Komentar belum dibaca berhasil diambil", map[string]interface{}{
+		"total_belum_dibaca": total,
+		"thread":             daftar,
+	})
+}
+
+// ==================== Siswa ====================
+
+// GetKomentarPengumpulanSiswa - Thread komentar pada pengumpulan siswa untuk sebuah tugas
+func GetKomentarPengumpulanSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	if err := config.DB.Where("tugas_id = ? AND siswa_id = ?", tugasID, siswa.ID).First(&pengumpulan).Error; err != nil {
+		helpers.Response(w, 404, "Pengumpulan tugas not found", nil)
+		return
+	}
+
+	komentarList, err := ambilThreadKomentar(pengumpulan.PengumpulanID)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+	if len(komentarList) > 0 {
+		tandaiKomentarDibaca(pengumpulan.PengumpulanID, "Siswa", siswa.ID, komentarList[len(komentarList)-1].KomentarID)
+	}
+
+	helpers.Response(w, 200, "Komentar berhasil diambil", komentarList)
+}
+
+// KirimKomentarSiswa - Siswa membalas thread komentar pengumpulannya; guru pengampu mendapat notifikasi
+func KirimKomentarSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var input komentarInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if err := input.validasi("Siswa", siswa.ID); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	if err := config.DB.Preload("Tugas.JadwalPelajaran").
+		Where("tugas_id = ? AND siswa_id = ?", tugasID, siswa.ID).
+		First(&pengumpulan).Error; err != nil {
+		helpers.Response(w, 404, "Pengumpulan tugas not found", nil)
+		return
+	}
+
+	komentar, err := simpanKomentar(pengumpulan.PengumpulanID, siswa.ID, "Siswa", input)
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan komentar", nil)
+		return
+	}
+	komentar.NamaPengirim = siswa.Name
+
+	go notifikasiGuru(pengumpulan.Tugas.JadwalPelajaran.GuruID, "Komentar baru dari siswa",
+		fmt.Sprintf("%s mengomentari tugas \"%s\": %s", siswa.Name, pengumpulan.Tugas.JudulTugas, cuplikanKomentar(komentar)),
+		"/guru/jadwal")
+
+	helpers.Response(w, 201, "Komentar berhasil dikirim", komentar)
+}
+
+// DeleteKomentarSiswa - Siswa menghapus komentarnya sendiri
+func DeleteKomentarSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+	hapusKomentar(w, r, "Siswa", siswa.ID)
+}
+
+// GetKomentarBelumDibacaSiswa - Thread pengumpulan siswa yang memiliki komentar guru belum dibaca
+func GetKomentarBelumDibacaSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	daftar, total, err := ringkasanKomentarBelumDibaca("Siswa", siswa.ID, func(db *gorm.DB) *gorm.DB {
+		return db.Where("pengumpulantugas.siswa_id = ?", siswa.ID)
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Komentar belum dibaca berhasil diambil", map[string]interface{}{
+		"total_belum_dibaca": total,
+		"thread":             daftar,
+	})
+}
@@ -0,0 +1,1099 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// soalKuisInput - Format soal pada request pembuatan/penggantian soal kuis
+type soalKuisInput struct {
+	TipeSoal        string          `json:"tipe_soal"`
+	Pertanyaan      string          `json:"pertanyaan"`
+	Poin            int             `json:"poin"`
+	JawabanBenar    *bool           `json:"jawaban_benar"`    // BenarSalah
+	JawabanDiterima []string        `json:"jawaban_diterima"` // IsianSingkat
+	Opsi            []opsiSoalInput `json:"opsi"`             // PilihanGanda & PilihanJamak
+}
+
+// opsiSoalInput - Format opsi pada soal pilihan ganda/jamak
+type opsiSoalInput struct {
+	Teks    string `json:"teks"`
+	IsBenar bool   `json:"is_benar"`
+}
+
+// findKuisGuru - Mengambil kuis jika jadwalnya milik guru
+func findKuisGuru(kuisID, guruID int) (models.Kuis, error) {
+	var kuis models.Kuis
+	err := config.DB.Preload("Tugas").
+		Joins("JOIN jadwalpelajaran ON kuis.jadwal_id = jadwalpelajaran.jadwal_id").
+		Where("kuis.kuis_id = ? AND jadwalpelajaran.guru_id = ?", kuisID, guruID).
+		First(&kuis).Error
+
+	return kuis, err
+}
+
+// findKuisSiswa - Mengambil kuis jika siswa anggota kelas jadwal kuis tersebut
+func findKuisSiswa(kuisID, siswaID int) (models.Kuis, error) {
+	var kuis models.Kuis
+	err := config.DB.Preload("Tugas").
+		Joins("JOIN jadwalpelajaran ON kuis.jadwal_id = jadwalpelajaran.jadwal_id").
+		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
+		Where("kuis.kuis_id = ? AND siswa.siswa_id = ?", kuisID, siswaID).
+		First(&kuis).Error
+
+	return kuis, err
+}
+
+// kuisIDTugas - ID kuis jika tugas merupakan tugas pendamping kuis, nil jika tugas biasa
+func kuisIDTugas(tugasID int) *int {
+	var kuis models.Kuis
+	if err := config.DB.Select("kuis_id").Where("tugas_id = ?", tugasID).Limit(1).Find(&kuis).Error; err != nil || kuis.KuisID == 0 {
+		return nil
+	}
+	return &kuis.KuisID
+}
+
+// buatSoalKuis - Validasi input soal dan mengubahnya menjadi model beserta total poin
+func buatSoalKuis(input []soalKuisInput) ([]models.SoalKuis, int, error) {
+	if len(input) == 0 {
+		return nil, 0, fmt.Errorf("kuis minimal memiliki satu soal")
+	}
+
+	var soalList []models.SoalKuis
+	total := 0
+	for i, s := range input {
+		nomor := i + 1
+		if strings.TrimSpace(s.Pertanyaan) == "" {
+			return nil, 0, fmt.Errorf("soal %d: pertanyaan wajib diisi", nomor)
+		}
+		if s.Poin == 0 {
+			s.Poin = 1
+		}
+		if s.Poin < 0 {
+			return nil, 0, fmt.Errorf("soal %d: poin tidak boleh negatif", nomor)
+		}
+
+		soal := models.SoalKuis{TipeSoal: s.TipeSoal, Pertanyaan: s.Pertanyaan, Poin: s.Poin, Urutan: nomor}
+		switch s.TipeSoal {
+		case "PilihanGanda", "PilihanJamak":
+			jumlahBenar := 0
+			for j, o := range s.Opsi {
+				if strings.TrimSpace(o.Teks) == "" {
+					return nil, 0, fmt.Errorf("soal %d: teks opsi wajib diisi", nomor)
+				}
+				if o.IsBenar {
+					jumlahBenar++
+				}
+				soal.Opsi = append(soal.Opsi, models.OpsiSoal{Teks: o.Teks, IsBenar: o.IsBenar, Urutan: j + 1})
+			}
+			if len(s.Opsi) < 2 {
+				return nil, 0, fmt.Errorf("soal %d: minimal dua opsi", nomor)
+			}
+			if s.TipeSoal == "PilihanGanda" && jumlahBenar != 1 {
+				return nil, 0, fmt.Errorf("soal %d: pilihan ganda harus memiliki tepat satu jawaban benar", nomor)
+			}
+			if s.TipeSoal == "PilihanJamak" && jumlahBenar == 0 {
+				return nil, 0, fmt.Errorf("soal %d: minimal satu jawaban benar", nomor)
+			}
+		case "BenarSalah":
+			if s.JawabanBenar == nil {
+				return nil, 0, fmt.Errorf("soal %d: jawaban_benar wajib diisi", nomor)
+			}
+			soal.Opsi = []models.OpsiSoal{
+				{Teks: "Benar", IsBenar: *s.JawabanBenar, Urutan: 1},
+				{Teks: "Salah", IsBenar: !*s.JawabanBenar, Urutan: 2},
+			}
+		case "IsianSingkat":
+			for j, jawaban := range s.JawabanDiterima {
+				if strings.TrimSpace(jawaban) == "" {
+					continue
+				}
+				soal.Opsi = append(soal.Opsi, models.OpsiSoal{Teks: jawaban, IsBenar: true, Urutan: j + 1})
+			}
+			if len(soal.Opsi) == 0 {
+				return nil, 0, fmt.Errorf("soal %d: minimal satu jawaban yang diterima", nomor)
+			}
+		default:
+			return nil, 0, fmt.Errorf("soal %d: tipe soal harus PilihanGanda, PilihanJamak, BenarSalah atau IsianSingkat", nomor)
+		}
+
+		total += s.Poin
+		soalList = append(soalList, soal)
+	}
+
+	return soalList, total, nil
+}
+
+// CreateKuis - Guru membuat kuis pada jadwal miliknya beserta soal-soalnya
+func CreateKuis(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	var input struct {
+		JadwalID     int             `json:"jadwal_id"`
+		Judul        string          `json:"judul"`
+		Deskripsi    string          `json:"deskripsi"`
+		WaktuMulai   string          `json:"waktu_mulai"`
+		WaktuSelesai string          `json:"waktu_selesai"`
+		DurasiMenit  int             `json:"durasi_menit"`
+		AcakSoal     bool            `json:"acak_soal"`
+		AcakOpsi     bool            `json:"acak_opsi"`
+		Soal         []soalKuisInput `json:"soal"`
+		SampelBank   []aturanSampel  `json:"sampel_bank"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if input.JadwalID == 0 || input.Judul == "" || input.WaktuMulai == "" || input.WaktuSelesai == "" || input.DurasiMenit <= 0 {
+		helpers.Response(w, 400, "Missing required fields", nil)
+		return
+	}
+
+	waktuMulai, err := time.Parse("2006-01-02T15:04", input.WaktuMulai)
+	if err != nil {
+		helpers.Response(w, 400, "Format waktu mulai tidak valid", nil)
+		return
+	}
+	waktuSelesai, err := time.Parse("2006-01-02T15:04", input.WaktuSelesai)
+	if err != nil {
+		helpers.Response(w, 400, "Format waktu selesai tidak valid", nil)
+		return
+	}
+	if !waktuSelesai.After(waktuMulai) {
+		helpers.Response(w, 400, "Waktu selesai harus setelah waktu mulai", nil)
+		return
+	}
+
+	var jadwal models.JadwalPelajaran
+	if err := config.DB.Where("jadwal_id = ? AND guru_id = ?", input.JadwalID, guru.ID).First(&jadwal).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.Response(w, 404, "Jadwal tidak ditemukan atau bukan milik Anda", nil)
+		} else {
+			helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		}
+		return
+	}
+
+	// Soal tambahan diambil acak dari bank soal mata pelajaran jadwal
+	if len(input.SampelBank) > 0 {
+		sampel, err := sampelBankSoal(jadwal.MapelID, input.SampelBank)
+		if err != nil {
+			helpers.Response(w, 400, err.Error(), nil)
+			return
+		}
+		input.Soal = append(input.Soal, sampel...)
+	}
+
+	soalList, totalPoin, err := buatSoalKuis(input.Soal)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	// Tugas pendamping: deadline = waktu selesai kuis, poin maksimal = total poin soal
+	tugas := models.Tugas{
+		JadwalID:              input.JadwalID,
+		JudulTugas:            input.Judul,
+		DeskripsiTugas:        input.Deskripsi,
+		DeadlinePengumpulan:   waktuSelesai,
+		TanggalTutup:          &waktuSelesai,
+		PoinMaksimal:          totalPoin,
+		TipeTugas:             "Individu",
+		PenaltiMaksimalPersen: 100,
+	}
+	kuis := models.Kuis{
+		JadwalID:     input.JadwalID,
+		WaktuMulai:   waktuMulai,
+		WaktuSelesai: waktuSelesai,
+		DurasiMenit:  input.DurasiMenit,
+		AcakSoal:     input.AcakSoal,
+		AcakOpsi:     input.AcakOpsi,
+		Soal:         soalList,
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&tugas).Error; err != nil {
+			return err
+		}
+		kuis.TugasID = tugas.TugasID
+		return tx.Create(&kuis).Error
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	kuis, _ = loadKuisLengkap(kuis.KuisID)
+	helpers.Response(w, 201, "Kuis berhasil dibuat", kuis)
+}
+
+// loadKuisLengkap - Kuis beserta tugas, soal dan opsi sesuai urutan
+func loadKuisLengkap(kuisID int) (models.Kuis, error) {
+	var kuis models.Kuis
+	err := config.DB.Preload("Tugas").
+		Preload("Soal", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, soal_id ASC")
+		}).
+		Preload("Soal.Opsi", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, opsi_id ASC")
+		}).
+		First(&kuis, kuisID).Error
+
+	return kuis, err
+}
+
+// GetKuisByJadwal - Daftar kuis pada jadwal milik guru
+func GetKuisByJadwal(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	jadwalID, err := strconv.Atoi(vars["jadwal_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid jadwal ID", nil)
+		return
+	}
+
+	var kuisList []models.Kuis
+	if err := config.DB.Preload("Tugas").
+		Joins("JOIN jadwalpelajaran ON kuis.jadwal_id = jadwalpelajaran.jadwal_id").
+		Where("kuis.jadwal_id = ? AND jadwalpelajaran.guru_id = ?", jadwalID, guru.ID).
+		Order("kuis.waktu_mulai DESC").
+		Find(&kuisList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Daftar kuis berhasil diambil", kuisList)
+}
+
+// GetKuisDetailGuru - Detail kuis lengkap dengan kunci jawaban
+func GetKuisDetailGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	if _, err := findKuisGuru(kuisID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	kuis, err := loadKuisLengkap(kuisID)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Detail kuis berhasil diambil", kuis)
+}
+
+// UpdateSoalKuis - Mengganti seluruh soal kuis (ditolak jika sudah ada siswa yang mengerjakan)
+func UpdateSoalKuis(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	var input struct {
+		Soal       []soalKuisInput `json:"soal"`
+		SampelBank []aturanSampel  `json:"sampel_bank"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	kuis, err := findKuisGuru(kuisID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if len(input.SampelBank) > 0 {
+		var jadwal models.JadwalPelajaran
+		if err := config.DB.Where("jadwal_id = ?", kuis.JadwalID).First(&jadwal).Error; err != nil {
+			helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+			return
+		}
+		sampel, err := sampelBankSoal(jadwal.MapelID, input.SampelBank)
+		if err != nil {
+			helpers.Response(w, 400, err.Error(), nil)
+			return
+		}
+		input.Soal = append(input.Soal, sampel...)
+	}
+
+	soalList, totalPoin, err := buatSoalKuis(input.Soal)
+	if err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	var jumlahPercobaan int64
+	config.DB.Model(&models.PercobaanKuis{}).Where("kuis_id = ?", kuisID).Count(&jumlahPercobaan)
+	if jumlahPercobaan > 0 {
+		helpers.Response(w, 400, "Soal tidak dapat diubah karena kuis sudah dikerjakan siswa", nil)
+		return
+	}
+
+	for i := range soalList {
+		soalList[i].KuisID = kuisID
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("kuis_id = ?", kuisID).Delete(&models.SoalKuis{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&soalList).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Tugas{}).Where("tugas_id = ?", kuis.TugasID).Update("poin_maksimal", totalPoin).Error
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	kuis, _ = loadKuisLengkap(kuisID)
+	helpers.Response(w, 200, "Soal kuis berhasil diperbarui", kuis)
+}
+
+// DeleteKuis - Menghapus kuis beserta tugas pendampingnya
+func DeleteKuis(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	kuis, err := findKuisGuru(kuisID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	// Kuis, soal dan percobaan ikut terhapus lewat ON DELETE CASCADE dari tugas
+	if err := config.DB.Delete(&models.Tugas{}, kuis.TugasID).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Kuis berhasil dihapus", nil)
+}
+
+// GetPercobaanKuis - Guru melihat semua percobaan siswa beserta jawabannya
+func GetPercobaanKuis(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	if _, err := findKuisGuru(kuisID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	// Percobaan yang waktunya habis diselesaikan dulu agar skor terbaru tampil
+	selesaikanPercobaanKedaluwarsa(kuisID)
+
+	query := config.DB.Preload("Siswa").Preload("Jawaban").Where("kuis_id = ?", kuisID)
+	if status := r.URL.Query().Get("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var percobaanList []models.PercobaanKuis
+	if err := query.Order("mulai_pada ASC").Find(&percobaanList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Percobaan kuis berhasil diambil", percobaanList)
+}
+
+// NilaiJawabanKuis - Guru menilai (atau mengoreksi) jawaban isian singkat
+func NilaiJawabanKuis(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	jawabanID, err := strconv.Atoi(vars["jawaban_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid jawaban ID", nil)
+		return
+	}
+
+	var request struct {
+		Poin int `json:"poin"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid JSON format", nil)
+		return
+	}
+
+	var jawaban models.JawabanKuis
+	if err := config.DB.First(&jawaban, jawabanID).Error; err != nil {
+		helpers.Response(w, 404, "Jawaban tidak ditemukan", nil)
+		return
+	}
+
+	var percobaan models.PercobaanKuis
+	if err := config.DB.First(&percobaan, jawaban.PercobaanID).Error; err != nil {
+		helpers.Response(w, 404, "Percobaan tidak ditemukan", nil)
+		return
+	}
+
+	kuis, err := findKuisGuru(percobaan.KuisID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 403, "Access denied - not your class", nil)
+		return
+	}
+
+	if percobaan.Status == "Berlangsung" {
+		helpers.Response(w, 400, "Percobaan masih berlangsung", nil)
+		return
+	}
+
+	var soal models.SoalKuis
+	if err := config.DB.First(&soal, jawaban.SoalID).Error; err != nil {
+		helpers.Response(w, 404, "Soal tidak ditemukan", nil)
+		return
+	}
+	if soal.TipeSoal != "IsianSingkat" {
+		helpers.Response(w, 400, "Hanya jawaban isian singkat yang dapat dinilai manual", nil)
+		return
+	}
+	if request.Poin < 0 || request.Poin > soal.Poin {
+		helpers.Response(w, 400, fmt.Sprintf("Poin harus antara 0 dan %d", soal.Poin), nil)
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&jawaban).Updates(map[string]interface{}{
+			"poin":           request.Poin,
+			"dinilai_manual": true,
+		}).Error; err != nil {
+			return err
+		}
+		return simpanSkorPercobaan(tx, kuis, &percobaan)
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan nilai jawaban", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Jawaban berhasil dinilai", percobaan)
+}
+
+// GetKuisSiswa - Informasi kuis untuk siswa (tanpa soal) dan status percobaannya
+func GetKuisSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	kuis, err := findKuisSiswa(kuisID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan", nil)
+		return
+	}
+
+	var jumlahSoal int64
+	config.DB.Model(&models.SoalKuis{}).Where("kuis_id = ?", kuisID).Count(&jumlahSoal)
+
+	data := map[string]interface{}{
+		"kuis_id":       kuis.KuisID,
+		"tugas_id":      kuis.TugasID,
+		"judul":         kuis.Tugas.JudulTugas,
+		"deskripsi":     kuis.Tugas.DeskripsiTugas,
+		"waktu_mulai":   kuis.WaktuMulai,
+		"waktu_selesai": kuis.WaktuSelesai,
+		"durasi_menit":  kuis.DurasiMenit,
+		"jumlah_soal":   jumlahSoal,
+		"poin_maksimal": kuis.Tugas.PoinMaksimal,
+		"percobaan":     nil,
+	}
+
+	var percobaan models.PercobaanKuis
+	if err := config.DB.Where("kuis_id = ? AND siswa_id = ?", kuisID, siswa.ID).First(&percobaan).Error; err == nil {
+		if percobaan.Status == "Berlangsung" && time.Now().After(percobaan.BatasWaktu) {
+			selesaikanPercobaan(kuis, &percobaan)
+		}
+		data["percobaan"] = percobaan
+	}
+
+	helpers.Response(w, 200, "Detail kuis berhasil diambil", data)
+}
+
+// MulaiKuis - Siswa memulai (atau melanjutkan) percobaan kuis. Waktu dikontrol server
+func MulaiKuis(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	kuis, err := findKuisSiswa(kuisID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan", nil)
+		return
+	}
+
+	kuisLengkap, err := loadKuisLengkap(kuisID)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	now := time.Now()
+	var percobaan models.PercobaanKuis
+	err = config.DB.Where("kuis_id = ? AND siswa_id = ?", kuisID, siswa.ID).First(&percobaan).Error
+	if err == nil {
+		if percobaan.Status == "Berlangsung" && now.After(percobaan.BatasWaktu) {
+			selesaikanPercobaan(kuis, &percobaan)
+		}
+		if percobaan.Status != "Berlangsung" {
+			helpers.Response(w, 400, "Kuis sudah selesai dikerjakan", percobaan)
+			return
+		}
+	} else {
+		if now.Before(kuis.WaktuMulai) {
+			helpers.Response(w, 400, "Kuis belum dimulai", nil)
+			return
+		}
+		if !now.Before(kuis.WaktuSelesai) {
+			helpers.Response(w, 400, "Kuis sudah berakhir", nil)
+			return
+		}
+
+		percobaan = models.PercobaanKuis{
+			KuisID:     kuisID,
+			SiswaID:    siswa.ID,
+			MulaiPada:  now,
+			BatasWaktu: now.Add(time.Duration(kuis.DurasiMenit) * time.Minute),
+			Status:     "Berlangsung",
+		}
+		if percobaan.BatasWaktu.After(kuis.WaktuSelesai) {
+			percobaan.BatasWaktu = kuis.WaktuSelesai
+		}
+		percobaan.UrutanSoal, percobaan.UrutanOpsi = acakUrutanKuis(kuisLengkap)
+
+		if err := config.DB.Create(&percobaan).Error; err != nil {
+			// Klik ganda: request lain sudah membuat percobaan (unique kuis_id + siswa_id), lanjutkan percobaan itu
+			var ada models.PercobaanKuis
+			if errBaca := config.DB.Where("kuis_id = ? AND siswa_id = ?", kuisID, siswa.ID).First(&ada).Error; errBaca != nil {
+				helpers.Response(w, 500, "Gagal memulai kuis", nil)
+				return
+			}
+			percobaan = ada
+			if percobaan.Status != "Berlangsung" {
+				helpers.Response(w, 400, "Kuis sudah selesai dikerjakan", percobaan)
+				return
+			}
+		}
+	}
+
+	var jawabanList []models.JawabanKuis
+	config.DB.Where("percobaan_id = ?", percobaan.PercobaanID).Find(&jawabanList)
+
+	helpers.Response(w, 200, "Kuis dimulai", map[string]interface{}{
+		"percobaan_id": percobaan.PercobaanID,
+		"mulai_pada":   percobaan.MulaiPada,
+		"batas_waktu":  percobaan.BatasWaktu,
+		"sisa_detik":   int(time.Until(percobaan.BatasWaktu).Seconds()),
+		"soal":         soalUntukSiswa(kuisLengkap, percobaan, jawabanList),
+	})
+}
+
+// SimpanJawabanKuis - Menyimpan jawaban siswa selama percobaan berlangsung
+func SimpanJawabanKuis(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	var request struct {
+		Jawaban []struct {
+			SoalID      int    `json:"soal_id"`
+			OpsiID      []int  `json:"opsi_id"`
+			JawabanTeks string `json:"jawaban_teks"`
+		} `json:"jawaban"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid JSON format", nil)
+		return
+	}
+
+	kuis, err := findKuisSiswa(kuisID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan", nil)
+		return
+	}
+
+	var percobaan models.PercobaanKuis
+	if err := config.DB.Where("kuis_id = ? AND siswa_id = ?", kuisID, siswa.ID).First(&percobaan).Error; err != nil {
+		helpers.Response(w, 400, "Kuis belum dimulai", nil)
+		return
+	}
+	if percobaan.Status != "Berlangsung" {
+		helpers.Response(w, 400, "Kuis sudah selesai dikerjakan", nil)
+		return
+	}
+	if time.Now().After(percobaan.BatasWaktu) {
+		selesaikanPercobaan(kuis, &percobaan)
+		helpers.Response(w, 400, "Waktu pengerjaan kuis sudah habis", percobaan)
+		return
+	}
+
+	// Opsi yang valid per soal kuis ini
+	var soalList []models.SoalKuis
+	config.DB.Preload("Opsi").Where("kuis_id = ?", kuisID).Find(&soalList)
+	opsiSoal := make(map[int]map[int]bool)
+	for _, soal := range soalList {
+		opsiSoal[soal.SoalID] = make(map[int]bool)
+		for _, opsi := range soal.Opsi {
+			opsiSoal[soal.SoalID][opsi.OpsiID] = true
+		}
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, j := range request.Jawaban {
+			opsiValid, ada := opsiSoal[j.SoalID]
+			if !ada {
+				return fmt.Errorf("soal %d bukan bagian dari kuis ini", j.SoalID)
+			}
+
+			var opsiDipilih []string
+			for _, opsiID := range j.OpsiID {
+				if !opsiValid[opsiID] {
+					return fmt.Errorf("opsi %d bukan milik soal %d", opsiID, j.SoalID)
+				}
+				opsiDipilih = append(opsiDipilih, strconv.Itoa(opsiID))
+			}
+
+			var jawaban models.JawabanKuis
+			tx.Where("percobaan_id = ? AND soal_id = ?", percobaan.PercobaanID, j.SoalID).Limit(1).Find(&jawaban)
+			jawaban.PercobaanID = percobaan.PercobaanID
+			jawaban.SoalID = j.SoalID
+			jawaban.OpsiDipilih = strings.Join(opsiDipilih, ",")
+			jawaban.JawabanTeks = j.JawabanTeks
+			if err := tx.Save(&jawaban).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		helpers.Response(w, 400, "Gagal menyimpan jawaban: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Jawaban berhasil disimpan", map[string]interface{}{
+		"batas_waktu": percobaan.BatasWaktu,
+		"sisa_detik":  int(time.Until(percobaan.BatasWaktu).Seconds()),
+	})
+}
+
+// SelesaiKuis - Siswa mengakhiri percobaan; jawaban dinilai otomatis
+func SelesaiKuis(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	kuisID, err := strconv.Atoi(vars["kuis_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid kuis ID", nil)
+		return
+	}
+
+	kuis, err := findKuisSiswa(kuisID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Kuis tidak ditemukan", nil)
+		return
+	}
+
+	var percobaan models.PercobaanKuis
+	if err := config.DB.Where("kuis_id = ? AND siswa_id = ?", kuisID, siswa.ID).First(&percobaan).Error; err != nil {
+		helpers.Response(w, 400, "Kuis belum dimulai", nil)
+		return
+	}
+	if percobaan.Status != "Berlangsung" {
+		helpers.Response(w, 400, "Kuis sudah selesai dikerjakan", percobaan)
+		return
+	}
+
+	if err := selesaikanPercobaan(kuis, &percobaan); err != nil {
+		helpers.Response(w, 500, "Gagal menyelesaikan kuis", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Kuis berhasil dikumpulkan", percobaan)
+}
+
+// acakUrutanKuis - Urutan soal dan opsi per siswa dalam bentuk JSON
+func acakUrutanKuis(kuis models.Kuis) (string, string) {
+	urutanSoal := make([]int, 0, len(kuis.Soal))
+	urutanOpsi := make(map[int][]int)
+	for _, soal := range kuis.Soal {
+		urutanSoal = append(urutanSoal, soal.SoalID)
+		var opsiIDs []int
+		for _, opsi := range soal.Opsi {
+			opsiIDs = append(opsiIDs, opsi.OpsiID)
+		}
+		// Urutan Benar/Salah tidak diacak agar tidak membingungkan
+		if kuis.AcakOpsi && soal.TipeSoal != "BenarSalah" {
+			rand.Shuffle(len(opsiIDs), func(i, j int) { opsiIDs[i], opsiIDs[j] = opsiIDs[j], opsiIDs[i] })
+		}
+		urutanOpsi[soal.SoalID] = opsiIDs
+	}
+	if kuis.AcakSoal {
+		rand.Shuffle(len(urutanSoal), func(i, j int) { urutanSoal[i], urutanSoal[j] = urutanSoal[j], urutanSoal[i] })
+	}
+
+	soalJSON, _ := json.Marshal(urutanSoal)
+	opsiJSON, _ := json.Marshal(urutanOpsi)
+	return string(soalJSON), string(opsiJSON)
+}
+
+// soalUntukSiswa - Soal sesuai urutan percobaan tanpa kunci jawaban
+func soalUntukSiswa(kuis models.Kuis, percobaan models.PercobaanKuis, jawabanList []models.JawabanKuis) []map[string]interface{} {
+	var urutanSoal []int
+	urutanOpsi := make(map[int][]int)
+	json.Unmarshal([]byte(percobaan.UrutanSoal), &urutanSoal)
+	json.Unmarshal([]byte(percobaan.UrutanOpsi), &urutanOpsi)
+
+	soalMap := make(map[int]models.SoalKuis)
+	for _, soal := range kuis.Soal {
+		soalMap[soal.SoalID] = soal
+	}
+	jawabanMap := make(map[int]models.JawabanKuis)
+	for _, jawaban := range jawabanList {
+		jawabanMap[jawaban.SoalID] = jawaban
+	}
+
+	var hasil []map[string]interface{}
+	for nomor, soalID := range urutanSoal {
+		soal, ada := soalMap[soalID]
+		if !ada {
+			continue
+		}
+
+		data := map[string]interface{}{
+			"nomor":      nomor + 1,
+			"soal_id":    soal.SoalID,
+			"tipe_soal":  soal.TipeSoal,
+			"pertanyaan": soal.Pertanyaan,
+			"poin":       soal.Poin,
+		}
+
+		// Jawaban yang diterima untuk isian singkat tidak boleh dikirim ke siswa
+		if soal.TipeSoal != "IsianSingkat" {
+			opsiMap := make(map[int]models.OpsiSoal)
+			for _, opsi := range soal.Opsi {
+				opsiMap[opsi.OpsiID] = opsi
+			}
+			var opsiList []map[string]interface{}
+			for _, opsiID := range urutanOpsi[soalID] {
+				if opsi, ada := opsiMap[opsiID]; ada {
+					opsiList = append(opsiList, map[string]interface{}{"opsi_id": opsi.OpsiID, "teks": opsi.Teks})
+				}
+			}
+			data["opsi"] = opsiList
+		}
+
+		if jawaban, ada := jawabanMap[soalID]; ada {
+			data["opsi_dipilih"] = parseOpsiDipilih(jawaban.OpsiDipilih)
+			data["jawaban_teks"] = jawaban.JawabanTeks
+		}
+
+		hasil = append(hasil, data)
+	}
+
+	return hasil
+}
+
+// parseOpsiDipilih - Mengubah "3,7" menjadi []int{3, 7}
+func parseOpsiDipilih(s string) []int {
+	var ids []int
+	for _, bagian := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(bagian)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// normalisasiJawaban - Huruf kecil dan spasi dirapikan untuk membandingkan isian singkat
+func normalisasiJawaban(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// nilaiJawabanOtomatis - Poin jawaban; nil jika isian singkat perlu direview guru.
+// Pilihan jamak dinilai semua-atau-tidak sama sekali
+func nilaiJawabanOtomatis(soal models.SoalKuis, jawaban models.JawabanKuis) *int {
+	nol, penuh := 0, soal.Poin
+
+	if soal.TipeSoal == "IsianSingkat" {
+		teks := normalisasiJawaban(jawaban.JawabanTeks)
+		if teks == "" {
+			return &nol
+		}
+		for _, opsi := range soal.Opsi {
+			if normalisasiJawaban(opsi.Teks) == teks {
+				return &penuh
+			}
+		}
+		return nil
+	}
+
+	var benar []int
+	for _, opsi := range soal.Opsi {
+		if opsi.IsBenar {
+			benar = append(benar, opsi.OpsiID)
+		}
+	}
+	dipilih := parseOpsiDipilih(jawaban.OpsiDipilih)
+	sort.Ints(benar)
+	sort.Ints(dipilih)
+
+	if len(benar) != len(dipilih) {
+		return &nol
+	}
+	for i := range benar {
+		if benar[i] != dipilih[i] {
+			return &nol
+		}
+	}
+	return &penuh
+}
+
+// selesaikanPercobaan - Menilai seluruh jawaban percobaan dan mengakhiri percobaan
+func selesaikanPercobaan(kuis models.Kuis, percobaan *models.PercobaanKuis) error {
+	var soalList []models.SoalKuis
+	if err := config.DB.Preload("Opsi").Where("kuis_id = ?", kuis.KuisID).Find(&soalList).Error; err != nil {
+		return err
+	}
+
+	selesai := time.Now()
+	if selesai.After(percobaan.BatasWaktu) {
+		selesai = percobaan.BatasWaktu
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, soal := range soalList {
+			var jawaban models.JawabanKuis
+			tx.Where("percobaan_id = ? AND soal_id = ?", percobaan.PercobaanID, soal.SoalID).Limit(1).Find(&jawaban)
+			jawaban.PercobaanID = percobaan.PercobaanID
+			jawaban.SoalID = soal.SoalID
+			if !jawaban.DinilaiManual {
+				jawaban.Poin = nilaiJawabanOtomatis(soal, jawaban)
+			}
+			if err := tx.Save(&jawaban).Error; err != nil {
+				return err
+			}
+		}
+
+		percobaan.SelesaiPada = &selesai
+		return simpanSkorPercobaan(tx, kuis, percobaan)
+	})
+}
+
+// simpanSkorPercobaan - Menghitung ulang skor percobaan dan menyalinnya ke PengumpulanTugas
+func simpanSkorPercobaan(tx *gorm.DB, kuis models.Kuis, percobaan *models.PercobaanKuis) error {
+	var jawabanList []models.JawabanKuis
+	if err := tx.Where("percobaan_id = ?", percobaan.PercobaanID).Find(&jawabanList).Error; err != nil {
+		return err
+	}
+
+	skor, menungguReview := 0, false
+	for _, jawaban := range jawabanList {
+		if jawaban.Poin == nil {
+			menungguReview = true
+			continue
+		}
+		skor += *jawaban.Poin
+	}
+
+	percobaan.Skor = skor
+	percobaan.Status = "Selesai"
+	if menungguReview {
+		percobaan.Status = "Menunggu Review"
+	}
+	if err := tx.Omit("Siswa", "Jawaban").Save(percobaan).Error; err != nil {
+		return err
+	}
+
+	// Skor kuis masuk ke pengumpulan tugas pendamping
+	var tugas models.Tugas
+	if err := tx.First(&tugas, kuis.TugasID).Error; err != nil {
+		return err
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	tx.Where("tugas_id = ? AND siswa_id = ?", kuis.TugasID, percobaan.SiswaID).Limit(1).Find(&pengumpulan)
+	baru := pengumpulan.PengumpulanID == 0
+
+	pengumpulan.TugasID = kuis.TugasID
+	pengumpulan.SiswaID = percobaan.SiswaID
+	pengumpulan.TanggalPengumpulan = *percobaan.SelesaiPada
+	pengumpulan.CatatanSiswa = fmt.Sprintf("Kuis online (percobaan #%d)", percobaan.PercobaanID)
+	terapkanPoinPengumpulan(tugas, &pengumpulan, skor, *percobaan.SelesaiPada)
+	pengumpulan.StatusPengumpulan = "Dinilai"
+	if menungguReview {
+		pengumpulan.StatusPengumpulan = "Mengerjakan"
+	}
+
+	if err := tx.Save(&pengumpulan).Error; err != nil {
+		return err
+	}
+	if baru {
+		return catatVersiPengumpulan(tx, &pengumpulan)
+	}
+	return nil
+}
+
+// selesaikanPercobaanKedaluwarsa - Menyelesaikan percobaan yang waktunya sudah habis
+// (kuisID 0 berarti semua kuis)
+func selesaikanPercobaanKedaluwarsa(kuisID int) int {
+	query := config.DB.Where("status = ? AND batas_waktu < ?", "Berlangsung", time.Now())
+	if kuisID != 0 {
+		query = query.Where("kuis_id = ?", kuisID)
+	}
+
+	var percobaanList []models.PercobaanKuis
+	if err := query.Find(&percobaanList).Error; err != nil {
+		log.Printf("❌ Failed to fetch expired quiz attempts: %v", err)
+		return 0
+	}
+
+	jumlah := 0
+	for i := range percobaanList {
+		var kuis models.Kuis
+		if err := config.DB.First(&kuis, percobaanList[i].KuisID).Error; err != nil {
+			continue
+		}
+		if err := selesaikanPercobaan(kuis, &percobaanList[i]); err != nil {
+			log.Printf("❌ Failed to finish quiz attempt %d: %v", percobaanList[i].PercobaanID, err)
+			continue
+		}
+		jumlah++
+	}
+
+	return jumlah
+}
+
+// RunSelesaikanKuisCron - Dipanggil cron untuk menutup percobaan kuis yang waktunya habis
+func RunSelesaikanKuisCron() {
+	if jumlah := selesaikanPercobaanKedaluwarsa(0); jumlah > 0 {
+		log.Printf("✅ %d expired quiz attempts finished", jumlah)
+	}
+}
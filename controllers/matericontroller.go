@@ -0,0 +1,873 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// kondisiGuruPertemuan - Guru pengampu jadwal atau guru pengganti yang sudah disetujui (alias jp & pertemuan)
+const kondisiGuruPertemuan = "(jp.guru_id = ? OR (pertemuan.guru_pengganti_id = ? AND pertemuan.pengganti_disetujui_pada IS NOT NULL))"
+
+type seksiMateriInput struct {
+	Judul     string `json:"judul"`
+	Deskripsi string `json:"deskripsi"`
+}
+
+type itemMateriInput struct {
+	Tipe      string `json:"tipe"`
+	Judul     string `json:"judul"`
+	Deskripsi string `json:"deskripsi"`
+	URL       string `json:"url"`
+	NamaFile  string `json:"nama_file"`
+}
+
+// validasi - File harus hasil upload guru sendiri (kecuali URL lama item yang diubah), tautan dan
+// video harus URL http(s)
+func (input *itemMateriInput) validasi(guruID int, urlLama string) error {
+	input.Judul = strings.TrimSpace(input.Judul)
+	input.URL = strings.TrimSpace(input.URL)
+	if input.Judul == "" || input.URL == "" {
+		return fmt.Errorf("Judul dan url materi wajib diisi")
+	}
+
+	switch input.Tipe {
+	case "File":
+		if !strings.HasPrefix(input.URL, "/uploads/tugas/") {
+			return fmt.Errorf("File materi harus diunggah melalui /api/upload/tugas")
+		}
+		if input.URL != urlLama && !fileUploadMilik(input.URL, "Guru", guruID) {
+			return fmt.Errorf("File materi harus file yang Anda unggah sendiri")
+		}
+	case "Tautan", "Video":
+		u, err := url.Parse(input.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("URL materi harus diawali http:// atau https://")
+		}
+		input.NamaFile = ""
+	default:
+		return fmt.Errorf("Tipe materi harus 'File', 'Tautan' atau 'Video'")
+	}
+	return nil
+}
+
+// embedURLVideo - URL embed untuk video YouTube/Vimeo, URL asli untuk penyedia lain
+func embedURLVideo(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	switch {
+	case host == "youtu.be":
+		return "https://www.youtube.com/embed/" + strings.Trim(u.Path, "/")
+	case host == "youtube.com" || host == "m.youtube.com":
+		if v := u.Query().Get("v"); v != "" {
+			return "https://www.youtube.com/embed/" + v
+		}
+		if strings.HasPrefix(u.Path, "/shorts/") {
+			return "https://www.youtube.com/embed/" + strings.TrimPrefix(u.Path, "/shorts/")
+		}
+	case host == "vimeo.com":
+		if id := strings.Trim(u.Path, "/"); id != "" && !strings.Contains(id, "/") {
+			return "https://player.vimeo.com/video/" + id
+		}
+	}
+	return raw
+}
+
+// findPertemuanSiswa - Pertemuan pada jadwal kelas siswa (cek keanggotaan kelas yang sama dengan SubmitTugas)
+func findPertemuanSiswa(pertemuanID, siswaID int) (models.Pertemuan, error) {
+	var pertemuan models.Pertemuan
+	err := config.DB.
+		Joins("JOIN jadwalpelajaran ON pertemuan.id_jadwal = jadwalpelajaran.jadwal_id").
+		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
+		Where("pertemuan.id_pertemuan = ? AND siswa.siswa_id = ?", pertemuanID, siswaID).
+		First(&pertemuan).Error
+
+	return pertemuan, err
+}
+
+// findSeksiMateriGuru - Seksi materi pada pertemuan yang diajar guru
+func findSeksiMateriGuru(seksiID, guruID int) (models.SeksiMateri, error) {
+	var seksi models.SeksiMateri
+	err := config.DB.
+		Joins("JOIN pertemuan ON pertemuan.id_pertemuan = seksimateri.pertemuan_id").
+		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
+		Where("seksimateri.seksi_id = ?", seksiID).
+		Where(kondisiGuruPertemuan, guruID, guruID).
+		First(&seksi).Error
+
+	return seksi, err
+}
+
+// findItemMateriGuru - Item materi pada pertemuan yang diajar guru
+func findItemMateriGuru(itemID, guruID int) (models.ItemMateri, error) {
+	var item models.ItemMateri
+	err := config.DB.
+		Joins("JOIN seksimateri ON seksimateri.seksi_id = itemmateri.seksi_id").
+		Joins("JOIN pertemuan ON pertemuan.id_pertemuan = seksimateri.pertemuan_id").
+		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
+		Where("itemmateri.item_id = ?", itemID).
+		Where(kondisiGuruPertemuan, guruID, guruID).
+		First(&item).Error
+
+	return item, err
+}
+
+// ambilSeksiMateri - Seksi beserta item materi beberapa pertemuan, sesuai urutan
+func ambilSeksiMateri(pertemuanIDs []int) ([]models.SeksiMateri, error) {
+	var seksiList []models.SeksiMateri
+	if len(pertemuanIDs) == 0 {
+		return seksiList, nil
+	}
+
+	err := config.DB.
+		Preload("Item", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, item_id ASC")
+		}).
+		Where("pertemuan_id IN ?", pertemuanIDs).
+		Order("urutan ASC, seksi_id ASC").
+		Find(&seksiList).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range seksiList {
+		for j := range seksiList[i].Item {
+			if item := &seksiList[i].Item[j]; item.Tipe == "Video" {
+				item.EmbedURL = embedURLVideo(item.URL)
+			}
+		}
+	}
+	return seksiList, nil
+}
+
+// isiJumlahDibuka - Jumlah siswa yang membuka tiap item (tampilan guru)
+func isiJumlahDibuka(seksiList []models.SeksiMateri) {
+	var itemIDs []int
+	for _, s := range seksiList {
+		for _, item := range s.Item {
+			itemIDs = append(itemIDs, item.ItemID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		return
+	}
+
+	var hasil []struct {
+		ItemID int
+		Jumlah int
+	}
+	config.DB.Model(&models.AksesMateri{}).
+		Select("item_id, COUNT(*) AS jumlah").
+		Where("item_id IN ?", itemIDs).
+		Group("item_id").
+		Scan(&hasil)
+
+	jumlah := make(map[int]int)
+	for _, h := range hasil {
+		jumlah[h.ItemID] = h.Jumlah
+	}
+	for i := range seksiList {
+		for j := range seksiList[i].Item {
+			seksiList[i].Item[j].JumlahDibuka = jumlah[seksiList[i].Item[j].ItemID]
+		}
+	}
+}
+
+// isiDibukaSiswa - Waktu terakhir siswa membuka tiap item (tampilan siswa)
+func isiDibukaSiswa(seksiList []models.SeksiMateri, siswaID int) {
+	var itemIDs []int
+	for _, s := range seksiList {
+		for _, item := range s.Item {
+			itemIDs = append(itemIDs, item.ItemID)
+		}
+	}
+	if len(itemIDs) == 0 {
+		return
+	}
+
+	var aksesList []models.AksesMateri
+	config.DB.Where("siswa_id = ? AND item_id IN ?", siswaID, itemIDs).Find(&aksesList)
+
+	dibuka := make(map[int]time.Time)
+	for _, a := range aksesList {
+		dibuka[a.ItemID] = a.TerakhirDibuka
+	}
+	for i := range seksiList {
+		for j := range seksiList[i].Item {
+			if waktu, ok := dibuka[seksiList[i].Item[j].ItemID]; ok {
+				seksiList[i].Item[j].DibukaPada = &waktu
+			}
+		}
+	}
+}
+
+// urutanBerikutnya - Nilai urutan setelah urutan terbesar pada tabel untuk kolom induk tertentu
+func urutanBerikutnya(model interface{}, kolomInduk string, indukID int) int {
+	var maks int
+	config.DB.Model(model).Where(kolomInduk+" = ?", indukID).Select("COALESCE(MAX(urutan), 0)").Scan(&maks)
+	return maks + 1
+}
+
+// simpanUrutan - Mengatur ulang kolom urutan sesuai daftar ID (harus berisi seluruh ID milik induk)
+func simpanUrutan(model interface{}, kolomID, kolomInduk string, indukID int, ids []int) error {
+	var idSaatIni []int
+	if err := config.DB.Model(model).Where(kolomInduk+" = ?", indukID).Pluck(kolomID, &idSaatIni).Error; err != nil {
+		return err
+	}
+
+	dikenal := make(map[int]bool)
+	for _, id := range idSaatIni {
+		dikenal[id] = true
+	}
+	if len(ids) != len(idSaatIni) {
+		return fmt.Errorf("Daftar urutan harus memuat seluruh %d data", len(idSaatIni))
+	}
+	for _, id := range ids {
+		if !dikenal[id] {
+			return fmt.Errorf("ID %d tidak termasuk dalam daftar", id)
+		}
+		delete(dikenal, id)
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(model).Where(kolomID+" = ?", id).Update("urutan", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetMateriPertemuanGuru - Seluruh seksi dan item materi pertemuan beserta jumlah siswa yang membuka
+func GetMateriPertemuanGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pertemuan ID", nil)
+		return
+	}
+
+	pertemuan, err := findPertemuanGuru(pertemuanID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	seksiList, err := ambilSeksiMateri([]int{pertemuanID})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+	isiJumlahDibuka(seksiList)
+
+	var totalSiswa int64
+	config.DB.Table("siswa").
+		Joins("JOIN jadwalpelajaran ON jadwalpelajaran.kelas_id = siswa.kelas_id").
+		Where("jadwalpelajaran.jadwal_id = ?", pertemuan.IDJadwal).
+		Count(&totalSiswa)
+
+	helpers.Response(w, 200, "Materi pertemuan berhasil diambil", map[string]interface{}{
+		"pertemuan":   pertemuan,
+		"seksi":       seksiList,
+		"total_siswa": totalSiswa,
+	})
+}
+
+// CreateSeksiMateri - Menambah seksi baru di akhir daftar materi pertemuan
+func CreateSeksiMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pertemuan ID", nil)
+		return
+	}
+
+	var input seksiMateriInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(input.Judul) == "" {
+		helpers.Response(w, 400, "Judul seksi wajib diisi", nil)
+		return
+	}
+
+	if _, err := findPertemuanGuru(pertemuanID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	seksi := models.SeksiMateri{
+		PertemuanID: pertemuanID,
+		Judul:       strings.TrimSpace(input.Judul),
+		Deskripsi:   input.Deskripsi,
+		Urutan:      urutanBerikutnya(&models.SeksiMateri{}, "pertemuan_id", pertemuanID),
+	}
+	if err := config.DB.Create(&seksi).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	seksi.Item = []models.ItemMateri{}
+	helpers.Response(w, 201, "Seksi materi berhasil dibuat", seksi)
+}
+
+// UpdateSeksiMateri - Mengubah judul/deskripsi seksi
+func UpdateSeksiMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	seksiID, err := strconv.Atoi(vars["seksi_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid seksi ID", nil)
+		return
+	}
+
+	var input seksiMateriInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if strings.TrimSpace(input.Judul) == "" {
+		helpers.Response(w, 400, "Judul seksi wajib diisi", nil)
+		return
+	}
+
+	seksi, err := findSeksiMateriGuru(seksiID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Seksi materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := config.DB.Model(&seksi).Updates(map[string]interface{}{
+		"judul":     strings.TrimSpace(input.Judul),
+		"deskripsi": input.Deskripsi,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Seksi materi berhasil diupdate", seksi)
+}
+
+// DeleteSeksiMateri - Menghapus seksi beserta seluruh item dan catatan aksesnya
+func DeleteSeksiMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	seksiID, err := strconv.Atoi(vars["seksi_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid seksi ID", nil)
+		return
+	}
+
+	seksi, err := findSeksiMateriGuru(seksiID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Seksi materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := config.DB.Delete(&seksi).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Seksi materi berhasil dihapus", nil)
+}
+
+// UrutkanSeksiMateri - Mengatur ulang urutan seksi pada pertemuan {seksi_ids: [...]}
+func UrutkanSeksiMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pertemuan ID", nil)
+		return
+	}
+
+	var input struct {
+		SeksiIDs []int `json:"seksi_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if _, err := findPertemuanGuru(pertemuanID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := simpanUrutan(&models.SeksiMateri{}, "seksi_id", "pertemuan_id", pertemuanID, input.SeksiIDs); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	seksiList, _ := ambilSeksiMateri([]int{pertemuanID})
+	helpers.Response(w, 200, "Urutan seksi berhasil disimpan", seksiList)
+}
+
+// UrutkanItemMateri - Mengatur ulang urutan item dalam seksi {item_ids: [...]}
+func UrutkanItemMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	seksiID, err := strconv.Atoi(vars["seksi_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid seksi ID", nil)
+		return
+	}
+
+	var input struct {
+		ItemIDs []int `json:"item_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if _, err := findSeksiMateriGuru(seksiID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Seksi materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := simpanUrutan(&models.ItemMateri{}, "item_id", "seksi_id", seksiID, input.ItemIDs); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Urutan materi berhasil disimpan", nil)
+}
+
+// CreateItemMateri - Menambah file, tautan atau video di akhir seksi
+func CreateItemMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	seksiID, err := strconv.Atoi(vars["seksi_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid seksi ID", nil)
+		return
+	}
+
+	var input itemMateriInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	if err := input.validasi(guru.ID, ""); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	if _, err := findSeksiMateriGuru(seksiID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Seksi materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	item := models.ItemMateri{
+		SeksiID:   seksiID,
+		Tipe:      input.Tipe,
+		Judul:     input.Judul,
+		Deskripsi: input.Deskripsi,
+		URL:       input.URL,
+		NamaFile:  input.NamaFile,
+		Urutan:    urutanBerikutnya(&models.ItemMateri{}, "seksi_id", seksiID),
+	}
+	if err := config.DB.Create(&item).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	if item.Tipe == "Video" {
+		item.EmbedURL = embedURLVideo(item.URL)
+	}
+	helpers.Response(w, 201, "Materi berhasil ditambahkan", item)
+}
+
+// UpdateItemMateri - Mengganti isi item materi
+func UpdateItemMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	itemID, err := strconv.Atoi(vars["item_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid item ID", nil)
+		return
+	}
+
+	var input itemMateriInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	item, err := findItemMateriGuru(itemID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := input.validasi(guru.ID, item.URL); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
+	if err := config.DB.Model(&item).Updates(map[string]interface{}{
+		"tipe":      input.Tipe,
+		"judul":     input.Judul,
+		"deskripsi": input.Deskripsi,
+		"url":       input.URL,
+		"nama_file": input.NamaFile,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	if item.Tipe == "Video" {
+		item.EmbedURL = embedURLVideo(item.URL)
+	}
+	helpers.Response(w, 200, "Materi berhasil diupdate", item)
+}
+
+// DeleteItemMateri - Menghapus item materi beserta catatan aksesnya
+func DeleteItemMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	itemID, err := strconv.Atoi(vars["item_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid item ID", nil)
+		return
+	}
+
+	item, err := findItemMateriGuru(itemID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	if err := config.DB.Delete(&item).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Materi berhasil dihapus", nil)
+}
+
+// GetAksesItemMateri - Daftar siswa kelas beserta status membuka sebuah item materi
+func GetAksesItemMateri(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	itemID, err := strconv.Atoi(vars["item_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid item ID", nil)
+		return
+	}
+
+	item, err := findItemMateriGuru(itemID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Materi tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	var hasil []struct {
+		SiswaID        int        `json:"siswa_id"`
+		NIS            string     `json:"nis"`
+		NamaLengkap    string     `json:"nama_lengkap"`
+		JumlahDibuka   int        `json:"jumlah_dibuka"`
+		PertamaDibuka  *time.Time `json:"pertama_dibuka"`
+		TerakhirDibuka *time.Time `json:"terakhir_dibuka"`
+	}
+	err = config.DB.Table("siswa").
+		Select("siswa.siswa_id, siswa.nis, siswa.nama_lengkap, COALESCE(am.jumlah_dibuka, 0) AS jumlah_dibuka, am.pertama_dibuka, am.terakhir_dibuka").
+		Joins("JOIN jadwalpelajaran jp ON jp.kelas_id = siswa.kelas_id").
+		Joins("JOIN pertemuan ON pertemuan.id_jadwal = jp.jadwal_id").
+		Joins("JOIN seksimateri ON seksimateri.pertemuan_id = pertemuan.id_pertemuan").
+		Joins("LEFT JOIN aksesmateri am ON am.item_id = ? AND am.siswa_id = siswa.siswa_id", item.ItemID).
+		Where("seksimateri.seksi_id = ?", item.SeksiID).
+		Order("am.terakhir_dibuka IS NULL, siswa.nama_lengkap ASC").
+		Scan(&hasil).Error
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	sudahDibuka := 0
+	for _, h := range hasil {
+		if h.JumlahDibuka > 0 {
+			sudahDibuka++
+		}
+	}
+
+	helpers.Response(w, 200, "Data akses materi berhasil diambil", map[string]interface{}{
+		"item":         item,
+		"siswa":        hasil,
+		"total_siswa":  len(hasil),
+		"sudah_dibuka": sudahDibuka,
+	})
+}
+
+// GetMateriSiswa - Materi seluruh pertemuan di kelas siswa, opsional difilter ?jadwal_id=
+func GetMateriSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	var pertemuanList []struct {
+		IDPertemuan int    `json:"id_pertemuan"`
+		JadwalID    int    `json:"jadwal_id"`
+		PertemuanKe int    `json:"pertemuan_ke"`
+		Tanggal     string `json:"tanggal"`
+		Materi      string `json:"materi"`
+		NamaMapel   string `json:"nama_mapel"`
+	}
+	query := config.DB.Table("pertemuan").
+		Select("pertemuan.id_pertemuan, jadwalpelajaran.jadwal_id, pertemuan.pertemuan_ke, DATE_FORMAT(pertemuan.tanggal, '%Y-%m-%d') AS tanggal, pertemuan.materi, mp.nama_mapel").
+		Joins("JOIN jadwalpelajaran ON pertemuan.id_jadwal = jadwalpelajaran.jadwal_id").
+		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
+		Joins("JOIN matapelajaran mp ON mp.mapel_id = jadwalpelajaran.mapel_id").
+		Where("siswa.siswa_id = ?", siswa.ID).
+		Where("EXISTS (SELECT 1 FROM seksimateri sm WHERE sm.pertemuan_id = pertemuan.id_pertemuan)")
+
+	if jadwalIDStr := r.URL.Query().Get("jadwal_id"); jadwalIDStr != "" {
+		jadwalID, err := strconv.Atoi(jadwalIDStr)
+		if err != nil {
+			helpers.Response(w, 400, "Invalid Jadwal ID", nil)
+			return
+		}
+		query = query.Where("jadwalpelajaran.jadwal_id = ?", jadwalID)
+	}
+
+	if err := query.Order("pertemuan.tanggal DESC, pertemuan.pertemuan_ke DESC").Scan(&pertemuanList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	pertemuanIDs := make([]int, 0, len(pertemuanList))
+	for _, p := range pertemuanList {
+		pertemuanIDs = append(pertemuanIDs, p.IDPertemuan)
+	}
+
+	seksiList, err := ambilSeksiMateri(pertemuanIDs)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+	isiDibukaSiswa(seksiList, siswa.ID)
+
+	seksiPerPertemuan := make(map[int][]models.SeksiMateri)
+	for _, s := range seksiList {
+		seksiPerPertemuan[s.PertemuanID] = append(seksiPerPertemuan[s.PertemuanID], s)
+	}
+
+	response := make([]map[string]interface{}, 0, len(pertemuanList))
+	for _, p := range pertemuanList {
+		response = append(response, map[string]interface{}{
+			"id_pertemuan": p.IDPertemuan,
+			"jadwal_id":    p.JadwalID,
+			"pertemuan_ke": p.PertemuanKe,
+			"tanggal":      p.Tanggal,
+			"materi":       p.Materi,
+			"nama_mapel":   p.NamaMapel,
+			"seksi":        seksiPerPertemuan[p.IDPertemuan],
+		})
+	}
+
+	helpers.Response(w, 200, "Materi berhasil diambil", response)
+}
+
+// GetMateriPertemuanSiswa - Materi satu pertemuan di kelas siswa
+func GetMateriPertemuanSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["pertemuan_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pertemuan ID", nil)
+		return
+	}
+
+	pertemuan, err := findPertemuanSiswa(pertemuanID, siswa.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Pertemuan not found or access denied", nil)
+		return
+	}
+
+	seksiList, err := ambilSeksiMateri([]int{pertemuanID})
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+	isiDibukaSiswa(seksiList, siswa.ID)
+
+	helpers.Response(w, 200, "Materi pertemuan berhasil diambil", map[string]interface{}{
+		"pertemuan": pertemuan,
+		"seksi":     seksiList,
+	})
+}
+
+// BukaItemMateri - Mencatat siswa membuka materi lalu mengembalikan URL untuk diunduh/diputar
+func BukaItemMateri(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	itemID, err := strconv.Atoi(vars["item_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid item ID", nil)
+		return
+	}
+
+	var item models.ItemMateri
+	err = config.DB.
+		Joins("JOIN seksimateri ON seksimateri.seksi_id = itemmateri.seksi_id").
+		Joins("JOIN pertemuan ON pertemuan.id_pertemuan = seksimateri.pertemuan_id").
+		Joins("JOIN jadwalpelajaran ON pertemuan.id_jadwal = jadwalpelajaran.jadwal_id").
+		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
+		Where("itemmateri.item_id = ? AND siswa.siswa_id = ?", itemID, siswa.ID).
+		First(&item).Error
+	if err != nil {
+		helpers.Response(w, 404, "Materi not found or access denied", nil)
+		return
+	}
+
+	now := time.Now()
+	config.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"jumlah_dibuka":   gorm.Expr("jumlah_dibuka + 1"),
+			"terakhir_dibuka": now,
+		}),
+	}).Create(&models.AksesMateri{
+		ItemID:         item.ItemID,
+		SiswaID:        siswa.ID,
+		JumlahDibuka:   1,
+		PertamaDibuka:  now,
+		TerakhirDibuka: now,
+	})
+
+	if item.Tipe == "Video" {
+		item.EmbedURL = embedURLVideo(item.URL)
+	}
+	item.DibukaPada = &now
+	helpers.Response(w, 200, "Materi berhasil dibuka", item)
+}
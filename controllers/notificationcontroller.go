@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	"Pasti/config"
 	"Pasti/helpers"
 	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Manual trigger untuk test notifikasi
@@ -17,12 +23,346 @@ func TriggerNotificationTest(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// Get notification stats
+// jumlahKirim - Jumlah reminder terkirim dan gagal untuk satu kelompok
+type jumlahKirim struct {
+	Terkirim int `json:"terkirim"`
+	Gagal    int `json:"gagal"`
+}
+
+func (j *jumlahKirim) tambah(status string) {
+	if status == "terkirim" {
+		j.Terkirim++
+	} else {
+		j.Gagal++
+	}
+}
+
+// statistikKanal - Reminder yang sampai lewat satu kanal dan percobaan kirim di kanal tersebut
+type statistikKanal struct {
+	Kanal          string `json:"kanal"`
+	Terkirim       int    `json:"terkirim"`
+	Percobaan      int    `json:"percobaan"`
+	PercobaanGagal int    `json:"percobaan_gagal"`
+}
+
+// alasanGagal - Alasan kegagalan dari respon penyedia beserta jumlah kemunculannya
+type alasanGagal struct {
+	Kanal  string `json:"kanal"`
+	Alasan string `json:"alasan"`
+	Jumlah int    `json:"jumlah"`
+}
+
+// korelasiReminder - Ketepatan waktu pengumpulan untuk pasangan tugas-siswa dengan jumlah reminder tertentu
+type korelasiReminder struct {
+	JumlahReminder    string  `json:"jumlah_reminder"`
+	Pasangan          int     `json:"pasangan"`
+	TepatWaktu        int     `json:"tepat_waktu"`
+	Terlambat         int     `json:"terlambat"`
+	TidakMengumpulkan int     `json:"tidak_mengumpulkan"`
+	PersenTepatWaktu  float64 `json:"persen_tepat_waktu"`
+}
+
+func (k *korelasiReminder) tambah(deadline time.Time, dikumpulkan *time.Time) {
+	k.Pasangan++
+	switch {
+	case dikumpulkan == nil:
+		k.TidakMengumpulkan++
+	case dikumpulkan.After(deadline):
+		k.Terlambat++
+	default:
+		k.TepatWaktu++
+	}
+	k.PersenTepatWaktu = persen(k.TepatWaktu, k.Pasangan)
+}
+
+func persen(bagian, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(int(float64(bagian)*1000/float64(total)+0.5)) / 10
+}
+
+// polaAngkaAlasan - Nomor telepon, ID dan kode di pesan error diganti "#" agar alasan yang sama terkelompok
+var polaAngkaAlasan = regexp.MustCompile(`\d{3,}`)
+
+// normalisasiAlasan - Alasan kegagalan yang ringkas untuk dikelompokkan
+func normalisasiAlasan(alasan string) string {
+	alasan = strings.Join(strings.Fields(polaAngkaAlasan.ReplaceAllString(alasan, "#")), " ")
+	if alasan == "" {
+		return "tidak diketahui"
+	}
+	if r := []rune(alasan); len(r) > 150 {
+		return string(r[:150]) + "..."
+	}
+	return alasan
+}
+
+// GetNotificationStats - Statistik pengiriman reminder deadline dalam rentang tanggal.
+// Query: dari, sampai (YYYY-MM-DD, default 30 hari terakhir), kelas_id (opsional)
 func GetNotificationStats(w http.ResponseWriter, r *http.Request) {
-	// Query statistik notifikasi dari database
-	// Implementasi query untuk mendapatkan stats
-	
-	helpers.Response(w, 200, "Feature coming soon", map[string]interface{}{
-		"status": "success",
+	q := r.URL.Query()
+	y, m, d := time.Now().Date()
+	hariIni := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	sampai, dari := hariIni, hariIni.AddDate(0, 0, -29)
+	var err error
+	if v := q.Get("sampai"); v != "" {
+		if sampai, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			helpers.Response(w, 400, "sampai harus berformat YYYY-MM-DD", nil)
+			return
+		}
+	}
+	if v := q.Get("dari"); v != "" {
+		if dari, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			helpers.Response(w, 400, "dari harus berformat YYYY-MM-DD", nil)
+			return
+		}
+	} else if q.Get("sampai") != "" {
+		dari = sampai.AddDate(0, 0, -29)
+	}
+	if dari.After(sampai) {
+		helpers.Response(w, 400, "dari tidak boleh setelah sampai", nil)
+		return
+	}
+	if sampai.Sub(dari) > 366*24*time.Hour {
+		helpers.Response(w, 400, "Rentang tanggal maksimal 366 hari", nil)
+		return
+	}
+	akhir := sampai.AddDate(0, 0, 1) // sampai inklusif
+
+	kelasID := 0
+	if v := q.Get("kelas_id"); v != "" {
+		if kelasID, err = strconv.Atoi(v); err != nil || kelasID <= 0 {
+			helpers.Response(w, 400, "Invalid kelas ID", nil)
+			return
+		}
+	}
+	filterKelas := ""
+	argKelas := []interface{}{}
+	if kelasID > 0 {
+		filterKelas = " AND jp.kelas_id = ?"
+		argKelas = append(argKelas, kelasID)
+	}
+
+	// Hasil akhir setiap reminder di rentang tanggal, dengan kelas dari jadwal tugas
+	var catatan []struct {
+		JenisNotifikasi string
+		Status          string
+		ResponseAPI     string
+		KelasID         int
+		NamaKelas       string
+	}
+	err = config.DB.Raw(`
+		SELECT nt.jenis_notifikasi, nt.status, COALESCE(nt.response_api, '') AS response_api, k.kelas_id, k.nama_kelas
+		FROM notifikasi_tugas nt
+		JOIN tugas t ON t.tugas_id = nt.tugas_id
+		JOIN jadwalpelajaran jp ON jp.jadwal_id = t.jadwal_id
+		JOIN kelas k ON k.kelas_id = jp.kelas_id
+		WHERE nt.tanggal_kirim >= ? AND nt.tanggal_kirim < ?`+filterKelas,
+		append([]interface{}{dari, akhir}, argKelas...)...).Scan(&catatan).Error
+	if err != nil {
+		helpers.Response(w, 500, "Gagal mengambil statistik notifikasi", nil)
+		return
+	}
+
+	total := jumlahKirim{}
+	perJenis := map[string]*jumlahKirim{}
+	for _, j := range []string{"3_hari", "1_hari", "2_jam", "lewat_deadline"} {
+		perJenis[j] = &jumlahKirim{}
+	}
+	type kelasKirim struct {
+		KelasID   int    `json:"kelas_id"`
+		NamaKelas string `json:"nama_kelas"`
+		jumlahKirim
+	}
+	perKelas := map[int]*kelasKirim{}
+	perKanal := map[string]*statistikKanal{}
+	for _, k := range append(append([]string{}, helpers.KanalEksternal...), helpers.KanalInApp) {
+		perKanal[k] = &statistikKanal{Kanal: k}
+	}
+	alasan := map[[2]string]int{}
+
+	for _, c := range catatan {
+		total.tambah(c.Status)
+		if perJenis[c.JenisNotifikasi] == nil {
+			perJenis[c.JenisNotifikasi] = &jumlahKirim{}
+		}
+		perJenis[c.JenisNotifikasi].tambah(c.Status)
+		if perKelas[c.KelasID] == nil {
+			perKelas[c.KelasID] = &kelasKirim{KelasID: c.KelasID, NamaKelas: c.NamaKelas}
+		}
+		perKelas[c.KelasID].tambah(c.Status)
+
+		l := helpers.LogPengirimanDariRespon(c.Status, c.ResponseAPI)
+		if c.Status == "terkirim" && l.KanalTerkirim != "" {
+			if perKanal[l.KanalTerkirim] == nil {
+				perKanal[l.KanalTerkirim] = &statistikKanal{Kanal: l.KanalTerkirim}
+			}
+			perKanal[l.KanalTerkirim].Terkirim++
+		}
+		for _, p := range l.Percobaan {
+			if perKanal[p.Kanal] == nil {
+				perKanal[p.Kanal] = &statistikKanal{Kanal: p.Kanal}
+			}
+			perKanal[p.Kanal].Percobaan++
+			if !p.Berhasil {
+				perKanal[p.Kanal].PercobaanGagal++
+				alasan[[2]string{p.Kanal, normalisasiAlasan(p.Error)}]++
+			}
+		}
+	}
+
+	daftarKanal := []statistikKanal{}
+	for _, k := range perKanal {
+		daftarKanal = append(daftarKanal, *k)
+	}
+	sort.Slice(daftarKanal, func(i, j int) bool { return daftarKanal[i].Kanal < daftarKanal[j].Kanal })
+
+	daftarKelas := []kelasKirim{}
+	for _, k := range perKelas {
+		daftarKelas = append(daftarKelas, *k)
+	}
+	sort.Slice(daftarKelas, func(i, j int) bool { return daftarKelas[i].NamaKelas < daftarKelas[j].NamaKelas })
+
+	daftarAlasan := []alasanGagal{}
+	for kunci, n := range alasan {
+		daftarAlasan = append(daftarAlasan, alasanGagal{Kanal: kunci[0], Alasan: kunci[1], Jumlah: n})
+	}
+	sort.Slice(daftarAlasan, func(i, j int) bool {
+		if daftarAlasan[i].Jumlah != daftarAlasan[j].Jumlah {
+			return daftarAlasan[i].Jumlah > daftarAlasan[j].Jumlah
+		}
+		return daftarAlasan[i].Alasan < daftarAlasan[j].Alasan
+	})
+	if len(daftarAlasan) > 20 {
+		daftarAlasan = daftarAlasan[:20]
+	}
+
+	// Pesan outbox yang diantrekan di rentang tanggal dan belum selesai atau masuk dead letter
+	var perStatusOutbox []struct {
+		Status string
+		Jumlah int64
+	}
+	config.DB.Raw(`
+		SELECT o.status, COUNT(*) AS jumlah
+		FROM outboxnotifikasi o
+		JOIN tugas t ON t.tugas_id = o.tugas_id
+		JOIN jadwalpelajaran jp ON jp.jadwal_id = t.jadwal_id
+		WHERE o.created_at >= ? AND o.created_at < ?`+filterKelas+`
+		GROUP BY o.status`, append([]interface{}{dari, akhir}, argKelas...)...).Scan(&perStatusOutbox)
+	outbox := map[string]int64{helpers.OutboxMenunggu: 0, helpers.OutboxDiproses: 0, helpers.OutboxDeadLetter: 0}
+	for _, s := range perStatusOutbox {
+		if _, ok := outbox[s.Status]; ok {
+			outbox[s.Status] = s.Jumlah
+		}
+	}
+
+	// Siswa tanpa nomor telepon atau dengan nomor yang tidak bisa dipakai WhatsApp
+	var daftarSiswa []struct {
+		SiswaID     int    `json:"siswa_id"`
+		NamaLengkap string `json:"nama_lengkap"`
+		NamaKelas   string `json:"nama_kelas"`
+		NoTelepon   string `json:"no_telepon"`
+		Email       string `json:"email"`
+		Masalah     string `json:"masalah" gorm:"-"`
+	}
+	querySiswa := `
+		SELECT s.siswa_id, s.nama_lengkap, k.nama_kelas, COALESCE(s.no_telepon, '') AS no_telepon, COALESCE(s.email, '') AS email
+		FROM siswa s
+		JOIN kelas k ON k.kelas_id = s.kelas_id`
+	argSiswa := []interface{}{}
+	if kelasID > 0 {
+		querySiswa += " WHERE s.kelas_id = ?"
+		argSiswa = append(argSiswa, kelasID)
+	}
+	if err := config.DB.Raw(querySiswa+" ORDER BY k.nama_kelas, s.nama_lengkap", argSiswa...).Scan(&daftarSiswa).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil data siswa", nil)
+		return
+	}
+	nomorBermasalah := daftarSiswa[:0]
+	kosong, tidakValid := 0, 0
+	for _, s := range daftarSiswa {
+		switch {
+		case strings.TrimSpace(s.NoTelepon) == "":
+			s.Masalah = "kosong"
+			kosong++
+		case helpers.NormalisasiNomorWhatsApp(s.NoTelepon) == "":
+			s.Masalah = "tidak_valid"
+			tidakValid++
+		default:
+			continue
+		}
+		nomorBermasalah = append(nomorBermasalah, s)
+	}
+
+	// Pasangan tugas-siswa dengan deadline (termasuk perpanjangan) di rentang tanggal yang sudah lewat
+	var pasangan []struct {
+		Deadline       time.Time
+		Dikumpulkan    *time.Time
+		JumlahReminder int
+	}
+	err = config.DB.Raw(`
+		SELECT
+			COALESCE((SELECT MAX(pd.deadline_baru) FROM perpanjangandeadline pd
+				WHERE pd.tugas_id = t.tugas_id AND pd.siswa_id = s.siswa_id), t.deadline_pengumpulan) AS deadline,
+			(SELECT MIN(pt.tanggal_pengumpulan) FROM pengumpulantugas pt
+				WHERE pt.tugas_id = t.tugas_id AND pt.siswa_id = s.siswa_id) AS dikumpulkan,
+			(SELECT COUNT(*) FROM notifikasi_tugas nt
+				WHERE nt.tugas_id = t.tugas_id AND nt.siswa_id = s.siswa_id AND nt.status = 'terkirim'
+					AND nt.jenis_notifikasi IN ('3_hari', '1_hari', '2_jam')) AS jumlah_reminder
+		FROM tugas t
+		JOIN jadwalpelajaran jp ON jp.jadwal_id = t.jadwal_id
+		JOIN siswa s ON s.kelas_id = jp.kelas_id
+		WHERE t.deadline_pengumpulan >= ? AND t.deadline_pengumpulan < ? AND t.deadline_pengumpulan < NOW()
+			AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= t.deadline_pengumpulan)`+filterKelas,
+		append([]interface{}{dari, akhir}, argKelas...)...).Scan(&pasangan).Error
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menghitung korelasi reminder", nil)
+		return
+	}
+	perJumlah := []*korelasiReminder{{JumlahReminder: "0"}, {JumlahReminder: "1"}, {JumlahReminder: "2"}, {JumlahReminder: "3+"}}
+	denganReminder := &korelasiReminder{JumlahReminder: "1+"}
+	for _, p := range pasangan {
+		i := p.JumlahReminder
+		if i > 3 {
+			i = 3
+		}
+		perJumlah[i].tambah(p.Deadline, p.Dikumpulkan)
+		if p.JumlahReminder > 0 {
+			denganReminder.tambah(p.Deadline, p.Dikumpulkan)
+		}
+	}
+
+	ringkasanJenis := map[string]jumlahKirim{}
+	for j, n := range perJenis {
+		ringkasanJenis[j] = *n
+	}
+	helpers.Response(w, 200, "Statistik notifikasi", map[string]interface{}{
+		"periode": map[string]interface{}{
+			"dari":     dari.Format("2006-01-02"),
+			"sampai":   sampai.Format("2006-01-02"),
+			"kelas_id": kelasID,
+		},
+		"ringkasan": map[string]interface{}{
+			"total":                total.Terkirim + total.Gagal,
+			"terkirim":             total.Terkirim,
+			"gagal":                total.Gagal,
+			"persen_terkirim":      persen(total.Terkirim, total.Terkirim+total.Gagal),
+			"outbox_belum_selesai": outbox,
+		},
+		"per_jenis":    ringkasanJenis,
+		"per_kanal":    daftarKanal,
+		"per_kelas":    daftarKelas,
+		"alasan_gagal": daftarAlasan,
+		"nomor_telepon": map[string]interface{}{
+			"kosong":      kosong,
+			"tidak_valid": tidakValid,
+			"siswa":       nomorBermasalah,
+		},
+		"korelasi_pengumpulan": map[string]interface{}{
+			"tanpa_reminder":  perJumlah[0],
+			"dengan_reminder": denganReminder,
+			"per_jumlah":      perJumlah,
+			"catatan":         "Reminder hanya dikirim ke siswa yang belum mengumpulkan, sehingga kelompok tanpa reminder didominasi siswa yang mengumpulkan lebih awal. Persentase kelompok dengan reminder menunjukkan berapa banyak siswa yang akhirnya mengumpulkan tepat waktu setelah diingatkan.",
+		},
 	})
 }
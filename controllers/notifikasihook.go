@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+)
+
+// notifikasiSiswa - Mengirim notifikasi ke siswa lewat kanal pilihannya (WhatsApp/email/Telegram dengan
+// fallback) dan menyimpan salinan in-app. Pemanggil menjalankannya di goroutine agar request tidak menunggu
+func notifikasiSiswa(siswaID int, judul, pesan, link string) {
+	kirimNotifikasi(penerimaSiswa(siswaID), judul, pesan, link)
+}
+
+// notifikasiGuru - Mengirim notifikasi ke guru lewat kanal pilihannya dan menyimpan salinan in-app
+func notifikasiGuru(guruID int, judul, pesan, link string) {
+	kirimNotifikasi(penerimaGuru(guruID), judul, pesan, link)
+}
+
+// penerimaSiswa - Alamat notifikasi siswa (nomor telepon dan email)
+func penerimaSiswa(siswaID int) helpers.Penerima {
+	penerima := helpers.Penerima{TipeUser: "Siswa", UserID: siswaID}
+	var siswa models.Siswa
+	if err := config.DB.Select("siswa_id, nama_lengkap, no_telepon, email").First(&siswa, "siswa_id = ?", siswaID).Error; err == nil {
+		penerima.Nama = siswa.NamaLengkap
+		penerima.NoTelepon = siswa.NoTelepon
+		penerima.Email = siswa.Email
+	}
+	return penerima
+}
+
+// penerimaGuru - Alamat notifikasi guru (email)
+func penerimaGuru(guruID int) helpers.Penerima {
+	penerima := helpers.Penerima{TipeUser: "Guru", UserID: guruID}
+	var guru models.Guru
+	if err := config.DB.Select("guru_id, nama_lengkap, email").First(&guru, "guru_id = ?", guruID).Error; err == nil {
+		penerima.Nama = guru.NamaLengkap
+		penerima.Email = guru.Email
+	}
+	return penerima
+}
+
+// kirimNotifikasi - Mengirim lewat dispatcher dan mencatat jika tidak ada kanal yang berhasil
+func kirimNotifikasi(penerima helpers.Penerima, judul, pesan, link string) {
+	hasil := helpers.NewNotifikasiService().KirimPesan(penerima, helpers.PesanNotifikasi{Judul: judul, Isi: pesan, Link: link})
+	if !hasil.Terkirim {
+		log.Printf("❌ Failed to notify %s %d: %s", penerima.TipeUser, penerima.UserID, hasil.Respon())
+	}
+}
+
+// notifikasiNilai - Memberi tahu siswa bahwa pengumpulannya sudah dinilai.
+// Pengumpulan yang statusnya bukan Dinilai dilewati
+func notifikasiNilai(tugas models.Tugas, pengumpulanList ...models.PengumpulanTugas) {
+	for _, p := range pengumpulanList {
+		if p.StatusPengumpulan != "Dinilai" {
+			continue
+		}
+		pesan := fmt.Sprintf("Tugas \"%s\" sudah dinilai. Poin: %d/%d", tugas.JudulTugas, p.PoinDidapat, tugas.PoinMaksimal)
+		if p.Nilai != nil {
+			pesan += fmt.Sprintf(" (nilai %.2f)", *p.Nilai)
+		}
+		notifikasiSiswa(p.SiswaID, "Tugas dinilai", pesan, "/tugas")
+	}
+}
+
+// notifikasiTugasTerbitMu - Pengumuman tugas baru dijalankan satu per satu
+var notifikasiTugasTerbitMu sync.Mutex
+
+// RunNotifikasiTugasTerbitCron - Mengumumkan tugas yang sudah terbit tetapi belum diumumkan ke kotak masuk
+// siswa di kelasnya. Dipanggil setelah tugas dibuat/diubah dan oleh cron untuk tugas terjadwal
+func RunNotifikasiTugasTerbitCron() {
+	notifikasiTugasTerbitMu.Lock()
+	defer notifikasiTugasTerbitMu.Unlock()
+
+	var daftar []struct {
+		TugasID             int
+		JudulTugas          string
+		DeadlinePengumpulan time.Time
+		KelasID             int
+		NamaMapel           string
+	}
+	err := config.DB.Raw(`
+		SELECT t.tugas_id, t.judul_tugas, t.deadline_pengumpulan, jp.kelas_id, mp.nama_mapel
+		FROM tugas t
+		JOIN jadwalpelajaran jp ON t.jadwal_id = jp.jadwal_id
+		JOIN matapelajaran mp ON jp.mapel_id = mp.mapel_id
+		WHERE t.notifikasi_terbit_at IS NULL
+			AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())`).Scan(&daftar).Error
+	if err != nil {
+		log.Printf("❌ Failed to query newly published tugas: %v", err)
+		return
+	}
+
+	for _, t := range daftar {
+		// Klaim lebih dulu agar instance lain tidak mengumumkan tugas yang sama
+		klaim := config.DB.Model(&models.Tugas{}).
+			Where("tugas_id = ? AND notifikasi_terbit_at IS NULL", t.TugasID).
+			UpdateColumn("notifikasi_terbit_at", time.Now())
+		if klaim.Error != nil || klaim.RowsAffected == 0 {
+			continue
+		}
+
+		var siswaIDs []int
+		if err := config.DB.Model(&models.Siswa{}).Where("kelas_id = ?", t.KelasID).Pluck("siswa_id", &siswaIDs).Error; err != nil {
+			log.Printf("❌ Failed to load students for tugas %d: %v", t.TugasID, err)
+			continue
+		}
+		pesan := fmt.Sprintf("%s: \"%s\". Deadline %s", t.NamaMapel, t.JudulTugas, t.DeadlinePengumpulan.Format("02 Jan 2006 15:04"))
+		notifikasi := make([]models.Notifikasi, 0, len(siswaIDs))
+		for _, id := range siswaIDs {
+			notifikasi = append(notifikasi, models.Notifikasi{
+				UserID:          id,
+				TipeUser:        "Siswa",
+				JudulNotifikasi: "Tugas baru",
+				PesanNotifikasi: pesan,
+				LinkTerkait:     "/tugas",
+			})
+		}
+		helpers.SimpanNotifikasiInApp(notifikasi)
+	}
+}
+
+// notifikasiAbsensi - Memberi tahu siswa bahwa guru mengubah status kehadirannya di suatu pertemuan
+func notifikasiAbsensi(pertemuanID, siswaID int, status string) {
+	var info struct {
+		PertemuanKe int
+		Tanggal     string
+		NamaMapel   string
+	}
+	config.DB.Raw(`
+		SELECT p.pertemuan_ke, DATE_FORMAT(p.tanggal, '%d-%m-%Y') AS tanggal, mp.nama_mapel
+		FROM pertemuan p
+		JOIN jadwalpelajaran jp ON p.id_jadwal = jp.jadwal_id
+		JOIN matapelajaran mp ON jp.mapel_id = mp.mapel_id
+		WHERE p.id_pertemuan = ?`, pertemuanID).Scan(&info)
+
+	pesan := fmt.Sprintf("Status kehadiran Anda di %s pertemuan ke-%d (%s) diubah menjadi %s", info.NamaMapel, info.PertemuanKe, info.Tanggal, status)
+	notifikasiSiswa(siswaID, "Kehadiran diperbarui", pesan, "/absensi")
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Pemantauan outbox reminder deadline: antrean, percobaan ulang dan dead letter
+
+// GetOutboxNotifikasi - Daftar pesan outbox terbaru beserta jumlah per status.
+// Query: status=menunggu|diproses|terkirim|dead_letter|usang, page (default 1), limit (default 20, maks 100)
+func GetOutboxNotifikasi(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := config.DB.Model(&models.OutboxNotifikasi{})
+	switch status := r.URL.Query().Get("status"); status {
+	case "":
+	case helpers.OutboxMenunggu, helpers.OutboxDiproses, helpers.OutboxTerkirim, helpers.OutboxDeadLetter, helpers.OutboxUsang:
+		query = query.Where("status = ?", status)
+	default:
+		helpers.Response(w, 400, "status harus menunggu, diproses, terkirim, dead_letter atau usang", nil)
+		return
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil outbox notifikasi", nil)
+		return
+	}
+	daftar := []models.OutboxNotifikasi{}
+	if err := query.Order("outbox_id DESC").Limit(limit).Offset((page - 1) * limit).Find(&daftar).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil outbox notifikasi", nil)
+		return
+	}
+
+	var perStatus []struct {
+		Status string
+		Jumlah int64
+	}
+	config.DB.Model(&models.OutboxNotifikasi{}).Select("status, COUNT(*) AS jumlah").Group("status").Scan(&perStatus)
+	ringkasan := map[string]int64{
+		helpers.OutboxMenunggu: 0, helpers.OutboxDiproses: 0, helpers.OutboxTerkirim: 0, helpers.OutboxDeadLetter: 0, helpers.OutboxUsang: 0,
+	}
+	for _, s := range perStatus {
+		ringkasan[s.Status] = s.Jumlah
+	}
+
+	helpers.Response(w, 200, "Outbox notifikasi", map[string]interface{}{
+		"outbox":         daftar,
+		"ringkasan":      ringkasan,
+		"maks_percobaan": helpers.MaksPercobaanOutbox(),
+		"pagination": map[string]interface{}{
+			"page":        page,
+			"limit":       limit,
+			"total":       total,
+			"total_pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// UlangOutboxNotifikasi - Mengantrekan ulang pesan dead letter dengan hitungan percobaan dari nol. Catatan
+// gagal di notifikasi_tugas dari dead letter tersebut dihapus; hasil pengiriman ulang dicatat sebagai gantinya
+func UlangOutboxNotifikasi(w http.ResponseWriter, r *http.Request) {
+	outboxID, err := strconv.Atoi(mux.Vars(r)["outbox_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid outbox ID", nil)
+		return
+	}
+
+	var outbox models.OutboxNotifikasi
+	if err := config.DB.Where("outbox_id = ? AND status = ?", outboxID, helpers.OutboxDeadLetter).First(&outbox).Error; err != nil {
+		helpers.Response(w, 404, "Pesan dead letter tidak ditemukan", nil)
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		hasil := tx.Model(&models.OutboxNotifikasi{}).
+			Where("outbox_id = ? AND status = ?", outboxID, helpers.OutboxDeadLetter).
+			Updates(map[string]interface{}{
+				"status":         helpers.OutboxMenunggu,
+				"percobaan":      0,
+				"berikutnya_at":  time.Now(),
+				"error_terakhir": "",
+				"selesai_at":     nil,
+			})
+		if hasil.Error != nil {
+			return hasil.Error
+		}
+		if hasil.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// Catatan gagal dari dead letter ini dihapus agar hasil pengiriman ulang tidak dihitung dua kali
+		// di statistik. Catatan ditulis tepat setelah selesai_at pesan diisi
+		if outbox.SelesaiAt == nil {
+			return nil
+		}
+		return tx.Where("tugas_id = ? AND siswa_id = ? AND jenis_notifikasi = ? AND status = ?",
+			outbox.TugasID, outbox.SiswaID, outbox.JenisNotifikasi, "gagal").
+			Where("tanggal_kirim BETWEEN ? AND ?", outbox.SelesaiAt.Add(-time.Minute), outbox.SelesaiAt.Add(time.Minute)).
+			Delete(&models.NotifikasiTugas{}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		helpers.Response(w, 404, "Pesan dead letter tidak ditemukan", nil)
+		return
+	}
+	if err != nil {
+		helpers.Response(w, 500, "Gagal mengantrekan ulang pesan", nil)
+		return
+	}
+	helpers.BangunkanOutbox()
+	helpers.Response(w, 200, "Pesan diantrekan ulang", map[string]interface{}{"outbox_id": outboxID})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// jendelaReminder - Rentang sisa waktu ke deadline yang ditangani satu tingkat reminder
+type jendelaReminder struct {
+	Jenis      string `json:"jenis"`
+	DariJam    int    `json:"dari_jam"`
+	SampaiJam  int    `json:"sampai_jam"`
+	Keterangan string `json:"keterangan"`
+}
+
+// responPengaturanReminder - Pengaturan beserta jendela tingkat aktif dan status jam tenang saat ini
+func responPengaturanReminder(p models.PengaturanReminder) map[string]interface{} {
+	tingkat := helpers.TingkatReminderAktif(p)
+	jendela := []jendelaReminder{}
+	for i, t := range tingkat {
+		var dari time.Duration
+		if i+1 < len(tingkat) {
+			dari = tingkat[i+1].Sebelum
+		}
+		jendela = append(jendela, jendelaReminder{
+			Jenis:      t.Jenis,
+			DariJam:    int(dari.Hours()),
+			SampaiJam:  int(t.Sebelum.Hours()),
+			Keterangan: fmt.Sprintf("deadline %d-%d jam lagi", int(dari.Hours()), int(t.Sebelum.Hours())),
+		})
+	}
+	return map[string]interface{}{
+		"pengaturan":       p,
+		"jendela":          jendela,
+		"dalam_jam_tenang": helpers.DalamJamTenang(p, time.Now()),
+	}
+}
+
+// GetPengaturanReminder - Admin melihat pengaturan reminder deadline sekolah
+func GetPengaturanReminder(w http.ResponseWriter, r *http.Request) {
+	helpers.Response(w, 200, "Pengaturan reminder", responPengaturanReminder(helpers.AmbilPengaturanReminder()))
+}
+
+// UpdatePengaturanReminder - Admin mengubah tingkat reminder aktif, reminder lewat deadline dan jam tenang.
+// Field yang tidak dikirim tidak berubah; jam tenang dinonaktifkan dengan mengosongkan kedua jam
+func UpdatePengaturanReminder(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value("admininfo").(*helpers.AdminCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid admin info format", nil)
+		return
+	}
+
+	var req struct {
+		Reminder3Hari         *bool   `json:"reminder_3_hari"`
+		Reminder1Hari         *bool   `json:"reminder_1_hari"`
+		Reminder2Jam          *bool   `json:"reminder_2_jam"`
+		ReminderLewatDeadline *bool   `json:"reminder_lewat_deadline"`
+		LewatDeadlineMaksHari *int    `json:"lewat_deadline_maks_hari"`
+		JamTenangMulai        *string `json:"jam_tenang_mulai"`
+		JamTenangSelesai      *string `json:"jam_tenang_selesai"`
+		ZonaWaktu             *string `json:"zona_waktu"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Response(w, 400, "Invalid request body", nil)
+		return
+	}
+
+	p := helpers.AmbilPengaturanReminder()
+	p.ID = 1
+	if req.Reminder3Hari != nil {
+		p.Reminder3Hari = *req.Reminder3Hari
+	}
+	if req.Reminder1Hari != nil {
+		p.Reminder1Hari = *req.Reminder1Hari
+	}
+	if req.Reminder2Jam != nil {
+		p.Reminder2Jam = *req.Reminder2Jam
+	}
+	if req.ReminderLewatDeadline != nil {
+		p.ReminderLewatDeadline = *req.ReminderLewatDeadline
+	}
+	if req.LewatDeadlineMaksHari != nil {
+		p.LewatDeadlineMaksHari = *req.LewatDeadlineMaksHari
+	}
+	if req.JamTenangMulai != nil {
+		p.JamTenangMulai = *req.JamTenangMulai
+	}
+	if req.JamTenangSelesai != nil {
+		p.JamTenangSelesai = *req.JamTenangSelesai
+	}
+	if req.ZonaWaktu != nil {
+		p.ZonaWaktu = *req.ZonaWaktu
+	}
+	if err := helpers.ValidasiPengaturanReminder(p); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+	p.DiubahOleh = admin.Username
+
+	if err := config.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&p).Error; err != nil {
+		log.Printf("❌ Failed to save reminder settings: %v", err)
+		helpers.Response(w, 500, "Gagal menyimpan pengaturan reminder", nil)
+		return
+	}
+	helpers.Response(w, 200, "Pengaturan reminder berhasil disimpan", responPengaturanReminder(p))
+}
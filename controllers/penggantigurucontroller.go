@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// findPertemuanGuru - Mengambil pertemuan yang boleh dikelola guru: guru pengampu jadwal
+// atau guru pengganti yang sudah disetujui admin untuk pertemuan tersebut
+func findPertemuanGuru(pertemuanID any, guruID int) (models.Pertemuan, error) {
+	var pertemuan models.Pertemuan
+	err := config.DB.
+		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
+		Where("pertemuan.id_pertemuan = ?", pertemuanID).
+		Where("jp.guru_id = ? OR (pertemuan.guru_pengganti_id = ? AND pertemuan.pengganti_disetujui_pada IS NOT NULL)", guruID, guruID).
+		First(&pertemuan).Error
+
+	return pertemuan, err
+}
+
+// queryJadwalPengganti - Query dasar daftar pertemuan yang memiliki guru pengganti
+const queryJadwalPengganti = `
+	SELECT
+		p.id_pertemuan,
+		p.id_jadwal AS jadwal_id,
+		p.pertemuan_ke,
+		p.tanggal,
+		mp.nama_mapel,
+		k.nama_kelas,
+		jp.hari,
+		TIME_FORMAT(jp.jam_mulai, '%H:%i') AS waktu_mulai,
+		TIME_FORMAT(jp.jam_selesai, '%H:%i') AS waktu_selesai,
+		jp.ruang,
+		jp.guru_id,
+		g.nama_lengkap AS nama_guru,
+		p.guru_pengganti_id,
+		gp.nama_lengkap AS nama_guru_pengganti,
+		p.alasan_pengganti,
+		p.pengganti_disetujui_oleh,
+		p.pengganti_disetujui_pada
+	FROM pertemuan p
+	JOIN jadwalpelajaran jp ON p.id_jadwal = jp.jadwal_id
+	JOIN matapelajaran mp ON jp.mapel_id = mp.mapel_id
+	JOIN kelas k ON jp.kelas_id = k.kelas_id
+	JOIN guru g ON jp.guru_id = g.guru_id
+	JOIN guru gp ON p.guru_pengganti_id = gp.guru_id
+	WHERE p.guru_pengganti_id IS NOT NULL`
+
+// AjukanGuruPengganti - Guru pengampu mengajukan guru pengganti untuk satu pertemuan
+func AjukanGuruPengganti(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "ID pertemuan tidak valid", nil)
+		return
+	}
+
+	var request struct {
+		GuruPenggantiID int    `json:"guru_pengganti_id"`
+		Alasan          string `json:"alasan"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Format JSON tidak valid", nil)
+		return
+	}
+
+	if request.GuruPenggantiID == 0 || request.Alasan == "" {
+		helpers.Response(w, 400, "Guru pengganti dan alasan wajib diisi", nil)
+		return
+	}
+
+	if request.GuruPenggantiID == guru.ID {
+		helpers.Response(w, 400, "Guru pengganti tidak boleh guru pengampu sendiri", nil)
+		return
+	}
+
+	// Hanya guru pengampu jadwal yang boleh mengajukan pengganti
+	var pertemuan models.Pertemuan
+	if err := config.DB.
+		Joins("JOIN jadwalpelajaran jp ON pertemuan.id_jadwal = jp.jadwal_id").
+		Where("pertemuan.id_pertemuan = ? AND jp.guru_id = ?", pertemuanID, guru.ID).
+		First(&pertemuan).Error; err != nil {
+		helpers.Response(w, 404, "Pertemuan tidak ditemukan atau Anda tidak memiliki akses", nil)
+		return
+	}
+
+	var pengganti models.Guru
+	if err := config.DB.First(&pengganti, "guru_id = ?", request.GuruPenggantiID).Error; err != nil {
+		helpers.Response(w, 404, "Guru pengganti tidak ditemukan", nil)
+		return
+	}
+
+	// Pengajuan baru selalu menunggu persetujuan admin
+	if err := config.DB.Model(&pertemuan).Updates(map[string]interface{}{
+		"guru_pengganti_id":        request.GuruPenggantiID,
+		"alasan_pengganti":         request.Alasan,
+		"pengganti_disetujui_oleh": nil,
+		"pengganti_disetujui_pada": nil,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan pengajuan guru pengganti", nil)
+		return
+	}
+
+	config.DB.First(&pertemuan, pertemuanID)
+	helpers.Response(w, 200, "Pengajuan guru pengganti berhasil dikirim, menunggu persetujuan admin", pertemuan)
+}
+
+// GetJadwalPengganti - Daftar pertemuan dengan guru pengganti, baik sebagai guru asli maupun pengganti
+func GetJadwalPengganti(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	var data []models.JadwalPengganti
+	query := queryJadwalPengganti + `
+		AND (jp.guru_id = ? OR p.guru_pengganti_id = ?)
+	ORDER BY p.tanggal ASC`
+
+	if err := config.DB.Raw(query, guru.ID, guru.ID).Scan(&data).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil jadwal pengganti", nil)
+		return
+	}
+
+	for i := range data {
+		if data[i].GuruPenggantiID == guru.ID {
+			data[i].Peran = "Pengganti"
+		} else {
+			data[i].Peran = "Pengampu"
+		}
+	}
+
+	helpers.Response(w, 200, "Jadwal guru pengganti", data)
+}
+
+// GetDaftarPengganti - Admin melihat semua pengajuan/penugasan guru pengganti
+// Query param status=menunggu|disetujui untuk memfilter
+func GetDaftarPengganti(w http.ResponseWriter, r *http.Request) {
+	query := queryJadwalPengganti
+
+	switch r.URL.Query().Get("status") {
+	case "menunggu":
+		query += " AND p.pengganti_disetujui_pada IS NULL"
+	case "disetujui":
+		query += " AND p.pengganti_disetujui_pada IS NOT NULL"
+	}
+	query += " ORDER BY p.tanggal ASC"
+
+	var data []models.JadwalPengganti
+	if err := config.DB.Raw(query).Scan(&data).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil data guru pengganti: "+err.Error(), nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Daftar guru pengganti", data)
+}
+
+// SetGuruPengganti - Admin menyetujui pengajuan atau langsung menugaskan guru pengganti
+func SetGuruPengganti(w http.ResponseWriter, r *http.Request) {
+	username := r.Header.Get("X-Username")
+
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "ID pertemuan tidak valid", nil)
+		return
+	}
+
+	// Body opsional: jika kosong, setujui pengajuan yang sudah ada
+	var request struct {
+		GuruPenggantiID int    `json:"guru_pengganti_id"`
+		Alasan          string `json:"alasan"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			helpers.Response(w, 400, "Format JSON tidak valid", nil)
+			return
+		}
+	}
+
+	var pertemuan models.Pertemuan
+	if err := config.DB.First(&pertemuan, pertemuanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			helpers.Response(w, 404, "Pertemuan tidak ditemukan", nil)
+		} else {
+			helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		}
+		return
+	}
+
+	penggantiID := request.GuruPenggantiID
+	if penggantiID == 0 && pertemuan.GuruPenggantiID != nil {
+		penggantiID = *pertemuan.GuruPenggantiID
+	}
+	if penggantiID == 0 {
+		helpers.Response(w, 400, "Belum ada guru pengganti yang diajukan untuk pertemuan ini", nil)
+		return
+	}
+
+	alasan := request.Alasan
+	if alasan == "" {
+		alasan = pertemuan.AlasanPengganti
+	}
+
+	var jadwal models.JadwalPelajaran
+	if err := config.DB.First(&jadwal, "jadwal_id = ?", pertemuan.IDJadwal).Error; err != nil {
+		helpers.Response(w, 404, "Jadwal pertemuan tidak ditemukan", nil)
+		return
+	}
+	if jadwal.GuruID == penggantiID {
+		helpers.Response(w, 400, "Guru pengganti tidak boleh guru pengampu jadwal", nil)
+		return
+	}
+
+	var pengganti models.Guru
+	if err := config.DB.First(&pengganti, "guru_id = ?", penggantiID).Error; err != nil {
+		helpers.Response(w, 404, "Guru pengganti tidak ditemukan", nil)
+		return
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&pertemuan).Updates(map[string]interface{}{
+		"guru_pengganti_id":        penggantiID,
+		"alasan_pengganti":         alasan,
+		"pengganti_disetujui_oleh": username,
+		"pengganti_disetujui_pada": now,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan guru pengganti", nil)
+		return
+	}
+
+	config.DB.First(&pertemuan, pertemuanID)
+	helpers.Response(w, 200, "Guru pengganti berhasil disetujui", pertemuan)
+}
+
+// DeleteGuruPengganti - Admin membatalkan/menolak guru pengganti pada pertemuan
+func DeleteGuruPengganti(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	pertemuanID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		helpers.Response(w, 400, "ID pertemuan tidak valid", nil)
+		return
+	}
+
+	var pertemuan models.Pertemuan
+	if err := config.DB.First(&pertemuan, pertemuanID).Error; err != nil {
+		helpers.Response(w, 404, "Pertemuan tidak ditemukan", nil)
+		return
+	}
+
+	if err := config.DB.Model(&pertemuan).Updates(map[string]interface{}{
+		"guru_pengganti_id":        nil,
+		"alasan_pengganti":         "",
+		"pengganti_disetujui_oleh": nil,
+		"pengganti_disetujui_pada": nil,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Gagal menghapus guru pengganti", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Guru pengganti berhasil dihapus", nil)
+}
@@ -12,6 +12,7 @@ import (
 	"Pasti/models"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
 )
 
 // GetTugasSiswa - Mendapatkan semua tugas berdasarkan kelas siswa
@@ -30,6 +31,7 @@ func GetTugasSiswa(w http.ResponseWriter, r *http.Request) {
 		Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
 		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
 		Where("siswa.siswa_id = ?", siswaID).
+		Where(kondisiTugasTerbit).
 		Find(&tugas)
 
 	if result.Error != nil {
@@ -63,6 +65,14 @@ func GetTugasSiswa(w http.ResponseWriter, r *http.Request) {
 			"poin_didapat":          0,
 		}
 
+		// Deadline efektif siswa (perpanjangan + masa tenggang) dan batas akhir pengumpulan
+		deadlineEfektif, tanggalTutup := batasPengumpulanSiswa(t, siswaID)
+		tugasData["deadline_efektif"] = deadlineEfektif
+		tugasData["tanggal_tutup"] = tanggalTutup
+
+		// Tugas berupa kuis online dikerjakan lewat endpoint kuis, bukan upload file
+		tugasData["kuis_id"] = kuisIDTugas(t.TugasID)
+
 		// Jika sudah ada pengumpulan, update status
 		if pengumpulan.PengumpulanID != 0 {
 			tugasData["status_pengumpulan"] = pengumpulan.StatusPengumpulan
@@ -117,6 +127,7 @@ func SubmitTugas(w http.ResponseWriter, r *http.Request) {
 		Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
 		Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
 		Where("tugas.tugas_id = ? AND siswa.siswa_id = ?", tugasID, siswaID).
+		Where(kondisiTugasTerbit).
 		First(&tugas)
 
 	if result.Error != nil {
@@ -124,13 +135,42 @@ func SubmitTugas(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Cek deadline
+	if kuisIDTugas(tugasID) != nil {
+		helpers.Response(w, 400, "Tugas ini berupa kuis online, kerjakan melalui halaman kuis", nil)
+		return
+	}
+
+	if !fileJawabanMilikSiswa(request.FileJawabanSiswa, tugasID, siswaID) {
+		helpers.Response(w, 400, "File jawaban harus file yang Anda unggah sendiri", nil)
+		return
+	}
+
+	// Cek deadline (termasuk perpanjangan, deadline bersama kelompok dan masa tenggang) serta batas akhir pengumpulan
 	now := time.Now()
+	deadline, tutup := batasPengumpulanSiswa(tugas, siswaID)
+	if tutup != nil && now.After(*tutup) {
+		helpers.Response(w, 400, "Pengumpulan tugas sudah ditutup", nil)
+		return
+	}
+
 	status := "Mengerjakan"
-	if now.After(tugas.DeadlinePengumpulan) {
+	if now.After(deadline) {
 		status = "Terlambat"
 	}
 
+	// Tugas kelompok: satu pengumpulan dipakai bersama seluruh anggota kelompok
+	if tugas.TipeTugas == "Kelompok" {
+		pengumpulan, err := submitTugasKelompok(tugas, siswaID, request.FileJawabanSiswa, request.CatatanSiswa, status, now)
+		if err != nil {
+			log.Printf("❌ Failed to submit group pengumpulan: %v", err)
+			helpers.Response(w, 400, "Failed to submit tugas: "+err.Error(), nil)
+			return
+		}
+		log.Printf("✅ Group pengumpulan saved with file: '%s'", request.FileJawabanSiswa)
+		helpers.Response(w, 200, "Tugas submitted successfully", pengumpulan)
+		return
+	}
+
 	// Cek apakah sudah ada pengumpulan sebelumnya
 	var pengumpulan models.PengumpulanTugas
 	existingResult := config.DB.Where("tugas_id = ? AND siswa_id = ?", tugasID, siswaID).First(&pengumpulan)
@@ -147,15 +187,26 @@ func SubmitTugas(w http.ResponseWriter, r *http.Request) {
 			PoinDidapat:           0,
 		}
 
-		if err := config.DB.Create(&pengumpulan).Error; err != nil {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&pengumpulan).Error; err != nil {
+				return err
+			}
+			return catatVersiPengumpulan(tx, &pengumpulan)
+		}); err != nil {
 			log.Printf("❌ Failed to create pengumpulan: %v", err)
 			helpers.Response(w, 500, "Failed to submit tugas", nil)
 			return
 		}
 		log.Printf("✅ New pengumpulan created with file: '%s'", request.FileJawabanSiswa)
 	} else {
-		// Update pengumpulan yang sudah ada
-		log.Printf("📝 Updating existing pengumpulan...")
+		// Cek kebijakan pengumpulan ulang pada tugas
+		if err := cekKebijakanPengumpulanUlang(tugas, pengumpulan); err != nil {
+			helpers.Response(w, 400, err.Error(), nil)
+			return
+		}
+
+		// Pengumpulan ulang disimpan sebagai versi baru, versi lama tetap tersimpan
+		log.Printf("📝 Adding new version to existing pengumpulan...")
 		log.Printf("   - Old file: '%s'", pengumpulan.FileJawabanSiswa)
 		log.Printf("   - New file: '%s'", request.FileJawabanSiswa)
 		
@@ -164,7 +215,12 @@ func SubmitTugas(w http.ResponseWriter, r *http.Request) {
 		pengumpulan.TanggalPengumpulan = now
 		pengumpulan.StatusPengumpulan = status
 
-		if err := config.DB.Save(&pengumpulan).Error; err != nil {
+		if err := config.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&pengumpulan).Error; err != nil {
+				return err
+			}
+			return catatVersiPengumpulan(tx, &pengumpulan)
+		}); err != nil {
 			log.Printf("❌ Failed to update pengumpulan: %v", err)
 			helpers.Response(w, 500, "Failed to update tugas submission", nil)
 			return
@@ -175,6 +231,19 @@ func SubmitTugas(w http.ResponseWriter, r *http.Request) {
 	helpers.Response(w, 200, "Tugas submitted successfully", pengumpulan)
 }
 
+// fileJawabanMilikSiswa - File jawaban harus diunggah siswa sendiri. File yang sudah menjadi jawaban pada
+// pengumpulannya (termasuk jawaban kelompok yang diunggah anggota lain) boleh dikirim ulang
+func fileJawabanMilikSiswa(fileURL string, tugasID, siswaID int) bool {
+	if fileURL == "" || fileUploadMilik(fileURL, "Siswa", siswaID) {
+		return true
+	}
+	var jumlah int64
+	config.DB.Model(&models.PengumpulanTugas{}).
+		Where("tugas_id = ? AND siswa_id = ? AND file_jawaban_siswa = ?", tugasID, siswaID, fileURL).
+		Count(&jumlah)
+	return jumlah > 0
+}
+
 // GetDetailPengumpulan - Mendapatkan detail pengumpulan tugas siswa
 func GetDetailPengumpulan(w http.ResponseWriter, r *http.Request) {
 	// Ambil siswa_id dari JWT token
@@ -190,11 +259,17 @@ func GetDetailPengumpulan(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var pengumpulan models.PengumpulanTugas
-	result := config.DB.
+	result := preloadRubrik(config.DB, "Tugas.Rubrik.").
 		Preload("Tugas").
 		Preload("Tugas.JadwalPelajaran").
 		Preload("Tugas.JadwalPelajaran.MataPelajaran").
 		Preload("Tugas.JadwalPelajaran.Kelas").
+		Preload("Versi", func(db *gorm.DB) *gorm.DB {
+			return db.Order("nomor_versi DESC")
+		}).
+		Preload("Tugas.Rubrik").
+		Preload("PenilaianRubrik.Kriteria").
+		Preload("PenilaianRubrik.Level").
 		Where("tugas_id = ? AND siswa_id = ?", tugasID, siswaID).
 		First(&pengumpulan)
 
@@ -206,7 +281,8 @@ func GetDetailPengumpulan(w http.ResponseWriter, r *http.Request) {
 	helpers.Response(w, 200, "Detail pengumpulan retrieved successfully", pengumpulan)
 }
 
-// DeletePengumpulan - Menghapus pengumpulan tugas (hanya jika belum dinilai)
+// DeletePengumpulan - Menghapus pengumpulan tugas (hanya jika belum dinilai, belum dikumpulkan ulang dan
+// tugas tidak membatasi pengumpulan ulang)
 func DeletePengumpulan(w http.ResponseWriter, r *http.Request) {
 	// Ambil siswa_id dari JWT token
 	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
@@ -234,6 +310,52 @@ func DeletePengumpulan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if kuisIDTugas(tugasID) != nil {
+		helpers.Response(w, 400, "Pengumpulan kuis tidak dapat dihapus", nil)
+		return
+	}
+
+	var tugas models.Tugas
+	if err := config.DB.First(&tugas, tugasID).Error; err != nil {
+		helpers.Response(w, 404, "Tugas not found", nil)
+		return
+	}
+
+	// Pengumpulan kelompok dihapus untuk semua anggota sekaligus
+	if pengumpulan.KelompokID != nil {
+		var dinilai int64
+		config.DB.Model(&models.PengumpulanTugas{}).
+			Where("tugas_id = ? AND kelompok_id = ? AND status_pengumpulan = ?", tugasID, *pengumpulan.KelompokID, "Dinilai").
+			Count(&dinilai)
+		if dinilai > 0 {
+			helpers.Response(w, 400, "Cannot delete graded submission", nil)
+			return
+		}
+
+		var pengumpulanIDs []int
+		config.DB.Model(&models.PengumpulanTugas{}).
+			Where("tugas_id = ? AND kelompok_id = ?", tugasID, *pengumpulan.KelompokID).
+			Pluck("pengumpulan_id", &pengumpulanIDs)
+		if err := cekHapusPengumpulan(tugas, pengumpulanIDs); err != nil {
+			helpers.Response(w, 400, err.Error(), nil)
+			return
+		}
+
+		if err := config.DB.Where("tugas_id = ? AND kelompok_id = ?", tugasID, *pengumpulan.KelompokID).
+			Delete(&models.PengumpulanTugas{}).Error; err != nil {
+			helpers.Response(w, 500, "Failed to delete pengumpulan", nil)
+			return
+		}
+
+		helpers.Response(w, 200, "Pengumpulan deleted successfully", nil)
+		return
+	}
+
+	if err := cekHapusPengumpulan(tugas, []int{pengumpulan.PengumpulanID}); err != nil {
+		helpers.Response(w, 400, err.Error(), nil)
+		return
+	}
+
 	if err := config.DB.Delete(&pengumpulan).Error; err != nil {
 		helpers.Response(w, 500, "Failed to delete pengumpulan", nil)
 		return
@@ -298,6 +420,31 @@ func GetPengumpulanByTugas(w http.ResponseWriter, r *http.Request) {
 		pengumpulanMap[p.SiswaID] = p
 	}
 
+	// Export CSV dengan data yang sama, dapat diedit lalu diimpor lewat BulkUpdatePoints
+	if r.URL.Query().Get("format") == "csv" {
+		tulisPengumpulanCSV(w, tugas, siswaList, pengumpulanMap)
+		return
+	}
+
+	// Laporan kemiripan dari analisis plagiarisme (cron), per pengumpulan
+	laporanKemiripan, err := laporanKemiripanTugas(tugasID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch similarity report: %v", err)
+	}
+	var sidikList []models.SidikFile
+	config.DB.Where("tugas_id = ?", tugasID).Find(&sidikList)
+
+	// Jumlah komentar siswa yang belum dibaca guru per pengumpulan
+	var pengumpulanIDs []int
+	for _, p := range pengumpulanList {
+		pengumpulanIDs = append(pengumpulanIDs, p.PengumpulanID)
+	}
+	komentarBelumDibaca := hitungKomentarBelumDibaca(pengumpulanIDs, "Guru", guru.ID)
+	sidikMap := make(map[int]models.SidikFile)
+	for _, s := range sidikList {
+		sidikMap[s.PengumpulanID] = s
+	}
+
 	// Format response dengan data semua siswa dan status pengumpulan mereka
 	var response []map[string]interface{}
 	for _, siswa := range siswaList {
@@ -313,25 +460,62 @@ func GetPengumpulanByTugas(w http.ResponseWriter, r *http.Request) {
 			siswaData["pengumpulan_id"] = pengumpulan.PengumpulanID
 			siswaData["tugas_id"] = pengumpulan.TugasID
 			siswaData["file_jawaban_siswa"] = pengumpulan.FileJawabanSiswa
+			siswaData["thumbnail_url"] = nil
+			if thumb := thumbnailPengumpulan(r.Context(), pengumpulan.FileJawabanSiswa); thumb != "" {
+				siswaData["thumbnail_url"] = thumb
+			}
 			siswaData["catatan_siswa"] = pengumpulan.CatatanSiswa
 			siswaData["tanggal_pengumpulan"] = pengumpulan.TanggalPengumpulan
 			siswaData["nilai"] = pengumpulan.Nilai
 			siswaData["catatan_guru"] = pengumpulan.CatatanGuru
 			siswaData["status_pengumpulan"] = pengumpulan.StatusPengumpulan
 			siswaData["poin_didapat"] = pengumpulan.PoinDidapat
+			siswaData["kelompok_id"] = pengumpulan.KelompokID
+			siswaData["penyesuaian_poin"] = pengumpulan.PenyesuaianPoin
+			siswaData["versi_dinilai_id"] = pengumpulan.VersiDinilaiID
+			siswaData["poin_mentah"] = pengumpulan.PoinMentah
+			siswaData["persen_penalti"] = pengumpulan.PersenPenalti
+			siswaData["hari_terlambat"] = pengumpulan.HariTerlambat
 			siswaData["has_submitted"] = true
+			siswaData["komentar_belum_dibaca"] = komentarBelumDibaca[pengumpulan.PengumpulanID]
+
+			// nil = file belum dianalisis atau sudah diganti sejak analisis terakhir
+			siswaData["status_analisis_file"] = nil
+			if sidik, ada := sidikMap[pengumpulan.PengumpulanID]; ada && sidik.FileJawabanSiswa == pengumpulan.FileJawabanSiswa {
+				siswaData["status_analisis_file"] = sidik.StatusEkstraksi
+			}
+			kemiripan := laporanKemiripan[pengumpulan.PengumpulanID]
+			if kemiripan == nil {
+				kemiripan = []kemiripanSiswa{}
+			}
+			siswaData["kemiripan"] = kemiripan
+			siswaData["kemiripan_maksimal"] = 0.0
+			if len(kemiripan) > 0 {
+				siswaData["kemiripan_maksimal"] = kemiripan[0].Skor
+			}
 		} else {
 			// Siswa belum mengumpulkan tugas
 			siswaData["pengumpulan_id"] = nil
 			siswaData["tugas_id"] = tugasID
 			siswaData["file_jawaban_siswa"] = nil
+			siswaData["thumbnail_url"] = nil
 			siswaData["catatan_siswa"] = nil
 			siswaData["tanggal_pengumpulan"] = nil
 			siswaData["nilai"] = nil
 			siswaData["catatan_guru"] = nil
 			siswaData["status_pengumpulan"] = "Belum Mengerjakan"
 			siswaData["poin_didapat"] = 0
+			siswaData["kelompok_id"] = nil
+			siswaData["penyesuaian_poin"] = 0
+			siswaData["versi_dinilai_id"] = nil
+			siswaData["poin_mentah"] = nil
+			siswaData["persen_penalti"] = 0
+			siswaData["hari_terlambat"] = 0
 			siswaData["has_submitted"] = false
+			siswaData["komentar_belum_dibaca"] = 0
+			siswaData["status_analisis_file"] = nil
+			siswaData["kemiripan"] = []kemiripanSiswa{}
+			siswaData["kemiripan_maksimal"] = 0.0
 		}
 
 		response = append(response, siswaData)
@@ -353,9 +537,10 @@ func UpdateStudentPoints(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var request struct {
-		PoinDidapat        int    `json:"poin_didapat"`
-		CatatanGuru        string `json:"catatan_guru"`
-		StatusPengumpulan  string `json:"status_pengumpulan"`
+		PoinDidapat       int    `json:"poin_didapat"`
+		CatatanGuru       string `json:"catatan_guru"`
+		StatusPengumpulan string `json:"status_pengumpulan"`
+		VersiID           int    `json:"versi_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -392,21 +577,42 @@ func UpdateStudentPoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if kuisIDTugas(tugas.TugasID) != nil {
+		helpers.Response(w, 409, "Nilai kuis online dihitung otomatis dan tidak dapat diubah manual", nil)
+		return
+	}
+
 	// Validasi poin tidak melebihi poin maksimal
 	if request.PoinDidapat > tugas.PoinMaksimal {
 		helpers.Response(w, 400, "Poin melebihi poin maksimal tugas", nil)
 		return
 	}
 
-	// Update pengumpulan tugas
-	pengumpulan.PoinDidapat = request.PoinDidapat
+	// Nilai diberikan untuk versi tertentu; default versi terkini
+	waktuKumpul, err := pilihVersiDinilai(&pengumpulan, request.VersiID)
+	if err != nil {
+		helpers.Response(w, 404, "Versi pengumpulan tidak ditemukan", nil)
+		return
+	}
+
+	// Update pengumpulan tugas (poin mentah disimpan, poin didapat sudah dikurangi penalti)
+	terapkanPoinPengumpulan(tugas, &pengumpulan, request.PoinDidapat, waktuKumpul)
 	pengumpulan.CatatanGuru = request.CatatanGuru
 	pengumpulan.StatusPengumpulan = request.StatusPengumpulan
 
-	if err := config.DB.Save(&pengumpulan).Error; err != nil {
+	// Poin manual menggantikan penilaian rubrik sebelumnya
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pengumpulan_id = ?", pengumpulan.PengumpulanID).Delete(&models.PenilaianRubrik{}).Error; err != nil {
+			return err
+		}
+		return tx.Save(&pengumpulan).Error
+	})
+	if err != nil {
 		helpers.Response(w, 500, "Failed to update points", nil)
 		return
 	}
 
+	go notifikasiNilai(tugas, pengumpulan)
+
 	helpers.Response(w, 200, "Poin siswa berhasil diupdate", pengumpulan)
 }
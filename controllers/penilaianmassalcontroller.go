@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// NilaiMassalRecord - Satu baris nilai dari JSON atau CSV (NIS, poin, catatan)
+type NilaiMassalRecord struct {
+	NIS     string `json:"nis"`
+	Poin    *int   `json:"poin"`
+	Catatan string `json:"catatan"`
+}
+
+// kolomCSVPengumpulan - Kolom export CSV; tiga kolom pertama dapat langsung diimpor kembali
+var kolomCSVPengumpulan = []string{"nis", "poin", "catatan", "nama_lengkap", "status_pengumpulan", "tanggal_pengumpulan", "poin_maksimal", "poin_mentah", "persen_penalti", "nilai"}
+
+// BulkUpdatePoints - Guru menilai banyak pengumpulan sekaligus dari JSON array atau file CSV.
+// Semua nilai diterapkan dalam satu transaksi; jika ada baris tidak valid tidak ada yang disimpan
+func BulkUpdatePoints(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var records []NilaiMassalRecord
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			helpers.Response(w, 400, "Failed to parse form data", nil)
+			return
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			helpers.Response(w, 400, "No file uploaded", nil)
+			return
+		}
+		defer file.Close()
+
+		if !strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
+			helpers.Response(w, 400, "Invalid file type. Only CSV files are allowed", nil)
+			return
+		}
+
+		records, err = parseNilaiCSV(file)
+		if err != nil {
+			helpers.Response(w, 400, "Failed to parse CSV: "+err.Error(), nil)
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&records); err != nil {
+		helpers.Response(w, 400, "Invalid JSON format", nil)
+		return
+	}
+
+	if len(records) == 0 {
+		helpers.Response(w, 400, "Tidak ada nilai yang dikirim", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if kuisIDTugas(tugas.TugasID) != nil {
+		helpers.Response(w, 409, "Nilai kuis online dihitung otomatis dan tidak dapat diubah manual", nil)
+		return
+	}
+
+	// Pengumpulan tugas ini diindeks berdasarkan NIS siswa
+	var pengumpulanList []models.PengumpulanTugas
+	if err := config.DB.Preload("Siswa").Where("tugas_id = ?", tugasID).Find(&pengumpulanList).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch pengumpulan", nil)
+		return
+	}
+	pengumpulanNIS := make(map[string]models.PengumpulanTugas)
+	for _, p := range pengumpulanList {
+		pengumpulanNIS[p.Siswa.NIS] = p
+	}
+
+	var errors []string
+	var dinilai []models.PengumpulanTugas
+	sudahAda := make(map[string]bool)
+	for i, record := range records {
+		baris := fmt.Sprintf("Baris %d (NIS %s)", i+1, record.NIS)
+		// Baris tanpa poin dilewati (misalnya siswa yang belum dinilai pada file export)
+		if record.Poin == nil {
+			continue
+		}
+		if sudahAda[record.NIS] {
+			errors = append(errors, baris+": NIS duplikat")
+			continue
+		}
+		sudahAda[record.NIS] = true
+
+		pengumpulan, ada := pengumpulanNIS[record.NIS]
+		if !ada {
+			errors = append(errors, baris+": siswa belum mengumpulkan tugas atau bukan anggota kelas")
+			continue
+		}
+		if *record.Poin < 0 || *record.Poin > tugas.PoinMaksimal {
+			errors = append(errors, fmt.Sprintf("%s: poin harus antara 0 dan %d", baris, tugas.PoinMaksimal))
+			continue
+		}
+
+		waktuKumpul, _ := pilihVersiDinilai(&pengumpulan, 0)
+		terapkanPoinPengumpulan(tugas, &pengumpulan, *record.Poin, waktuKumpul)
+		pengumpulan.CatatanGuru = record.Catatan
+		pengumpulan.StatusPengumpulan = "Dinilai"
+		dinilai = append(dinilai, pengumpulan)
+	}
+
+	if len(errors) > 0 {
+		helpers.Response(w, 400, "Nilai tidak disimpan karena terdapat data tidak valid", map[string]interface{}{
+			"errors": errors,
+		})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range dinilai {
+			if err := tx.Where("pengumpulan_id = ?", dinilai[i].PengumpulanID).Delete(&models.PenilaianRubrik{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Omit("Siswa").Save(&dinilai[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Failed to update points", nil)
+		return
+	}
+
+	go notifikasiNilai(tugas, dinilai...)
+
+	helpers.Response(w, 200, fmt.Sprintf("%d nilai siswa berhasil disimpan", len(dinilai)), map[string]interface{}{
+		"total_records": len(records),
+		"success_count": len(dinilai),
+		"skipped_count": len(records) - len(dinilai),
+	})
+}
+
+// parseNilaiCSV - Membaca CSV nilai. Header opsional; tanpa header urutan kolom nis, poin, catatan
+func parseNilaiCSV(file io.Reader) ([]NilaiMassalRecord, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file CSV kosong")
+	}
+
+	kolomNIS, kolomPoin, kolomCatatan := 0, 1, 2
+	if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(rows[0][0], "\ufeff")), "nis") {
+		kolomNIS, kolomPoin, kolomCatatan = -1, -1, -1
+		for i, nama := range rows[0] {
+			switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(nama, "\ufeff"))) {
+			case "nis":
+				kolomNIS = i
+			case "poin":
+				kolomPoin = i
+			case "catatan":
+				kolomCatatan = i
+			}
+		}
+		if kolomPoin == -1 {
+			return nil, fmt.Errorf("kolom poin tidak ditemukan")
+		}
+		rows = rows[1:]
+	}
+
+	var records []NilaiMassalRecord
+	for i, row := range rows {
+		kolom := func(idx int) string {
+			if idx < 0 || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
+		record := NilaiMassalRecord{NIS: kolom(kolomNIS), Catatan: kolom(kolomCatatan)}
+		if record.NIS == "" {
+			continue
+		}
+		if poinStr := kolom(kolomPoin); poinStr != "" {
+			poin, err := strconv.Atoi(poinStr)
+			if err != nil {
+				return nil, fmt.Errorf("baris %d: poin '%s' bukan angka", i+1, poinStr)
+			}
+			record.Poin = &poin
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// tulisPengumpulanCSV - Export daftar pengumpulan (format sama dengan GetPengumpulanByTugas) sebagai CSV
+func tulisPengumpulanCSV(w http.ResponseWriter, tugas models.Tugas, siswaList []models.Siswa, pengumpulanMap map[int]models.PengumpulanTugas) {
+	filename := fmt.Sprintf("nilai_tugas_%d_%s.csv", tugas.TugasID, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+
+	writer := csv.NewWriter(w)
+	writer.Write(kolomCSVPengumpulan)
+
+	for _, siswa := range siswaList {
+		row := []string{siswa.NIS, "", "", siswa.NamaLengkap, "Belum Mengerjakan", "", strconv.Itoa(tugas.PoinMaksimal), "", "", ""}
+
+		if pengumpulan, exists := pengumpulanMap[siswa.SiswaID]; exists {
+			row[4] = pengumpulan.StatusPengumpulan
+			row[5] = pengumpulan.TanggalPengumpulan.Format("2006-01-02 15:04:05")
+			if pengumpulan.StatusPengumpulan == "Dinilai" {
+				// Impor ulang akan menerapkan penalti lagi, jadi kolom poin berisi poin mentah
+				poin := pengumpulan.PoinDidapat
+				if pengumpulan.PoinMentah != nil {
+					poin = *pengumpulan.PoinMentah
+					row[7] = strconv.Itoa(poin)
+				}
+				row[1] = strconv.Itoa(poin)
+				row[2] = pengumpulan.CatatanGuru
+				row[8] = strconv.FormatFloat(pengumpulan.PersenPenalti, 'f', -1, 64)
+			}
+			if pengumpulan.Nilai != nil {
+				row[9] = strconv.FormatFloat(*pengumpulan.Nilai, 'f', 2, 64)
+			}
+		}
+
+		writer.Write(row)
+	}
+
+	writer.Flush()
+}
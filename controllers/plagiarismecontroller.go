@@ -0,0 +1,393 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const (
+	// minimalKataKemiripan - Teks yang terlalu pendek tidak dibandingkan agar tidak banyak positif palsu
+	minimalKataKemiripan = 30
+	// ambangKemiripanDefault - Persen kemiripan MinHash minimal untuk ditandai Mirip
+	ambangKemiripanDefault = 70.0
+)
+
+// analisisPlagiarismeMu - Analisis dari cron dan dari guru tidak berjalan bersamaan
+var analisisPlagiarismeMu sync.Mutex
+
+// kemiripanSiswa - Satu pengumpulan siswa lain yang identik/mirip dengan sebuah pengumpulan
+type kemiripanSiswa struct {
+	PengumpulanID int     `json:"pengumpulan_id"`
+	SiswaID       int     `json:"siswa_id"`
+	NIS           string  `json:"nis"`
+	NamaLengkap   string  `json:"nama_lengkap"`
+	Jenis         string  `json:"jenis"`
+	Skor          float64 `json:"skor"`
+}
+
+// ambangKemiripan - Ambang persen kemiripan dari env PLAGIARISME_AMBANG_MIRIP (default 70)
+func ambangKemiripan() float64 {
+	if ambang, err := strconv.ParseFloat(os.Getenv("PLAGIARISME_AMBANG_MIRIP"), 64); err == nil && ambang > 0 && ambang <= 100 {
+		return ambang
+	}
+	return ambangKemiripanDefault
+}
+
+// hitungSidikFile - Hash SHA-256, ekstraksi teks dan MinHash file jawaban sebuah pengumpulan
+func hitungSidikFile(p models.PengumpulanTugas) models.SidikFile {
+	sidik := models.SidikFile{
+		PengumpulanID:    p.PengumpulanID,
+		TugasID:          p.TugasID,
+		SiswaID:          p.SiswaID,
+		FileJawabanSiswa: p.FileJawabanSiswa,
+		DianalisisPada:   time.Now(),
+	}
+
+	data, err := bacaFileTugas(p.FileJawabanSiswa)
+	if err != nil {
+		log.Printf("⚠️ Plagiarism check: cannot read %s: %v", p.FileJawabanSiswa, err)
+		sidik.StatusEkstraksi = "Gagal"
+		return sidik
+	}
+
+	hash := sha256.Sum256(data)
+	sidik.HashSHA256 = hex.EncodeToString(hash[:])
+
+	teks, err := helpers.EkstrakTeks(p.FileJawabanSiswa, data)
+	if err != nil {
+		sidik.StatusEkstraksi = "Gagal"
+		if errors.Is(err, helpers.ErrFormatTidakDidukung) {
+			sidik.StatusEkstraksi = "TidakDidukung"
+		}
+		return sidik
+	}
+
+	kata := helpers.NormalisasiKata(teks)
+	sidik.StatusEkstraksi = "Berhasil"
+	sidik.JumlahKata = len(kata)
+	if len(kata) > 0 {
+		sidik.TandaTangan = helpers.EncodeMinHash(helpers.MinHash(helpers.Shingle(kata, helpers.UkuranShingle)))
+	}
+	return sidik
+}
+
+// analisisPlagiarismeTugas - Memperbarui sidik file pengumpulan yang berubah lalu menghitung ulang
+// pasangan identik/mirip untuk satu tugas. paksa = analisis ulang seluruh file.
+// Anggota kelompok yang sama tidak dibandingkan karena memang berbagi file
+func analisisPlagiarismeTugas(tugasID int, paksa bool) (int, error) {
+	analisisPlagiarismeMu.Lock()
+	defer analisisPlagiarismeMu.Unlock()
+
+	var pengumpulanList []models.PengumpulanTugas
+	if err := config.DB.Where("tugas_id = ? AND file_jawaban_siswa IS NOT NULL AND file_jawaban_siswa <> ''", tugasID).
+		Order("pengumpulan_id ASC").
+		Find(&pengumpulanList).Error; err != nil {
+		return 0, err
+	}
+
+	var sidikList []models.SidikFile
+	if err := config.DB.Where("tugas_id = ?", tugasID).Find(&sidikList).Error; err != nil {
+		return 0, err
+	}
+	sidikMap := make(map[int]models.SidikFile)
+	for _, s := range sidikList {
+		sidikMap[s.PengumpulanID] = s
+	}
+
+	berubah := paksa
+	// File kelompok yang sama cukup dianalisis sekali per putaran
+	cacheFile := make(map[string]models.SidikFile)
+	sidikAktif := make(map[int]models.SidikFile)
+	for _, p := range pengumpulanList {
+		sidik, ada := sidikMap[p.PengumpulanID]
+		if !ada || paksa || sidik.FileJawabanSiswa != p.FileJawabanSiswa {
+			if cache, ok := cacheFile[p.FileJawabanSiswa]; ok {
+				sidik = cache
+				sidik.PengumpulanID, sidik.SiswaID = p.PengumpulanID, p.SiswaID
+			} else {
+				sidik = hitungSidikFile(p)
+				cacheFile[p.FileJawabanSiswa] = sidik
+			}
+			if err := config.DB.Save(&sidik).Error; err != nil {
+				return 0, err
+			}
+			berubah = true
+		}
+		sidikAktif[p.PengumpulanID] = sidik
+		delete(sidikMap, p.PengumpulanID)
+	}
+
+	// Sisa sidikMap = pengumpulan yang filenya sudah dihapus
+	for pengumpulanID := range sidikMap {
+		config.DB.Delete(&models.SidikFile{}, "pengumpulan_id = ?", pengumpulanID)
+		berubah = true
+	}
+
+	if !berubah {
+		return 0, nil
+	}
+
+	ambang := ambangKemiripan()
+	tandaTangan := make(map[int][]uint64)
+	for id, s := range sidikAktif {
+		if s.StatusEkstraksi == "Berhasil" && s.JumlahKata >= minimalKataKemiripan {
+			tandaTangan[id] = helpers.DecodeMinHash(s.TandaTangan)
+		}
+	}
+
+	var pasangan []models.KemiripanPengumpulan
+	for i, a := range pengumpulanList {
+		for _, b := range pengumpulanList[i+1:] {
+			if a.SiswaID == b.SiswaID || (a.KelompokID != nil && b.KelompokID != nil && *a.KelompokID == *b.KelompokID) {
+				continue
+			}
+
+			sidikA, sidikB := sidikAktif[a.PengumpulanID], sidikAktif[b.PengumpulanID]
+			if sidikA.HashSHA256 != "" && sidikA.HashSHA256 == sidikB.HashSHA256 {
+				pasangan = append(pasangan, models.KemiripanPengumpulan{
+					TugasID: tugasID, PengumpulanID: a.PengumpulanID, PengumpulanPembandingID: b.PengumpulanID,
+					Jenis: "Identik", Skor: 100,
+				})
+				continue
+			}
+
+			ttA, okA := tandaTangan[a.PengumpulanID]
+			ttB, okB := tandaTangan[b.PengumpulanID]
+			if !okA || !okB {
+				continue
+			}
+			if skor := math.Round(helpers.KemiripanMinHash(ttA, ttB)*10000) / 100; skor >= ambang {
+				pasangan = append(pasangan, models.KemiripanPengumpulan{
+					TugasID: tugasID, PengumpulanID: a.PengumpulanID, PengumpulanPembandingID: b.PengumpulanID,
+					Jenis: "Mirip", Skor: skor,
+				})
+			}
+		}
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tugas_id = ?", tugasID).Delete(&models.KemiripanPengumpulan{}).Error; err != nil {
+			return err
+		}
+		if len(pasangan) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&pasangan, 200).Error
+	})
+	return len(pasangan), err
+}
+
+// RunAnalisisPlagiarismeCron - Menganalisis tugas yang memiliki file jawaban baru atau berubah
+func RunAnalisisPlagiarismeCron() {
+	var tugasIDs []int
+	if err := config.DB.Table("pengumpulantugas").
+		Joins("LEFT JOIN sidikfile ON sidikfile.pengumpulan_id = pengumpulantugas.pengumpulan_id").
+		Where("pengumpulantugas.file_jawaban_siswa IS NOT NULL AND pengumpulantugas.file_jawaban_siswa <> ''").
+		Where("sidikfile.pengumpulan_id IS NULL OR sidikfile.file_jawaban_siswa <> pengumpulantugas.file_jawaban_siswa").
+		Distinct().
+		Pluck("pengumpulantugas.tugas_id", &tugasIDs).Error; err != nil {
+		log.Printf("❌ Plagiarism cron: failed to fetch tugas: %v", err)
+		return
+	}
+
+	for _, tugasID := range tugasIDs {
+		jumlah, err := analisisPlagiarismeTugas(tugasID, false)
+		if err != nil {
+			log.Printf("❌ Plagiarism analysis failed for tugas %d: %v", tugasID, err)
+			continue
+		}
+		if jumlah > 0 {
+			log.Printf("⚠️ Tugas %d: %d similar/duplicate submission pairs flagged", tugasID, jumlah)
+		}
+	}
+}
+
+// laporanKemiripanTugas - Pasangan kemiripan per pengumpulan (dua arah) untuk sebuah tugas
+func laporanKemiripanTugas(tugasID int) (map[int][]kemiripanSiswa, error) {
+	var pasangan []models.KemiripanPengumpulan
+	if err := config.DB.Where("tugas_id = ?", tugasID).Find(&pasangan).Error; err != nil {
+		return nil, err
+	}
+
+	laporan := make(map[int][]kemiripanSiswa)
+	if len(pasangan) == 0 {
+		return laporan, nil
+	}
+
+	var pemilik []struct {
+		PengumpulanID int
+		SiswaID       int
+		NIS           string
+		NamaLengkap   string
+	}
+	if err := config.DB.Table("pengumpulantugas").
+		Select("pengumpulantugas.pengumpulan_id, siswa.siswa_id, siswa.nis, siswa.nama_lengkap").
+		Joins("JOIN siswa ON siswa.siswa_id = pengumpulantugas.siswa_id").
+		Where("pengumpulantugas.tugas_id = ?", tugasID).
+		Scan(&pemilik).Error; err != nil {
+		return nil, err
+	}
+	siswaMap := make(map[int]kemiripanSiswa)
+	for _, p := range pemilik {
+		siswaMap[p.PengumpulanID] = kemiripanSiswa{PengumpulanID: p.PengumpulanID, SiswaID: p.SiswaID, NIS: p.NIS, NamaLengkap: p.NamaLengkap}
+	}
+
+	for _, k := range pasangan {
+		a, b := siswaMap[k.PengumpulanPembandingID], siswaMap[k.PengumpulanID]
+		a.Jenis, a.Skor = k.Jenis, k.Skor
+		b.Jenis, b.Skor = k.Jenis, k.Skor
+		laporan[k.PengumpulanID] = append(laporan[k.PengumpulanID], a)
+		laporan[k.PengumpulanPembandingID] = append(laporan[k.PengumpulanPembandingID], b)
+	}
+
+	for id := range laporan {
+		sort.Slice(laporan[id], func(i, j int) bool { return laporan[id][i].Skor > laporan[id][j].Skor })
+	}
+	return laporan, nil
+}
+
+// AnalisisPlagiarisme - Guru menjalankan ulang analisis plagiarisme seluruh file sebuah tugas di background
+func AnalisisPlagiarisme(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	go func() {
+		jumlah, err := analisisPlagiarismeTugas(tugasID, true)
+		if err != nil {
+			log.Printf("❌ Plagiarism analysis failed for tugas %d: %v", tugasID, err)
+			return
+		}
+		log.Printf("✅ Plagiarism analysis for tugas %d finished: %d pairs flagged", tugasID, jumlah)
+	}()
+
+	helpers.Response(w, 202, "Analisis plagiarisme sedang berjalan, muat ulang laporan beberapa saat lagi", nil)
+}
+
+// GetLaporanPlagiarisme - Laporan plagiarisme sebuah tugas: pasangan identik/mirip dan status analisis file
+func GetLaporanPlagiarisme(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	var pasangan []models.KemiripanPengumpulan
+	if err := config.DB.Where("tugas_id = ?", tugasID).Order("skor DESC, kemiripan_id ASC").Find(&pasangan).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	var sidikList []models.SidikFile
+	if err := config.DB.Where("tugas_id = ?", tugasID).Find(&sidikList).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	statusAnalisis := map[string]int{"Berhasil": 0, "TidakDidukung": 0, "Gagal": 0}
+	var terakhir *time.Time
+	for i, s := range sidikList {
+		statusAnalisis[s.StatusEkstraksi]++
+		if terakhir == nil || s.DianalisisPada.After(*terakhir) {
+			terakhir = &sidikList[i].DianalisisPada
+		}
+	}
+
+	var belumDianalisis int64
+	config.DB.Table("pengumpulantugas").
+		Joins("LEFT JOIN sidikfile ON sidikfile.pengumpulan_id = pengumpulantugas.pengumpulan_id").
+		Where("pengumpulantugas.tugas_id = ? AND pengumpulantugas.file_jawaban_siswa IS NOT NULL AND pengumpulantugas.file_jawaban_siswa <> ''", tugasID).
+		Where("sidikfile.pengumpulan_id IS NULL OR sidikfile.file_jawaban_siswa <> pengumpulantugas.file_jawaban_siswa").
+		Count(&belumDianalisis)
+
+	laporan, err := laporanKemiripanTugas(tugasID)
+	if err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	var daftarPasangan []map[string]interface{}
+	for _, k := range pasangan {
+		var siswaA, siswaB kemiripanSiswa
+		for _, s := range laporan[k.PengumpulanPembandingID] {
+			if s.PengumpulanID == k.PengumpulanID {
+				siswaA = s
+			}
+		}
+		for _, s := range laporan[k.PengumpulanID] {
+			if s.PengumpulanID == k.PengumpulanPembandingID {
+				siswaB = s
+			}
+		}
+		daftarPasangan = append(daftarPasangan, map[string]interface{}{
+			"kemiripan_id": k.KemiripanID,
+			"jenis":        k.Jenis,
+			"skor":         k.Skor,
+			"siswa_a":      siswaA,
+			"siswa_b":      siswaB,
+		})
+	}
+
+	helpers.Response(w, 200, "Laporan plagiarisme berhasil diambil", map[string]interface{}{
+		"tugas_id":          tugasID,
+		"ambang_mirip":      ambangKemiripan(),
+		"status_analisis":   statusAnalisis,
+		"belum_dianalisis":  belumDianalisis,
+		"terakhir_analisis": terakhir,
+		"pasangan":          daftarPasangan,
+	})
+}
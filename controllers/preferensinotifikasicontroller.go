@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// Preferensi kanal notifikasi milik siswa/guru yang sedang login
+
+// polaTelegramChatID - Chat ID numerik (boleh negatif untuk grup) atau username kanal @nama
+var polaTelegramChatID = regexp.MustCompile(`^(-?\d{1,20}|@[A-Za-z0-9_]{5,32})$`)
+
+// preferensiNotifikasiResponse - Preferensi efektif beserta kanal yang sudah dikonfigurasi server
+type preferensiNotifikasiResponse struct {
+	UrutanKanal    []string `json:"urutan_kanal"`
+	SimpanInApp    bool     `json:"simpan_inapp"`
+	TelegramChatID string   `json:"telegram_chat_id"`
+	Bahasa         string   `json:"bahasa"`
+	KanalTersedia  []string `json:"kanal_tersedia"`
+	Tersimpan      bool     `json:"tersimpan"`
+}
+
+// penerimaNotifikasi - Tipe user (Siswa/Guru) dan ID dari token; kosong untuk admin
+func penerimaNotifikasi(r *http.Request) (string, int) {
+	tipeUser, id := pemilikUpload(r)
+	if tipeUser != "Siswa" && tipeUser != "Guru" {
+		return "", 0
+	}
+	return tipeUser, id
+}
+
+// buatPreferensiResponse - Preferensi tersimpan atau default server jika belum diatur
+func buatPreferensiResponse(tipeUser string, userID int) preferensiNotifikasiResponse {
+	d := helpers.GetDispatcherNotifikasi()
+	resp := preferensiNotifikasiResponse{
+		UrutanKanal:   d.Default.Urutan,
+		SimpanInApp:   d.Default.SimpanInApp,
+		Bahasa:        d.Default.Bahasa,
+		KanalTersedia: d.KanalTersedia(),
+	}
+
+	var pref models.PreferensiNotifikasi
+	if err := config.DB.Where("tipe_user = ? AND user_id = ?", tipeUser, userID).First(&pref).Error; err == nil {
+		resp.UrutanKanal = helpers.ParseUrutanKanal(pref.UrutanKanal)
+		resp.SimpanInApp = pref.SimpanInApp
+		resp.TelegramChatID = pref.TelegramChatID
+		resp.Bahasa = helpers.BahasaValid(pref.Bahasa)
+		resp.Tersimpan = true
+	}
+	return resp
+}
+
+// GetPreferensiNotifikasi - Preferensi kanal notifikasi pengguna yang login
+func GetPreferensiNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID := penerimaNotifikasi(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "Unauthorized", nil)
+		return
+	}
+	helpers.Response(w, 200, "Preferensi notifikasi", buatPreferensiResponse(tipeUser, userID))
+}
+
+// UpdatePreferensiNotifikasi - Mengatur urutan kanal (whatsapp/email/telegram), salinan in-app, chat ID Telegram
+// dan bahasa pesan (id/en).
+// Kanal yang tidak dicantumkan tidak dipakai; urutan kosong berarti hanya notifikasi in-app
+func UpdatePreferensiNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID := penerimaNotifikasi(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "Unauthorized", nil)
+		return
+	}
+
+	var req struct {
+		UrutanKanal    []string `json:"urutan_kanal"`
+		SimpanInApp    *bool    `json:"simpan_inapp"`
+		TelegramChatID *string  `json:"telegram_chat_id"`
+		Bahasa         *string  `json:"bahasa"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Response(w, 400, "Invalid request body", nil)
+		return
+	}
+
+	sekarang := buatPreferensiResponse(tipeUser, userID)
+	pref := models.PreferensiNotifikasi{
+		TipeUser:       tipeUser,
+		UserID:         userID,
+		UrutanKanal:    strings.Join(sekarang.UrutanKanal, ","),
+		SimpanInApp:    sekarang.SimpanInApp,
+		TelegramChatID: sekarang.TelegramChatID,
+		Bahasa:         sekarang.Bahasa,
+	}
+
+	if req.UrutanKanal != nil {
+		sudah := make(map[string]bool)
+		for i, k := range req.UrutanKanal {
+			k = strings.ToLower(strings.TrimSpace(k))
+			if !helpers.KanalValid(k) {
+				helpers.Response(w, 400, "Kanal tidak dikenal: "+k+" (pilihan: "+strings.Join(helpers.KanalEksternal, ", ")+")", nil)
+				return
+			}
+			if sudah[k] {
+				helpers.Response(w, 400, "Kanal "+k+" dicantumkan lebih dari sekali", nil)
+				return
+			}
+			sudah[k] = true
+			req.UrutanKanal[i] = k
+		}
+		pref.UrutanKanal = strings.Join(req.UrutanKanal, ",")
+	}
+	if req.SimpanInApp != nil {
+		pref.SimpanInApp = *req.SimpanInApp
+	}
+	if req.TelegramChatID != nil {
+		chatID := strings.TrimSpace(*req.TelegramChatID)
+		if chatID != "" && !polaTelegramChatID.MatchString(chatID) {
+			helpers.Response(w, 400, "telegram_chat_id harus berupa angka atau @username kanal", nil)
+			return
+		}
+		pref.TelegramChatID = chatID
+	}
+	if req.Bahasa != nil {
+		bahasa := strings.ToLower(strings.TrimSpace(*req.Bahasa))
+		if helpers.BahasaValid(bahasa) != bahasa {
+			helpers.Response(w, 400, "Bahasa tidak dikenal: "+bahasa+" (pilihan: "+strings.Join(helpers.BahasaNotifikasi, ", ")+")", nil)
+			return
+		}
+		pref.Bahasa = bahasa
+	}
+	if strings.Contains(pref.UrutanKanal, helpers.KanalTelegram) && pref.TelegramChatID == "" {
+		helpers.Response(w, 400, "telegram_chat_id wajib diisi untuk memakai kanal telegram", nil)
+		return
+	}
+	if pref.UrutanKanal == "" && !pref.SimpanInApp {
+		helpers.Response(w, 400, "Minimal satu kanal atau notifikasi in-app harus aktif", nil)
+		return
+	}
+
+	err := config.DB.Clauses(clause.OnConflict{
+		UpdateAll: true,
+	}).Create(&pref).Error
+	if err != nil {
+		log.Printf("❌ Failed to save notification preference for %s %d: %v", tipeUser, userID, err)
+		helpers.Response(w, 500, "Gagal menyimpan preferensi notifikasi", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Preferensi notifikasi berhasil disimpan", buatPreferensiResponse(tipeUser, userID))
+}
+
+// TesPreferensiNotifikasi - Mengirim pesan uji coba lewat preferensi pengguna dan mengembalikan hasil setiap kanal
+func TesPreferensiNotifikasi(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID := penerimaNotifikasi(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "Unauthorized", nil)
+		return
+	}
+
+	penerima := penerimaGuru(userID)
+	if tipeUser == "Siswa" {
+		penerima = penerimaSiswa(userID)
+	}
+
+	hasil := helpers.NewNotifikasiService().KirimPesan(penerima, helpers.PesanNotifikasi{
+		Judul: "Tes notifikasi",
+		Isi:   "Ini pesan uji coba pengaturan notifikasi Anda.",
+	})
+	if !hasil.Terkirim {
+		helpers.Response(w, 502, "Pesan uji coba gagal dikirim di semua kanal", hasil)
+		return
+	}
+	helpers.Response(w, 200, "Pesan uji coba terkirim lewat "+hasil.KanalTerkirim, hasil)
+}
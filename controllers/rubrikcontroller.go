@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// preloadRubrik - Preload kriteria dan level rubrik sesuai urutan
+func preloadRubrik(db *gorm.DB, prefix string) *gorm.DB {
+	return db.
+		Preload(prefix+"Kriteria", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, kriteria_id ASC")
+		}).
+		Preload(prefix+"Kriteria.Level", func(db *gorm.DB) *gorm.DB {
+			return db.Order("urutan ASC, level_id ASC")
+		})
+}
+
+// findRubrikTugas - Mengambil rubrik lengkap sebuah tugas
+func findRubrikTugas(tugasID int) (models.Rubrik, error) {
+	var rubrik models.Rubrik
+	err := preloadRubrik(config.DB, "").Where("tugas_id = ?", tugasID).First(&rubrik).Error
+	return rubrik, err
+}
+
+// poinMaksimalRubrik - Jumlah poin level tertinggi dari setiap kriteria
+func poinMaksimalRubrik(rubrik models.Rubrik) int {
+	total := 0
+	for _, kriteria := range rubrik.Kriteria {
+		maks := 0
+		for _, level := range kriteria.Level {
+			if level.Poin > maks {
+				maks = level.Poin
+			}
+		}
+		total += maks
+	}
+	return total
+}
+
+// GetRubrikTugas - Mengambil rubrik penilaian sebuah tugas
+func GetRubrikTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	rubrik, err := findRubrikTugas(tugasID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas ini belum memiliki rubrik", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Rubrik berhasil diambil", map[string]interface{}{
+		"rubrik":        rubrik,
+		"poin_maksimal": poinMaksimalRubrik(rubrik),
+	})
+}
+
+// SetRubrikTugas - Membuat atau mengganti seluruh rubrik tugas
+// Ditolak jika sudah ada pengumpulan yang dinilai dengan rubrik ini
+func SetRubrikTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var input struct {
+		Judul    string `json:"judul"`
+		Kriteria []struct {
+			NamaKriteria string `json:"nama_kriteria"`
+			Deskripsi    string `json:"deskripsi"`
+			Level        []struct {
+				NamaLevel string `json:"nama_level"`
+				Deskripsi string `json:"deskripsi"`
+				Poin      int    `json:"poin"`
+			} `json:"level"`
+		} `json:"kriteria"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if len(input.Kriteria) == 0 {
+		helpers.Response(w, 400, "Rubrik minimal memiliki satu kriteria", nil)
+		return
+	}
+
+	rubrik := models.Rubrik{TugasID: tugasID, Judul: input.Judul}
+	for i, k := range input.Kriteria {
+		if k.NamaKriteria == "" || len(k.Level) == 0 {
+			helpers.Response(w, 400, "Setiap kriteria wajib memiliki nama dan minimal satu level", nil)
+			return
+		}
+
+		kriteria := models.KriteriaRubrik{NamaKriteria: k.NamaKriteria, Deskripsi: k.Deskripsi, Urutan: i + 1}
+		for j, l := range k.Level {
+			if l.NamaLevel == "" || l.Poin < 0 {
+				helpers.Response(w, 400, "Setiap level wajib memiliki nama dan poin tidak negatif", nil)
+				return
+			}
+			kriteria.Level = append(kriteria.Level, models.LevelRubrik{
+				NamaLevel: l.NamaLevel,
+				Deskripsi: l.Deskripsi,
+				Poin:      l.Poin,
+				Urutan:    j + 1,
+			})
+		}
+		rubrik.Kriteria = append(rubrik.Kriteria, kriteria)
+	}
+
+	if poinMaksimalRubrik(rubrik) == 0 {
+		helpers.Response(w, 400, "Total poin maksimal rubrik harus lebih dari 0", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if rubrikSudahDipakai(tugasID) {
+		helpers.Response(w, 400, "Rubrik tidak dapat diubah karena sudah dipakai untuk menilai pengumpulan", nil)
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tugas_id = ?", tugasID).Delete(&models.Rubrik{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rubrik).Error
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan rubrik: "+err.Error(), nil)
+		return
+	}
+
+	rubrik, _ = findRubrikTugas(tugasID)
+	helpers.Response(w, 200, "Rubrik berhasil disimpan", map[string]interface{}{
+		"rubrik":        rubrik,
+		"poin_maksimal": poinMaksimalRubrik(rubrik),
+	})
+}
+
+// DeleteRubrikTugas - Menghapus rubrik tugas (hanya jika belum dipakai menilai)
+func DeleteRubrikTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(tugasID, guru.ID); err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if rubrikSudahDipakai(tugasID) {
+		helpers.Response(w, 400, "Rubrik tidak dapat dihapus karena sudah dipakai untuk menilai pengumpulan", nil)
+		return
+	}
+
+	result := config.DB.Where("tugas_id = ?", tugasID).Delete(&models.Rubrik{})
+	if result.Error != nil {
+		helpers.Response(w, 500, "Database error: "+result.Error.Error(), nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		helpers.Response(w, 404, "Tugas ini belum memiliki rubrik", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Rubrik berhasil dihapus", nil)
+}
+
+// NilaiDenganRubrik - Guru menilai pengumpulan dengan memilih satu level per kriteria.
+// Poin didapat = total poin rubrik diskalakan ke poin maksimal tugas, lalu dikurangi penalti
+func NilaiDenganRubrik(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pengumpulanID, err := strconv.Atoi(vars["pengumpulan_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pengumpulan ID", nil)
+		return
+	}
+
+	var request struct {
+		Penilaian []struct {
+			KriteriaID int    `json:"kriteria_id"`
+			LevelID    int    `json:"level_id"`
+			Komentar   string `json:"komentar"`
+		} `json:"penilaian"`
+		CatatanGuru string `json:"catatan_guru"`
+		VersiID     int    `json:"versi_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		helpers.Response(w, 400, "Invalid JSON format", nil)
+		return
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	if err := config.DB.Where("pengumpulan_id = ?", pengumpulanID).First(&pengumpulan).Error; err != nil {
+		helpers.Response(w, 404, "Pengumpulan tugas not found", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(pengumpulan.TugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 403, "Access denied - not your class", nil)
+		return
+	}
+
+	if kuisIDTugas(tugas.TugasID) != nil {
+		helpers.Response(w, 409, "Nilai kuis online dihitung otomatis dan tidak dapat diubah manual", nil)
+		return
+	}
+
+	rubrik, err := findRubrikTugas(tugas.TugasID)
+	if err != nil {
+		helpers.Response(w, 400, "Tugas ini belum memiliki rubrik", nil)
+		return
+	}
+
+	// Setiap kriteria wajib dinilai tepat satu kali dengan level milik kriteria tersebut
+	levelKriteria := make(map[int]map[int]models.LevelRubrik)
+	for _, kriteria := range rubrik.Kriteria {
+		levelKriteria[kriteria.KriteriaID] = make(map[int]models.LevelRubrik)
+		for _, level := range kriteria.Level {
+			levelKriteria[kriteria.KriteriaID][level.LevelID] = level
+		}
+	}
+
+	if len(request.Penilaian) != len(rubrik.Kriteria) {
+		helpers.Response(w, 400, "Semua kriteria rubrik wajib dinilai", nil)
+		return
+	}
+
+	var penilaianList []models.PenilaianRubrik
+	dinilai := make(map[int]bool)
+	totalPoin := 0
+	for _, p := range request.Penilaian {
+		levels, ada := levelKriteria[p.KriteriaID]
+		if !ada || dinilai[p.KriteriaID] {
+			helpers.Response(w, 400, "Kriteria tidak valid atau dinilai lebih dari sekali", nil)
+			return
+		}
+		level, ada := levels[p.LevelID]
+		if !ada {
+			helpers.Response(w, 400, "Level tidak sesuai dengan kriteria", nil)
+			return
+		}
+
+		dinilai[p.KriteriaID] = true
+		totalPoin += level.Poin
+		penilaianList = append(penilaianList, models.PenilaianRubrik{
+			PengumpulanID: pengumpulan.PengumpulanID,
+			KriteriaID:    p.KriteriaID,
+			LevelID:       p.LevelID,
+			Poin:          level.Poin,
+			Komentar:      p.Komentar,
+		})
+	}
+
+	waktuKumpul, err := pilihVersiDinilai(&pengumpulan, request.VersiID)
+	if err != nil {
+		helpers.Response(w, 404, "Versi pengumpulan tidak ditemukan", nil)
+		return
+	}
+
+	poinMentah := int(math.Round(float64(totalPoin) / float64(poinMaksimalRubrik(rubrik)) * float64(tugas.PoinMaksimal)))
+	terapkanPoinPengumpulan(tugas, &pengumpulan, poinMentah, waktuKumpul)
+	pengumpulan.CatatanGuru = request.CatatanGuru
+	pengumpulan.StatusPengumpulan = "Dinilai"
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pengumpulan_id = ?", pengumpulan.PengumpulanID).Delete(&models.PenilaianRubrik{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&penilaianList).Error; err != nil {
+			return err
+		}
+		return tx.Omit("PenilaianRubrik", "Versi").Save(&pengumpulan).Error
+	})
+	if err != nil {
+		helpers.Response(w, 500, "Gagal menyimpan penilaian rubrik", nil)
+		return
+	}
+
+	go notifikasiNilai(tugas, pengumpulan)
+
+	config.DB.Preload("PenilaianRubrik.Kriteria").Preload("PenilaianRubrik.Level").
+		First(&pengumpulan, pengumpulan.PengumpulanID)
+	helpers.Response(w, 200, "Penilaian rubrik berhasil disimpan", map[string]interface{}{
+		"pengumpulan":          pengumpulan,
+		"total_poin_rubrik":    totalPoin,
+		"poin_maksimal_rubrik": poinMaksimalRubrik(rubrik),
+	})
+}
+
+// rubrikSudahDipakai - Cek apakah rubrik tugas sudah dipakai untuk menilai pengumpulan
+func rubrikSudahDipakai(tugasID int) bool {
+	var jumlah int64
+	config.DB.Model(&models.PenilaianRubrik{}).
+		Joins("JOIN pengumpulantugas pt ON penilaianrubrik.pengumpulan_id = pt.pengumpulan_id").
+		Where("pt.tugas_id = ?", tugasID).
+		Count(&jumlah)
+	return jumlah > 0
+}
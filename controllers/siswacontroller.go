@@ -19,16 +19,17 @@ func Me(w http.ResponseWriter, r *http.Request) {
 	}
 
 	siswaResponse := &models.SiswaProfile{
-		SiswaID: siswa.SiswaID,
-		NamaLengkap: siswa.NamaLengkap,
-		Email: siswa.Email,
-		NIS: siswa.NIS,
-		KelasID: siswa.KelasID,
-		NoTelepon: siswa.NoTelepon,
-		PoinMotivasi: siswa.PoinMotivasi,
-		TingkatDisiplin: siswa.TingkatDisiplin,
-		FotoProfil: siswa.FotoProfil,
-		Kelas: siswa.Kelas,
+		SiswaID:          siswa.SiswaID,
+		NamaLengkap:      siswa.NamaLengkap,
+		Email:            siswa.Email,
+		NIS:              siswa.NIS,
+		KelasID:          siswa.KelasID,
+		NoTelepon:        siswa.NoTelepon,
+		PoinMotivasi:     siswa.PoinMotivasi,
+		TingkatDisiplin:  siswa.TingkatDisiplin,
+		FotoProfil:       siswa.FotoProfil,
+		FotoProfilUkuran: urlAvatarSiswa(siswa.FotoProfil),
+		Kelas:            siswa.Kelas,
 	}
 
 	helpers.Response(w, 200, "Siswa profile", siswaResponse)
@@ -81,16 +82,17 @@ func UpdateSiswaProfile(w http.ResponseWriter, r *http.Request) {
 
 	// Return updated profile
 	updatedProfile := &models.SiswaProfile{
-		SiswaID:         currentSiswa.SiswaID,
-		NIS:             currentSiswa.NIS,
-		NamaLengkap:     currentSiswa.NamaLengkap,
-		KelasID:         currentSiswa.KelasID,
-		Email:           currentSiswa.Email,
-		NoTelepon:       currentSiswa.NoTelepon,
-		PoinMotivasi:    currentSiswa.PoinMotivasi,
-		TingkatDisiplin: currentSiswa.TingkatDisiplin,
-		FotoProfil:      currentSiswa.FotoProfil,
+		SiswaID:          currentSiswa.SiswaID,
+		NIS:              currentSiswa.NIS,
+		NamaLengkap:      currentSiswa.NamaLengkap,
+		KelasID:          currentSiswa.KelasID,
+		Email:            currentSiswa.Email,
+		NoTelepon:        currentSiswa.NoTelepon,
+		PoinMotivasi:     currentSiswa.PoinMotivasi,
+		TingkatDisiplin:  currentSiswa.TingkatDisiplin,
+		FotoProfil:       currentSiswa.FotoProfil,
+		FotoProfilUkuran: urlAvatarSiswa(currentSiswa.FotoProfil),
 	}
 
 	helpers.Response(w, 200, "Profil berhasil diupdate", updatedProfile)
-}
\ No newline at end of file
+}
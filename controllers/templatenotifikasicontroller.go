@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm/clause"
+)
+
+// Template pesan reminder yang bisa diubah admin per jenis, kanal dan bahasa
+
+// templateNotifikasiResponse - Template efektif untuk satu kombinasi jenis/kanal/bahasa
+type templateNotifikasiResponse struct {
+	Jenis  string `json:"jenis"`
+	Kanal  string `json:"kanal"`
+	Bahasa string `json:"bahasa"`
+	Judul  string `json:"judul"`
+	Isi    string `json:"isi"`
+	Sumber string `json:"sumber"` // database atau bawaan
+}
+
+// GetTemplateNotifikasi - Semua template efektif beserta variabel yang tersedia.
+// Query opsional: jenis, kanal, bahasa untuk menyaring
+func GetTemplateNotifikasi(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	daftar := []templateNotifikasiResponse{}
+	for _, jenis := range helpers.JenisTemplateNotifikasi {
+		for _, kanal := range helpers.KanalTemplateNotifikasi {
+			for _, bahasa := range helpers.BahasaNotifikasi {
+				if (q.Get("jenis") != "" && q.Get("jenis") != jenis) ||
+					(q.Get("kanal") != "" && q.Get("kanal") != kanal) ||
+					(q.Get("bahasa") != "" && q.Get("bahasa") != bahasa) {
+					continue
+				}
+				t, sumber := helpers.TemplateEfektif(jenis, kanal, bahasa)
+				daftar = append(daftar, templateNotifikasiResponse{
+					Jenis: jenis, Kanal: kanal, Bahasa: bahasa, Judul: t.Judul, Isi: t.Isi, Sumber: sumber,
+				})
+			}
+		}
+	}
+
+	helpers.Response(w, 200, "Template notifikasi", map[string]interface{}{
+		"template": daftar,
+		"variabel": helpers.VariabelTemplateNotifikasi,
+		"fungsi":   []string{"tanggal", "upper"},
+	})
+}
+
+// templateDariURL - Jenis, kanal dan bahasa dari URL; menulis respon 404 jika kombinasi tidak dikenal
+func templateDariURL(w http.ResponseWriter, r *http.Request) (string, string, string, bool) {
+	vars := mux.Vars(r)
+	if !helpers.TemplateDikenal(vars["jenis"], vars["kanal"], vars["bahasa"]) {
+		helpers.Response(w, 404, helpers.ErrTemplateTidakDikenal.Error(), nil)
+		return "", "", "", false
+	}
+	return vars["jenis"], vars["kanal"], vars["bahasa"], true
+}
+
+// UpdateTemplateNotifikasi - Admin mengganti judul dan isi template. Template divalidasi dengan merender
+// data contoh sebelum disimpan
+func UpdateTemplateNotifikasi(w http.ResponseWriter, r *http.Request) {
+	admin, ok := r.Context().Value("admininfo").(*helpers.AdminCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid admin info format", nil)
+		return
+	}
+	jenis, kanal, bahasa, ok := templateDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Judul string `json:"judul"`
+		Isi   string `json:"isi"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Response(w, 400, "Invalid request body", nil)
+		return
+	}
+	if req.Judul == "" || req.Isi == "" {
+		helpers.Response(w, 400, "judul dan isi wajib diisi", nil)
+		return
+	}
+
+	data := helpers.DataTemplateDariTugas(helpers.ContohTugasWithSiswa(), "1_hari", bahasa)
+	judul, isi, err := helpers.RenderTemplate(kanal, helpers.TemplateBawaan{Judul: req.Judul, Isi: req.Isi}, data)
+	if err != nil {
+		helpers.Response(w, 400, "Template tidak valid: "+err.Error(), nil)
+		return
+	}
+
+	t := models.TemplateNotifikasi{
+		Jenis:      jenis,
+		Kanal:      kanal,
+		Bahasa:     bahasa,
+		Judul:      req.Judul,
+		Isi:        req.Isi,
+		DiubahOleh: admin.Username,
+	}
+	if err := config.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(&t).Error; err != nil {
+		log.Printf("❌ Failed to save notification template %s/%s/%s: %v", jenis, kanal, bahasa, err)
+		helpers.Response(w, 500, "Gagal menyimpan template notifikasi", nil)
+		return
+	}
+	helpers.ResetCacheTemplateNotifikasi()
+
+	helpers.Response(w, 200, "Template notifikasi berhasil disimpan", map[string]interface{}{
+		"template": templateNotifikasiResponse{
+			Jenis: jenis, Kanal: kanal, Bahasa: bahasa, Judul: req.Judul, Isi: req.Isi, Sumber: "database",
+		},
+		"preview": map[string]string{"judul": judul, "isi": isi},
+	})
+}
+
+// DeleteTemplateNotifikasi - Menghapus template admin sehingga kombinasi kembali memakai template bawaan
+func DeleteTemplateNotifikasi(w http.ResponseWriter, r *http.Request) {
+	jenis, kanal, bahasa, ok := templateDariURL(w, r)
+	if !ok {
+		return
+	}
+
+	hasil := config.DB.Where("jenis = ? AND kanal = ? AND bahasa = ?", jenis, kanal, bahasa).Delete(&models.TemplateNotifikasi{})
+	if hasil.Error != nil {
+		helpers.Response(w, 500, "Gagal menghapus template notifikasi", nil)
+		return
+	}
+	if hasil.RowsAffected == 0 {
+		helpers.Response(w, 404, "Template ini sudah memakai template bawaan", nil)
+		return
+	}
+	helpers.ResetCacheTemplateNotifikasi()
+
+	t, sumber := helpers.TemplateEfektif(jenis, kanal, bahasa)
+	helpers.Response(w, 200, "Template notifikasi dikembalikan ke bawaan", templateNotifikasiResponse{
+		Jenis: jenis, Kanal: kanal, Bahasa: bahasa, Judul: t.Judul, Isi: t.Isi, Sumber: sumber,
+	})
+}
+
+// PreviewTemplateNotifikasi - Merender template (yang dikirim atau yang sedang dipakai) dengan data contoh.
+// Field data opsional menimpa data contoh, misal {"nama_siswa": "Ani", "deadline_pengumpulan": "..."}
+func PreviewTemplateNotifikasi(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Jenis         string          `json:"jenis"`
+		Kanal         string          `json:"kanal"`
+		Bahasa        string          `json:"bahasa"`
+		JenisReminder string          `json:"jenis_reminder"`
+		Judul         string          `json:"judul"`
+		Isi           string          `json:"isi"`
+		Data          json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helpers.Response(w, 400, "Invalid request body", nil)
+		return
+	}
+	if req.Kanal == "" {
+		req.Kanal = helpers.KanalTemplateDefault
+	}
+	if req.Bahasa == "" {
+		req.Bahasa = "id"
+	}
+	if !helpers.TemplateDikenal(req.Jenis, req.Kanal, req.Bahasa) {
+		helpers.Response(w, 400, helpers.ErrTemplateTidakDikenal.Error(), nil)
+		return
+	}
+
+	t, sumber := helpers.TemplateEfektif(req.Jenis, req.Kanal, req.Bahasa)
+	if req.Judul != "" {
+		t.Judul = req.Judul
+		sumber = "request"
+	}
+	if req.Isi != "" {
+		t.Isi = req.Isi
+		sumber = "request"
+	}
+
+	tugas := helpers.ContohTugasWithSiswa()
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &tugas); err != nil {
+			helpers.Response(w, 400, "data tidak valid: "+err.Error(), nil)
+			return
+		}
+	}
+	if req.JenisReminder == "" {
+		req.JenisReminder = "1_hari"
+		if req.Jenis == helpers.TemplateLewatDeadline {
+			req.JenisReminder = "lewat_deadline"
+		}
+	}
+
+	data := helpers.DataTemplateDariTugas(tugas, req.JenisReminder, req.Bahasa)
+	judul, isi, err := helpers.RenderTemplate(req.Kanal, t, data)
+	if err != nil {
+		helpers.Response(w, 400, "Template tidak valid: "+err.Error(), nil)
+		return
+	}
+	helpers.Response(w, 200, "Preview template notifikasi", map[string]interface{}{
+		"sumber": sumber,
+		"judul":  judul,
+		"isi":    isi,
+		"data":   data,
+	})
+}
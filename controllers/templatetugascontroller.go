@@ -0,0 +1,445 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// errJadwalBukanMilikGuru - Salah satu jadwal tujuan tidak ditemukan atau milik guru lain
+var errJadwalBukanMilikGuru = errors.New("jadwal tidak ditemukan atau bukan milik Anda")
+
+// inputJadwalTujuan - Jadwal tujuan beserta tanggal untuk tugas hasil salinan/template
+type inputJadwalTujuan struct {
+	JadwalIDs           []int  `json:"jadwal_ids"`
+	DeadlinePengumpulan string `json:"deadline_pengumpulan"`
+	TanggalTutup        string `json:"tanggal_tutup"`
+	TanggalTerbit       string `json:"tanggal_terbit"`
+}
+
+// terapkanTanggal - Mengisi deadline, tanggal tutup dan tanggal terbit dari input ke tugas dasar.
+// Deadline kosong mempertahankan deadline (dan tanggal tutup) yang sudah ada pada tugas dasar
+func (input inputJadwalTujuan) terapkanTanggal(tugas *models.Tugas) string {
+	if input.DeadlinePengumpulan != "" {
+		deadline, err := time.Parse("2006-01-02T15:04", input.DeadlinePengumpulan)
+		if err != nil {
+			return "Format deadline tidak valid"
+		}
+		tugas.DeadlinePengumpulan = deadline
+		tugas.TanggalTutup = nil
+	}
+	if tugas.DeadlinePengumpulan.IsZero() {
+		return "Deadline pengumpulan wajib diisi"
+	}
+
+	if input.TanggalTutup != "" {
+		tutup, err := time.Parse("2006-01-02T15:04", input.TanggalTutup)
+		if err != nil {
+			return "Format tanggal tutup tidak valid"
+		}
+		tugas.TanggalTutup = &tutup
+	}
+	if tugas.TanggalTutup != nil && tugas.TanggalTutup.Before(tugas.DeadlinePengumpulan) {
+		return "Tanggal tutup tidak boleh sebelum deadline"
+	}
+
+	tugas.TanggalTerbit = nil
+	if input.TanggalTerbit != "" {
+		terbit, err := time.Parse("2006-01-02T15:04", input.TanggalTerbit)
+		if err != nil {
+			return "Format tanggal terbit tidak valid"
+		}
+		if !terbit.Before(tugas.DeadlinePengumpulan) {
+			return "Tanggal terbit harus sebelum deadline"
+		}
+		tugas.TanggalTerbit = &terbit
+	}
+
+	return ""
+}
+
+// salinRubrik - Salinan rubrik tanpa ID agar dapat dibuat ulang untuk tugas lain
+func salinRubrik(rubrik models.Rubrik) models.Rubrik {
+	salinan := models.Rubrik{Judul: rubrik.Judul}
+	for _, k := range rubrik.Kriteria {
+		kriteria := models.KriteriaRubrik{NamaKriteria: k.NamaKriteria, Deskripsi: k.Deskripsi, Urutan: k.Urutan}
+		for _, l := range k.Level {
+			kriteria.Level = append(kriteria.Level, models.LevelRubrik{
+				NamaLevel: l.NamaLevel,
+				Deskripsi: l.Deskripsi,
+				Poin:      l.Poin,
+				Urutan:    l.Urutan,
+			})
+		}
+		salinan.Kriteria = append(salinan.Kriteria, kriteria)
+	}
+	return salinan
+}
+
+// buatTugasUntukJadwal - Membuat satu tugas (beserta rubrik jika ada) untuk setiap jadwal tujuan
+// dalam satu transaksi. Semua jadwal harus milik guru, jika tidak seluruhnya dibatalkan
+func buatTugasUntukJadwal(guruID int, dasar models.Tugas, rubrik *models.Rubrik, jadwalIDs []int) ([]models.Tugas, error) {
+	var hasil []models.Tugas
+	dibuat := make(map[int]bool)
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, jadwalID := range jadwalIDs {
+			if dibuat[jadwalID] {
+				continue
+			}
+			dibuat[jadwalID] = true
+
+			var jadwal models.JadwalPelajaran
+			if err := tx.Where("jadwal_id = ? AND guru_id = ?", jadwalID, guruID).First(&jadwal).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					return errJadwalBukanMilikGuru
+				}
+				return err
+			}
+
+			tugas := dasar
+			tugas.TugasID = 0
+			tugas.JadwalID = jadwalID
+			tugas.TanggalDibuat, tugas.CreatedAt, tugas.UpdatedAt = time.Time{}, time.Time{}, time.Time{}
+			tugas.JadwalPelajaran = models.JadwalPelajaran{}
+			tugas.Rubrik = nil
+			if err := tx.Create(&tugas).Error; err != nil {
+				return err
+			}
+
+			if rubrik != nil {
+				salinan := salinRubrik(*rubrik)
+				salinan.TugasID = tugas.TugasID
+				if err := tx.Create(&salinan).Error; err != nil {
+					return err
+				}
+			}
+
+			tugas.JadwalPelajaran = jadwal
+			hasil = append(hasil, tugas)
+		}
+		return nil
+	})
+
+	return hasil, err
+}
+
+// responBuatTugasJadwal - Respon standar hasil buatTugasUntukJadwal
+func responBuatTugasJadwal(w http.ResponseWriter, hasil []models.Tugas, err error) {
+	if err != nil {
+		if errors.Is(err, errJadwalBukanMilikGuru) {
+			helpers.Response(w, 404, "Salah satu jadwal tidak ditemukan atau bukan milik Anda", nil)
+		} else {
+			helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		}
+		return
+	}
+
+	go RunNotifikasiTugasTerbitCron()
+
+	helpers.Response(w, 201, "Tugas berhasil dibuat untuk "+strconv.Itoa(len(hasil))+" jadwal", hasil)
+}
+
+// SalinTugas - Menyalin tugas (beserta rubrik) ke satu atau beberapa jadwal sekaligus.
+// Kelompok dan pengumpulan tidak ikut disalin
+func SalinTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var input inputJadwalTujuan
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if len(input.JadwalIDs) == 0 {
+		helpers.Response(w, 400, "Minimal satu jadwal tujuan wajib dipilih", nil)
+		return
+	}
+
+	tugas, err := findTugasGuru(tugasID, guru.ID)
+	if err != nil {
+		helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	if pesan := input.terapkanTanggal(&tugas); pesan != "" {
+		helpers.Response(w, 400, pesan, nil)
+		return
+	}
+
+	var rubrik *models.Rubrik
+	if rb, err := findRubrikTugas(tugasID); err == nil {
+		rubrik = &rb
+	}
+
+	hasil, err := buatTugasUntukJadwal(guru.ID, tugas, rubrik, input.JadwalIDs)
+	responBuatTugasJadwal(w, hasil, err)
+}
+
+// GetTemplateTugas - Daftar template tugas milik guru
+func GetTemplateTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	var templates []models.TemplateTugas
+	if err := config.DB.Where("guru_id = ?", guru.ID).Order("nama_template ASC").Find(&templates).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	for i := range templates {
+		templates[i].Rubrik = rubrikTemplate(templates[i])
+	}
+
+	helpers.Response(w, 200, "Template tugas berhasil diambil", templates)
+}
+
+// rubrikTemplate - Decode rubrik yang tersimpan pada template, nil jika tidak ada
+func rubrikTemplate(template models.TemplateTugas) *models.Rubrik {
+	if template.RubrikJSON == "" {
+		return nil
+	}
+	var rubrik models.Rubrik
+	if err := json.Unmarshal([]byte(template.RubrikJSON), &rubrik); err != nil {
+		return nil
+	}
+	return &rubrik
+}
+
+// CreateTemplateTugas - Menyimpan template baru, baik dari isian langsung maupun dari tugas
+// yang sudah ada (tugas_id) beserta rubriknya
+func CreateTemplateTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	var input struct {
+		TugasID               int     `json:"tugas_id"`
+		NamaTemplate          string  `json:"nama_template"`
+		JudulTugas            string  `json:"judul_tugas"`
+		DeskripsiTugas        string  `json:"deskripsi_tugas"`
+		FileTugasGuru         string  `json:"file_tugas_guru"`
+		PoinMaksimal          int     `json:"poin_maksimal"`
+		TipeTugas             string  `json:"tipe_tugas"`
+		MaksPengumpulanUlang  *int    `json:"maks_pengumpulan_ulang"`
+		TolakSetelahDinilai   bool    `json:"tolak_setelah_dinilai"`
+		GracePeriodMenit      int     `json:"grace_period_menit"`
+		PenaltiPersenPerHari  float64 `json:"penalti_persen_per_hari"`
+		PenaltiMaksimalPersen float64 `json:"penalti_maksimal_persen"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if input.NamaTemplate == "" {
+		helpers.Response(w, 400, "Nama template wajib diisi", nil)
+		return
+	}
+
+	template := models.TemplateTugas{GuruID: guru.ID, NamaTemplate: input.NamaTemplate}
+
+	if input.TugasID != 0 {
+		tugas, err := findTugasGuru(input.TugasID, guru.ID)
+		if err != nil {
+			helpers.Response(w, 404, "Tugas tidak ditemukan atau bukan milik Anda", nil)
+			return
+		}
+
+		template.JudulTugas = tugas.JudulTugas
+		template.DeskripsiTugas = tugas.DeskripsiTugas
+		template.FileTugasGuru = tugas.FileTugasGuru
+		template.PoinMaksimal = tugas.PoinMaksimal
+		template.TipeTugas = tugas.TipeTugas
+		template.MaksPengumpulanUlang = tugas.MaksPengumpulanUlang
+		template.TolakSetelahDinilai = tugas.TolakSetelahDinilai
+		template.GracePeriodMenit = tugas.GracePeriodMenit
+		template.PenaltiPersenPerHari = tugas.PenaltiPersenPerHari
+		template.PenaltiMaksimalPersen = tugas.PenaltiMaksimalPersen
+
+		if rubrik, err := findRubrikTugas(input.TugasID); err == nil {
+			data, _ := json.Marshal(salinRubrik(rubrik))
+			template.RubrikJSON = string(data)
+		}
+	} else {
+		if input.JudulTugas == "" || input.DeskripsiTugas == "" {
+			helpers.Response(w, 400, "Missing required fields", nil)
+			return
+		}
+		if input.TipeTugas != "Individu" && input.TipeTugas != "Kelompok" {
+			helpers.Response(w, 400, "Tipe tugas harus 'Individu' atau 'Kelompok'", nil)
+			return
+		}
+		if input.GracePeriodMenit < 0 || input.PenaltiPersenPerHari < 0 || input.PenaltiPersenPerHari > 100 ||
+			input.PenaltiMaksimalPersen < 0 || input.PenaltiMaksimalPersen > 100 {
+			helpers.Response(w, 400, "Kebijakan keterlambatan tidak valid", nil)
+			return
+		}
+		if input.PoinMaksimal == 0 {
+			input.PoinMaksimal = 100
+		}
+		if input.PenaltiMaksimalPersen == 0 {
+			input.PenaltiMaksimalPersen = 100
+		}
+
+		template.JudulTugas = input.JudulTugas
+		template.DeskripsiTugas = input.DeskripsiTugas
+		template.FileTugasGuru = input.FileTugasGuru
+		template.PoinMaksimal = input.PoinMaksimal
+		template.TipeTugas = input.TipeTugas
+		template.TolakSetelahDinilai = input.TolakSetelahDinilai
+		template.GracePeriodMenit = input.GracePeriodMenit
+		template.PenaltiPersenPerHari = input.PenaltiPersenPerHari
+		template.PenaltiMaksimalPersen = input.PenaltiMaksimalPersen
+		// Nilai negatif berarti pengumpulan ulang tanpa batas
+		if input.MaksPengumpulanUlang != nil && *input.MaksPengumpulanUlang >= 0 {
+			template.MaksPengumpulanUlang = input.MaksPengumpulanUlang
+		}
+	}
+
+	if err := config.DB.Create(&template).Error; err != nil {
+		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
+		return
+	}
+
+	template.Rubrik = rubrikTemplate(template)
+	helpers.Response(w, 201, "Template tugas berhasil disimpan", template)
+}
+
+// DeleteTemplateTugas - Menghapus template milik guru (tugas yang sudah dibuat tidak terpengaruh)
+func DeleteTemplateTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	templateID, err := strconv.Atoi(vars["template_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid template ID", nil)
+		return
+	}
+
+	result := config.DB.Where("template_id = ? AND guru_id = ?", templateID, guru.ID).Delete(&models.TemplateTugas{})
+	if result.Error != nil {
+		helpers.Response(w, 500, "Database error: "+result.Error.Error(), nil)
+		return
+	}
+	if result.RowsAffected == 0 {
+		helpers.Response(w, 404, "Template tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Template tugas berhasil dihapus", nil)
+}
+
+// GunakanTemplateTugas - Membuat tugas dari template untuk satu atau beberapa jadwal sekaligus
+func GunakanTemplateTugas(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	templateID, err := strconv.Atoi(vars["template_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid template ID", nil)
+		return
+	}
+
+	var input inputJadwalTujuan
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+
+	if len(input.JadwalIDs) == 0 {
+		helpers.Response(w, 400, "Minimal satu jadwal tujuan wajib dipilih", nil)
+		return
+	}
+
+	var template models.TemplateTugas
+	if err := config.DB.Where("template_id = ? AND guru_id = ?", templateID, guru.ID).First(&template).Error; err != nil {
+		helpers.Response(w, 404, "Template tidak ditemukan atau bukan milik Anda", nil)
+		return
+	}
+
+	tugas := models.Tugas{
+		JudulTugas:            template.JudulTugas,
+		DeskripsiTugas:        template.DeskripsiTugas,
+		FileTugasGuru:         template.FileTugasGuru,
+		PoinMaksimal:          template.PoinMaksimal,
+		TipeTugas:             template.TipeTugas,
+		MaksPengumpulanUlang:  template.MaksPengumpulanUlang,
+		TolakSetelahDinilai:   template.TolakSetelahDinilai,
+		GracePeriodMenit:      template.GracePeriodMenit,
+		PenaltiPersenPerHari:  template.PenaltiPersenPerHari,
+		PenaltiMaksimalPersen: template.PenaltiMaksimalPersen,
+	}
+
+	if pesan := input.terapkanTanggal(&tugas); pesan != "" {
+		helpers.Response(w, 400, pesan, nil)
+		return
+	}
+
+	hasil, err := buatTugasUntukJadwal(guru.ID, tugas, rubrikTemplate(template), input.JadwalIDs)
+	responBuatTugasJadwal(w, hasil, err)
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -110,13 +111,21 @@ func CreateTugas(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input struct {
-		JadwalID            int    `json:"jadwal_id"`
-		JudulTugas          string `json:"judul_tugas"`
-		DeskripsiTugas      string `json:"deskripsi_tugas"`
-		DeadlinePengumpulan string `json:"deadline_pengumpulan"`
-		PoinMaksimal        int    `json:"poin_maksimal"`
-		TipeTugas           string `json:"tipe_tugas"`
-		FileTugasGuru       string `json:"file_tugas_guru"`
+		JadwalID              int     `json:"jadwal_id"`
+		JudulTugas            string  `json:"judul_tugas"`
+		DeskripsiTugas        string  `json:"deskripsi_tugas"`
+		DeadlinePengumpulan   string  `json:"deadline_pengumpulan"`
+		PoinMaksimal          int     `json:"poin_maksimal"`
+		TipeTugas             string  `json:"tipe_tugas"`
+		FileTugasGuru         string  `json:"file_tugas_guru"`
+		MaksPengumpulanUlang  *int    `json:"maks_pengumpulan_ulang"`
+		TolakSetelahDinilai   bool    `json:"tolak_setelah_dinilai"`
+		GracePeriodMenit      int     `json:"grace_period_menit"`
+		TanggalTutup          string  `json:"tanggal_tutup"`
+		PenaltiPersenPerHari  float64 `json:"penalti_persen_per_hari"`
+		PenaltiMaksimalPersen float64 `json:"penalti_maksimal_persen"`
+		TanggalTerbit         string  `json:"tanggal_terbit"`
+		MaksUkuranFileMB      *int    `json:"maks_ukuran_file_mb"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -159,15 +168,77 @@ func CreateTugas(w http.ResponseWriter, r *http.Request) {
 		input.PoinMaksimal = 100
 	}
 
+	// Validasi kebijakan keterlambatan
+	if input.GracePeriodMenit < 0 || input.PenaltiPersenPerHari < 0 || input.PenaltiPersenPerHari > 100 ||
+		input.PenaltiMaksimalPersen < 0 || input.PenaltiMaksimalPersen > 100 {
+		helpers.Response(w, 400, "Kebijakan keterlambatan tidak valid", nil)
+		return
+	}
+	if input.PenaltiMaksimalPersen == 0 {
+		input.PenaltiMaksimalPersen = 100
+	}
+
+	var tanggalTutup *time.Time
+	if input.TanggalTutup != "" {
+		tutup, err := time.Parse("2006-01-02T15:04", input.TanggalTutup)
+		if err != nil {
+			helpers.Response(w, 400, "Format tanggal tutup tidak valid", nil)
+			return
+		}
+		if tutup.Before(deadline) {
+			helpers.Response(w, 400, "Tanggal tutup tidak boleh sebelum deadline", nil)
+			return
+		}
+		tanggalTutup = &tutup
+	}
+
+	// Publikasi terjadwal: kosong = langsung terlihat siswa
+	var tanggalTerbit *time.Time
+	if input.TanggalTerbit != "" {
+		terbit, err := time.Parse("2006-01-02T15:04", input.TanggalTerbit)
+		if err != nil {
+			helpers.Response(w, 400, "Format tanggal terbit tidak valid", nil)
+			return
+		}
+		if !terbit.Before(deadline) {
+			helpers.Response(w, 400, "Tanggal terbit harus sebelum deadline", nil)
+			return
+		}
+		tanggalTerbit = &terbit
+	}
+
+	// Batas ukuran file jawaban: kosong atau 0 = batas default
+	if input.MaksUkuranFileMB != nil {
+		if *input.MaksUkuranFileMB < 0 || int64(*input.MaksUkuranFileMB)<<20 > batasMaksUploadBertahap() {
+			helpers.Response(w, 400, fmt.Sprintf("Batas ukuran file harus antara 1 dan %d MB", batasMaksUploadBertahap()>>20), nil)
+			return
+		}
+		if *input.MaksUkuranFileMB == 0 {
+			input.MaksUkuranFileMB = nil
+		}
+	}
+
 	// Create tugas
 	tugas := models.Tugas{
-		JadwalID:            input.JadwalID,
-		JudulTugas:          input.JudulTugas,
-		DeskripsiTugas:      input.DeskripsiTugas,
-		DeadlinePengumpulan: deadline,
-		PoinMaksimal:        input.PoinMaksimal,
-		TipeTugas:           input.TipeTugas,
-		FileTugasGuru:       input.FileTugasGuru,
+		JadwalID:              input.JadwalID,
+		JudulTugas:            input.JudulTugas,
+		DeskripsiTugas:        input.DeskripsiTugas,
+		DeadlinePengumpulan:   deadline,
+		PoinMaksimal:          input.PoinMaksimal,
+		TipeTugas:             input.TipeTugas,
+		FileTugasGuru:         input.FileTugasGuru,
+		TolakSetelahDinilai:   input.TolakSetelahDinilai,
+		GracePeriodMenit:      input.GracePeriodMenit,
+		TanggalTutup:          tanggalTutup,
+		PenaltiPersenPerHari:  input.PenaltiPersenPerHari,
+		PenaltiMaksimalPersen: input.PenaltiMaksimalPersen,
+		TanggalTerbit:         tanggalTerbit,
+		MaksUkuranFileMB:      input.MaksUkuranFileMB,
+	}
+
+	// Nilai negatif berarti pengumpulan ulang tanpa batas
+	if input.MaksPengumpulanUlang != nil && *input.MaksPengumpulanUlang >= 0 {
+		tugas.MaksPengumpulanUlang = input.MaksPengumpulanUlang
 	}
 
 	if err := config.DB.Create(&tugas).Error; err != nil {
@@ -178,6 +249,8 @@ func CreateTugas(w http.ResponseWriter, r *http.Request) {
 	// Load jadwal relation
 	config.DB.Preload("JadwalPelajaran").First(&tugas, tugas.TugasID)
 
+	go RunNotifikasiTugasTerbitCron()
+
 	helpers.Response(w, 201, "Tugas berhasil dibuat", tugas)
 }
 
@@ -205,12 +278,20 @@ func UpdateTugas(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var input struct {
-		JudulTugas          string `json:"judul_tugas"`
-		DeskripsiTugas      string `json:"deskripsi_tugas"`
-		DeadlinePengumpulan string `json:"deadline_pengumpulan"`
-		PoinMaksimal        int    `json:"poin_maksimal"`
-		TipeTugas           string `json:"tipe_tugas"`
-		FileTugasGuru       string `json:"file_tugas_guru"`
+		JudulTugas            string   `json:"judul_tugas"`
+		DeskripsiTugas        string   `json:"deskripsi_tugas"`
+		DeadlinePengumpulan   string   `json:"deadline_pengumpulan"`
+		PoinMaksimal          int      `json:"poin_maksimal"`
+		TipeTugas             string   `json:"tipe_tugas"`
+		FileTugasGuru         string   `json:"file_tugas_guru"`
+		MaksPengumpulanUlang  *int     `json:"maks_pengumpulan_ulang"`
+		TolakSetelahDinilai   *bool    `json:"tolak_setelah_dinilai"`
+		GracePeriodMenit      *int     `json:"grace_period_menit"`
+		TanggalTutup          *string  `json:"tanggal_tutup"`
+		PenaltiPersenPerHari  *float64 `json:"penalti_persen_per_hari"`
+		PenaltiMaksimalPersen *float64 `json:"penalti_maksimal_persen"`
+		TanggalTerbit         *string  `json:"tanggal_terbit"`
+		MaksUkuranFileMB      *int     `json:"maks_ukuran_file_mb"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -263,6 +344,105 @@ func UpdateTugas(w http.ResponseWriter, r *http.Request) {
 	if input.FileTugasGuru != "" {
 		updates["file_tugas_guru"] = input.FileTugasGuru
 	}
+	if input.MaksPengumpulanUlang != nil {
+		// Nilai negatif menghapus batas pengumpulan ulang
+		if *input.MaksPengumpulanUlang < 0 {
+			updates["maks_pengumpulan_ulang"] = nil
+		} else {
+			updates["maks_pengumpulan_ulang"] = *input.MaksPengumpulanUlang
+		}
+	}
+	if input.TolakSetelahDinilai != nil {
+		updates["tolak_setelah_dinilai"] = *input.TolakSetelahDinilai
+	}
+	if input.GracePeriodMenit != nil {
+		if *input.GracePeriodMenit < 0 {
+			helpers.Response(w, 400, "Kebijakan keterlambatan tidak valid", nil)
+			return
+		}
+		updates["grace_period_menit"] = *input.GracePeriodMenit
+	}
+	if input.TanggalTutup != nil {
+		// String kosong menghapus tanggal tutup
+		if *input.TanggalTutup == "" {
+			updates["tanggal_tutup"] = nil
+		} else {
+			tutup, err := time.Parse("2006-01-02T15:04", *input.TanggalTutup)
+			if err != nil {
+				helpers.Response(w, 400, "Format tanggal tutup tidak valid", nil)
+				return
+			}
+			updates["tanggal_tutup"] = tutup
+		}
+	}
+	if input.PenaltiPersenPerHari != nil {
+		if *input.PenaltiPersenPerHari < 0 || *input.PenaltiPersenPerHari > 100 {
+			helpers.Response(w, 400, "Kebijakan keterlambatan tidak valid", nil)
+			return
+		}
+		updates["penalti_persen_per_hari"] = *input.PenaltiPersenPerHari
+	}
+	if input.PenaltiMaksimalPersen != nil {
+		if *input.PenaltiMaksimalPersen <= 0 || *input.PenaltiMaksimalPersen > 100 {
+			helpers.Response(w, 400, "Kebijakan keterlambatan tidak valid", nil)
+			return
+		}
+		updates["penalti_maksimal_persen"] = *input.PenaltiMaksimalPersen
+	}
+	if input.TanggalTerbit != nil {
+		// String kosong langsung menerbitkan tugas
+		if *input.TanggalTerbit == "" {
+			updates["tanggal_terbit"] = nil
+		} else {
+			terbit, err := time.Parse("2006-01-02T15:04", *input.TanggalTerbit)
+			if err != nil {
+				helpers.Response(w, 400, "Format tanggal terbit tidak valid", nil)
+				return
+			}
+			updates["tanggal_terbit"] = terbit
+		}
+	}
+
+	if input.MaksUkuranFileMB != nil {
+		// 0 mengembalikan ke batas default
+		if *input.MaksUkuranFileMB < 0 || int64(*input.MaksUkuranFileMB)<<20 > batasMaksUploadBertahap() {
+			helpers.Response(w, 400, fmt.Sprintf("Batas ukuran file harus antara 1 dan %d MB", batasMaksUploadBertahap()>>20), nil)
+			return
+		}
+		if *input.MaksUkuranFileMB == 0 {
+			updates["maks_ukuran_file_mb"] = nil
+		} else {
+			updates["maks_ukuran_file_mb"] = *input.MaksUkuranFileMB
+		}
+	}
+
+	// Tanggal tutup dan tanggal terbit dibandingkan dengan deadline efektif (nilai baru atau yang tersimpan)
+	deadlineEfektif := tugas.DeadlinePengumpulan
+	if deadline, ok := updates["deadline_pengumpulan"].(time.Time); ok {
+		deadlineEfektif = deadline
+	}
+	tutupEfektif := tugas.TanggalTutup
+	if nilai, ada := updates["tanggal_tutup"]; ada {
+		tutupEfektif = nil
+		if tutup, ok := nilai.(time.Time); ok {
+			tutupEfektif = &tutup
+		}
+	}
+	if tutupEfektif != nil && tutupEfektif.Before(deadlineEfektif) {
+		helpers.Response(w, 400, "Tanggal tutup tidak boleh sebelum deadline", nil)
+		return
+	}
+	terbitEfektif := tugas.TanggalTerbit
+	if nilai, ada := updates["tanggal_terbit"]; ada {
+		terbitEfektif = nil
+		if terbit, ok := nilai.(time.Time); ok {
+			terbitEfektif = &terbit
+		}
+	}
+	if terbitEfektif != nil && !terbitEfektif.Before(deadlineEfektif) {
+		helpers.Response(w, 400, "Tanggal terbit harus sebelum deadline", nil)
+		return
+	}
 
 	if err := config.DB.Model(&tugas).Updates(updates).Error; err != nil {
 		helpers.Response(w, 500, "Database error: "+err.Error(), nil)
@@ -272,6 +452,9 @@ func UpdateTugas(w http.ResponseWriter, r *http.Request) {
 	// Load updated tugas with relations
 	config.DB.Preload("JadwalPelajaran").First(&tugas, tugas.TugasID)
 
+	// Tugas terjadwal yang tanggal terbitnya dimajukan langsung diumumkan
+	go RunNotifikasiTugasTerbitCron()
+
 	helpers.Response(w, 200, "Tugas berhasil diperbarui", tugas)
 }
 
@@ -368,3 +551,17 @@ func GetTugasDetail(w http.ResponseWriter, r *http.Request) {
 	}
 	helpers.Response(w, 200, "Detail tugas berhasil diambil", response)
 }
+
+// kondisiTugasTerbit - Tugas terjadwal baru terlihat siswa setelah tanggal_terbit
+const kondisiTugasTerbit = "(tugas.tanggal_terbit IS NULL OR tugas.tanggal_terbit <= NOW())"
+
+// findTugasGuru - Mengambil tugas beserta jadwalnya jika tugas tersebut milik guru
+func findTugasGuru(tugasID, guruID int) (models.Tugas, error) {
+	var tugas models.Tugas
+	err := config.DB.Preload("JadwalPelajaran").
+		Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
+		Where("tugas.tugas_id = ? AND jadwalpelajaran.guru_id = ?", tugasID, guruID).
+		First(&tugas).Error
+
+	return tugas, err
+}
@@ -0,0 +1,555 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+	"Pasti/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Upload bertahap (resumable) untuk file besar, mengikuti pola offset protokol tus:
+//
+//	POST   /api/upload/bertahap        {nama_file, ukuran, checksum, tugas_id, tujuan} -> upload_id
+//	HEAD   /api/upload/bertahap/{id}   header Upload-Offset = byte yang sudah diterima
+//	PATCH  /api/upload/bertahap/{id}   header Upload-Offset + Upload-Checksum "sha256 <base64>", body = potongan
+//	DELETE /api/upload/bertahap/{id}   membatalkan upload
+//
+// Setiap potongan disimpan sebagai objek storage "bertahap/<upload_id>/<offset>" sehingga PATCH dapat
+// diterima instance server mana pun. Sesi dikunci di database (dikunci_sampai) selama potongan ditulis.
+// Setelah lengkap potongan digabung, checksum seluruh file dicek, lalu file divalidasi dan disimpan ke
+// storage "tugas/" lewat simpanFileUpload, sama seperti UploadFileHandler.
+
+// statusChecksumTidakCocok - Status "460 Checksum Mismatch" dari ekstensi checksum tus
+const statusChecksumTidakCocok = 460
+
+// maksSesiUploadBertahap - Jumlah upload bertahap yang boleh berjalan bersamaan per user
+const maksSesiUploadBertahap = 5
+
+// masaKunciUploadBertahap - Lama sesi dikunci satu request; kunci request yang terhenti diambil alih setelahnya
+const masaKunciUploadBertahap = 10 * time.Minute
+
+// ukuranPotonganMaks - UPLOAD_CHUNK_MAKS_MB (default 16): ukuran maksimal satu potongan
+func ukuranPotonganMaks() int64 {
+	if v := kuotaMBDariEnv("UPLOAD_CHUNK_MAKS_MB", 16); v > 0 {
+		return v
+	}
+	return 16 << 20
+}
+
+// masaBerlakuUploadBertahap - UPLOAD_BERTAHAP_JAM (default 24): sesi kedaluwarsa jika tidak ada potongan baru
+func masaBerlakuUploadBertahap() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("UPLOAD_BERTAHAP_JAM")); err == nil && v > 0 {
+		return time.Duration(v) * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// prefixPotonganUpload - Awalan key storage potongan sebuah upload
+func prefixPotonganUpload(uploadID string) string {
+	return "bertahap/" + uploadID + "/"
+}
+
+// kunciPotonganUpload - Key storage potongan; offset diberi nol di depan agar urut secara leksikal
+func kunciPotonganUpload(uploadID string, offset int64) string {
+	return fmt.Sprintf("%s%020d", prefixPotonganUpload(uploadID), offset)
+}
+
+// responUploadBertahap - Status sesi beserta header Upload-Offset/Upload-Length
+func responUploadBertahap(w http.ResponseWriter, u *models.UploadBertahap) map[string]interface{} {
+	w.Header().Set("Upload-Offset", strconv.FormatInt(u.Diterima, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(u.Ukuran, 10))
+	w.Header().Set("Cache-Control", "no-store")
+
+	data := map[string]interface{}{
+		"upload_id":         u.UploadID,
+		"nama_file":         u.NamaFile,
+		"ukuran":            u.Ukuran,
+		"offset":            u.Diterima,
+		"status":            u.Status,
+		"kedaluwarsa":       u.Kedaluwarsa,
+		"ukuran_chunk_maks": ukuranPotonganMaks(),
+	}
+	if u.URLHasil != "" {
+		data["url"] = u.URLHasil
+	}
+	if u.Pesan != "" {
+		data["pesan"] = u.Pesan
+	}
+	return data
+}
+
+// BuatUploadBertahap - Memulai upload bertahap. Ekstensi, batas ukuran tugas dan kuota dicek di awal
+// agar file yang pasti ditolak tidak perlu dikirim
+func BuatUploadBertahap(w http.ResponseWriter, r *http.Request) {
+	tipeUser, userID := pemilikUpload(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "unauthorized", nil)
+		return
+	}
+
+	var input struct {
+		NamaFile string `json:"nama_file"`
+		Ukuran   int64  `json:"ukuran"`
+		Checksum string `json:"checksum"`
+		TugasID  *int   `json:"tugas_id"`
+		Tujuan   string `json:"tujuan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		helpers.Response(w, 400, "Invalid input: "+err.Error(), nil)
+		return
+	}
+	input.NamaFile = filepath.Base(strings.TrimSpace(input.NamaFile))
+	if input.NamaFile == "" || input.NamaFile == "." || len(input.NamaFile) > 255 || input.Ukuran <= 0 {
+		helpers.Response(w, 400, "nama_file dan ukuran wajib diisi", nil)
+		return
+	}
+	input.Checksum = strings.TrimSpace(input.Checksum)
+	if _, _, err := bacaChecksumPotongan(input.Checksum); err != nil || len(input.Checksum) > 150 {
+		helpers.Response(w, 400, "checksum seluruh file wajib diisi dengan format \"sha256 <base64>\"", nil)
+		return
+	}
+	if _, _, ok := ekstensiUpload(input.NamaFile); !ok {
+		helpers.Response(w, 400, "Format file tidak didukung", nil)
+		return
+	}
+
+	tugasIDStr := ""
+	if input.TugasID != nil {
+		tugasIDStr = strconv.Itoa(*input.TugasID)
+	}
+	tugasID, tujuan, galat := metaUploadDariForm(tugasIDStr, input.Tujuan, tipeUser, userID)
+	if galat != nil {
+		helpers.Response(w, galat.status, galat.pesan, nil)
+		return
+	}
+
+	if batas := batasUkuranUpload(tugasID); input.Ukuran > batas {
+		helpers.Response(w, 413, fmt.Sprintf("File terlalu besar (maksimal %d MB)", batas>>20), nil)
+		return
+	}
+	if pesan, err := cekKuotaUpload(tipeUser, userID, tugasID, input.Ukuran); err != nil {
+		helpers.Response(w, 500, "Gagal memeriksa kuota penyimpanan", nil)
+		return
+	} else if pesan != "" {
+		helpers.Response(w, 413, pesan, nil)
+		return
+	}
+
+	var berjalan int64
+	config.DB.Model(&models.UploadBertahap{}).
+		Where("tipe_user = ? AND user_id = ? AND status = ? AND kedaluwarsa > ?", tipeUser, userID, "Berjalan", time.Now()).
+		Count(&berjalan)
+	if berjalan >= maksSesiUploadBertahap {
+		helpers.Response(w, 429, fmt.Sprintf("Maksimal %d upload bertahap berjalan bersamaan", maksSesiUploadBertahap), nil)
+		return
+	}
+
+	sesi := models.UploadBertahap{
+		UploadID:    uuid.New().String(),
+		TipeUser:    tipeUser,
+		UserID:      userID,
+		NamaFile:    input.NamaFile,
+		Ukuran:      input.Ukuran,
+		Checksum:    input.Checksum,
+		TugasID:     tugasID,
+		Tujuan:      tujuan,
+		Status:      "Berjalan",
+		Kedaluwarsa: time.Now().Add(masaBerlakuUploadBertahap()),
+	}
+	if err := config.DB.Create(&sesi).Error; err != nil {
+		helpers.Response(w, 500, "Gagal memulai upload", nil)
+		return
+	}
+
+	log.Printf("📦 Resumable upload %s started: %s (%d bytes)", sesi.UploadID, sesi.NamaFile, sesi.Ukuran)
+	w.Header().Set("Location", "/api/upload/bertahap/"+sesi.UploadID)
+	helpers.Response(w, 201, "Upload bertahap dimulai", responUploadBertahap(w, &sesi))
+}
+
+// findUploadBertahap - Sesi {upload_id} milik user yang login
+func findUploadBertahap(w http.ResponseWriter, r *http.Request) (*models.UploadBertahap, bool) {
+	tipeUser, userID := pemilikUpload(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "unauthorized", nil)
+		return nil, false
+	}
+
+	var sesi models.UploadBertahap
+	if err := config.DB.Where("upload_id = ? AND tipe_user = ? AND user_id = ?", mux.Vars(r)["upload_id"], tipeUser, userID).
+		First(&sesi).Error; err != nil {
+		helpers.Response(w, 404, "Upload tidak ditemukan", nil)
+		return nil, false
+	}
+	return &sesi, true
+}
+
+// StatusUploadBertahap - Offset terakhir yang diterima (GET untuk JSON, HEAD cukup membaca header)
+func StatusUploadBertahap(w http.ResponseWriter, r *http.Request) {
+	sesi, ok := findUploadBertahap(w, r)
+	if !ok {
+		return
+	}
+	helpers.Response(w, 200, "Status upload bertahap", responUploadBertahap(w, sesi))
+}
+
+// bacaChecksumPotongan - Header Upload-Checksum "<algoritma> <base64>" (sha256, sha1 atau md5)
+func bacaChecksumPotongan(header string) (hash.Hash, []byte, error) {
+	algo, nilai, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, nil, errors.New("header Upload-Checksum wajib diisi dengan format \"sha256 <base64>\"")
+	}
+	harapan, err := base64.StdEncoding.DecodeString(strings.TrimSpace(nilai))
+	if err != nil {
+		return nil, nil, errors.New("nilai Upload-Checksum harus base64")
+	}
+
+	switch strings.ToLower(algo) {
+	case "sha256":
+		return sha256.New(), harapan, nil
+	case "sha1":
+		return sha1.New(), harapan, nil
+	case "md5":
+		return md5.New(), harapan, nil
+	}
+	return nil, nil, fmt.Errorf("algoritma checksum %q tidak didukung", algo)
+}
+
+// kunciSesiUpload - Mengunci sesi yang masih berada di offset untuk request ini, berlaku di semua
+// instance server. Mengembalikan waktu kunci (dipakai sebagai penanda pemilik kunci), nil jika sesi
+// sedang dikunci request lain atau offset sudah berubah
+func kunciSesiUpload(uploadID string, offset int64) (*time.Time, error) {
+	now := time.Now()
+	// Dibulatkan ke detik agar sama persis dengan nilai kolom timestamp
+	sampai := now.Add(masaKunciUploadBertahap).Truncate(time.Second)
+	hasil := config.DB.Model(&models.UploadBertahap{}).
+		Where("upload_id = ? AND status = ? AND diterima = ? AND (dikunci_sampai IS NULL OR dikunci_sampai < ?)",
+			uploadID, "Berjalan", offset, now).
+		Update("dikunci_sampai", sampai)
+	if hasil.Error != nil || hasil.RowsAffected == 0 {
+		return nil, hasil.Error
+	}
+	return &sampai, nil
+}
+
+// lepasKunciSesiUpload - Melepas kunci jika masih dipegang request ini
+func lepasKunciSesiUpload(uploadID string, kunci *time.Time) {
+	config.DB.Model(&models.UploadBertahap{}).
+		Where("upload_id = ? AND dikunci_sampai = ?", uploadID, *kunci).
+		Update("dikunci_sampai", nil)
+}
+
+// KirimPotonganUpload - Menerima satu potongan pada offset Upload-Offset. Potongan yang checksum-nya
+// tidak cocok ditolak (460) dan dapat dikirim ulang. Setelah potongan terakhir file langsung diproses;
+// PATCH kosong pada offset akhir mengulang pemrosesan yang sebelumnya gagal karena error server
+func KirimPotonganUpload(w http.ResponseWriter, r *http.Request) {
+	sesi, ok := findUploadBertahap(w, r)
+	if !ok {
+		return
+	}
+	switch {
+	case sesi.Status == "Selesai":
+		helpers.Response(w, 200, "Upload sudah selesai", responUploadBertahap(w, sesi))
+		return
+	case sesi.Status == "Gagal":
+		helpers.Response(w, 410, "Upload gagal: "+sesi.Pesan, responUploadBertahap(w, sesi))
+		return
+	case time.Now().After(sesi.Kedaluwarsa):
+		helpers.Response(w, 410, "Upload sudah kedaluwarsa, mulai ulang upload", nil)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		helpers.Response(w, 400, "Header Upload-Offset wajib diisi", nil)
+		return
+	}
+	if offset != sesi.Diterima {
+		helpers.Response(w, 409, "Upload-Offset tidak sesuai, lanjutkan dari offset terakhir", responUploadBertahap(w, sesi))
+		return
+	}
+
+	sisa := sesi.Ukuran - sesi.Diterima
+	batas := min(sisa, ukuranPotonganMaks())
+	potongan, err := io.ReadAll(io.LimitReader(r.Body, batas+1))
+	if err != nil {
+		helpers.Response(w, 400, "Gagal membaca potongan", nil)
+		return
+	}
+	if int64(len(potongan)) > batas {
+		helpers.Response(w, 413, fmt.Sprintf("Potongan terlalu besar (maksimal %d byte)", batas), responUploadBertahap(w, sesi))
+		return
+	}
+	if len(potongan) == 0 && sisa > 0 {
+		helpers.Response(w, 400, "Potongan kosong", responUploadBertahap(w, sesi))
+		return
+	}
+	if len(potongan) > 0 {
+		h, harapan, err := bacaChecksumPotongan(r.Header.Get("Upload-Checksum"))
+		if err != nil {
+			helpers.Response(w, 400, err.Error(), nil)
+			return
+		}
+		h.Write(potongan)
+		if !bytes.Equal(h.Sum(nil), harapan) {
+			log.Printf("⚠️ Checksum mismatch on upload %s at offset %d", sesi.UploadID, offset)
+			helpers.Response(w, statusChecksumTidakCocok, "Checksum potongan tidak cocok, kirim ulang potongan", responUploadBertahap(w, sesi))
+			return
+		}
+	}
+
+	// Potongan dibaca dan dicek sebelum mengunci agar client yang lambat tidak menahan kunci
+	kunci, err := kunciSesiUpload(sesi.UploadID, offset)
+	if err != nil {
+		helpers.Response(w, 500, "Gagal mengunci upload", nil)
+		return
+	}
+	if kunci == nil {
+		config.DB.First(sesi, "upload_id = ?", sesi.UploadID)
+		helpers.Response(w, 409, "Potongan lain untuk upload ini sedang diproses atau offset sudah berubah", responUploadBertahap(w, sesi))
+		return
+	}
+	defer lepasKunciSesiUpload(sesi.UploadID, kunci)
+
+	if len(potongan) > 0 {
+		if err := storage.Default().Put(r.Context(), kunciPotonganUpload(sesi.UploadID, offset), bytes.NewReader(potongan), int64(len(potongan)), "application/octet-stream"); err != nil {
+			log.Printf("❌ Failed to store chunk for upload %s: %v", sesi.UploadID, err)
+			helpers.Response(w, 500, "Gagal menyimpan potongan", responUploadBertahap(w, sesi))
+			return
+		}
+
+		diterima := offset + int64(len(potongan))
+		kedaluwarsa := time.Now().Add(masaBerlakuUploadBertahap())
+		hasil := config.DB.Model(&models.UploadBertahap{}).
+			Where("upload_id = ? AND diterima = ? AND dikunci_sampai = ?", sesi.UploadID, offset, *kunci).
+			Updates(map[string]interface{}{"diterima": diterima, "kedaluwarsa": kedaluwarsa})
+		if hasil.Error != nil {
+			// Potongan ditulis ulang di offset yang sama pada percobaan berikutnya
+			helpers.Response(w, 500, "Gagal menyimpan status upload", nil)
+			return
+		}
+		if hasil.RowsAffected == 0 {
+			// Kunci kedaluwarsa dan diambil alih request lain
+			config.DB.First(sesi, "upload_id = ?", sesi.UploadID)
+			helpers.Response(w, 409, "Upload-Offset tidak sesuai, lanjutkan dari offset terakhir", responUploadBertahap(w, sesi))
+			return
+		}
+		sesi.Diterima, sesi.Kedaluwarsa = diterima, kedaluwarsa
+	}
+
+	if sesi.Diterima < sesi.Ukuran {
+		helpers.Response(w, 200, "Potongan diterima", responUploadBertahap(w, sesi))
+		return
+	}
+
+	hasil, galat := selesaikanUploadBertahap(r.Context(), sesi)
+	if galat != nil {
+		helpers.Response(w, galat.status, galat.pesan, responUploadBertahap(w, sesi))
+		return
+	}
+	data := responUploadBertahap(w, sesi)
+	for k, v := range hasil {
+		data[k] = v
+	}
+	log.Printf("🎉 Resumable upload %s completed - URL: %s", sesi.UploadID, hasil["url"])
+	helpers.Response(w, 200, "File uploaded successfully", data)
+}
+
+// errPotonganRusak - Potongan di storage tidak membentuk file yang utuh
+var errPotonganRusak = errors.New("potongan upload tidak lengkap")
+
+// gabungPotonganUpload - Menyalin potongan dari storage secara berurutan ke file sementara lokal
+// sambil menghitung checksum seluruh file. Potongan yang hilang atau ukuran yang tidak sesuai
+// menghasilkan errPotonganRusak
+func gabungPotonganUpload(ctx context.Context, sesi *models.UploadBertahap, tujuan io.Writer, h hash.Hash) error {
+	ukuran := make(map[string]int64)
+	if err := storage.Default().List(ctx, prefixPotonganUpload(sesi.UploadID), func(info storage.Info) error {
+		ukuran[info.Key] = info.Size
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	var offset int64
+	for offset < sesi.Ukuran {
+		key := kunciPotonganUpload(sesi.UploadID, offset)
+		size, ada := ukuran[key]
+		if !ada || size <= 0 {
+			return fmt.Errorf("%w: potongan offset %d tidak ada", errPotonganRusak, offset)
+		}
+
+		file, _, err := storage.Default().Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(io.MultiWriter(tujuan, h), file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		if n != size {
+			return fmt.Errorf("%w: potongan offset %d berukuran %d, seharusnya %d", errPotonganRusak, offset, n, size)
+		}
+		offset += n
+	}
+	if offset != sesi.Ukuran {
+		return fmt.Errorf("%w: total %d byte, seharusnya %d", errPotonganRusak, offset, sesi.Ukuran)
+	}
+	return nil
+}
+
+// hapusPotonganUpload - Menghapus semua potongan sebuah upload dari storage
+func hapusPotonganUpload(ctx context.Context, uploadID string) {
+	var keys []string
+	storage.Default().List(ctx, prefixPotonganUpload(uploadID), func(info storage.Info) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	for _, key := range keys {
+		if err := storage.Default().Delete(ctx, key); err != nil {
+			log.Printf("⚠️ Failed to delete chunk %s: %v", key, err)
+		}
+	}
+}
+
+// selesaikanUploadBertahap - Menggabungkan potongan, mencocokkan checksum seluruh file, lalu memvalidasi
+// dan menyimpan file. File yang rusak atau ditolak validasi menandai sesi Gagal; error server membiarkan
+// sesi Berjalan agar dapat dicoba lagi
+func selesaikanUploadBertahap(ctx context.Context, sesi *models.UploadBertahap) (map[string]string, *galatUpload) {
+	h, harapan, err := bacaChecksumPotongan(sesi.Checksum)
+	if err != nil {
+		return nil, tandaiUploadGagal(ctx, sesi, &galatUpload{400, "Checksum file tidak valid, mulai ulang upload"})
+	}
+
+	f, err := os.CreateTemp("", "pasti-upload-*")
+	if err != nil {
+		log.Printf("❌ Failed to create temp file for upload %s: %v", sesi.UploadID, err)
+		return nil, &galatUpload{500, "Gagal menggabungkan file upload"}
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := gabungPotonganUpload(ctx, sesi, f, h); err != nil {
+		log.Printf("❌ Failed to assemble upload %s: %v", sesi.UploadID, err)
+		if errors.Is(err, errPotonganRusak) {
+			return nil, tandaiUploadGagal(ctx, sesi, &galatUpload{422, "File upload tidak lengkap, mulai ulang upload"})
+		}
+		return nil, &galatUpload{500, "Gagal menggabungkan file upload"}
+	}
+	if !bytes.Equal(h.Sum(nil), harapan) {
+		log.Printf("⚠️ Whole-file checksum mismatch on upload %s", sesi.UploadID)
+		return nil, tandaiUploadGagal(ctx, sesi, &galatUpload{statusChecksumTidakCocok, "Checksum file tidak cocok, mulai ulang upload"})
+	}
+
+	hasil, galat := simpanFileUpload(ctx, f, metaUpload{
+		NamaAsli: sesi.NamaFile,
+		Ukuran:   sesi.Ukuran,
+		TipeUser: sesi.TipeUser,
+		UserID:   sesi.UserID,
+		TugasID:  sesi.TugasID,
+		Tujuan:   sesi.Tujuan,
+	})
+	if galat != nil {
+		if galat.status >= 500 {
+			return nil, galat
+		}
+		return nil, tandaiUploadGagal(ctx, sesi, galat)
+	}
+
+	sesi.Status, sesi.URLHasil = "Selesai", hasil["url"]
+	config.DB.Model(sesi).Updates(map[string]interface{}{"status": "Selesai", "url_hasil": hasil["url"]})
+	hapusPotonganUpload(ctx, sesi.UploadID)
+
+	return hasil, nil
+}
+
+// tandaiUploadGagal - Menandai sesi Gagal dengan pesan penolakan dan menghapus potongannya
+func tandaiUploadGagal(ctx context.Context, sesi *models.UploadBertahap, galat *galatUpload) *galatUpload {
+	sesi.Status, sesi.Pesan = "Gagal", galat.pesan
+	config.DB.Model(sesi).Updates(map[string]interface{}{"status": "Gagal", "pesan": galat.pesan})
+	hapusPotonganUpload(ctx, sesi.UploadID)
+	return galat
+}
+
+// BatalkanUploadBertahap - Membatalkan upload yang belum selesai dan menghapus potongannya
+func BatalkanUploadBertahap(w http.ResponseWriter, r *http.Request) {
+	sesi, ok := findUploadBertahap(w, r)
+	if !ok {
+		return
+	}
+	if sesi.Status == "Selesai" {
+		helpers.Response(w, 400, "Upload sudah selesai", nil)
+		return
+	}
+
+	if err := config.DB.Delete(sesi).Error; err != nil {
+		helpers.Response(w, 500, "Gagal membatalkan upload", nil)
+		return
+	}
+	hapusPotonganUpload(r.Context(), sesi.UploadID)
+	helpers.Response(w, 200, "Upload dibatalkan", nil)
+}
+
+// RunBersihkanUploadBertahapCron - Menghapus sesi kedaluwarsa beserta potongannya, riwayat sesi
+// selesai/gagal lebih dari 7 hari, dan potongan tanpa sesi
+func RunBersihkanUploadBertahapCron() {
+	ctx := context.Background()
+
+	var kedaluwarsa []models.UploadBertahap
+	config.DB.Where("status = ? AND kedaluwarsa < ? AND (dikunci_sampai IS NULL OR dikunci_sampai < ?)", "Berjalan", time.Now(), time.Now()).
+		Find(&kedaluwarsa)
+	for _, sesi := range kedaluwarsa {
+		config.DB.Delete(&sesi)
+		hapusPotonganUpload(ctx, sesi.UploadID)
+	}
+
+	config.DB.Where("status IN ? AND updated_at < ?", []string{"Selesai", "Gagal"}, time.Now().AddDate(0, 0, -7)).
+		Delete(&models.UploadBertahap{})
+
+	var aktif []string
+	config.DB.Model(&models.UploadBertahap{}).Where("status = ?", "Berjalan").Pluck("upload_id", &aktif)
+	masihAktif := make(map[string]bool, len(aktif))
+	for _, id := range aktif {
+		masihAktif[id] = true
+	}
+
+	// Potongan yatim (misal sesi sudah dihapus saat instance lain masih menulis)
+	var yatim []string
+	if err := storage.Default().List(ctx, "bertahap/", func(info storage.Info) error {
+		bagian := strings.Split(info.Key, "/")
+		if len(bagian) == 3 && !masihAktif[bagian[1]] && time.Since(info.ModTime) > masaBerlakuUploadBertahap() {
+			yatim = append(yatim, info.Key)
+		}
+		return nil
+	}); err != nil {
+		log.Printf("⚠️ Failed to list resumable upload chunks: %v", err)
+	}
+	for _, key := range yatim {
+		storage.Default().Delete(ctx, key)
+	}
+
+	if len(kedaluwarsa) > 0 || len(yatim) > 0 {
+		log.Printf("🧹 Resumable upload cleanup: %d sesi kedaluwarsa, %d potongan yatim dihapus", len(kedaluwarsa), len(yatim))
+	}
+}
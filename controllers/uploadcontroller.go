@@ -1,24 +1,48 @@
 package controllers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"Pasti/config"
 	"Pasti/helpers"
+	"Pasti/models"
+	"Pasti/storage"
 
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
-// UploadFileHandler - Handle file upload untuk tugas siswa
+// Batas ukuran satu file upload
+const maksUkuranUpload = 10 << 20
+
+// UploadFileHandler - Handle file upload untuk tugas siswa (maksimal 10MB dalam satu request;
+// file lebih besar memakai upload bertahap). Validasi dan penyimpanan di simpanFileUpload
 func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 	log.Println("📁 Upload request received")
-	
-	// Limit ukuran file 10MB
-	err := r.ParseMultipartForm(10 << 20)
+
+	tipeUser, userID := pemilikUpload(r)
+	if tipeUser == "" {
+		helpers.Response(w, 401, "unauthorized", nil)
+		return
+	}
+
+	// Limit ukuran file 10MB (ditambah ruang untuk field form lainnya)
+	r.Body = http.MaxBytesReader(w, r.Body, maksUkuranUpload+(1<<20))
+	err := r.ParseMultipartForm(maksUkuranUpload)
 	if err != nil {
 		log.Printf("❌ Error parsing multipart form: %v", err)
 		helpers.Response(w, 400, "File terlalu besar (maksimal 10MB)", nil)
@@ -35,66 +59,461 @@ func UploadFileHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("📄 File received: %s (size: %d bytes)", handler.Filename, handler.Size)
 
-	// Validasi tipe file
-	allowedExts := map[string]bool{
-		".pdf":  true,
-		".doc":  true,
-		".docx": true,
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".zip":  true,
-		".rar":  true,
-	}
-	ext := filepath.Ext(handler.Filename)
-	if !allowedExts[ext] {
-		log.Printf("❌ File extension not allowed: %s", ext)
-		helpers.Response(w, 400, "Format file tidak didukung", nil)
+	tugasID, tujuan, galat := metaUploadDariForm(r.FormValue("tugas_id"), r.FormValue("tujuan"), tipeUser, userID)
+	if galat != nil {
+		helpers.Response(w, galat.status, galat.pesan, nil)
+		return
+	}
+
+	if handler.Size > maksUkuranUpload {
+		pesan := "File terlalu besar (maksimal 10MB)"
+		if batasUkuranUpload(tugasID) > maksUkuranUpload {
+			pesan += ", gunakan upload bertahap /api/upload/bertahap untuk file besar"
+		}
+		helpers.Response(w, 400, pesan, nil)
+		return
+	}
+
+	hasil, galat := simpanFileUpload(r.Context(), file, metaUpload{
+		NamaAsli: handler.Filename,
+		Ukuran:   handler.Size,
+		TipeUser: tipeUser,
+		UserID:   userID,
+		TugasID:  tugasID,
+		Tujuan:   tujuan,
+	})
+	if galat != nil {
+		helpers.Response(w, galat.status, galat.pesan, nil)
 		return
 	}
 
+	log.Printf("🎉 Upload completed - URL: %s", hasil["url"])
+	helpers.Response(w, 200, "File uploaded successfully", hasil)
+}
+
+// metaUpload - Data file yang diupload beserta pemilik dan keperluannya
+type metaUpload struct {
+	NamaAsli string
+	Ukuran   int64
+	TipeUser string
+	UserID   int
+	TugasID  *int
+	Tujuan   string
+}
+
+// galatUpload - Penolakan upload dengan status HTTP dan pesan untuk client
+type galatUpload struct {
+	status int
+	pesan  string
+}
+
+// sumberUpload - Isi file yang dapat dibaca ulang (multipart.File atau file sementara upload bertahap)
+type sumberUpload interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+}
+
+// metaUploadDariForm - Validasi field tugas_id dan tujuan (keduanya opsional). Tugas harus milik kelas siswa
+// atau jadwal guru yang mengupload agar batas ukuran dan kuota tugas lain tidak bisa dipakai
+func metaUploadDariForm(tugasIDStr, tujuan, tipeUser string, userID int) (*int, string, *galatUpload) {
+	var tugasID *int
+	if tugasIDStr != "" {
+		id, err := strconv.Atoi(tugasIDStr)
+		if err != nil {
+			return nil, "", &galatUpload{400, "Invalid tugas ID"}
+		}
+		var jumlah int64
+		config.DB.Model(&models.Tugas{}).Where("tugas_id = ?", id).Count(&jumlah)
+		if jumlah == 0 {
+			return nil, "", &galatUpload{404, "Tugas tidak ditemukan"}
+		}
+		if !tugasBolehDiupload(id, tipeUser, userID) {
+			return nil, "", &galatUpload{403, "Anda tidak memiliki akses ke tugas ini"}
+		}
+		tugasID = &id
+	}
+
+	if tujuan == "" {
+		tujuan = "Lainnya"
+	} else if !tujuanUploadValid[tujuan] {
+		return nil, "", &galatUpload{400, "Tujuan upload tidak valid"}
+	}
+	return tugasID, tujuan, nil
+}
+
+// tugasBolehDiupload - Siswa hanya untuk tugas terbit di kelasnya, guru hanya untuk tugas di jadwal yang diajarnya
+func tugasBolehDiupload(tugasID int, tipeUser string, userID int) bool {
+	query := config.DB.Model(&models.Tugas{}).
+		Joins("JOIN jadwalpelajaran ON tugas.jadwal_id = jadwalpelajaran.jadwal_id").
+		Where("tugas.tugas_id = ?", tugasID)
+	switch tipeUser {
+	case "Admin":
+		return true
+	case "Siswa":
+		query = query.Joins("JOIN siswa ON siswa.kelas_id = jadwalpelajaran.kelas_id").
+			Where("siswa.siswa_id = ?", userID).
+			Where(kondisiTugasTerbit)
+	case "Guru":
+		query = query.Where("jadwalpelajaran.guru_id = ?", userID)
+	default:
+		return false
+	}
+	var jumlah int64
+	query.Count(&jumlah)
+	return jumlah > 0
+}
+
+// ekstensiUpload - Ekstensi file (huruf kecil) dan tipe isi yang wajib cocok, ok=false jika tidak diizinkan
+func ekstensiUpload(nama string) (string, string, bool) {
+	ext := strings.ToLower(filepath.Ext(nama))
+	tipe, ok := helpers.TipeFileEkstensi[ext]
+	return ext, tipe, ok
+}
+
+// simpanFileUpload - Validasi isi file lalu simpan ke storage "tugas/" dan catat di registry.
+// Isi file dicocokkan dengan ekstensinya (magic bytes), arsip diperiksa, kuota user/tugas dicek,
+// lalu file dipindai malware; file yang terdeteksi disimpan ke karantina
+func simpanFileUpload(ctx context.Context, file sumberUpload, meta metaUpload) (map[string]string, *galatUpload) {
+	// Validasi tipe file (ekstensi tidak peka huruf besar/kecil)
+	ext, tipeWajib, ok := ekstensiUpload(meta.NamaAsli)
+	if !ok {
+		log.Printf("❌ File extension not allowed: %s", ext)
+		return nil, &galatUpload{400, "Format file tidak didukung"}
+	}
+
+	if batas := batasUkuranUpload(meta.TugasID); meta.Ukuran > batas {
+		return nil, &galatUpload{413, fmt.Sprintf("File terlalu besar (maksimal %d MB)", batas>>20)}
+	}
+
+	// Validasi isi file dari magic bytes, bukan dari nama file
+	header := make([]byte, 512)
+	n, _ := file.ReadAt(header, 0)
+	if tipe := helpers.DeteksiTipeFile(header[:n]); tipe != tipeWajib {
+		log.Printf("❌ Content mismatch: ext %s, detected %q", ext, tipe)
+		return nil, &galatUpload{400, "Isi file tidak sesuai dengan format " + ext}
+	}
+
+	// Pemeriksaan arsip: jumlah entri, ukuran hasil ekstrak dan rasio kompresi (zip bomb)
+	var err error
+	switch ext {
+	case ".zip", ".docx":
+		err = helpers.PeriksaZip(file, meta.Ukuran, ext == ".docx", helpers.BatasArsipDariEnv())
+	case ".rar":
+		err = helpers.PeriksaRar(file, meta.Ukuran, helpers.BatasArsipDariEnv())
+	}
+	if err != nil {
+		log.Printf("❌ Archive rejected: %v", err)
+		return nil, &galatUpload{400, err.Error()}
+	}
+
+	// Kuota penyimpanan per user dan per tugas
+	if pesan, err := cekKuotaUpload(meta.TipeUser, meta.UserID, meta.TugasID, meta.Ukuran); err != nil {
+		log.Printf("❌ Failed to check quota: %v", err)
+		return nil, &galatUpload{500, "Gagal memeriksa kuota penyimpanan"}
+	} else if pesan != "" {
+		return nil, &galatUpload{413, pesan}
+	}
+
+	hash, err := hashFileUpload(file)
+	if err != nil {
+		log.Printf("❌ Failed to read file: %v", err)
+		return nil, &galatUpload{500, "Failed to save file"}
+	}
+
+	// Pemindaian malware (jika PEMINDAI_FILE diaktifkan)
+	hasil, err := pindaiFileUpload(ctx, file)
+	if err != nil {
+		log.Printf("⚠️ File scan failed: %v", err)
+		if helpers.PemindaiWajib() {
+			return nil, &galatUpload{503, "Pemindai file tidak tersedia, coba lagi nanti"}
+		}
+		hasil = helpers.HasilPindai{Bersih: true}
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, &galatUpload{500, "Failed to save file"}
+	}
+
 	// Buat nama file unik
 	uniqueID := uuid.New().String()
 	timestamp := time.Now().Format("20060102_150405")
 	filename := timestamp + "_" + uniqueID + ext
 	log.Printf("✅ Generated unique filename: %s", filename)
 
-	// Buat direktori uploads jika belum ada
-	uploadDir := "uploads/tugas"
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		log.Printf("❌ Failed to create directory %s: %v", uploadDir, err)
-		helpers.Response(w, 500, "Failed to create upload directory", nil)
+	catatan := models.FileUpload{
+		NamaAsli:    meta.NamaAsli,
+		Ukuran:      meta.Ukuran,
+		ContentType: mime.TypeByExtension(ext),
+		HashSHA256:  hash,
+		TipeUser:    meta.TipeUser,
+		UserID:      meta.UserID,
+		TugasID:     meta.TugasID,
+		Tujuan:      meta.Tujuan,
+		Status:      "Aktif",
+	}
+
+	// File terinfeksi disimpan ke karantina (tidak dapat diakses lewat /uploads) untuk ditinjau admin
+	if !hasil.Bersih {
+		catatan.Kunci = "karantina/" + filename
+		catatan.Status = "Karantina"
+		catatan.AlasanKarantina = hasil.Ancaman
+		log.Printf("🚫 Malware detected in %s (%s), quarantining", meta.NamaAsli, hasil.Ancaman)
+		if err := storage.Default().Put(ctx, catatan.Kunci, file, meta.Ukuran, catatan.ContentType); err != nil {
+			log.Printf("❌ Failed to quarantine file %s: %v", catatan.Kunci, err)
+		} else if err := config.DB.Create(&catatan).Error; err != nil {
+			log.Printf("❌ Failed to record quarantined file %s: %v", catatan.Kunci, err)
+		}
+		return nil, &galatUpload{422, "File terdeteksi mengandung malware (" + hasil.Ancaman + ") dan telah dikarantina"}
+	}
+
+	// Simpan file ke storage (disk lokal atau S3/MinIO sesuai STORAGE_BACKEND)
+	key := "tugas/" + filename
+	catatan.Kunci = key
+	if err := storage.Default().Put(ctx, key, file, meta.Ukuran, catatan.ContentType); err != nil {
+		log.Printf("❌ Failed to store file %s: %v", key, err)
+		return nil, &galatUpload{500, "Failed to save file"}
+	}
+	if err := config.DB.Create(&catatan).Error; err != nil {
+		log.Printf("❌ Failed to record upload %s: %v", key, err)
+		storage.Default().Delete(context.Background(), key)
+		return nil, &galatUpload{500, "Failed to save file"}
+	}
+
+	log.Printf("✅ File saved successfully: %s (%d bytes)", key, meta.Ukuran)
+
+	// Thumbnail untuk jawaban berupa gambar, ditampilkan di daftar pengumpulan guru
+	if kunciThumb := kunciThumbnail(key); kunciThumb != "" && meta.Ukuran <= maksUkuranGambarThumbnail {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			if data, err := io.ReadAll(file); err == nil {
+				if err := buatThumbnail(ctx, data, kunciThumb); err != nil {
+					log.Printf("⚠️ Failed to create thumbnail %s: %v", kunciThumb, err)
+				}
+			}
+		}
+	}
+
+	// Return URL file
+	return map[string]string{
+		"url":      "/uploads/" + key,
+		"filename": filename,
+		"original": meta.NamaAsli,
+	}, nil
+}
+
+// batasMaksUploadBertahap - UPLOAD_MAKS_MB (default 2048): batas tertinggi yang boleh diatur per tugas
+func batasMaksUploadBertahap() int64 {
+	return kuotaMBDariEnv("UPLOAD_MAKS_MB", 2048)
+}
+
+// batasUkuranUpload - Ukuran file maksimal: maks_ukuran_file_mb tugas jika diatur, selain itu 10MB
+func batasUkuranUpload(tugasID *int) int64 {
+	if tugasID == nil {
+		return maksUkuranUpload
+	}
+	var tugas models.Tugas
+	if err := config.DB.Select("tugas_id", "maks_ukuran_file_mb").Where("tugas_id = ?", *tugasID).First(&tugas).Error; err != nil ||
+		tugas.MaksUkuranFileMB == nil || *tugas.MaksUkuranFileMB <= 0 {
+		return maksUkuranUpload
+	}
+	return min(int64(*tugas.MaksUkuranFileMB)<<20, batasMaksUploadBertahap())
+}
+
+// tujuanUploadValid - Nilai field form tujuan (keperluan file)
+var tujuanUploadValid = map[string]bool{
+	"JawabanTugas":     true,
+	"LampiranTugas":    true,
+	"LampiranKomentar": true,
+	"Materi":           true,
+	"Lainnya":          true,
+}
+
+// pemilikUpload - Tipe dan ID user yang mengupload dari context AuthPengguna (ID admin selalu 0)
+func pemilikUpload(r *http.Request) (string, int) {
+	switch u := penggunaDariContext(r).(type) {
+	case *helpers.MyCustomClaims:
+		return "Siswa", u.ID
+	case *helpers.GuruCustomClaims:
+		return "Guru", u.ID
+	case *helpers.AdminCustomClaims:
+		return "Admin", 0
+	}
+	return "", 0
+}
+
+// kuotaMBDariEnv - Kuota dalam byte dari env (MB), 0 berarti tanpa batas
+func kuotaMBDariEnv(nama string, defaultMB int64) int64 {
+	if v, err := strconv.ParseInt(os.Getenv(nama), 10, 64); err == nil && v >= 0 {
+		return v << 20
+	}
+	return defaultMB << 20
+}
+
+// cekKuotaUpload - Pesan penolakan jika file baru melebihi kuota user (KUOTA_SISWA_MB default 200,
+// KUOTA_GURU_MB default 1024) atau kuota tugas (KUOTA_TUGAS_MB default 2048). File karantina tidak dihitung
+func cekKuotaUpload(tipeUser string, userID int, tugasID *int, ukuran int64) (string, error) {
+	var kuotaUser int64
+	switch tipeUser {
+	case "Siswa":
+		kuotaUser = kuotaMBDariEnv("KUOTA_SISWA_MB", 200)
+	case "Guru":
+		kuotaUser = kuotaMBDariEnv("KUOTA_GURU_MB", 1024)
+	}
+
+	if kuotaUser > 0 {
+		var terpakai int64
+		if err := config.DB.Model(&models.FileUpload{}).
+			Where("tipe_user = ? AND user_id = ? AND status = ?", tipeUser, userID, "Aktif").
+			Select("COALESCE(SUM(ukuran), 0)").Scan(&terpakai).Error; err != nil {
+			return "", err
+		}
+		if terpakai+ukuran > kuotaUser {
+			return fmt.Sprintf("Kuota penyimpanan Anda habis (terpakai %d MB dari %d MB)", terpakai>>20, kuotaUser>>20), nil
+		}
+	}
+
+	if kuotaTugas := kuotaMBDariEnv("KUOTA_TUGAS_MB", 2048); tugasID != nil && kuotaTugas > 0 {
+		var terpakai int64
+		if err := config.DB.Model(&models.FileUpload{}).
+			Where("tugas_id = ? AND status = ?", *tugasID, "Aktif").
+			Select("COALESCE(SUM(ukuran), 0)").Scan(&terpakai).Error; err != nil {
+			return "", err
+		}
+		if terpakai+ukuran > kuotaTugas {
+			return fmt.Sprintf("Kuota penyimpanan tugas ini habis (terpakai %d MB dari %d MB)", terpakai>>20, kuotaTugas>>20), nil
+		}
+	}
+	return "", nil
+}
+
+// hashFileUpload - SHA-256 seluruh isi file
+func hashFileUpload(file sumberUpload) (string, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// pindaiFileUpload - Memindai file dengan pemindai aktif; tanpa pemindai file dianggap bersih
+func pindaiFileUpload(ctx context.Context, file sumberUpload) (helpers.HasilPindai, error) {
+	pemindai := helpers.GetPemindaiFile()
+	if pemindai == nil {
+		return helpers.HasilPindai{Bersih: true}, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return helpers.HasilPindai{}, err
+	}
+	hasil, err := pemindai.Pindai(ctx, file)
+	if err != nil {
+		return helpers.HasilPindai{}, fmt.Errorf("%s: %w", pemindai.Nama(), err)
+	}
+	return hasil, nil
+}
+
+// GetFileKarantina - Admin melihat daftar file yang dikarantina
+func GetFileKarantina(w http.ResponseWriter, r *http.Request) {
+	var daftar []models.FileUpload
+	if err := config.DB.Where("status = ?", "Karantina").Order("created_at DESC").Find(&daftar).Error; err != nil {
+		helpers.Response(w, 500, "Gagal mengambil file karantina", nil)
 		return
 	}
-	log.Printf("📁 Upload directory ready: %s", uploadDir)
+	helpers.Response(w, 200, "Daftar file karantina", daftar)
+}
 
-	// Simpan file
-	filePath := filepath.Join(uploadDir, filename)
-	dst, err := os.Create(filePath)
+// findFileKarantina - File karantina berdasarkan {file_id}
+func findFileKarantina(w http.ResponseWriter, r *http.Request) (*models.FileUpload, bool) {
+	fileID, err := strconv.Atoi(mux.Vars(r)["file_id"])
 	if err != nil {
-		log.Printf("❌ Failed to create file %s: %v", filePath, err)
-		helpers.Response(w, 500, "Failed to create file", nil)
+		helpers.Response(w, 400, "Invalid file ID", nil)
+		return nil, false
+	}
+	var catatan models.FileUpload
+	if err := config.DB.Where("file_id = ? AND status = ?", fileID, "Karantina").First(&catatan).Error; err != nil {
+		helpers.Response(w, 404, "File karantina tidak ditemukan", nil)
+		return nil, false
+	}
+	return &catatan, true
+}
+
+// DeleteFileKarantina - Admin menghapus file karantina dari storage dan database
+func DeleteFileKarantina(w http.ResponseWriter, r *http.Request) {
+	catatan, ok := findFileKarantina(w, r)
+	if !ok {
+		return
+	}
+	if err := storage.Default().Delete(r.Context(), catatan.Kunci); err != nil {
+		log.Printf("❌ Failed to delete quarantined file %s: %v", catatan.Kunci, err)
+		helpers.Response(w, 500, "Gagal menghapus file", nil)
+		return
+	}
+	if err := config.DB.Delete(catatan).Error; err != nil {
+		helpers.Response(w, 500, "Gagal menghapus catatan file", nil)
+		return
+	}
+	helpers.Response(w, 200, "File karantina berhasil dihapus", nil)
+}
+
+// LepasFileKarantina - Admin melepas file karantina (false positive) ke tugas/ sehingga dapat dipakai
+func LepasFileKarantina(w http.ResponseWriter, r *http.Request) {
+	catatan, ok := findFileKarantina(w, r)
+	if !ok {
 		return
 	}
-	defer dst.Close()
 
-	// Copy file content
-	bytesWritten, err := io.Copy(dst, file)
+	sumber, info, err := storage.Default().Get(r.Context(), catatan.Kunci)
 	if err != nil {
-		log.Printf("❌ Failed to write file content: %v", err)
-		helpers.Response(w, 500, "Failed to save file", nil)
+		if errors.Is(err, storage.ErrNotFound) {
+			helpers.Response(w, 404, "File karantina tidak ada di storage", nil)
+		} else {
+			helpers.Response(w, 500, "Gagal membaca file", nil)
+		}
 		return
 	}
-	
-	log.Printf("✅ File saved successfully: %s (%d bytes)", filePath, bytesWritten)
-	// Return URL file
-	fileURL := "/uploads/tugas/" + filename
-	response := map[string]string{
-		"url":      fileURL,
-		"filename": filename,
-		"original": handler.Filename,
+	defer sumber.Close()
+
+	kunciBaru := "tugas/" + path.Base(catatan.Kunci)
+	if err := storage.Default().Put(r.Context(), kunciBaru, sumber, info.Size, catatan.ContentType); err != nil {
+		log.Printf("❌ Failed to release quarantined file %s: %v", catatan.Kunci, err)
+		helpers.Response(w, 500, "Gagal memindahkan file", nil)
+		return
 	}
+	kunciLama := catatan.Kunci
+	if err := config.DB.Model(catatan).Updates(map[string]interface{}{
+		"kunci":            kunciBaru,
+		"status":           "Aktif",
+		"alasan_karantina": nil,
+	}).Error; err != nil {
+		helpers.Response(w, 500, "Gagal memperbarui catatan file", nil)
+		return
+	}
+	storage.Default().Delete(r.Context(), kunciLama)
+
+	helpers.Response(w, 200, "File dilepas dari karantina", map[string]string{"url": "/uploads/" + kunciBaru})
+}
 
-	log.Printf("🎉 Upload completed - URL: %s", fileURL)
-	helpers.Response(w, 200, "File uploaded successfully", response)
+// kunciFileTugas - Key storage dari file_jawaban_siswa yang disimpan sebagai URL /uploads/tugas/<nama file>
+func kunciFileTugas(fileURL string) string {
+	return "tugas/" + path.Base(strings.Split(fileURL, "?")[0])
+}
+
+// fileUploadMilik - File /uploads/tugas/ tercatat di registry upload sebagai milik user dan tidak dikarantina
+func fileUploadMilik(fileURL, tipeUser string, userID int) bool {
+	var jumlah int64
+	config.DB.Model(&models.FileUpload{}).
+		Where("kunci = ? AND tipe_user = ? AND user_id = ? AND status = ?", kunciFileTugas(fileURL), tipeUser, userID, "Aktif").
+		Count(&jumlah)
+	return jumlah > 0
+}
+
+// bacaFileTugas - Seluruh isi file upload dari storage
+func bacaFileTugas(fileURL string) ([]byte, error) {
+	file, _, err := storage.Default().Get(context.Background(), kunciFileTugas(fileURL))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
 }
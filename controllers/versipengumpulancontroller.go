@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"Pasti/config"
+	"Pasti/helpers"
+	"Pasti/models"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetVersiPengumpulanGuru - Riwayat versi sebuah pengumpulan untuk guru pengampu
+func GetVersiPengumpulanGuru(w http.ResponseWriter, r *http.Request) {
+	guruInfo := r.Context().Value("guruinfo")
+	if guruInfo == nil {
+		helpers.Response(w, 401, "Unauthorized: no guru info in context", nil)
+		return
+	}
+
+	guru, ok := guruInfo.(*helpers.GuruCustomClaims)
+	if !ok {
+		helpers.Response(w, 401, "Unauthorized: invalid guru info format", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+	pengumpulanID, err := strconv.Atoi(vars["pengumpulan_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid pengumpulan ID", nil)
+		return
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	if err := config.DB.Where("pengumpulan_id = ?", pengumpulanID).First(&pengumpulan).Error; err != nil {
+		helpers.Response(w, 404, "Pengumpulan tugas not found", nil)
+		return
+	}
+
+	if _, err := findTugasGuru(pengumpulan.TugasID, guru.ID); err != nil {
+		helpers.Response(w, 403, "Access denied - not your class", nil)
+		return
+	}
+
+	var versiList []models.VersiPengumpulan
+	if err := config.DB.Where("pengumpulan_id = ?", pengumpulanID).
+		Order("nomor_versi DESC").
+		Find(&versiList).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch versi pengumpulan", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Versi pengumpulan berhasil diambil", map[string]interface{}{
+		"pengumpulan_id":   pengumpulan.PengumpulanID,
+		"versi_dinilai_id": pengumpulan.VersiDinilaiID,
+		"versi":            versiList,
+	})
+}
+
+// GetVersiPengumpulanSiswa - Riwayat versi pengumpulan milik siswa untuk sebuah tugas
+func GetVersiPengumpulanSiswa(w http.ResponseWriter, r *http.Request) {
+	siswa := r.Context().Value("siswainfo").(*helpers.MyCustomClaims)
+
+	vars := mux.Vars(r)
+	tugasID, err := strconv.Atoi(vars["tugas_id"])
+	if err != nil {
+		helpers.Response(w, 400, "Invalid tugas ID", nil)
+		return
+	}
+
+	var pengumpulan models.PengumpulanTugas
+	if err := config.DB.Where("tugas_id = ? AND siswa_id = ?", tugasID, siswa.ID).First(&pengumpulan).Error; err != nil {
+		helpers.Response(w, 404, "Pengumpulan tugas not found", nil)
+		return
+	}
+
+	var versiList []models.VersiPengumpulan
+	if err := config.DB.Where("pengumpulan_id = ?", pengumpulan.PengumpulanID).
+		Order("nomor_versi DESC").
+		Find(&versiList).Error; err != nil {
+		helpers.Response(w, 500, "Failed to fetch versi pengumpulan", nil)
+		return
+	}
+
+	helpers.Response(w, 200, "Versi pengumpulan berhasil diambil", versiList)
+}
+
+// catatVersiPengumpulan - Menyimpan isi pengumpulan saat ini sebagai versi terbaru
+func catatVersiPengumpulan(tx *gorm.DB, pengumpulan *models.PengumpulanTugas) error {
+	var nomorTerakhir int
+	if err := tx.Model(&models.VersiPengumpulan{}).
+		Where("pengumpulan_id = ?", pengumpulan.PengumpulanID).
+		Select("COALESCE(MAX(nomor_versi), 0)").
+		Scan(&nomorTerakhir).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.VersiPengumpulan{}).
+		Where("pengumpulan_id = ? AND is_current = ?", pengumpulan.PengumpulanID, true).
+		Update("is_current", false).Error; err != nil {
+		return err
+	}
+
+	versi := models.VersiPengumpulan{
+		PengumpulanID:      pengumpulan.PengumpulanID,
+		NomorVersi:         nomorTerakhir + 1,
+		FileJawabanSiswa:   pengumpulan.FileJawabanSiswa,
+		CatatanSiswa:       pengumpulan.CatatanSiswa,
+		TanggalPengumpulan: pengumpulan.TanggalPengumpulan,
+		StatusPengumpulan:  pengumpulan.StatusPengumpulan,
+		IsCurrent:          true,
+	}
+
+	return tx.Create(&versi).Error
+}
+
+// pilihVersiDinilai - Menandai versi yang dinilai (default versi terkini) dan
+// mengembalikan waktu pengumpulan versi tersebut
+func pilihVersiDinilai(pengumpulan *models.PengumpulanTugas, versiID int) (time.Time, error) {
+	var versi models.VersiPengumpulan
+	query := config.DB.Where("pengumpulan_id = ?", pengumpulan.PengumpulanID)
+	if versiID != 0 {
+		query = query.Where("versi_id = ?", versiID)
+	} else {
+		query = query.Where("is_current = ?", true)
+	}
+
+	if err := query.First(&versi).Error; err != nil {
+		if versiID != 0 {
+			return time.Time{}, err
+		}
+		// Pengumpulan lama tanpa riwayat versi
+		return pengumpulan.TanggalPengumpulan, nil
+	}
+
+	pengumpulan.VersiDinilaiID = &versi.VersiID
+	return versi.TanggalPengumpulan, nil
+}
+
+// cekKebijakanPengumpulanUlang - Validasi kebijakan pengumpulan ulang pada tugas
+func cekKebijakanPengumpulanUlang(tugas models.Tugas, pengumpulan models.PengumpulanTugas) error {
+	if tugas.TolakSetelahDinilai && pengumpulan.StatusPengumpulan == "Dinilai" {
+		return fmt.Errorf("tugas sudah dinilai, pengumpulan ulang tidak diizinkan")
+	}
+
+	if tugas.MaksPengumpulanUlang != nil {
+		var jumlahVersi int64
+		if err := config.DB.Model(&models.VersiPengumpulan{}).
+			Where("pengumpulan_id = ?", pengumpulan.PengumpulanID).
+			Count(&jumlahVersi).Error; err != nil {
+			return err
+		}
+
+		// Versi pertama bukan pengumpulan ulang
+		if jumlahVersi > 0 && int(jumlahVersi)-1 >= *tugas.MaksPengumpulanUlang {
+			return fmt.Errorf("batas pengumpulan ulang (%d kali) sudah tercapai", *tugas.MaksPengumpulanUlang)
+		}
+	}
+
+	return nil
+}
+
+// cekHapusPengumpulan - Pengumpulan tidak boleh dihapus jika tugas membatasi pengumpulan ulang (hapus lalu
+// kumpulkan lagi akan mengatur ulang hitungannya) atau sudah pernah dikumpulkan ulang (riwayat versi ikut terhapus)
+func cekHapusPengumpulan(tugas models.Tugas, pengumpulanIDs []int) error {
+	if tugas.MaksPengumpulanUlang != nil {
+		return fmt.Errorf("pengumpulan tidak dapat dihapus karena tugas membatasi pengumpulan ulang")
+	}
+
+	var jumlahUlang int64
+	if err := config.DB.Model(&models.VersiPengumpulan{}).
+		Where("pengumpulan_id IN ? AND nomor_versi > 1", pengumpulanIDs).
+		Count(&jumlahUlang).Error; err != nil {
+		return err
+	}
+	if jumlahUlang > 0 {
+		return fmt.Errorf("pengumpulan yang sudah dikumpulkan ulang tidak dapat dihapus")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@
 package cron
 
 import (
+	"Pasti/controllers"
 	"Pasti/helpers"
 	"log"
 	"time"
@@ -12,6 +13,9 @@ import (
 func StartCronJobs() {
     c := cron.New(cron.WithLocation(time.FixedZone("UTC", 0)))
     notifService := helpers.NewNotifikasiService()
+    
+    // Worker outbox mengirim reminder yang diantrekan cron notifikasi
+    notifService.MulaiWorkerOutbox()
 
 	go func() {
         log.Println("🚀 Running notification job immediately on startup...")
@@ -23,7 +27,36 @@ func StartCronJobs() {
         log.Println("🔄 Starting scheduled notification job...")
         notifService.RunNotificationCron()
     })
-    
+
+    // Tutup percobaan kuis yang waktunya habis setiap menit
+    c.AddFunc("* * * * *", func() {
+        controllers.RunSelesaikanKuisCron()
+    })
+
+    // Umumkan tugas terjadwal yang sudah terbit ke kotak masuk siswa setiap 5 menit
+    c.AddFunc("*/5 * * * *", func() {
+        controllers.RunNotifikasiTugasTerbitCron()
+    })
+
+    // Analisis plagiarisme file jawaban baru setiap 15 menit
+    c.AddFunc("*/15 * * * *", func() {
+        controllers.RunAnalisisPlagiarismeCron()
+    })
+
+    // Bersihkan sesi upload bertahap yang kedaluwarsa setiap jam
+    c.AddFunc("0 * * * *", func() {
+        controllers.RunBersihkanUploadBertahapCron()
+    })
+
+    // Hapus file upload yatim yang melewati masa retensi setiap hari pukul 03:00
+    c.AddFunc("0 3 * * *", func() {
+        controllers.RunGCUploadCron()
+    })
+
+    // Hapus pesan outbox notifikasi yang sudah terkirim setiap hari pukul 03:30
+    c.AddFunc("30 3 * * *", func() {
+        helpers.BersihkanOutboxNotifikasi()
+    })
     
     log.Println("⏰ Cron jobs started")
     c.Start()
@@ -0,0 +1,209 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+)
+
+// Pengolahan gambar dengan library standar Go: decode JPEG/PNG, rotasi sesuai orientasi EXIF,
+// potong persegi, ubah ukuran dan encode ulang ke JPEG. Encode ulang tidak menyalin metadata
+// apa pun sehingga EXIF (termasuk lokasi GPS) otomatis terhapus.
+
+// MaksPikselGambar - Batas jumlah piksel yang mau di-decode (mencegah "decompression bomb")
+const MaksPikselGambar = 40_000_000
+
+// ErrGambarTidakValid - File bukan gambar JPEG/PNG yang dapat dibaca
+var ErrGambarTidakValid = errors.New("gambar tidak valid")
+
+// BacaGambar - Decode JPEG/PNG, terapkan orientasi EXIF dan ratakan transparansi di atas latar putih
+func BacaGambar(data []byte) (*image.RGBA, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrGambarTidakValid
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaksPikselGambar {
+		return nil, fmt.Errorf("%w: ukuran %dx%d terlalu besar", ErrGambarTidakValid, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrGambarTidakValid
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Over)
+
+	if format == "jpeg" {
+		rgba = terapkanOrientasi(rgba, orientasiEXIF(data))
+	}
+	return rgba, nil
+}
+
+// orientasiEXIF - Nilai tag Orientation (0x0112) dari segmen APP1 Exif JPEG, 1 jika tidak ada
+func orientasiEXIF(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		panjang := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if marker == 0xDA || panjang < 2 || pos+2+panjang > len(data) {
+			break // awal data gambar atau segmen rusak
+		}
+		segmen := data[pos+4 : pos+2+panjang]
+		if marker == 0xE1 && len(segmen) > 14 && string(segmen[:6]) == "Exif\x00\x00" {
+			return orientasiTIFF(segmen[6:])
+		}
+		pos += 2 + panjang
+	}
+	return 1
+}
+
+// orientasiTIFF - Mencari tag Orientation di IFD0 header TIFF milik Exif
+func orientasiTIFF(tiff []byte) int {
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(bo.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	jumlah := int(bo.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < jumlah; i++ {
+		entri := ifd + 2 + i*12
+		if entri+12 > len(tiff) {
+			break
+		}
+		if bo.Uint16(tiff[entri:entri+2]) == 0x0112 {
+			if o := int(bo.Uint16(tiff[entri+8 : entri+10])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// terapkanOrientasi - Memutar/membalik gambar agar tampil tegak sesuai orientasi EXIF 1-8
+func terapkanOrientasi(src *image.RGBA, o int) *image.RGBA {
+	if o <= 1 || o > 8 {
+		return src
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// PotongPersegiTengah - Potongan persegi terbesar di tengah gambar (untuk avatar)
+func PotongPersegiTengah(src *image.RGBA) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	sisi := min(w, h)
+	x0, y0 := (w-sisi)/2, (h-sisi)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, sisi, sisi))
+	draw.Draw(dst, dst.Bounds(), src, image.Pt(src.Bounds().Min.X+x0, src.Bounds().Min.Y+y0), draw.Src)
+	return dst
+}
+
+// UkuranMuat - Ukuran baru dengan rasio sama yang muat dalam kotak maks x maks (tidak memperbesar)
+func UkuranMuat(w, h, maks int) (int, int) {
+	if w <= maks && h <= maks {
+		return w, h
+	}
+	if w >= h {
+		return maks, max(1, h*maks/w)
+	}
+	return max(1, w*maks/h), maks
+}
+
+// UbahUkuran - Mengubah ukuran dengan rata-rata area (box filter) sehingga hasil pengecilan tetap halus
+func UbahUkuran(src *image.RGBA, dw, dh int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		sy0 := y * sh / dh
+		sy1 := max(sy0+1, (y+1)*sh/dh)
+		for x := 0; x < dw; x++ {
+			sx0 := x * sw / dw
+			sx1 := max(sx0+1, (x+1)*sw/dw)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				i := src.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			di := dst.PixOffset(x, y)
+			dst.Pix[di] = uint8(r / n)
+			dst.Pix[di+1] = uint8(g / n)
+			dst.Pix[di+2] = uint8(b / n)
+			dst.Pix[di+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// EncodeJPEG - Encode gambar ke JPEG tanpa metadata
+func EncodeJPEG(img image.Image, kualitas int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: kualitas}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,73 @@
+package helpers
+
+import (
+	"log"
+	"strconv"
+	"sync"
+
+	"Pasti/config"
+	"Pasti/models"
+)
+
+// Penyebaran notifikasi in-app baru ke koneksi stream (SSE) yang sedang terbuka di instance ini.
+// Instance lain tetap menerima notifikasi lewat polling database di handler stream.
+
+var (
+	pelangganNotifikasi   = make(map[string]map[chan models.Notifikasi]struct{})
+	pelangganNotifikasiMu sync.Mutex
+)
+
+func kunciPelanggan(tipeUser string, userID int) string {
+	return tipeUser + ":" + strconv.Itoa(userID)
+}
+
+// BerlanggananNotifikasi - Channel notifikasi baru milik pengguna; panggil fungsi yang dikembalikan saat koneksi ditutup
+func BerlanggananNotifikasi(tipeUser string, userID int) (<-chan models.Notifikasi, func()) {
+	ch := make(chan models.Notifikasi, 16)
+	kunci := kunciPelanggan(tipeUser, userID)
+
+	pelangganNotifikasiMu.Lock()
+	if pelangganNotifikasi[kunci] == nil {
+		pelangganNotifikasi[kunci] = make(map[chan models.Notifikasi]struct{})
+	}
+	pelangganNotifikasi[kunci][ch] = struct{}{}
+	pelangganNotifikasiMu.Unlock()
+
+	return ch, func() {
+		pelangganNotifikasiMu.Lock()
+		delete(pelangganNotifikasi[kunci], ch)
+		if len(pelangganNotifikasi[kunci]) == 0 {
+			delete(pelangganNotifikasi, kunci)
+		}
+		pelangganNotifikasiMu.Unlock()
+	}
+}
+
+// SiarkanNotifikasi - Mengirim notifikasi ke semua stream milik penerimanya. Stream yang lambat
+// dilewati (notifikasi tetap terambil oleh polling berikutnya)
+func SiarkanNotifikasi(notif models.Notifikasi) {
+	pelangganNotifikasiMu.Lock()
+	defer pelangganNotifikasiMu.Unlock()
+	for ch := range pelangganNotifikasi[kunciPelanggan(notif.TipeUser, notif.UserID)] {
+		select {
+		case ch <- notif:
+		default:
+		}
+	}
+}
+
+// SimpanNotifikasiInApp - Menyimpan banyak notifikasi in-app sekaligus (misal untuk satu kelas)
+// lalu menyiarkannya ke stream yang terbuka
+func SimpanNotifikasiInApp(daftar []models.Notifikasi) error {
+	if len(daftar) == 0 {
+		return nil
+	}
+	if err := config.DB.CreateInBatches(&daftar, 200).Error; err != nil {
+		log.Printf("❌ Failed to save %d in-app notifications: %v", len(daftar), err)
+		return err
+	}
+	for _, n := range daftar {
+		SiarkanNotifikasi(n)
+	}
+	return nil
+}
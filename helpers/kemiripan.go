@@ -0,0 +1,359 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Utilitas deteksi kemiripan dokumen: ekstraksi teks (PDF, DOCX, teks biasa),
+// shingling kata dan tanda tangan MinHash untuk estimasi kemiripan Jaccard.
+
+const (
+	// UkuranShingle - Jumlah kata per shingle
+	UkuranShingle = 5
+	// JumlahHashMinHash - Panjang tanda tangan MinHash
+	JumlahHashMinHash = 128
+)
+
+// ErrFormatTidakDidukung - File tidak dapat diekstrak teksnya (gambar, arsip, dll)
+var ErrFormatTidakDidukung = errors.New("format file tidak didukung untuk ekstraksi teks")
+
+// ekstensiTeks - Ekstensi yang dibaca langsung sebagai teks biasa
+var ekstensiTeks = map[string]bool{
+	".txt": true, ".md": true, ".csv": true, ".rtf": true, ".html": true, ".htm": true,
+	".py": true, ".java": true, ".c": true, ".cpp": true, ".h": true, ".go": true,
+	".js": true, ".ts": true, ".php": true, ".sql": true, ".json": true, ".xml": true,
+}
+
+// EkstrakTeks - Mengambil teks dari isi file berdasarkan ekstensi nama file
+func EkstrakTeks(namaFile string, data []byte) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(namaFile)); {
+	case ext == ".pdf":
+		return ekstrakTeksPDF(data)
+	case ext == ".docx":
+		return ekstrakTeksDOCX(data)
+	case ekstensiTeks[ext]:
+		return string(bytes.ToValidUTF8(data, []byte(" "))), nil
+	default:
+		// File tanpa ekstensi dikenal tetap dianggap teks jika UTF-8 valid dan tanpa byte NUL
+		contoh := data
+		if len(contoh) > 8192 {
+			contoh = contoh[:8192]
+		}
+		if len(data) > 0 && utf8.Valid(contoh) && !bytes.ContainsRune(contoh, 0) {
+			return string(data), nil
+		}
+		return "", ErrFormatTidakDidukung
+	}
+}
+
+// ekstrakTeksDOCX - Teks dari word/document.xml (elemen w:t), paragraf dipisah baris baru
+func ekstrakTeksDOCX(data []byte) (string, error) {
+	arsip, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range arsip.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+
+		var b strings.Builder
+		decoder := xml.NewDecoder(rc)
+		dalamTeks := false
+		for {
+			token, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				switch t.Name.Local {
+				case "t":
+					dalamTeks = true
+				case "tab", "br":
+					b.WriteString(" ")
+				}
+			case xml.EndElement:
+				switch t.Name.Local {
+				case "t":
+					dalamTeks = false
+				case "p":
+					b.WriteString("\n")
+				}
+			case xml.CharData:
+				if dalamTeks {
+					b.Write(t)
+				}
+			}
+		}
+		return b.String(), nil
+	}
+
+	return "", errors.New("word/document.xml tidak ditemukan")
+}
+
+// polaStreamPDF - Kamus objek dan isi stream PDF
+var polaStreamPDF = regexp.MustCompile(`(?s)<<(.{0,1000}?)>>\s*stream\r?\n`)
+
+// ekstrakTeksPDF - Ekstraksi teks sederhana dari content stream PDF (operator Tj/TJ/'/").
+// Stream FlateDecode didekompresi, stream dengan filter lain (gambar) dilewati.
+// Font dengan encoding CID/hex tidak selalu terbaca sempurna, namun cukup untuk perbandingan
+func ekstrakTeksPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data[:min(len(data), 1024)]), []byte("%PDF")) {
+		return "", errors.New("file bukan PDF yang valid")
+	}
+
+	var b strings.Builder
+	for _, loc := range polaStreamPDF.FindAllSubmatchIndex(data, -1) {
+		kamus := string(data[loc[2]:loc[3]])
+		mulai := loc[1]
+		akhir := bytes.Index(data[mulai:], []byte("endstream"))
+		if akhir < 0 {
+			continue
+		}
+		isi := data[mulai : mulai+akhir]
+
+		if strings.Contains(kamus, "/Filter") {
+			if !strings.Contains(kamus, "/FlateDecode") || strings.Contains(kamus, "/DCTDecode") {
+				continue
+			}
+			rc, err := zlib.NewReader(bytes.NewReader(isi))
+			if err != nil {
+				continue
+			}
+			// Data rusak di akhir stream tetap dipakai sejauh yang terbaca
+			isi, _ = io.ReadAll(io.LimitReader(rc, 20<<20))
+			rc.Close()
+		}
+
+		if bytes.Contains(isi, []byte("BT")) {
+			teksContentStream(&b, isi)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// teksContentStream - Mengumpulkan string literal dan hex dari operator teks PDF
+func teksContentStream(b *strings.Builder, isi []byte) {
+	for i := 0; i < len(isi); i++ {
+		switch c := isi[i]; {
+		case c == '(':
+			teks, next := stringLiteralPDF(isi, i+1)
+			b.WriteString(teks)
+			i = next
+		case c == '<' && i+1 < len(isi) && isi[i+1] == '<':
+			// Kamus inline (misal properti marked content), bukan teks
+			i++
+		case c == '<':
+			akhir := bytes.IndexByte(isi[i:], '>')
+			if akhir < 0 {
+				return
+			}
+			if raw, err := hex.DecodeString(strings.Map(func(r rune) rune {
+				if unicode.IsSpace(r) {
+					return -1
+				}
+				return r
+			}, string(isi[i+1:i+akhir]))); err == nil {
+				b.WriteString(teksDapatDicetak(raw))
+			}
+			i += akhir
+		case c == ']' || c == '\'' || c == '"':
+			b.WriteString(" ")
+		case c == 'T' && i+1 < len(isi) && strings.IndexByte("dD*m", isi[i+1]) >= 0:
+			b.WriteString(" ")
+		case c == 'E' && i+1 < len(isi) && isi[i+1] == 'T':
+			b.WriteString("\n")
+		case c >= '0' && c <= '9' || c == '-':
+			// Kerning besar dalam array TJ biasanya berarti spasi antar kata
+			j := i
+			for j < len(isi) && (isi[j] == '-' || isi[j] == '.' || isi[j] >= '0' && isi[j] <= '9') {
+				j++
+			}
+			if angka, err := strconv.ParseFloat(string(isi[i:j]), 64); err == nil && angka < -200 {
+				b.WriteString(" ")
+			}
+			i = j - 1
+		}
+	}
+}
+
+// stringLiteralPDF - Membaca string (...) PDF mulai dari posisi setelah '(' dengan escape dan kurung bersarang
+func stringLiteralPDF(isi []byte, i int) (string, int) {
+	var raw []byte
+	kedalaman := 1
+	for ; i < len(isi); i++ {
+		c := isi[i]
+		switch c {
+		case '\\':
+			if i+1 >= len(isi) {
+				return teksDapatDicetak(raw), i
+			}
+			i++
+			switch e := isi[i]; e {
+			case 'n', 'r', 't':
+				raw = append(raw, ' ')
+			case 'b', 'f':
+			case '\r', '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(isi) && j < i+3 && isi[j] >= '0' && isi[j] <= '7' {
+						j++
+					}
+					oktal, _ := strconv.ParseUint(string(isi[i:j]), 8, 8)
+					raw = append(raw, byte(oktal))
+					i = j - 1
+				} else {
+					raw = append(raw, e)
+				}
+			}
+		case '(':
+			kedalaman++
+			raw = append(raw, c)
+		case ')':
+			kedalaman--
+			if kedalaman == 0 {
+				return teksDapatDicetak(raw), i
+			}
+			raw = append(raw, c)
+		default:
+			raw = append(raw, c)
+		}
+	}
+	return teksDapatDicetak(raw), i
+}
+
+// teksDapatDicetak - Byte PDF (Latin-1/WinAnsi) menjadi teks, karakter kontrol menjadi spasi
+func teksDapatDicetak(raw []byte) string {
+	var b strings.Builder
+	for _, c := range raw {
+		if c < 0x20 {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteRune(rune(c))
+	}
+	return b.String()
+}
+
+// NormalisasiKata - Memecah teks menjadi kata huruf kecil tanpa tanda baca
+func NormalisasiKata(teks string) []string {
+	return strings.FieldsFunc(strings.ToLower(teks), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Shingle - Himpunan hash shingle k kata berurutan. Teks yang lebih pendek dari k kata
+// menghasilkan satu shingle berisi seluruh kata
+func Shingle(kata []string, k int) map[uint64]struct{} {
+	hasil := make(map[uint64]struct{})
+	if len(kata) == 0 {
+		return hasil
+	}
+	if len(kata) < k {
+		k = len(kata)
+	}
+	for i := 0; i+k <= len(kata); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(kata[i:i+k], " ")))
+		hasil[h.Sum64()] = struct{}{}
+	}
+	return hasil
+}
+
+// koefisienMinHash - Koefisien fungsi hash a*x+b (mod 2^64) yang deterministik (splitmix64)
+var koefisienMinHash = func() [JumlahHashMinHash][2]uint64 {
+	var koef [JumlahHashMinHash][2]uint64
+	state := uint64(0x5eed)
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for i := range koef {
+		koef[i] = [2]uint64{next() | 1, next()}
+	}
+	return koef
+}()
+
+// MinHash - Tanda tangan MinHash dari himpunan shingle
+func MinHash(shingle map[uint64]struct{}) []uint64 {
+	tandaTangan := make([]uint64, JumlahHashMinHash)
+	for i := range tandaTangan {
+		tandaTangan[i] = ^uint64(0)
+	}
+	for s := range shingle {
+		for i, k := range koefisienMinHash {
+			if h := k[0]*s + k[1]; h < tandaTangan[i] {
+				tandaTangan[i] = h
+			}
+		}
+	}
+	return tandaTangan
+}
+
+// KemiripanMinHash - Estimasi kemiripan Jaccard (0-1) dari dua tanda tangan
+func KemiripanMinHash(a, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	sama := 0
+	for i := range a {
+		if a[i] == b[i] {
+			sama++
+		}
+	}
+	return float64(sama) / float64(len(a))
+}
+
+// EncodeMinHash - Tanda tangan MinHash sebagai string hex (16 karakter per hash) untuk disimpan di database
+func EncodeMinHash(tandaTangan []uint64) string {
+	var b strings.Builder
+	for _, h := range tandaTangan {
+		fmt.Fprintf(&b, "%016x", h)
+	}
+	return b.String()
+}
+
+// DecodeMinHash - Kebalikan EncodeMinHash, nil jika format tidak valid
+func DecodeMinHash(s string) []uint64 {
+	if len(s)%16 != 0 {
+		return nil
+	}
+	hasil := make([]uint64, 0, len(s)/16)
+	for i := 0; i < len(s); i += 16 {
+		h, err := strconv.ParseUint(s[i:i+16], 16, 64)
+		if err != nil {
+			return nil
+		}
+		hasil = append(hasil, h)
+	}
+	return hasil
+}
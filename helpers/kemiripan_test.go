@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+const teksContoh = `Fotosintesis adalah proses tumbuhan hijau mengubah energi cahaya matahari menjadi energi kimia.
+Proses ini terjadi di kloroplas dan menghasilkan glukosa serta oksigen dari karbon dioksida dan air.`
+
+func tandaTanganTeks(teks string) []uint64 {
+	return MinHash(Shingle(NormalisasiKata(teks), UkuranShingle))
+}
+
+func TestKemiripanMinHash(t *testing.T) {
+	asli := tandaTanganTeks(teksContoh)
+
+	if got := KemiripanMinHash(asli, tandaTanganTeks(teksContoh)); got != 1 {
+		t.Errorf("teks identik = %v, want 1", got)
+	}
+	// Normalisasi mengabaikan huruf besar dan tanda baca
+	if got := KemiripanMinHash(asli, tandaTanganTeks(strings.ToUpper(strings.ReplaceAll(teksContoh, ".", " !")))); got != 1 {
+		t.Errorf("teks identik beda huruf/tanda baca = %v, want 1", got)
+	}
+
+	lain := tandaTanganTeks(`Hukum Newton pertama menyatakan benda diam tetap diam dan benda bergerak tetap bergerak
+lurus beraturan kecuali ada gaya luar yang bekerja padanya menurut buku fisika kelas sepuluh.`)
+	if got := KemiripanMinHash(asli, lain); got > 0.05 {
+		t.Errorf("teks berbeda = %v, want ~0", got)
+	}
+
+	// Mengubah sebagian kecil teks tetap menghasilkan kemiripan tinggi tapi kurang dari 1
+	sebagian := tandaTanganTeks(strings.Replace(teksContoh, "glukosa", "gula", 1))
+	if got := KemiripanMinHash(asli, sebagian); got <= 0.4 || got >= 1 {
+		t.Errorf("teks diubah sedikit = %v, want antara 0.4 dan 1", got)
+	}
+
+	if got := KemiripanMinHash(asli, asli[:64]); got != 0 {
+		t.Errorf("panjang berbeda = %v, want 0", got)
+	}
+	if got := KemiripanMinHash(nil, nil); got != 0 {
+		t.Errorf("tanda tangan kosong = %v, want 0", got)
+	}
+}
+
+func TestShingle(t *testing.T) {
+	kata := NormalisasiKata("satu dua tiga empat lima enam")
+	if got := len(Shingle(kata, 5)); got != 2 {
+		t.Errorf("shingle 6 kata = %d, want 2", got)
+	}
+	if got := len(Shingle(kata[:3], 5)); got != 1 {
+		t.Errorf("teks lebih pendek dari k = %d shingle, want 1", got)
+	}
+	if got := len(Shingle(nil, 5)); got != 0 {
+		t.Errorf("teks kosong = %d shingle, want 0", got)
+	}
+	// Shingle yang berulang hanya dihitung sekali
+	if got := len(Shingle(NormalisasiKata("a b a b a b"), 2)); got != 2 {
+		t.Errorf("shingle berulang = %d, want 2", got)
+	}
+}
+
+func TestEncodeDecodeMinHash(t *testing.T) {
+	asli := tandaTanganTeks(teksContoh)
+	encoded := EncodeMinHash(asli)
+	if len(encoded) != JumlahHashMinHash*16 {
+		t.Fatalf("panjang encode = %d", len(encoded))
+	}
+	decoded := DecodeMinHash(encoded)
+	if len(decoded) != len(asli) {
+		t.Fatalf("decode = %d hash, want %d", len(decoded), len(asli))
+	}
+	for i := range asli {
+		if decoded[i] != asli[i] {
+			t.Fatalf("hash %d = %x, want %x", i, decoded[i], asli[i])
+		}
+	}
+
+	for _, rusak := range []string{
+		encoded[:len(encoded)-1],                 // panjang bukan kelipatan 16
+		"zz" + encoded[2:],                       // bukan hex
+		strings.Repeat("-", 16),                  // tanda minus
+		encoded[:16] + "0x00000000000000" + "xx", // awalan 0x
+	} {
+		if got := DecodeMinHash(rusak); got != nil {
+			t.Errorf("DecodeMinHash(%q...) = %d hash, want nil", rusak[:min(len(rusak), 20)], len(got))
+		}
+	}
+	if got := KemiripanMinHash(asli, DecodeMinHash("abc")); got != 0 {
+		t.Errorf("kemiripan dengan blob rusak = %v, want 0", got)
+	}
+}
+
+// pdfFlate - PDF minimal dengan satu content stream FlateDecode
+func pdfFlate(t *testing.T, content string) []byte {
+	t.Helper()
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write([]byte(content))
+	zw.Close()
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", stream.Len())
+	b.Write(stream.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+	// Stream gambar dengan filter lain dilewati
+	b.WriteString("5 0 obj\n<< /Length 6 /Filter /DCTDecode >>\nstream\nBT(xx)\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestEkstrakTeksPDF(t *testing.T) {
+	data := pdfFlate(t, "BT /F1 12 Tf 72 712 Td (Fotosintesis adalah) Tj T* [(pro) -10 (ses) -300 (tumbuhan)] TJ <4869646175> Tj (\\(hijau\\)) Tj ET")
+	teks, err := EkstrakTeks("jawaban.PDF", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kata := strings.Join(NormalisasiKata(teks), " ")
+	if kata != "fotosintesis adalah proses tumbuhan hidau hijau" {
+		t.Fatalf("teks PDF = %q (%q)", kata, teks)
+	}
+	if strings.Contains(teks, "xx") {
+		t.Fatalf("stream DCTDecode ikut dibaca: %q", teks)
+	}
+
+	if _, err := EkstrakTeks("palsu.pdf", []byte("bukan pdf")); err == nil {
+		t.Fatal("file bukan PDF tidak ditolak")
+	}
+}
+
+func TestEkstrakTeksDOCXDanTeks(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("word/document.xml")
+	f.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Paragraf</w:t><w:tab/><w:t>satu</w:t></w:r></w:p><w:p><w:r><w:t>dua</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+
+	teks, err := EkstrakTeks("jawaban.docx", buf.Bytes())
+	if err != nil || teks != "Paragraf satu\ndua\n" {
+		t.Fatalf("teks DOCX = %q, %v", teks, err)
+	}
+
+	if teks, err := EkstrakTeks("kode.go", []byte("package main\xff")); err != nil || teks != "package main " {
+		t.Fatalf("teks biasa = %q, %v", teks, err)
+	}
+	if teks, err := EkstrakTeks("CATATAN", []byte("tanpa ekstensi")); err != nil || teks != "tanpa ekstensi" {
+		t.Fatalf("tanpa ekstensi = %q, %v", teks, err)
+	}
+	if _, err := EkstrakTeks("foto.jpg", []byte{0xFF, 0xD8, 0xFF, 0x00}); !errors.Is(err, ErrFormatTidakDidukung) {
+		t.Fatalf("gambar = %v, want ErrFormatTidakDidukung", err)
+	}
+}
+
+func TestMinHashDeterministik(t *testing.T) {
+	// Tanda tangan disimpan di database, jadi harus sama di setiap proses
+	a := tandaTanganTeks(teksContoh)
+	b := tandaTanganTeks(teksContoh)
+	for i := range a {
+		if a[i] != b[i] || a[i] == math.MaxUint64 {
+			t.Fatalf("hash %d tidak deterministik atau kosong: %x %x", i, a[i], b[i])
+		}
+	}
+}
@@ -0,0 +1,567 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"Pasti/config"
+	"Pasti/models"
+)
+
+// Pengiriman notifikasi multi-kanal. Setiap kanal (WhatsApp lewat Fonnte, email SMTP, bot Telegram
+// dan notifikasi in-app) mengimplementasikan Notifier. DispatcherNotifikasi mencoba kanal sesuai
+// urutan preferensi pengguna dan pindah ke kanal berikutnya jika pengiriman gagal.
+
+// Nama kanal notifikasi
+const (
+	KanalWhatsApp = "whatsapp"
+	KanalEmail    = "email"
+	KanalTelegram = "telegram"
+	KanalInApp    = "inapp"
+)
+
+// KanalEksternal - Kanal yang bisa diatur urutannya oleh pengguna
+var KanalEksternal = []string{KanalWhatsApp, KanalEmail, KanalTelegram}
+
+// ErrPenerimaTanpaAlamat - Penerima tidak punya alamat untuk kanal tersebut (misal nomor telepon kosong)
+var ErrPenerimaTanpaAlamat = errors.New("penerima tidak punya alamat untuk kanal ini")
+
+// Penerima - Tujuan notifikasi beserta alamat di setiap kanal
+type Penerima struct {
+	TipeUser       string // Siswa / Guru
+	UserID         int
+	Nama           string
+	NoTelepon      string
+	Email          string
+	TelegramChatID string
+}
+
+// punyaAlamat - ErrPenerimaTanpaAlamat jika alamat penerima untuk kanal eksternal kosong
+func (p Penerima) punyaAlamat(kanal string) error {
+	alamat := map[string]string{KanalWhatsApp: p.NoTelepon, KanalEmail: p.Email, KanalTelegram: p.TelegramChatID}
+	if a, eksternal := alamat[kanal]; eksternal && a == "" {
+		return ErrPenerimaTanpaAlamat
+	}
+	return nil
+}
+
+// PesanNotifikasi - Isi notifikasi untuk semua kanal, dengan varian opsional per kanal
+type PesanNotifikasi struct {
+	Judul string
+	Isi   string
+	Link  string
+	// IsiLengkap - Isi sudah berisi judul dan penutup (pesan reminder) sehingga dikirim apa adanya
+	IsiLengkap bool
+	// IsiHTML - Versi HTML untuk email (dikirim bersama Isi sebagai multipart/alternative)
+	IsiHTML string
+	// Varian - Isi khusus kanal tertentu (misal format WhatsApp), kanal lain memakai isi utama
+	Varian map[string]PesanNotifikasi
+}
+
+// untukKanal - Varian pesan untuk kanal, atau pesan utama jika tidak ada varian
+func (p PesanNotifikasi) untukKanal(kanal string) PesanNotifikasi {
+	if v, ok := p.Varian[kanal]; ok {
+		return v
+	}
+	return p
+}
+
+// teksChat - Isi pesan untuk kanal chat (WhatsApp/Telegram)
+func (p PesanNotifikasi) teksChat() string {
+	if p.IsiLengkap {
+		return p.Isi
+	}
+	return fmt.Sprintf("🔔 *%s*\n\n%s\n\n---\n📱 Pesan otomatis dari Sistem PASTI", p.Judul, p.Isi)
+}
+
+// Notifier - Satu kanal pengiriman notifikasi. Kirim mengembalikan respon mentah penyedia (jika ada)
+type Notifier interface {
+	Kanal() string
+	Aktif() bool
+	Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error)
+}
+
+// envDefault - Nilai variabel lingkungan atau default jika kosong
+func envDefault(nama, def string) string {
+	if v := os.Getenv(nama); v != "" {
+		return v
+	}
+	return def
+}
+
+// bacaRespon - Membaca body respon (maks 64KB) untuk dicatat
+func bacaRespon(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return string(body)
+}
+
+// NotifierFonnte - WhatsApp lewat API Fonnte (FONNTE_URL, FONNTE_API_KEY)
+type NotifierFonnte struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+}
+
+// NewNotifierFonnte - Konfigurasi dari FONNTE_URL (default https://api.fonnte.com/send) dan FONNTE_API_KEY
+func NewNotifierFonnte() *NotifierFonnte {
+	return &NotifierFonnte{
+		URL:    envDefault("FONNTE_URL", "https://api.fonnte.com/send"),
+		APIKey: os.Getenv("FONNTE_API_KEY"),
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *NotifierFonnte) Kanal() string { return KanalWhatsApp }
+
+func (n *NotifierFonnte) Aktif() bool { return n.APIKey != "" }
+
+// NormalisasiNomorWhatsApp - Nomor telepon dalam format 08..., kosong jika tidak valid
+func NormalisasiNomorWhatsApp(noTelepon string) string {
+	var b strings.Builder
+	for _, c := range noTelepon {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	nomor := b.String()
+	if len(nomor) < 10 {
+		return ""
+	}
+	if len(nomor) >= 12 && nomor[0:2] == "62" {
+		return "0" + nomor[2:] // 62812... → 0812...
+	}
+	if nomor[0] != '0' {
+		return "0" + nomor // 812... → 0812...
+	}
+	return nomor
+}
+
+func (n *NotifierFonnte) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error) {
+	if penerima.NoTelepon == "" {
+		return "", ErrPenerimaTanpaAlamat
+	}
+	nomor := NormalisasiNomorWhatsApp(penerima.NoTelepon)
+	if nomor == "" {
+		return "", fmt.Errorf("nomor telepon tidak valid: %s", penerima.NoTelepon)
+	}
+
+	// Format target sesuai API Fonnte: nomor|a (a = WhatsApp)
+	payload, err := json.Marshal(map[string]string{"target": nomor + "|a", "message": pesan.teksChat()})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", n.APIKey)
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respon := bacaRespon(resp)
+	if resp.StatusCode != http.StatusOK {
+		return respon, fmt.Errorf("fonnte status %d", resp.StatusCode)
+	}
+	// Fonnte membalas 200 dengan {"status": false, "reason": ...} jika pesan ditolak
+	var hasil struct {
+		Status *bool  `json:"status"`
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal([]byte(respon), &hasil) == nil && hasil.Status != nil && !*hasil.Status {
+		return respon, fmt.Errorf("fonnte menolak pesan: %s", hasil.Reason)
+	}
+	return respon, nil
+}
+
+// NotifierEmail - Email lewat server SMTP (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM).
+// STARTTLS dipakai otomatis jika server mendukungnya
+type NotifierEmail struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewNotifierEmail - Konfigurasi SMTP dari variabel lingkungan (port default 587)
+func NewNotifierEmail() *NotifierEmail {
+	return &NotifierEmail{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     envDefault("SMTP_PORT", "587"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     envDefault("SMTP_FROM", os.Getenv("SMTP_USERNAME")),
+	}
+}
+
+func (n *NotifierEmail) Kanal() string { return KanalEmail }
+
+func (n *NotifierEmail) Aktif() bool { return n.Host != "" && n.From != "" }
+
+func (n *NotifierEmail) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error) {
+	if penerima.Email == "" {
+		return "", ErrPenerimaTanpaAlamat
+	}
+
+	isi := strings.ReplaceAll(pesan.Isi, "*", "")
+	if pesan.Link != "" && !pesan.IsiLengkap {
+		isi += "\n\n" + pesan.Link
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", penerima.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", pesan.Judul))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	if pesan.IsiHTML == "" {
+		msg.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n")
+		msg.WriteString(strings.ReplaceAll(isi, "\n", "\r\n"))
+	} else {
+		batas := fmt.Sprintf("pasti-%d", time.Now().UnixNano())
+		fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", batas)
+		fmt.Fprintf(&msg, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n%s\r\n", batas, strings.ReplaceAll(isi, "\n", "\r\n"))
+		fmt.Fprintf(&msg, "--%s\r\nContent-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: 8bit\r\n\r\n%s\r\n", batas, pesan.IsiHTML)
+		fmt.Fprintf(&msg, "--%s--\r\n", batas)
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	// smtp.SendMail tidak menerima context; batalkan lebih awal jika context sudah selesai
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := smtp.SendMail(net.JoinHostPort(n.Host, n.Port), auth, n.From, []string{penerima.Email}, msg.Bytes()); err != nil {
+		return "", err
+	}
+	return "250 OK", nil
+}
+
+// NotifierTelegram - Pesan lewat bot Telegram (TELEGRAM_API_URL, TELEGRAM_BOT_TOKEN)
+type NotifierTelegram struct {
+	APIURL string
+	Token  string
+	Client *http.Client
+}
+
+// NewNotifierTelegram - Konfigurasi dari TELEGRAM_API_URL (default https://api.telegram.org) dan TELEGRAM_BOT_TOKEN
+func NewNotifierTelegram() *NotifierTelegram {
+	return &NotifierTelegram{
+		APIURL: strings.TrimRight(envDefault("TELEGRAM_API_URL", "https://api.telegram.org"), "/"),
+		Token:  os.Getenv("TELEGRAM_BOT_TOKEN"),
+		Client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (n *NotifierTelegram) Kanal() string { return KanalTelegram }
+
+func (n *NotifierTelegram) Aktif() bool { return n.Token != "" }
+
+func (n *NotifierTelegram) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error) {
+	if penerima.TelegramChatID == "" {
+		return "", ErrPenerimaTanpaAlamat
+	}
+
+	teks := strings.ReplaceAll(pesan.teksChat(), "*", "")
+	if pesan.Link != "" && !pesan.IsiLengkap {
+		teks += "\n" + pesan.Link
+	}
+	payload, err := json.Marshal(map[string]string{"chat_id": penerima.TelegramChatID, "text": teks})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", n.APIURL+"/bot"+n.Token+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return "", tanpaURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return "", tanpaURL(err)
+	}
+	defer resp.Body.Close()
+	respon := bacaRespon(resp)
+
+	var hasil struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if json.Unmarshal([]byte(respon), &hasil) != nil || !hasil.OK {
+		return respon, fmt.Errorf("telegram status %d: %s", resp.StatusCode, hasil.Description)
+	}
+	return respon, nil
+}
+
+// tanpaURL - Error tanpa URL request. URL Telegram berisi token bot, sedangkan error ini dicatat di log
+// dan response_api yang ditampilkan ke admin
+func tanpaURL(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("telegram %s: %w", strings.ToLower(uerr.Op), uerr.Err)
+	}
+	return err
+}
+
+// NotifierInApp - Menyimpan notifikasi ke tabel notifikasi (selalu aktif)
+type NotifierInApp struct{}
+
+func (NotifierInApp) Kanal() string { return KanalInApp }
+
+func (NotifierInApp) Aktif() bool { return true }
+
+func (NotifierInApp) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error) {
+	notif := models.Notifikasi{
+		UserID:          penerima.UserID,
+		TipeUser:        penerima.TipeUser,
+		JudulNotifikasi: pesan.Judul,
+		PesanNotifikasi: pesan.Isi,
+		LinkTerkait:     pesan.Link,
+	}
+	if err := config.DB.WithContext(ctx).Create(&notif).Error; err != nil {
+		return "", err
+	}
+	SiarkanNotifikasi(notif)
+	return fmt.Sprintf("notifikasi_id=%d", notif.NotifikasiID), nil
+}
+
+// PercobaanKirim - Hasil satu percobaan pengiriman di satu kanal
+type PercobaanKirim struct {
+	Kanal    string `json:"kanal"`
+	Berhasil bool   `json:"berhasil"`
+	Respon   string `json:"respon,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HasilKirim - Ringkasan pengiriman ke satu penerima
+type HasilKirim struct {
+	// Terkirim - Pesan sampai di minimal satu kanal eksternal, atau di in-app jika tidak ada kanal eksternal yang berhasil
+	Terkirim      bool             `json:"terkirim"`
+	KanalTerkirim string           `json:"kanal_terkirim,omitempty"`
+	Percobaan     []PercobaanKirim `json:"percobaan"`
+}
+
+// Respon - Gabungan respon/error setiap percobaan untuk disimpan sebagai log
+func (h HasilKirim) Respon() string {
+	bagian := make([]string, 0, len(h.Percobaan))
+	for _, p := range h.Percobaan {
+		if p.Berhasil {
+			bagian = append(bagian, p.Kanal+": "+p.Respon)
+		} else {
+			bagian = append(bagian, p.Kanal+": "+p.Error)
+		}
+	}
+	return strings.Join(bagian, "\n")
+}
+
+// PreferensiKanal - Urutan kanal eksternal yang dicoba, apakah salinan in-app selalu disimpan dan bahasa pesan
+type PreferensiKanal struct {
+	Urutan      []string
+	SimpanInApp bool
+	Bahasa      string
+}
+
+// DispatcherNotifikasi - Mengirim notifikasi sesuai preferensi kanal dengan fallback ke kanal berikutnya
+type DispatcherNotifikasi struct {
+	notifier map[string]Notifier
+	pembatas map[string]*PembatasLaju // batas laju per penyedia, nil = tanpa batas
+	// Default - Preferensi untuk pengguna yang belum mengatur apa pun (NOTIFIKASI_KANAL_DEFAULT)
+	Default PreferensiKanal
+}
+
+// NewDispatcherNotifikasi - Dispatcher dengan daftar notifier tertentu
+func NewDispatcherNotifikasi(notifier ...Notifier) *DispatcherNotifikasi {
+	d := &DispatcherNotifikasi{
+		notifier: make(map[string]Notifier, len(notifier)),
+		pembatas: make(map[string]*PembatasLaju),
+		Default: PreferensiKanal{
+			Urutan:      ParseUrutanKanal(envDefault("NOTIFIKASI_KANAL_DEFAULT", "whatsapp,email,telegram")),
+			SimpanInApp: true,
+			Bahasa:      BahasaValid(os.Getenv("NOTIFIKASI_BAHASA_DEFAULT")),
+		},
+	}
+	for _, n := range notifier {
+		d.notifier[n.Kanal()] = n
+		if p := pembatasDariEnv(n.Kanal()); p != nil {
+			d.pembatas[n.Kanal()] = p
+		}
+	}
+	return d
+}
+
+// ParseUrutanKanal - Daftar kanal eksternal valid dari string dipisah koma, tanpa duplikat
+func ParseUrutanKanal(s string) []string {
+	urutan := []string{}
+	sudah := make(map[string]bool)
+	for _, k := range strings.Split(s, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if !sudah[k] && KanalValid(k) {
+			urutan = append(urutan, k)
+			sudah[k] = true
+		}
+	}
+	return urutan
+}
+
+// KanalValid - Apakah nama termasuk kanal eksternal yang dikenal
+func KanalValid(kanal string) bool {
+	for _, k := range KanalEksternal {
+		if k == kanal {
+			return true
+		}
+	}
+	return false
+}
+
+// KanalTersedia - Kanal eksternal yang sudah dikonfigurasi di server
+func (d *DispatcherNotifikasi) KanalTersedia() []string {
+	tersedia := []string{}
+	for _, k := range KanalEksternal {
+		if n, ok := d.notifier[k]; ok && n.Aktif() {
+			tersedia = append(tersedia, k)
+		}
+	}
+	return tersedia
+}
+
+// Kirim - Menyimpan salinan in-app (jika diminta) lalu mencoba kanal eksternal sesuai urutan sampai
+// satu berhasil. Kanal yang belum dikonfigurasi atau penerima tanpa alamat dilewati. Jika semua kanal
+// eksternal gagal dan salinan in-app tidak disimpan, in-app dipakai sebagai kanal terakhir
+func (d *DispatcherNotifikasi) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi, pref PreferensiKanal) HasilKirim {
+	return d.kirim(ctx, penerima, pesan, pref, !pref.SimpanInApp)
+}
+
+// kirim - Seperti Kirim; fallbackInApp menentukan apakah in-app dipakai jika semua kanal eksternal gagal.
+// Outbox menunda fallback ini sampai percobaan terakhir
+func (d *DispatcherNotifikasi) kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi, pref PreferensiKanal, fallbackInApp bool) HasilKirim {
+	hasil := HasilKirim{Percobaan: []PercobaanKirim{}}
+	inApp, adaInApp := d.notifier[KanalInApp]
+
+	inAppTersimpan := false
+	if pref.SimpanInApp && adaInApp {
+		inAppTersimpan = d.coba(ctx, &hasil, inApp, penerima, pesan)
+	}
+
+	for _, kanal := range pref.Urutan {
+		n, ok := d.notifier[kanal]
+		if !ok || !n.Aktif() {
+			continue
+		}
+		if d.coba(ctx, &hasil, n, penerima, pesan) {
+			hasil.Terkirim = true
+			hasil.KanalTerkirim = kanal
+			return hasil
+		}
+	}
+
+	if !inAppTersimpan && fallbackInApp && adaInApp {
+		inAppTersimpan = d.coba(ctx, &hasil, inApp, penerima, pesan)
+	}
+	if inAppTersimpan {
+		hasil.Terkirim = true
+		hasil.KanalTerkirim = KanalInApp
+	}
+	return hasil
+}
+
+// bisaEksternal - Apakah ada kanal eksternal pilihan pengguna yang aktif dan punya alamat penerima
+func (d *DispatcherNotifikasi) bisaEksternal(penerima Penerima, pref PreferensiKanal) bool {
+	for _, kanal := range pref.Urutan {
+		if n, ok := d.notifier[kanal]; ok && n.Aktif() && penerima.punyaAlamat(kanal) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// coba - Satu percobaan kirim setelah menunggu batas laju penyedia; penerima tanpa alamat tidak dicatat
+// sebagai percobaan
+func (d *DispatcherNotifikasi) coba(ctx context.Context, hasil *HasilKirim, n Notifier, penerima Penerima, pesan PesanNotifikasi) bool {
+	var respon string
+	err := penerima.punyaAlamat(n.Kanal())
+	if err == nil {
+		if p := d.pembatas[n.Kanal()]; p != nil {
+			err = p.Tunggu(ctx)
+		}
+	}
+	if err == nil {
+		respon, err = n.Kirim(ctx, penerima, pesan.untukKanal(n.Kanal()))
+	}
+	if errors.Is(err, ErrPenerimaTanpaAlamat) {
+		return false
+	}
+	percobaan := PercobaanKirim{Kanal: n.Kanal(), Berhasil: err == nil, Respon: respon}
+	if err != nil {
+		percobaan.Error = err.Error()
+		log.Printf("⚠️ Notifikasi %s ke %s %d gagal: %v", n.Kanal(), penerima.TipeUser, penerima.UserID, err)
+	}
+	hasil.Percobaan = append(hasil.Percobaan, percobaan)
+	return err == nil
+}
+
+// PreferensiPengguna - Preferensi kanal pengguna dari database, default jika belum diatur.
+// Chat ID Telegram yang tersimpan dikembalikan terpisah
+func (d *DispatcherNotifikasi) PreferensiPengguna(tipeUser string, userID int) (PreferensiKanal, string) {
+	var p models.PreferensiNotifikasi
+	if err := config.DB.Where("tipe_user = ? AND user_id = ?", tipeUser, userID).First(&p).Error; err != nil {
+		return d.Default, ""
+	}
+	return PreferensiKanal{Urutan: ParseUrutanKanal(p.UrutanKanal), SimpanInApp: p.SimpanInApp, Bahasa: BahasaValid(p.Bahasa)}, p.TelegramChatID
+}
+
+// KirimKePengguna - Kirim memakai preferensi yang disimpan pengguna
+func (d *DispatcherNotifikasi) KirimKePengguna(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) HasilKirim {
+	pref, chatID := d.PreferensiPengguna(penerima.TipeUser, penerima.UserID)
+	if penerima.TelegramChatID == "" {
+		penerima.TelegramChatID = chatID
+	}
+	return d.Kirim(ctx, penerima, pesan, pref)
+}
+
+var (
+	dispatcherDefault   *DispatcherNotifikasi
+	dispatcherDefaultMu sync.RWMutex
+)
+
+// GetDispatcherNotifikasi - Dispatcher bersama dengan semua kanal yang dikonfigurasi dari variabel lingkungan
+func GetDispatcherNotifikasi() *DispatcherNotifikasi {
+	dispatcherDefaultMu.RLock()
+	d := dispatcherDefault
+	dispatcherDefaultMu.RUnlock()
+	if d != nil {
+		return d
+	}
+
+	dispatcherDefaultMu.Lock()
+	defer dispatcherDefaultMu.Unlock()
+	if dispatcherDefault == nil {
+		dispatcherDefault = NewDispatcherNotifikasi(NewNotifierFonnte(), NewNotifierEmail(), NewNotifierTelegram(), NotifierInApp{})
+	}
+	return dispatcherDefault
+}
+
+// SetDispatcherNotifikasi - Mengganti dispatcher bersama (misal dengan notifier tiruan)
+func SetDispatcherNotifikasi(d *DispatcherNotifikasi) {
+	dispatcherDefaultMu.Lock()
+	dispatcherDefault = d
+	dispatcherDefaultMu.Unlock()
+}
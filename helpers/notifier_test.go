@@ -0,0 +1,474 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var penerimaUji = Penerima{
+	TipeUser:       "Siswa",
+	UserID:         7,
+	Nama:           "Budi",
+	NoTelepon:      "+62 812-3456-7890",
+	Email:          "budi@example.com",
+	TelegramChatID: "12345",
+}
+
+var pesanUji = PesanNotifikasi{Judul: "Tugas baru", Isi: "Ada *tugas* baru", Link: "http://localhost/tugas"}
+
+func TestNotifierFonnte(t *testing.T) {
+	kasus := []struct {
+		nama     string
+		status   int
+		body     string
+		galat    string // potongan pesan error, kosong = berhasil
+		penerima Penerima
+	}{
+		{nama: "berhasil", status: 200, body: `{"status":true,"detail":"success! message in queue"}`, penerima: penerimaUji},
+		{nama: "ditolak", status: 200, body: `{"status":false,"reason":"invalid target"}`, galat: "invalid target", penerima: penerimaUji},
+		{nama: "status bukan 200", status: 500, body: `internal error`, galat: "fonnte status 500", penerima: penerimaUji},
+		{nama: "nomor tidak valid", status: 200, body: `{"status":true}`, galat: "nomor telepon tidak valid", penerima: Penerima{NoTelepon: "123"}},
+	}
+
+	for _, k := range kasus {
+		t.Run(k.nama, func(t *testing.T) {
+			var diterima map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "kunci-uji" {
+					t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+				}
+				json.NewDecoder(r.Body).Decode(&diterima)
+				w.WriteHeader(k.status)
+				io.WriteString(w, k.body)
+			}))
+			defer server.Close()
+
+			n := &NotifierFonnte{URL: server.URL, APIKey: "kunci-uji", Client: server.Client()}
+			respon, err := n.Kirim(context.Background(), k.penerima, pesanUji)
+			if k.galat == "" {
+				if err != nil {
+					t.Fatalf("Kirim error: %v", err)
+				}
+				if diterima["target"] != "081234567890|a" || !strings.Contains(diterima["message"], "Tugas baru") {
+					t.Fatalf("payload = %v", diterima)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), k.galat) {
+				t.Fatalf("Kirim error = %v, want %q", err, k.galat)
+			}
+			if diterima != nil && respon != k.body {
+				t.Fatalf("respon = %q, want %q", respon, k.body)
+			}
+		})
+	}
+
+	n := &NotifierFonnte{URL: "http://127.0.0.1:1", APIKey: "kunci-uji", Client: http.DefaultClient}
+	if _, err := n.Kirim(context.Background(), Penerima{}, pesanUji); !errors.Is(err, ErrPenerimaTanpaAlamat) {
+		t.Fatalf("tanpa nomor = %v, want ErrPenerimaTanpaAlamat", err)
+	}
+}
+
+func TestNotifierTelegram(t *testing.T) {
+	kasus := []struct {
+		nama   string
+		status int
+		body   string
+		galat  string
+	}{
+		{nama: "berhasil", status: 200, body: `{"ok":true,"result":{"message_id":1}}`},
+		{nama: "ditolak", status: 200, body: `{"ok":false,"description":"chat not found"}`, galat: "chat not found"},
+		{nama: "status bukan 200", status: 403, body: `{"ok":false,"description":"Forbidden: bot was blocked by the user"}`, galat: "telegram status 403"},
+	}
+
+	for _, k := range kasus {
+		t.Run(k.nama, func(t *testing.T) {
+			var diterima map[string]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/botTOKEN-RAHASIA/sendMessage" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				json.NewDecoder(r.Body).Decode(&diterima)
+				w.WriteHeader(k.status)
+				io.WriteString(w, k.body)
+			}))
+			defer server.Close()
+
+			n := &NotifierTelegram{APIURL: server.URL, Token: "TOKEN-RAHASIA", Client: server.Client()}
+			respon, err := n.Kirim(context.Background(), penerimaUji, pesanUji)
+			if k.galat == "" {
+				if err != nil {
+					t.Fatalf("Kirim error: %v", err)
+				}
+				if diterima["chat_id"] != "12345" || strings.Contains(diterima["text"], "*") || !strings.Contains(diterima["text"], pesanUji.Link) {
+					t.Fatalf("payload = %v", diterima)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), k.galat) {
+				t.Fatalf("Kirim error = %v, want %q", err, k.galat)
+			}
+			if respon != k.body {
+				t.Fatalf("respon = %q, want %q", respon, k.body)
+			}
+		})
+	}
+}
+
+func TestNotifierTelegramErrorTanpaToken(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	n := &NotifierTelegram{APIURL: server.URL, Token: "TOKEN-RAHASIA", Client: http.DefaultClient}
+	_, err := n.Kirim(context.Background(), penerimaUji, pesanUji)
+	if err == nil {
+		t.Fatal("Kirim ke server yang mati tidak error")
+	}
+	if strings.Contains(err.Error(), "TOKEN-RAHASIA") || strings.Contains(err.Error(), "/bot") {
+		t.Fatalf("error memuat URL bot: %v", err)
+	}
+}
+
+// smtpTiruan - Server SMTP minimal untuk menguji NotifierEmail. tolakRCPT membalas RCPT dengan 550
+type smtpTiruan struct {
+	listener  net.Listener
+	tolakRCPT bool
+
+	mu    sync.Mutex
+	auth  string
+	rcpt  []string
+	pesan string
+}
+
+func jalankanSMTPTiruan(t *testing.T, tolakRCPT bool) *smtpTiruan {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpTiruan{listener: l, tolakRCPT: tolakRCPT}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.layani(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpTiruan) layani(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	balas := func(baris string) { io.WriteString(conn, baris+"\r\n") }
+
+	balas("220 smtp.tiruan ESMTP")
+	for {
+		baris, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		baris = strings.TrimRight(baris, "\r\n")
+		perintah := strings.ToUpper(baris)
+		switch {
+		case strings.HasPrefix(perintah, "EHLO"):
+			balas("250-smtp.tiruan")
+			balas("250 AUTH PLAIN")
+		case strings.HasPrefix(perintah, "AUTH PLAIN"):
+			s.mu.Lock()
+			s.auth = strings.TrimSpace(baris[len("AUTH PLAIN"):])
+			s.mu.Unlock()
+			balas("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(perintah, "MAIL FROM"):
+			balas("250 OK")
+		case strings.HasPrefix(perintah, "RCPT TO"):
+			if s.tolakRCPT {
+				balas("550 5.1.1 mailbox unavailable")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, baris)
+			s.mu.Unlock()
+			balas("250 OK")
+		case perintah == "DATA":
+			balas("354 End data with <CR><LF>.<CR><LF>")
+			var isi strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				isi.WriteString(l)
+			}
+			s.mu.Lock()
+			s.pesan = isi.String()
+			s.mu.Unlock()
+			balas("250 OK queued")
+		case perintah == "QUIT":
+			balas("221 Bye")
+			return
+		default:
+			balas("250 OK")
+		}
+	}
+}
+
+func notifierEmailTiruan(s *smtpTiruan) *NotifierEmail {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return &NotifierEmail{Host: host, Port: port, Username: "pasti", Password: "rahasia", From: "pasti@example.com"}
+}
+
+func TestNotifierEmail(t *testing.T) {
+	s := jalankanSMTPTiruan(t, false)
+	n := notifierEmailTiruan(s)
+
+	pesan := pesanUji
+	pesan.Judul = "Tugas baru ⏰"
+	pesan.IsiHTML = "<p>Ada tugas baru</p>"
+	respon, err := n.Kirim(context.Background(), penerimaUji, pesan)
+	if err != nil {
+		t.Fatalf("Kirim error: %v", err)
+	}
+	if respon != "250 OK" {
+		t.Fatalf("respon = %q", respon)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if auth, _ := base64.StdEncoding.DecodeString(s.auth); string(auth) != "\x00pasti\x00rahasia" {
+		t.Errorf("AUTH PLAIN = %q", auth)
+	}
+	if len(s.rcpt) != 1 || !strings.Contains(s.rcpt[0], "<budi@example.com>") {
+		t.Errorf("RCPT = %v", s.rcpt)
+	}
+	for _, bagian := range []string{
+		"To: budi@example.com",
+		"Subject: =?utf-8?q?Tugas_baru_=E2=8F=B0?=",
+		"multipart/alternative",
+		"Ada tugas baru\r\n\r\nhttp://localhost/tugas",
+		"<p>Ada tugas baru</p>",
+	} {
+		if !strings.Contains(s.pesan, bagian) {
+			t.Errorf("pesan tidak memuat %q:\n%s", bagian, s.pesan)
+		}
+	}
+}
+
+func TestNotifierEmailDitolakServer(t *testing.T) {
+	s := jalankanSMTPTiruan(t, true)
+	n := notifierEmailTiruan(s)
+
+	if _, err := n.Kirim(context.Background(), penerimaUji, pesanUji); err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("Kirim error = %v, want 550", err)
+	}
+	if _, err := n.Kirim(context.Background(), Penerima{}, pesanUji); !errors.Is(err, ErrPenerimaTanpaAlamat) {
+		t.Fatalf("tanpa email = %v, want ErrPenerimaTanpaAlamat", err)
+	}
+}
+
+// notifierTiruan - Notifier yang mencatat pemanggilan dan mengembalikan error tertentu
+type notifierTiruan struct {
+	kanal     string
+	nonaktif  bool
+	galat     error
+	dipanggil int
+}
+
+func (n *notifierTiruan) Kanal() string { return n.kanal }
+
+func (n *notifierTiruan) Aktif() bool { return !n.nonaktif }
+
+func (n *notifierTiruan) Kirim(ctx context.Context, penerima Penerima, pesan PesanNotifikasi) (string, error) {
+	n.dipanggil++
+	if n.galat != nil {
+		return "", n.galat
+	}
+	return n.kanal + " ok", nil
+}
+
+// dispatcherUji - Dispatcher dengan notifier tiruan tanpa batas laju
+func dispatcherUji(t *testing.T, notifier ...*notifierTiruan) *DispatcherNotifikasi {
+	t.Helper()
+	for _, kanal := range KanalEksternal {
+		t.Setenv("NOTIFIKASI_RATE_PER_MENIT_"+strings.ToUpper(kanal), "0")
+	}
+	daftar := make([]Notifier, len(notifier))
+	for i, n := range notifier {
+		daftar[i] = n
+	}
+	return NewDispatcherNotifikasi(daftar...)
+}
+
+// kanalPercobaan - Ringkasan percobaan "kanal:ok|gagal" dipisah koma
+func kanalPercobaan(h HasilKirim) string {
+	bagian := make([]string, len(h.Percobaan))
+	for i, p := range h.Percobaan {
+		status := "gagal"
+		if p.Berhasil {
+			status = "ok"
+		}
+		bagian[i] = p.Kanal + ":" + status
+	}
+	return strings.Join(bagian, ",")
+}
+
+func TestDispatcherKirim(t *testing.T) {
+	galat := errors.New("penyedia down")
+	semuaKanal := PreferensiKanal{Urutan: []string{KanalWhatsApp, KanalEmail, KanalTelegram}}
+
+	kasus := []struct {
+		nama      string
+		wa, email *notifierTiruan
+		telegram  *notifierTiruan
+		penerima  Penerima
+		pref      PreferensiKanal
+		terkirim  bool
+		kanal     string
+		percobaan string
+		inApp     int
+	}{
+		{
+			nama:      "kanal pertama berhasil",
+			wa:        &notifierTiruan{kanal: KanalWhatsApp},
+			penerima:  penerimaUji,
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalWhatsApp,
+			percobaan: "whatsapp:ok",
+		},
+		{
+			nama:      "pindah ke kanal berikutnya",
+			wa:        &notifierTiruan{kanal: KanalWhatsApp, galat: galat},
+			penerima:  penerimaUji,
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalEmail,
+			percobaan: "whatsapp:gagal,email:ok",
+		},
+		{
+			nama:      "urutan preferensi pengguna",
+			penerima:  penerimaUji,
+			pref:      PreferensiKanal{Urutan: []string{KanalTelegram, KanalWhatsApp}},
+			terkirim:  true,
+			kanal:     KanalTelegram,
+			percobaan: "telegram:ok",
+		},
+		{
+			nama:      "penerima tanpa nomor dilewati",
+			penerima:  Penerima{TipeUser: "Guru", UserID: 3, Email: "guru@example.com"},
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalEmail,
+			percobaan: "email:ok",
+		},
+		{
+			nama:      "kanal belum dikonfigurasi dilewati",
+			wa:        &notifierTiruan{kanal: KanalWhatsApp, nonaktif: true},
+			penerima:  penerimaUji,
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalEmail,
+			percobaan: "email:ok",
+		},
+		{
+			nama:      "fallback in-app jika semua kanal gagal",
+			wa:        &notifierTiruan{kanal: KanalWhatsApp, galat: galat},
+			email:     &notifierTiruan{kanal: KanalEmail, galat: galat},
+			telegram:  &notifierTiruan{kanal: KanalTelegram, galat: galat},
+			penerima:  penerimaUji,
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalInApp,
+			percobaan: "whatsapp:gagal,email:gagal,telegram:gagal,inapp:ok",
+			inApp:     1,
+		},
+		{
+			nama:      "fallback in-app jika penerima tanpa alamat",
+			penerima:  Penerima{TipeUser: "Siswa", UserID: 9},
+			pref:      semuaKanal,
+			terkirim:  true,
+			kanal:     KanalInApp,
+			percobaan: "inapp:ok",
+			inApp:     1,
+		},
+		{
+			nama:      "salinan in-app disimpan lebih dulu",
+			penerima:  penerimaUji,
+			pref:      PreferensiKanal{Urutan: []string{KanalEmail}, SimpanInApp: true},
+			terkirim:  true,
+			kanal:     KanalEmail,
+			percobaan: "inapp:ok,email:ok",
+			inApp:     1,
+		},
+		{
+			nama:      "salinan in-app tidak disimpan dua kali",
+			email:     &notifierTiruan{kanal: KanalEmail, galat: galat},
+			penerima:  penerimaUji,
+			pref:      PreferensiKanal{Urutan: []string{KanalEmail}, SimpanInApp: true},
+			terkirim:  true,
+			kanal:     KanalInApp,
+			percobaan: "inapp:ok,email:gagal",
+			inApp:     1,
+		},
+	}
+
+	for _, k := range kasus {
+		t.Run(k.nama, func(t *testing.T) {
+			if k.wa == nil {
+				k.wa = &notifierTiruan{kanal: KanalWhatsApp}
+			}
+			if k.email == nil {
+				k.email = &notifierTiruan{kanal: KanalEmail}
+			}
+			if k.telegram == nil {
+				k.telegram = &notifierTiruan{kanal: KanalTelegram}
+			}
+			inApp := &notifierTiruan{kanal: KanalInApp}
+			d := dispatcherUji(t, k.wa, k.email, k.telegram, inApp)
+
+			hasil := d.Kirim(context.Background(), k.penerima, pesanUji, k.pref)
+			if hasil.Terkirim != k.terkirim || hasil.KanalTerkirim != k.kanal {
+				t.Errorf("hasil = terkirim %v lewat %q, want %v lewat %q", hasil.Terkirim, hasil.KanalTerkirim, k.terkirim, k.kanal)
+			}
+			if got := kanalPercobaan(hasil); got != k.percobaan {
+				t.Errorf("percobaan = %s, want %s", got, k.percobaan)
+			}
+			if inApp.dipanggil != k.inApp {
+				t.Errorf("in-app dipanggil %d kali, want %d", inApp.dipanggil, k.inApp)
+			}
+		})
+	}
+}
+
+func TestDispatcherTanpaAlamatTidakMemanggilNotifier(t *testing.T) {
+	wa := &notifierTiruan{kanal: KanalWhatsApp}
+	email := &notifierTiruan{kanal: KanalEmail}
+	d := dispatcherUji(t, wa, email)
+
+	hasil := d.Kirim(context.Background(), Penerima{Email: "a@example.com"}, pesanUji, PreferensiKanal{Urutan: []string{KanalWhatsApp, KanalEmail}})
+	if wa.dipanggil != 0 {
+		t.Fatalf("notifier WhatsApp dipanggil %d kali untuk penerima tanpa nomor", wa.dipanggil)
+	}
+	if !hasil.Terkirim || hasil.KanalTerkirim != KanalEmail || email.dipanggil != 1 {
+		t.Fatalf("hasil = %+v", hasil)
+	}
+
+	// Tanpa notifier in-app, semua kanal gagal berarti tidak terkirim
+	wa.galat, email.galat = errors.New("down"), errors.New("down")
+	hasil = d.Kirim(context.Background(), penerimaUji, pesanUji, PreferensiKanal{Urutan: []string{KanalWhatsApp, KanalEmail}})
+	if hasil.Terkirim || kanalPercobaan(hasil) != "whatsapp:gagal,email:gagal" {
+		t.Fatalf("hasil = %+v", hasil)
+	}
+}
@@ -4,22 +4,13 @@ package helpers
 import (
 	"Pasti/config"
 	"Pasti/models"
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"io"
+	"context"
 	"log"
-	"net/http"
-	"os"
-	"sync"
 	"time"
 )
 
 type NotifikasiService struct {
-    FontteAPIKey string
-    FontteURL    string
-    cache        map[string]time.Time // Cache untuk mencegah duplikasi
-    cacheMutex   sync.RWMutex         // Mutex untuk thread safety
+    Dispatcher   *DispatcherNotifikasi // Pengiriman multi-kanal (WhatsApp, email, Telegram, in-app)
 }
 
 type WhatsAppMessage struct {
@@ -29,10 +20,7 @@ type WhatsAppMessage struct {
 
 func NewNotifikasiService() *NotifikasiService {
     return &NotifikasiService{
-        FontteAPIKey: os.Getenv("FONNTE_API_KEY"),
-        FontteURL:    "https://api.fonnte.com/send",
-        cache:        make(map[string]time.Time),
-        cacheMutex:   sync.RWMutex{},
+        Dispatcher:   GetDispatcherNotifikasi(),
     }
 }
 
@@ -47,31 +35,55 @@ type TugasWithSiswa struct {
     SiswaID            int       `json:"siswa_id"`
     NamaSiswa          string    `json:"nama_siswa"`
     NoTelepon          string    `json:"no_telepon"`
+    Email              string    `json:"email"`
     HasSubmitted       bool      `json:"has_submitted"`
 }
 
+// Untuk tugas kelompok, siswa tidak diingatkan lagi jika salah satu anggota kelompoknya sudah mengumpulkan
+const kelompokSudahKumpul = `
+                SELECT 1
+                FROM anggotakelompok ak
+                JOIN kelompoktugas kt ON kt.kelompok_id = ak.kelompok_id AND kt.tugas_id = t.tugas_id
+                JOIN anggotakelompok ak2 ON ak2.kelompok_id = ak.kelompok_id
+                JOIN pengumpulantugas pt2 ON pt2.tugas_id = t.tugas_id AND pt2.siswa_id = ak2.siswa_id
+                WHERE ak.siswa_id = s.siswa_id`
+
+const filterKelompokBelumKumpul = `
+            AND NOT EXISTS (` + kelompokSudahKumpul + `
+            )`
+
 // Main function untuk menjalankan cron job
 func (ns *NotifikasiService) RunNotificationCron() {
     log.Println("🔔 Running notification cron job...")
-      
-    ns.sendDeadlineReminders(24 * time.Hour, "1_hari")
+    
+    pengaturan := AmbilPengaturanReminder()
+    now := time.Now()
+    // Selama jam tenang reminder tetap diantrekan agar tidak terlewat, tapi baru dikirim saat jam tenang berakhir
+    kirimAt := AkhirJamTenang(pengaturan, now)
+    if kirimAt.After(now) {
+        log.Printf("🌙 Quiet hours (%s-%s), reminders queued for %s", pengaturan.JamTenangMulai, pengaturan.JamTenangSelesai, kirimAt.Format("2006-01-02 15:04 MST"))
+    }
+    
+    // Jendela setiap tingkat tidak tumpang tindih: deadline dalam (tingkat aktif berikutnya, tingkat ini].
+    // Contoh semua aktif: 3_hari = 24-72 jam lagi, 1_hari = 2-24 jam lagi, 2_jam = 0-2 jam lagi
+    tingkat := TingkatReminderAktif(pengaturan)
+    for i, t := range tingkat {
+        startTime := now
+        if i+1 < len(tingkat) {
+            startTime = now.Add(tingkat[i+1].Sebelum)
+        }
+        ns.sendDeadlineReminders(startTime, now.Add(t.Sebelum), t.Jenis, kirimAt)
+    }
+    
+    if pengaturan.ReminderLewatDeadline {
+        ns.sendOverdueReminders(pengaturan.LewatDeadlineMaksHari, kirimAt)
+    }
     
     log.Println("✅ Notification cron job completed")
 }
 
-func (ns *NotifikasiService) sendDeadlineReminders(duration time.Duration, jenisNotifikasi string) {
-    // targetDate := time.Now().Add(duration)
-    now := time.Now()
-    targetTime := now.Add(duration)
-    // startTime := targetTime.Add(-30 * time.Minute) // Buffer 30 menit
-    // endTime := targetTime.Add(30 * time.Minute)
-
-	// testing perpose
-	// startTime := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 0, 0, 0, 0, targetDate.Location())
-    // endTime := time.Date(targetDate.Year(), targetDate.Month(), targetDate.Day(), 23, 59, 59, 0, targetDate.Location())
-
-    startTime := now
-    endTime := targetTime
+// sendDeadlineReminders - Reminder untuk tugas dengan deadline dalam (startTime, endTime], dikirim mulai kirimAt
+func (ns *NotifikasiService) sendDeadlineReminders(startTime, endTime time.Time, jenisNotifikasi string, kirimAt time.Time) {
     var tugasList []TugasWithSiswa
     query := `
         SELECT DISTINCT
@@ -84,6 +96,7 @@ func (ns *NotifikasiService) sendDeadlineReminders(duration time.Duration, jenis
             s.siswa_id,
             s.nama_lengkap as nama_siswa,
             s.no_telepon,
+            s.email,
             CASE 
                 WHEN pt.pengumpulan_id IS NOT NULL THEN true 
                 ELSE false 
@@ -97,11 +110,11 @@ func (ns *NotifikasiService) sendDeadlineReminders(duration time.Duration, jenis
         LEFT JOIN notifikasi_tugas nt ON t.tugas_id = nt.tugas_id 
             AND s.siswa_id = nt.siswa_id 
             AND nt.jenis_notifikasi = ?
-        WHERE t.deadline_pengumpulan BETWEEN ? AND ?
-            AND s.no_telepon IS NOT NULL 
-            AND s.no_telepon != ''
+        WHERE t.deadline_pengumpulan > ? AND t.deadline_pengumpulan <= ?
             AND pt.pengumpulan_id IS NULL  -- Belum mengumpulkan
             AND nt.id IS NULL  -- Belum pernah dikirim notifikasi jenis ini
+            AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())  -- Tugas terjadwal belum terbit tidak diingatkan
+    ` + filterKelompokBelumKumpul + `
         ORDER BY t.deadline_pengumpulan ASC
     `
     
@@ -111,30 +124,18 @@ func (ns *NotifikasiService) sendDeadlineReminders(duration time.Duration, jenis
     }
     
     log.Printf("📋 Found %d students to notify (%s)", len(tugasList), jenisNotifikasi)
-      // Kirim notifikasi ke setiap siswa
+    // Antrekan ke outbox; pesan yang sudah pernah diantrekan (kunci idempotensi sama) dilewati
+    diantrekan := 0
     for _, tugas := range tugasList {
-        // Check cache untuk mencegah duplikasi rapid
-        cacheKey := fmt.Sprintf("%d_%d_%s", tugas.TugasID, tugas.SiswaID, jenisNotifikasi)
-        if ns.isRecentlySent(cacheKey) {
-            log.Printf("⏭️ Skipping %s for student %d (recently sent)", jenisNotifikasi, tugas.SiswaID)
-            continue
+        if ns.antreReminder(tugas, TemplateReminder, jenisNotifikasi, kirimAt) {
+            diantrekan++
         }
-        
-        message := ns.generateReminderMessage(tugas, jenisNotifikasi)
-        success := ns.sendWhatsAppMessage(tugas.NoTelepon, message)
-        
-        // Save notification record
-        ns.saveNotificationRecord(tugas.TugasID, tugas.SiswaID, jenisNotifikasi, success)
-        
-        // Update cache
-        ns.markAsSent(cacheKey)
-        
-        // Delay untuk menghindari rate limiting
-        time.Sleep(2 * time.Second)
     }
+    log.Printf("📮 Queued %d new %s reminders", diantrekan, jenisNotifikasi)
 }
 
-func (ns *NotifikasiService) sendOverdueReminders() {    // Query tugas yang sudah lewat deadline tapi belum dikumpulkan
+// sendOverdueReminders - Reminder harian untuk tugas yang lewat deadline (maksimal maksHari yang lalu) tapi belum dikumpulkan
+func (ns *NotifikasiService) sendOverdueReminders(maksHari int, kirimAt time.Time) {
     var tugasList []TugasWithSiswa
     query := `
         SELECT DISTINCT
@@ -147,6 +148,7 @@ func (ns *NotifikasiService) sendOverdueReminders() {    // Query tugas yang sud
             s.siswa_id,
             s.nama_lengkap as nama_siswa,
             s.no_telepon,
+            s.email,
             false as has_submitted
         FROM tugas t
         JOIN jadwalpelajaran jp ON t.jadwal_id = jp.jadwal_id
@@ -158,221 +160,75 @@ func (ns *NotifikasiService) sendOverdueReminders() {    // Query tugas yang sud
             AND nt.jenis_notifikasi = 'lewat_deadline'
             AND DATE(nt.tanggal_kirim) = CURDATE()  -- Sudah dikirim hari ini
         WHERE t.deadline_pengumpulan < NOW()
-            AND t.deadline_pengumpulan > DATE_SUB(NOW(), INTERVAL 7 DAY)  -- Maksimal 7 hari yang lalu
-            AND s.no_telepon IS NOT NULL 
-            AND s.no_telepon != ''
+            AND t.deadline_pengumpulan > ?  -- Maksimal maksHari yang lalu
             AND pt.pengumpulan_id IS NULL  -- Belum mengumpulkan
             AND nt.id IS NULL  -- Belum dikirim hari ini
-    `
+            AND (t.tanggal_terbit IS NULL OR t.tanggal_terbit <= NOW())  -- Tugas terjadwal belum terbit tidak diingatkan
+    ` + filterKelompokBelumKumpul
     
-    if err := config.DB.Raw(query).Scan(&tugasList).Error; err != nil {
+    if err := config.DB.Raw(query, time.Now().AddDate(0, 0, -maksHari)).Scan(&tugasList).Error; err != nil {
         log.Printf("❌ Error querying overdue tasks: %v", err)
         return
     }
     
     log.Printf("⏰ Found %d overdue tasks to notify", len(tugasList))
-      for _, tugas := range tugasList {
-        // Check cache untuk mencegah duplikasi rapid
-        cacheKey := fmt.Sprintf("%d_%d_lewat_deadline", tugas.TugasID, tugas.SiswaID)
-        if ns.isRecentlySent(cacheKey) {
-            log.Printf("⏭️ Skipping overdue notification for student %d (recently sent)", tugas.SiswaID)
-            continue
+    diantrekan := 0
+    for _, tugas := range tugasList {
+        if ns.antreReminder(tugas, TemplateLewatDeadline, "lewat_deadline", kirimAt) {
+            diantrekan++
         }
-        
-        message := ns.generateOverdueMessage(tugas)
-        success := ns.sendWhatsAppMessage(tugas.NoTelepon, message)
-        
-        ns.saveNotificationRecord(tugas.TugasID, tugas.SiswaID, "lewat_deadline", success)
-        ns.markAsSent(cacheKey)
-        time.Sleep(2 * time.Second)
-    }
-}
-
-func (ns *NotifikasiService) generateReminderMessage(tugas TugasWithSiswa, jenisNotifikasi string) string {
-    var timeMsg string
-    switch jenisNotifikasi {
-    case "3_hari":
-        timeMsg = "3 hari lagi"
-    case "1_hari":
-        timeMsg = "besok"
-    case "2_jam":
-        timeMsg = "2 jam lagi"
     }
-    
-    deadline := tugas.DeadlinePengumpulan
-    
-    message := fmt.Sprintf(`🔔 *REMINDER TUGAS* 🔔
-
-Halo %s!
-
-📚 *Tugas:* %s
-📖 *Mata Pelajaran:* %s
-🏫 *Kelas:* %s
-⏰ *Deadline:* %s (%s)
-
-⚠️ Jangan lupa untuk mengumpulkan tugas ya!
-
-Akses Tugas Disini yaa!:
-🌐 http://localhost:5174/tugas
-
----
-📱 Pesan otomatis dari Sistem PASTI`,
-        tugas.NamaSiswa,
-        tugas.JudulTugas,
-        tugas.NamaMapel,
-        tugas.NamaKelas,
-        deadline.Format("02 Jan 2006 15:04"),
-        timeMsg,
-    )
-    
-    return message
-}
-
-func (ns *NotifikasiService) generateOverdueMessage(tugas TugasWithSiswa) string {
-    deadline := tugas.DeadlinePengumpulan
-    
-    message := fmt.Sprintf(`⚠️ *TUGAS TERLAMBAT* ⚠️
-
-Halo %s!
-
-📚 *Tugas:* %s
-📖 *Mata Pelajaran:* %s
-🏫 *Kelas:* %s
-⏰ *Deadline:* %s (SUDAH LEWAT)
-
-🚨 Tugas ini sudah melewati deadline. Segera hubungi guru pengampu untuk menanyakan apakah masih bisa dikumpulkan terlambat.
-
-Akses sistem PASTI:
-🌐 http://localhost:5174/tugas-siswa
-
----
-📱 Pesan otomatis dari Sistem PASTI`,
-        tugas.NamaSiswa,
-        tugas.JudulTugas,
-        tugas.NamaMapel,
-        tugas.NamaKelas,
-        deadline.Format("02 Jan 2006 15:04"),
-    )
-    
-    return message
+    log.Printf("📮 Queued %d new overdue reminders", diantrekan)
 }
 
-func (ns *NotifikasiService) sendWhatsAppMessage(phoneNumber, message string) bool {
-    // Clean nomor telepon (hapus karakter non-digit)
-    cleanNumber := ""
-    for _, char := range phoneNumber {
-        if char >= '0' && char <= '9' {
-            cleanNumber += string(char)
-        }
-    }
-    
-    // Validasi nomor telepon
-    if len(cleanNumber) < 10 {
-        log.Printf("❌ Invalid phone number: %s", phoneNumber)
-        return false
-    }
-    
-    // Format nomor telepon untuk Fonnte API
-    if len(cleanNumber) >= 12 && cleanNumber[0:2] == "62" {
-        cleanNumber = "0" + cleanNumber[2:] // 62812... → 0812...
-    } else if cleanNumber[0] != '0' {
-        cleanNumber = "0" + cleanNumber      // 812... → 0812...
-    }
-    
-    // Format target sesuai API Fonnte: nomor|a (a = WhatsApp)
-    target := cleanNumber + "|a"
-    
-    payload := map[string]string{
-        "target":  target,
-        "message": message,
-    }
-    
-    jsonData, err := json.Marshal(payload)
-    if err != nil {
-        log.Printf("❌ Error marshaling JSON: %v", err)
-        return false
-    }
-    
-    log.Printf("📱 Sending to target: %s", target)
-    
-    req, err := http.NewRequest("POST", ns.FontteURL, bytes.NewBuffer(jsonData))
-    if err != nil {
-        log.Printf("❌ Error creating request: %v", err)
-        return false
-    }
-    
-    // Header sesuai dokumentasi Fonnte
-    req.Header.Set("Content-Type", "application/json")
-    req.Header.Set("Authorization", ns.FontteAPIKey)
-    
-    client := &http.Client{Timeout: 30 * time.Second}
-    resp, err := client.Do(req)
-    if err != nil {
-        log.Printf("❌ Error sending request: %v", err)
-        return false
-    }
-    defer resp.Body.Close()
-    
-    // Read response body untuk logging
-    var responseBody []byte
-    if resp.Body != nil {
-        responseBody, _ = io.ReadAll(resp.Body)
-    }
-    
-    log.Printf("📊 Fonnte API Response: Status=%d, Body=%s", resp.StatusCode, string(responseBody))
-    
-    if resp.StatusCode == 200 {
-        log.Printf("✅ WhatsApp sent to %s", target)
-        return true
+// kirimKeSiswa - Satu percobaan kirim reminder ke siswa lewat kanal pilihannya. Pesan dirender dari template
+// notifikasi dalam bahasa pilihan siswa. Salinan in-app hanya disimpan di percobaan pertama, dan fallback
+// in-app ditunda sampai percobaan terakhir kecuali tidak ada kanal eksternal yang bisa dicoba
+func (ns *NotifikasiService) kirimKeSiswa(tugas TugasWithSiswa, jenisTemplate, jenisNotifikasi string, percobaanSebelumnya int, terakhir bool) HasilKirim {
+    pref, chatID := ns.Dispatcher.PreferensiPengguna("Siswa", tugas.SiswaID)
+    penerima := Penerima{
+        TipeUser:       "Siswa",
+        UserID:         tugas.SiswaID,
+        Nama:           tugas.NamaSiswa,
+        NoTelepon:      tugas.NoTelepon,
+        Email:          tugas.Email,
+        TelegramChatID: chatID,
+    }
+    data := DataTemplateDariTugas(tugas, jenisNotifikasi, pref.Bahasa)
+    pesan := RenderPesanNotifikasi(jenisTemplate, pref.Bahasa, data, "/tugas")
+    
+    kirimPref := pref
+    if percobaanSebelumnya > 0 {
+        kirimPref.SimpanInApp = false
+    }
+    fallback := !pref.SimpanInApp && (terakhir || !ns.Dispatcher.bisaEksternal(penerima, pref))
+    
+    hasil := ns.Dispatcher.kirim(context.Background(), penerima, pesan, kirimPref, fallback)
+    if hasil.Terkirim {
+        log.Printf("✅ Reminder sent to student %d via %s", tugas.SiswaID, hasil.KanalTerkirim)
     } else {
-        log.Printf("❌ Failed to send to %s, status: %d, response: %s", target, resp.StatusCode, string(responseBody))
-        return false
+        log.Printf("❌ Failed to send reminder to student %d", tugas.SiswaID)
     }
+    return hasil
 }
 
-func (ns *NotifikasiService) saveNotificationRecord(tugasID, siswaID int, jenisNotifikasi string, success bool) {
-    status := "terkirim"
-    if !success {
-        status = "gagal"
-    }
-    
+// saveNotificationRecord - Hasil akhir reminder (terkirim/gagal) beserta respon penyedia di setiap percobaan
+func (ns *NotifikasiService) saveNotificationRecord(tugasID, siswaID int, jenisNotifikasi, status, respon string) {
     notifikasi := models.NotifikasiTugas{
         TugasID:          tugasID,
         SiswaID:          siswaID,
         JenisNotifikasi:  jenisNotifikasi,
         TanggalKirim:     time.Now(),
         Status:           status,
+        ResponseAPI:      respon,
     }
       if err := config.DB.Create(&notifikasi).Error; err != nil {
         log.Printf("❌ Error saving notification record: %v", err)
     }
 }
 
-// Cache methods untuk mencegah duplikasi rapid
-func (ns *NotifikasiService) isRecentlySent(cacheKey string) bool {
-    ns.cacheMutex.RLock()
-    defer ns.cacheMutex.RUnlock()
-    
-    sentTime, exists := ns.cache[cacheKey]
-    if !exists {
-        return false
-    }
-    
-    // Cek apakah sudah lewat 1 jam
-    return time.Since(sentTime) < time.Hour
+// KirimPesan - Mengirim notifikasi di luar cron reminder (komentar guru, nilai baru, dll) lewat
+// kanal pilihan penerima dengan fallback ke kanal berikutnya
+func (ns *NotifikasiService) KirimPesan(penerima Penerima, pesan PesanNotifikasi) HasilKirim {
+    return ns.Dispatcher.KirimKePengguna(context.Background(), penerima, pesan)
 }
-
-func (ns *NotifikasiService) markAsSent(cacheKey string) {
-    ns.cacheMutex.Lock()
-    defer ns.cacheMutex.Unlock()
-    
-    ns.cache[cacheKey] = time.Now()
-    
-    // Cleanup cache entries yang sudah lama (lebih dari 24 jam)
-    cutoff := time.Now().Add(-24 * time.Hour)
-    for key, timestamp := range ns.cache {
-        if timestamp.Before(cutoff) {
-            delete(ns.cache, key)
-        }
-    }
-}
\ No newline at end of file
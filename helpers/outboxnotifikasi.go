@@ -0,0 +1,436 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"Pasti/config"
+	"Pasti/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// Outbox reminder deadline. Cron hanya mengantrekan pesan ke tabel outboxnotifikasi; worker mengambil pesan
+// yang jatuh tempo, mengirim lewat dispatcher (dengan batas laju per penyedia), mencoba ulang dengan backoff
+// eksponensial dan memindahkan pesan ke dead letter setelah NOTIFIKASI_MAKS_PERCOBAAN percobaan.
+
+// Status baris outbox
+const (
+	OutboxMenunggu   = "menunggu"
+	OutboxDiproses   = "diproses"
+	OutboxTerkirim   = "terkirim"
+	OutboxDeadLetter = "dead_letter"
+	OutboxUsang      = "usang"
+)
+
+// lamaKunciOutbox - Baris 'diproses' dianggap ditinggalkan (worker mati) setelah selang ini dan diambil ulang
+const lamaKunciOutbox = 10 * time.Minute
+
+// maksTeksLog - Batas panjang respon/error satu percobaan yang disimpan di log pengiriman
+const maksTeksLog = 1024
+
+// bangunOutbox - Membangunkan poller saat ada pesan baru tanpa menunggu interval polling
+var bangunOutbox = make(chan struct{}, 1)
+
+// envAngka - Bilangan bulat positif dari env atau def
+func envAngka(nama string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(nama)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// MaksPercobaanOutbox - NOTIFIKASI_MAKS_PERCOBAAN (default 5)
+func MaksPercobaanOutbox() int {
+	return envAngka("NOTIFIKASI_MAKS_PERCOBAAN", 5)
+}
+
+// backoffOutbox - Jeda sebelum percobaan berikutnya: NOTIFIKASI_BACKOFF_DETIK (default 60) dikali dua setiap
+// percobaan, maksimal NOTIFIKASI_BACKOFF_MAKS_MENIT (default 60), ditambah jitter sampai 20%
+func backoffOutbox(percobaan int) time.Duration {
+	jeda := time.Duration(envAngka("NOTIFIKASI_BACKOFF_DETIK", 60)) * time.Second
+	maks := time.Duration(envAngka("NOTIFIKASI_BACKOFF_MAKS_MENIT", 60)) * time.Minute
+	for i := 1; i < percobaan && jeda < maks; i++ {
+		jeda *= 2
+	}
+	if jeda > maks {
+		jeda = maks
+	}
+	return jeda + time.Duration(rand.Int63n(int64(jeda)/5+1))
+}
+
+// KunciIdempotensiReminder - Kunci unik satu reminder: jenis, tugas dan siswa. Reminder lewat_deadline
+// dikirim harian sehingga kuncinya ditambah tanggal
+func KunciIdempotensiReminder(jenisNotifikasi string, tugasID, siswaID int, waktu time.Time) string {
+	kunci := fmt.Sprintf("%s:%d:%d", jenisNotifikasi, tugasID, siswaID)
+	if jenisNotifikasi == "lewat_deadline" {
+		kunci += ":" + waktu.Format("2006-01-02")
+	}
+	return kunci
+}
+
+// PercobaanOutbox - Satu percobaan kirim di satu kanal pada percobaan outbox ke-Ke
+type PercobaanOutbox struct {
+	Ke    int       `json:"ke"`
+	Waktu time.Time `json:"waktu"`
+	PercobaanKirim
+}
+
+// LogPengiriman - Riwayat pengiriman satu reminder, disimpan sebagai JSON di response_api outbox dan
+// notifikasi_tugas
+type LogPengiriman struct {
+	KanalTerkirim string            `json:"kanal_terkirim,omitempty"`
+	Percobaan     []PercobaanOutbox `json:"percobaan"`
+}
+
+// ParseLogPengiriman - Membaca response_api; false untuk catatan lama yang bukan JSON LogPengiriman
+func ParseLogPengiriman(respon string) (LogPengiriman, bool) {
+	var l LogPengiriman
+	if !strings.HasPrefix(strings.TrimSpace(respon), "{") || json.Unmarshal([]byte(respon), &l) != nil || l.Percobaan == nil {
+		return LogPengiriman{}, false
+	}
+	return l, true
+}
+
+// LogPengirimanDariRespon - Riwayat dari response_api notifikasi_tugas, termasuk catatan lama: baris
+// "kanal: respon" dari dispatcher multi-kanal, atau respon mentah Fonnte dari sebelum ada kanal lain
+func LogPengirimanDariRespon(status, respon string) LogPengiriman {
+	if l, ok := ParseLogPengiriman(respon); ok {
+		return l
+	}
+
+	berhasil := status == "terkirim"
+	l := LogPengiriman{Percobaan: []PercobaanOutbox{}}
+	for _, baris := range strings.Split(strings.TrimSpace(respon), "\n") {
+		kanal, isi, ok := strings.Cut(baris, ": ")
+		if !ok || (!KanalValid(kanal) && kanal != KanalInApp) {
+			continue
+		}
+		l.Percobaan = append(l.Percobaan, PercobaanOutbox{Ke: 1, PercobaanKirim: PercobaanKirim{Kanal: kanal, Respon: isi}})
+	}
+	if len(l.Percobaan) == 0 {
+		l.Percobaan = append(l.Percobaan, PercobaanOutbox{Ke: 1, PercobaanKirim: PercobaanKirim{Kanal: KanalWhatsApp, Respon: respon}})
+	}
+	// Salinan in-app berhasil jika tercatat notifikasi_id-nya. Dispatcher berhenti di kanal eksternal pertama
+	// yang berhasil, jadi hanya baris eksternal terakhir yang mungkin berhasil
+	for i := range l.Percobaan {
+		p := &l.Percobaan[i].PercobaanKirim
+		if p.Kanal == KanalInApp {
+			p.Berhasil = strings.HasPrefix(p.Respon, "notifikasi_id=")
+		} else {
+			p.Berhasil = berhasil && i == len(l.Percobaan)-1
+		}
+		if !p.Berhasil {
+			p.Error, p.Respon = p.Respon, ""
+		}
+		if berhasil && p.Berhasil && (p.Kanal != KanalInApp || l.KanalTerkirim == "") {
+			l.KanalTerkirim = p.Kanal
+		}
+	}
+	return l
+}
+
+// potongTeks - Memotong teks menjadi paling banyak maks byte tanpa memotong karakter UTF-8
+func potongTeks(teks string, maks int) string {
+	if len(teks) <= maks {
+		return teks
+	}
+	potong := maks - len("…")
+	for potong > 0 && !utf8.RuneStart(teks[potong]) {
+		potong--
+	}
+	return teks[:potong] + "…"
+}
+
+// tambahLog - Riwayat outbox ditambah hasil percobaan ke-ke. Respon dan error setiap percobaan dipotong
+// maksTeksLog byte agar riwayat banyak percobaan tetap muat di kolom response_api
+func tambahLog(responSebelumnya string, ke int, hasil HasilKirim, selesai bool) string {
+	l, _ := ParseLogPengiriman(responSebelumnya)
+	sekarang := time.Now()
+	for _, p := range hasil.Percobaan {
+		p.Respon = potongTeks(p.Respon, maksTeksLog)
+		p.Error = potongTeks(p.Error, maksTeksLog)
+		l.Percobaan = append(l.Percobaan, PercobaanOutbox{Ke: ke, Waktu: sekarang, PercobaanKirim: p})
+	}
+	if l.Percobaan == nil {
+		l.Percobaan = []PercobaanOutbox{}
+	}
+	if selesai {
+		l.KanalTerkirim = hasil.KanalTerkirim
+	}
+	data, _ := json.Marshal(l)
+	return string(data)
+}
+
+// BangunkanOutbox - Memberi tahu poller bahwa ada pesan yang siap dikirim
+func BangunkanOutbox() {
+	select {
+	case bangunOutbox <- struct{}{}:
+	default:
+	}
+}
+
+// antreReminder - Memasukkan reminder ke outbox untuk dikirim mulai kirimAt. Mengembalikan false jika kunci
+// idempotensinya sudah ada
+func (ns *NotifikasiService) antreReminder(tugas TugasWithSiswa, jenisTemplate, jenisNotifikasi string, kirimAt time.Time) bool {
+	data, err := json.Marshal(tugas)
+	if err != nil {
+		log.Printf("❌ Failed to encode reminder for student %d: %v", tugas.SiswaID, err)
+		return false
+	}
+	sekarang := time.Now()
+	outbox := models.OutboxNotifikasi{
+		KunciIdempotensi: KunciIdempotensiReminder(jenisNotifikasi, tugas.TugasID, tugas.SiswaID, sekarang),
+		TugasID:          tugas.TugasID,
+		SiswaID:          tugas.SiswaID,
+		JenisNotifikasi:  jenisNotifikasi,
+		JenisTemplate:    jenisTemplate,
+		Data:             string(data),
+		Status:           OutboxMenunggu,
+		BerikutnyaAt:     kirimAt,
+	}
+	hasil := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&outbox)
+	if hasil.Error != nil {
+		log.Printf("❌ Failed to queue %s reminder for student %d: %v", jenisNotifikasi, tugas.SiswaID, hasil.Error)
+		return false
+	}
+	if hasil.RowsAffected == 0 {
+		return false
+	}
+	BangunkanOutbox()
+	return true
+}
+
+// MulaiWorkerOutbox - Menjalankan poller dan NOTIFIKASI_WORKER (default 4) worker pengirim di background
+func (ns *NotifikasiService) MulaiWorkerOutbox() {
+	jumlah := envAngka("NOTIFIKASI_WORKER", 4)
+	antrean := make(chan models.OutboxNotifikasi)
+	for i := 0; i < jumlah; i++ {
+		go func() {
+			for outbox := range antrean {
+				ns.prosesOutbox(outbox)
+			}
+		}()
+	}
+	go ns.pollOutbox(antrean, jumlah)
+	log.Printf("📮 Notification outbox started with %d workers", jumlah)
+}
+
+// pollOutbox - Mengambil pesan jatuh tempo setiap NOTIFIKASI_OUTBOX_POLL_DETIK (default 10) atau saat dibangunkan
+func (ns *NotifikasiService) pollOutbox(antrean chan<- models.OutboxNotifikasi, jumlah int) {
+	ticker := time.NewTicker(time.Duration(envAngka("NOTIFIKASI_OUTBOX_POLL_DETIK", 10)) * time.Second)
+	defer ticker.Stop()
+	for {
+		if ns.ambilOutbox(antrean, jumlah*2) > 0 {
+			continue
+		}
+		select {
+		case <-bangunOutbox:
+		case <-ticker.C:
+		}
+	}
+}
+
+// ambilOutbox - Mengklaim sampai batas pesan jatuh tempo dan menyerahkannya ke worker. Selama jam tenang
+// tidak ada pesan yang diambil
+func (ns *NotifikasiService) ambilOutbox(antrean chan<- models.OutboxNotifikasi, batas int) int {
+	sekarang := time.Now()
+	if DalamJamTenang(AmbilPengaturanReminder(), sekarang) {
+		return 0
+	}
+
+	const jatuhTempo = "(status = ? AND berikutnya_at <= ?) OR (status = ? AND dikunci_sampai < ?)"
+	var daftar []models.OutboxNotifikasi
+	if err := config.DB.Where(jatuhTempo, OutboxMenunggu, sekarang, OutboxDiproses, sekarang).
+		Order("berikutnya_at ASC").Limit(batas).Find(&daftar).Error; err != nil {
+		log.Printf("❌ Failed to poll notification outbox: %v", err)
+		return 0
+	}
+
+	diambil := 0
+	for _, outbox := range daftar {
+		// Klaim dengan kondisi yang sama agar instance lain tidak mengirim pesan yang sama
+		kunci := sekarang.Add(lamaKunciOutbox)
+		klaim := config.DB.Model(&models.OutboxNotifikasi{}).
+			Where("outbox_id = ?", outbox.OutboxID).
+			Where(jatuhTempo, OutboxMenunggu, sekarang, OutboxDiproses, sekarang).
+			Updates(map[string]interface{}{"status": OutboxDiproses, "dikunci_sampai": kunci})
+		if klaim.Error != nil || klaim.RowsAffected == 0 {
+			continue
+		}
+		outbox.Status = OutboxDiproses
+		outbox.DikunciSampai = &kunci
+		antrean <- outbox
+		diambil++
+	}
+	return diambil
+}
+
+// outboxSelesai - Pesan dianggap sampai jika terkirim di kanal eksternal, atau lewat in-app karena tidak ada
+// kanal eksternal yang bisa dicoba. Kanal eksternal yang gagal dicoba ulang walaupun salinan in-app tersimpan
+func outboxSelesai(hasil HasilKirim) bool {
+	if !hasil.Terkirim {
+		return false
+	}
+	if hasil.KanalTerkirim != KanalInApp {
+		return true
+	}
+	for _, p := range hasil.Percobaan {
+		if p.Kanal != KanalInApp {
+			return false
+		}
+	}
+	return true
+}
+
+// errorPercobaan - Gabungan error percobaan yang gagal
+func errorPercobaan(hasil HasilKirim) string {
+	var daftar []string
+	for _, p := range hasil.Percobaan {
+		if !p.Berhasil {
+			daftar = append(daftar, p.Kanal+": "+p.Error)
+		}
+	}
+	if len(daftar) == 0 {
+		return "tidak ada kanal yang berhasil"
+	}
+	return potongTeks(strings.Join(daftar, "; "), maksTeksLog)
+}
+
+// alasanReminderUsang - Alasan reminder tidak perlu dikirim lagi, kosong jika masih berlaku. Reminder sebelum
+// deadline usang jika deadline sudah lewat atau siswa (atau kelompoknya) sudah mengumpulkan; reminder
+// lewat_deadline usang jika siswa sudah mengumpulkan atau deadline diundur
+func alasanReminderUsang(outbox models.OutboxNotifikasi, sekarang time.Time) (string, error) {
+	var cek []struct {
+		DeadlinePengumpulan time.Time
+		SudahKumpul         bool
+	}
+	query := `
+		SELECT t.deadline_pengumpulan,
+			(EXISTS (SELECT 1 FROM pengumpulantugas pt WHERE pt.tugas_id = t.tugas_id AND pt.siswa_id = s.siswa_id)
+				OR EXISTS (` + kelompokSudahKumpul + `)) AS sudah_kumpul
+		FROM tugas t
+		JOIN siswa s ON s.siswa_id = ?
+		WHERE t.tugas_id = ?`
+	if err := config.DB.Raw(query, outbox.SiswaID, outbox.TugasID).Scan(&cek).Error; err != nil {
+		return "", err
+	}
+	if len(cek) == 0 {
+		return "tugas atau siswa sudah dihapus", nil
+	}
+
+	switch {
+	case cek[0].SudahKumpul:
+		return "siswa sudah mengumpulkan", nil
+	case outbox.JenisNotifikasi != "lewat_deadline" && !cek[0].DeadlinePengumpulan.After(sekarang):
+		return "deadline sudah lewat", nil
+	case outbox.JenisNotifikasi == "lewat_deadline" && cek[0].DeadlinePengumpulan.After(sekarang):
+		return "deadline diundur", nil
+	}
+	return "", nil
+}
+
+// tutupOutboxUsang - Menutup reminder yang tidak berlaku lagi tanpa mengirim dan tanpa mencatatnya gagal
+func tutupOutboxUsang(outbox models.OutboxNotifikasi, alasan string) {
+	log.Printf("🗑️ Reminder %s for student %d dropped: %s", outbox.KunciIdempotensi, outbox.SiswaID, alasan)
+	err := config.DB.Model(&models.OutboxNotifikasi{}).Where("outbox_id = ?", outbox.OutboxID).Updates(map[string]interface{}{
+		"status":         OutboxUsang,
+		"dikunci_sampai": nil,
+		"error_terakhir": alasan,
+		"selesai_at":     time.Now(),
+	}).Error
+	if err != nil {
+		log.Printf("❌ Failed to update outbox %d: %v", outbox.OutboxID, err)
+	}
+}
+
+// prosesOutbox - Satu percobaan kirim lalu menandai pesan terkirim, menjadwalkan ulang atau dead letter
+func (ns *NotifikasiService) prosesOutbox(outbox models.OutboxNotifikasi) {
+	percobaanKe := outbox.Percobaan + 1
+	terakhir := percobaanKe >= MaksPercobaanOutbox()
+
+	var tugas TugasWithSiswa
+	if err := json.Unmarshal([]byte(outbox.Data), &tugas); err != nil {
+		ns.selesaikanOutbox(outbox, percobaanKe, OutboxDeadLetter, "", "data outbox rusak: "+err.Error(), "")
+		return
+	}
+
+	// Status tugas bisa berubah sejak pesan diantrekan (siswa mengumpulkan, deadline diubah); jika
+	// pemeriksaan gagal pesan tetap dikirim
+	if alasan, err := alasanReminderUsang(outbox, time.Now()); err != nil {
+		log.Printf("⚠️ Failed to recheck reminder %s: %v", outbox.KunciIdempotensi, err)
+	} else if alasan != "" {
+		tutupOutboxUsang(outbox, alasan)
+		return
+	}
+
+	hasil := ns.kirimKeSiswa(tugas, outbox.JenisTemplate, outbox.JenisNotifikasi, outbox.Percobaan, terakhir)
+	selesai := outboxSelesai(hasil)
+	respon := tambahLog(outbox.ResponseAPI, percobaanKe, hasil, selesai)
+
+	switch {
+	case selesai:
+		ns.selesaikanOutbox(outbox, percobaanKe, OutboxTerkirim, hasil.KanalTerkirim, "", respon)
+	case terakhir:
+		log.Printf("☠️ Reminder %s for student %d moved to dead letter after %d attempts", outbox.KunciIdempotensi, outbox.SiswaID, percobaanKe)
+		ns.selesaikanOutbox(outbox, percobaanKe, OutboxDeadLetter, hasil.KanalTerkirim, errorPercobaan(hasil), respon)
+	default:
+		berikutnya := time.Now().Add(backoffOutbox(percobaanKe))
+		log.Printf("🔁 Reminder %s for student %d failed (attempt %d), retrying at %s", outbox.KunciIdempotensi, outbox.SiswaID, percobaanKe, berikutnya.Format("15:04:05"))
+		err := config.DB.Model(&models.OutboxNotifikasi{}).Where("outbox_id = ?", outbox.OutboxID).Updates(map[string]interface{}{
+			"status":         OutboxMenunggu,
+			"percobaan":      percobaanKe,
+			"berikutnya_at":  berikutnya,
+			"dikunci_sampai": nil,
+			"error_terakhir": errorPercobaan(hasil),
+			"response_api":   respon,
+		}).Error
+		if err != nil {
+			log.Printf("❌ Failed to reschedule outbox %d: %v", outbox.OutboxID, err)
+		}
+	}
+}
+
+// selesaikanOutbox - Menutup pesan outbox dan mencatat hasil akhirnya di notifikasi_tugas
+func (ns *NotifikasiService) selesaikanOutbox(outbox models.OutboxNotifikasi, percobaan int, status, kanal, errTerakhir, respon string) {
+	sekarang := time.Now()
+	err := config.DB.Model(&models.OutboxNotifikasi{}).Where("outbox_id = ?", outbox.OutboxID).Updates(map[string]interface{}{
+		"status":         status,
+		"percobaan":      percobaan,
+		"dikunci_sampai": nil,
+		"kanal_terkirim": kanal,
+		"error_terakhir": errTerakhir,
+		"response_api":   respon,
+		"selesai_at":     sekarang,
+	}).Error
+	if err != nil {
+		log.Printf("❌ Failed to update outbox %d: %v", outbox.OutboxID, err)
+	}
+
+	statusNotifikasi := "terkirim"
+	if status == OutboxDeadLetter {
+		statusNotifikasi = "gagal"
+	}
+	ns.saveNotificationRecord(outbox.TugasID, outbox.SiswaID, outbox.JenisNotifikasi, statusNotifikasi, respon)
+}
+
+// BersihkanOutboxNotifikasi - Menghapus pesan terkirim dan usang yang lebih lama dari NOTIFIKASI_OUTBOX_RETENSI_HARI
+// (default 30). Dead letter disimpan sampai ditangani admin
+func BersihkanOutboxNotifikasi() {
+	batas := time.Now().AddDate(0, 0, -envAngka("NOTIFIKASI_OUTBOX_RETENSI_HARI", 30))
+	hasil := config.DB.Where("status IN ? AND selesai_at < ?", []string{OutboxTerkirim, OutboxUsang}, batas).Delete(&models.OutboxNotifikasi{})
+	if hasil.Error != nil {
+		log.Printf("❌ Failed to clean notification outbox: %v", hasil.Error)
+		return
+	}
+	if hasil.RowsAffected > 0 {
+		log.Printf("🧹 Removed %d delivered outbox messages", hasil.RowsAffected)
+	}
+}
@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPotongTeks(t *testing.T) {
+	if got := potongTeks("pendek", 10); got != "pendek" {
+		t.Fatalf("potongTeks = %q", got)
+	}
+	// "⏰" 3 byte; potongan tidak boleh berhenti di tengah karakter
+	got := potongTeks(strings.Repeat("⏰", 10), 10)
+	if len(got) > 10 || !utf8.ValidString(got) || !strings.HasSuffix(got, "…") {
+		t.Fatalf("potongTeks = %q (%d byte)", got, len(got))
+	}
+}
+
+func TestTambahLogMemotongRespon(t *testing.T) {
+	hasil := HasilKirim{Percobaan: []PercobaanKirim{
+		{Kanal: KanalWhatsApp, Error: strings.Repeat("x", 64*1024)},
+		{Kanal: KanalEmail, Respon: strings.Repeat("y", 64*1024)},
+	}}
+	respon := ""
+	for ke := 1; ke <= 5; ke++ {
+		respon = tambahLog(respon, ke, hasil, false)
+	}
+
+	l, ok := ParseLogPengiriman(respon)
+	if !ok || len(l.Percobaan) != 10 {
+		t.Fatalf("log = %d percobaan, ok=%v", len(l.Percobaan), ok)
+	}
+	for _, p := range l.Percobaan {
+		if len(p.Respon) > maksTeksLog || len(p.Error) > maksTeksLog {
+			t.Fatalf("percobaan %d %s tidak dipotong: %d/%d byte", p.Ke, p.Kanal, len(p.Respon), len(p.Error))
+		}
+	}
+	if len(respon) > 16*1024 {
+		t.Fatalf("response_api %d byte", len(respon))
+	}
+}
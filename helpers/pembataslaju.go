@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PembatasLaju - Token bucket untuk membatasi jumlah pesan per menit ke satu penyedia (Fonnte, SMTP, Telegram).
+// Bucket berisi paling banyak Kapasitas token dan terisi ulang perMenit token setiap menit
+type PembatasLaju struct {
+	mu          sync.Mutex
+	token       float64
+	kapasitas   float64
+	isiPerDetik float64
+	terakhir    time.Time
+}
+
+// NewPembatasLaju - Bucket penuh dengan perMenit token per menit dan kapasitas burst
+func NewPembatasLaju(perMenit, burst int) *PembatasLaju {
+	if burst < 1 {
+		burst = 1
+	}
+	return &PembatasLaju{
+		token:       float64(burst),
+		kapasitas:   float64(burst),
+		isiPerDetik: float64(perMenit) / 60,
+		terakhir:    time.Now(),
+	}
+}
+
+// ambil - Mengambil satu token jika ada; jika tidak, lama menunggu sampai token berikutnya tersedia
+func (p *PembatasLaju) ambil() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sekarang := time.Now()
+	p.token += sekarang.Sub(p.terakhir).Seconds() * p.isiPerDetik
+	if p.token > p.kapasitas {
+		p.token = p.kapasitas
+	}
+	p.terakhir = sekarang
+
+	if p.token >= 1 {
+		p.token--
+		return 0
+	}
+	return time.Duration((1 - p.token) / p.isiPerDetik * float64(time.Second))
+}
+
+// Tunggu - Memblokir sampai satu token tersedia atau ctx dibatalkan
+func (p *PembatasLaju) Tunggu(ctx context.Context) error {
+	for {
+		tunggu := p.ambil()
+		if tunggu == 0 {
+			return nil
+		}
+		timer := time.NewTimer(tunggu)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// batasDefaultPerMenit - Batas bawaan per penyedia; WhatsApp (Fonnte) paling ketat
+var batasDefaultPerMenit = map[string]int{
+	KanalWhatsApp: 30,
+	KanalEmail:    60,
+	KanalTelegram: 60,
+}
+
+// pembatasDariEnv - NOTIFIKASI_RATE_PER_MENIT_<KANAL> (0 = tanpa batas) dan NOTIFIKASI_RATE_BURST (default 3).
+// In-app tidak dibatasi
+func pembatasDariEnv(kanal string) *PembatasLaju {
+	perMenit, ok := batasDefaultPerMenit[kanal]
+	if !ok {
+		return nil
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIKASI_RATE_PER_MENIT_" + strings.ToUpper(kanal))); err == nil && v >= 0 {
+		perMenit = v
+	}
+	if perMenit == 0 {
+		return nil
+	}
+	burst := 3
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIKASI_RATE_BURST")); err == nil && v > 0 {
+		burst = v
+	}
+	return NewPembatasLaju(perMenit, burst)
+}
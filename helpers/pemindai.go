@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pemindai malware untuk file upload. Default tidak ada pemindai (semua file dianggap bersih);
+// PEMINDAI_FILE=clamav memakai daemon clamd lokal lewat CLAMAV_ADDR.
+
+// HasilPindai - Hasil pemindaian satu file
+type HasilPindai struct {
+	Bersih  bool
+	Ancaman string // nama signature jika terdeteksi, misal "Eicar-Signature"
+}
+
+// PemindaiFile - Hook pemindai yang dapat diganti (ClamAV, layanan eksternal, dsb.)
+type PemindaiFile interface {
+	Nama() string
+	Pindai(ctx context.Context, r io.Reader) (HasilPindai, error)
+}
+
+var (
+	pemindaiAktif PemindaiFile
+	pemindaiOnce  sync.Once
+	pemindaiMu    sync.RWMutex
+)
+
+// SetPemindaiFile - Mengganti pemindai yang dipakai (nil = tanpa pemindaian)
+func SetPemindaiFile(p PemindaiFile) {
+	pemindaiOnce.Do(func() {})
+	pemindaiMu.Lock()
+	pemindaiAktif = p
+	pemindaiMu.Unlock()
+}
+
+// GetPemindaiFile - Pemindai aktif dari env PEMINDAI_FILE, nil jika pemindaian tidak diaktifkan
+func GetPemindaiFile() PemindaiFile {
+	pemindaiOnce.Do(func() {
+		if strings.EqualFold(os.Getenv("PEMINDAI_FILE"), "clamav") {
+			pemindaiAktif = NewPemindaiClamAV(os.Getenv("CLAMAV_ADDR"))
+		}
+	})
+	pemindaiMu.RLock()
+	defer pemindaiMu.RUnlock()
+	return pemindaiAktif
+}
+
+// PemindaiWajib - PEMINDAI_WAJIB=true menolak upload jika pemindai gagal dihubungi (fail-closed)
+func PemindaiWajib() bool {
+	return strings.EqualFold(os.Getenv("PEMINDAI_WAJIB"), "true")
+}
+
+// PemindaiClamAV - Pemindai memakai perintah INSTREAM dari clamd
+type PemindaiClamAV struct {
+	network string
+	alamat  string
+	timeout time.Duration
+}
+
+// NewPemindaiClamAV - alamat "host:port" (TCP, default 127.0.0.1:3310) atau "unix:/path/clamd.sock"
+func NewPemindaiClamAV(alamat string) *PemindaiClamAV {
+	p := &PemindaiClamAV{network: "tcp", alamat: alamat, timeout: 60 * time.Second}
+	if alamat == "" {
+		p.alamat = "127.0.0.1:3310"
+	}
+	if strings.HasPrefix(alamat, "unix:") {
+		p.network = "unix"
+		p.alamat = strings.TrimPrefix(alamat, "unix:")
+	}
+	return p
+}
+
+func (p *PemindaiClamAV) Nama() string {
+	return "clamav"
+}
+
+// Pindai - Mengirim isi file dalam potongan (panjang 4 byte big-endian + data), diakhiri panjang 0.
+// Balasan clamd: "stream: OK" atau "stream: <signature> FOUND"
+func (p *PemindaiClamAV) Pindai(ctx context.Context, r io.Reader) (HasilPindai, error) {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, p.network, p.alamat)
+	if err != nil {
+		return HasilPindai{}, fmt.Errorf("gagal terhubung ke clamd: %w", err)
+	}
+	defer conn.Close()
+
+	tenggat := time.Now().Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(tenggat) {
+		tenggat = d
+	}
+	conn.SetDeadline(tenggat)
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return HasilPindai{}, err
+	}
+
+	buf := make([]byte, 64<<10)
+	panjang := make([]byte, 4)
+	for {
+		n, errBaca := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(panjang, uint32(n))
+			if _, err := conn.Write(panjang); err != nil {
+				return HasilPindai{}, err
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return HasilPindai{}, err
+			}
+		}
+		if errBaca == io.EOF {
+			break
+		}
+		if errBaca != nil {
+			return HasilPindai{}, errBaca
+		}
+	}
+	binary.BigEndian.PutUint32(panjang, 0)
+	if _, err := conn.Write(panjang); err != nil {
+		return HasilPindai{}, err
+	}
+
+	balasan, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return HasilPindai{}, err
+	}
+	balasan = strings.TrimSpace(strings.TrimRight(balasan, "\x00"))
+
+	switch {
+	case strings.HasSuffix(balasan, " OK"):
+		return HasilPindai{Bersih: true}, nil
+	case strings.HasSuffix(balasan, " FOUND"):
+		ancaman := strings.TrimSuffix(strings.TrimPrefix(balasan, "stream: "), " FOUND")
+		return HasilPindai{Bersih: false, Ancaman: ancaman}, nil
+	default:
+		return HasilPindai{}, fmt.Errorf("balasan clamd tidak dikenal: %q", balasan)
+	}
+}
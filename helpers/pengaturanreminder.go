@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata" // zona waktu tetap tersedia di image tanpa tzdata sistem
+
+	"Pasti/config"
+	"Pasti/models"
+)
+
+// Pengaturan reminder deadline sekolah: tingkat reminder yang aktif, reminder lewat deadline dan jam tenang.
+
+// TingkatReminder - Satu tingkat reminder sebelum deadline
+type TingkatReminder struct {
+	Jenis   string        // nilai notifikasi_tugas.jenis_notifikasi
+	Sebelum time.Duration // jarak ke deadline
+}
+
+// SemuaTingkatReminder - Tingkat reminder berurutan dari yang paling jauh dari deadline
+var SemuaTingkatReminder = []TingkatReminder{
+	{Jenis: "3_hari", Sebelum: 72 * time.Hour},
+	{Jenis: "1_hari", Sebelum: 24 * time.Hour},
+	{Jenis: "2_jam", Sebelum: 2 * time.Hour},
+}
+
+// PengaturanReminderDefault - Dipakai jika tabel pengaturan belum berisi baris
+func PengaturanReminderDefault() models.PengaturanReminder {
+	return models.PengaturanReminder{
+		ID:                    1,
+		Reminder3Hari:         true,
+		Reminder1Hari:         true,
+		Reminder2Jam:          true,
+		ReminderLewatDeadline: true,
+		LewatDeadlineMaksHari: 7,
+		JamTenangMulai:        "21:00",
+		JamTenangSelesai:      "06:00",
+		ZonaWaktu:             "Asia/Jakarta",
+	}
+}
+
+// AmbilPengaturanReminder - Pengaturan reminder dari database atau default
+func AmbilPengaturanReminder() models.PengaturanReminder {
+	var p models.PengaturanReminder
+	if err := config.DB.First(&p, 1).Error; err != nil {
+		return PengaturanReminderDefault()
+	}
+	return p
+}
+
+// TingkatReminderAktif - Tingkat reminder yang diaktifkan, berurutan dari yang paling jauh
+func TingkatReminderAktif(p models.PengaturanReminder) []TingkatReminder {
+	aktif := map[string]bool{"3_hari": p.Reminder3Hari, "1_hari": p.Reminder1Hari, "2_jam": p.Reminder2Jam}
+	var hasil []TingkatReminder
+	for _, t := range SemuaTingkatReminder {
+		if aktif[t.Jenis] {
+			hasil = append(hasil, t)
+		}
+	}
+	return hasil
+}
+
+// parseJamMenit - "HH:MM" menjadi menit sejak tengah malam
+func parseJamMenit(s string) (int, error) {
+	var jam, menit int
+	if _, err := fmt.Sscanf(s, "%d:%d", &jam, &menit); err != nil || len(s) != 5 || jam < 0 || jam > 23 || menit < 0 || menit > 59 {
+		return 0, fmt.Errorf("jam %q harus berformat HH:MM", s)
+	}
+	return jam*60 + menit, nil
+}
+
+// ValidasiPengaturanReminder - Memeriksa format jam tenang, zona waktu dan batas hari lewat deadline
+func ValidasiPengaturanReminder(p models.PengaturanReminder) error {
+	if (p.JamTenangMulai == "") != (p.JamTenangSelesai == "") {
+		return errors.New("jam_tenang_mulai dan jam_tenang_selesai harus diisi keduanya atau dikosongkan keduanya")
+	}
+	if p.JamTenangMulai != "" {
+		if _, err := parseJamMenit(p.JamTenangMulai); err != nil {
+			return err
+		}
+		if _, err := parseJamMenit(p.JamTenangSelesai); err != nil {
+			return err
+		}
+	}
+	if _, err := time.LoadLocation(p.ZonaWaktu); err != nil || p.ZonaWaktu == "" {
+		return fmt.Errorf("zona waktu %q tidak dikenal", p.ZonaWaktu)
+	}
+	if p.LewatDeadlineMaksHari < 1 || p.LewatDeadlineMaksHari > 30 {
+		return errors.New("lewat_deadline_maks_hari harus antara 1 dan 30")
+	}
+	return nil
+}
+
+// DalamJamTenang - Apakah waktu t (di zona waktu sekolah) berada di jam tenang. Jam tenang boleh
+// melewati tengah malam (misal 21:00-06:00)
+func DalamJamTenang(p models.PengaturanReminder, t time.Time) bool {
+	mulai, err1 := parseJamMenit(p.JamTenangMulai)
+	selesai, err2 := parseJamMenit(p.JamTenangSelesai)
+	if err1 != nil || err2 != nil || mulai == selesai {
+		return false
+	}
+	if lokasi, err := time.LoadLocation(p.ZonaWaktu); err == nil {
+		t = t.In(lokasi)
+	}
+	sekarang := t.Hour()*60 + t.Minute()
+	if mulai < selesai {
+		return sekarang >= mulai && sekarang < selesai
+	}
+	return sekarang >= mulai || sekarang < selesai
+}
+
+// AkhirJamTenang - Waktu jam tenang berakhir jika t berada di jam tenang, selain itu t sendiri
+func AkhirJamTenang(p models.PengaturanReminder, t time.Time) time.Time {
+	if !DalamJamTenang(p, t) {
+		return t
+	}
+	selesai, _ := parseJamMenit(p.JamTenangSelesai)
+	lokal := t
+	if lokasi, err := time.LoadLocation(p.ZonaWaktu); err == nil {
+		lokal = t.In(lokasi)
+	}
+	akhir := time.Date(lokal.Year(), lokal.Month(), lokal.Day(), selesai/60, selesai%60, 0, 0, lokal.Location())
+	if !akhir.After(lokal) {
+		akhir = time.Date(lokal.Year(), lokal.Month(), lokal.Day()+1, selesai/60, selesai%60, 0, 0, lokal.Location())
+	}
+	return akhir
+}
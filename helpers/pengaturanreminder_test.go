@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"Pasti/models"
+)
+
+func TestParseJamMenit(t *testing.T) {
+	tests := []struct {
+		jam   string
+		menit int
+		valid bool
+	}{
+		{"00:00", 0, true},
+		{"06:30", 390, true},
+		{"23:59", 1439, true},
+		{"24:00", 0, false},
+		{"12:60", 0, false},
+		{"6:30", 0, false},
+		{"06:30:00", 0, false},
+		{"-1:30", 0, false},
+		{"ab:cd", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		menit, err := parseJamMenit(tt.jam)
+		if (err == nil) != tt.valid || menit != tt.menit {
+			t.Errorf("parseJamMenit(%q) = %d, %v; want %d, valid %v", tt.jam, menit, err, tt.menit, tt.valid)
+		}
+	}
+}
+
+func TestDalamJamTenang(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	pada := func(jam, menit int) time.Time {
+		return time.Date(2026, 3, 10, jam, menit, 0, 0, jakarta)
+	}
+	pengaturan := func(mulai, selesai string) models.PengaturanReminder {
+		p := PengaturanReminderDefault()
+		p.JamTenangMulai, p.JamTenangSelesai = mulai, selesai
+		return p
+	}
+
+	tests := []struct {
+		nama    string
+		mulai   string
+		selesai string
+		waktu   time.Time
+		want    bool
+	}{
+		{"lewat tengah malam, sebelum mulai", "21:00", "06:00", pada(20, 59), false},
+		{"lewat tengah malam, tepat mulai", "21:00", "06:00", pada(21, 0), true},
+		{"lewat tengah malam, tengah malam", "21:00", "06:00", pada(0, 0), true},
+		{"lewat tengah malam, sebelum selesai", "21:00", "06:00", pada(5, 59), true},
+		{"lewat tengah malam, tepat selesai", "21:00", "06:00", pada(6, 0), false},
+		{"lewat tengah malam, siang", "21:00", "06:00", pada(12, 0), false},
+		{"hari yang sama, di dalam", "12:00", "13:00", pada(12, 30), true},
+		{"hari yang sama, tepat selesai", "12:00", "13:00", pada(13, 0), false},
+		{"hari yang sama, malam", "12:00", "13:00", pada(23, 0), false},
+		{"batas sama berarti nonaktif", "06:00", "06:00", pada(6, 0), false},
+		{"batas sama, jam lain", "06:00", "06:00", pada(18, 0), false},
+		{"jam tenang kosong", "", "", pada(23, 0), false},
+		{"format rusak", "21.00", "06:00", pada(23, 0), false},
+		// 14:00 UTC = 21:00 WIB
+		{"waktu dibandingkan di zona sekolah", "21:00", "06:00", time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := DalamJamTenang(pengaturan(tt.mulai, tt.selesai), tt.waktu); got != tt.want {
+				t.Errorf("DalamJamTenang(%s-%s, %s) = %v, want %v", tt.mulai, tt.selesai, tt.waktu.Format("15:04 MST"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAkhirJamTenang(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	p := PengaturanReminderDefault()
+
+	tests := []struct {
+		nama  string
+		waktu time.Time
+		want  time.Time
+	}{
+		{"sebelum tengah malam", time.Date(2026, 3, 10, 22, 15, 0, 0, jakarta), time.Date(2026, 3, 11, 6, 0, 0, 0, jakarta)},
+		{"setelah tengah malam", time.Date(2026, 3, 11, 1, 0, 0, 0, jakarta), time.Date(2026, 3, 11, 6, 0, 0, 0, jakarta)},
+		{"di luar jam tenang", time.Date(2026, 3, 11, 9, 0, 0, 0, jakarta), time.Date(2026, 3, 11, 9, 0, 0, 0, jakarta)},
+		{"server UTC", time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC), time.Date(2026, 3, 11, 6, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := AkhirJamTenang(p, tt.waktu); !got.Equal(tt.want) {
+				t.Errorf("AkhirJamTenang(%s) = %s, want %s", tt.waktu, got, tt.want)
+			}
+		})
+	}
+}
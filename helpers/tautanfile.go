@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Tautan unduhan bertanda tangan (HMAC-SHA256) untuk file di /uploads, agar link <a href>
+// di frontend tetap bisa dibuka tanpa header Authorization selama belum kedaluwarsa
+
+// DurasiTautanFile - Masa berlaku tautan, dapat diatur lewat env FILE_URL_TTL_MENIT (default 15 menit)
+func DurasiTautanFile() time.Duration {
+	if menit, err := strconv.Atoi(os.Getenv("FILE_URL_TTL_MENIT")); err == nil && menit > 0 {
+		return time.Duration(menit) * time.Minute
+	}
+	return 15 * time.Minute
+}
+
+// kunciTautanFile - Kunci HMAC dari env FILE_SIGNING_KEY, jatuh ke kunci JWT jika tidak diatur
+func kunciTautanFile() []byte {
+	if kunci := os.Getenv("FILE_SIGNING_KEY"); kunci != "" {
+		return []byte(kunci)
+	}
+	return mySigningKey
+}
+
+// tandaTanganFile - HMAC dari path file dan waktu kedaluwarsa (unix)
+func tandaTanganFile(path string, exp int64) string {
+	mac := hmac.New(sha256.New, kunciTautanFile())
+	mac.Write([]byte(path + "|" + strconv.FormatInt(exp, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TautanFileBertandaTangan - Path file ditambah query exp & sig yang berlaku selama durasi
+func TautanFileBertandaTangan(path string, durasi time.Duration) string {
+	exp := time.Now().Add(durasi).Unix()
+	query := url.Values{}
+	query.Set("exp", strconv.FormatInt(exp, 10))
+	query.Set("sig", tandaTanganFile(path, exp))
+	return path + "?" + query.Encode()
+}
+
+// VerifikasiTautanFile - Memastikan tanda tangan cocok dengan path dan belum kedaluwarsa
+func VerifikasiTautanFile(path, expStr, sig string) bool {
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(tandaTanganFile(path, exp)))
+}
@@ -0,0 +1,441 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"Pasti/config"
+	"Pasti/models"
+)
+
+// Template pesan notifikasi reminder. Setiap kombinasi jenis, kanal dan bahasa memakai template dari
+// tabel templatenotifikasi (diubah admin) atau template bawaan di file ini. Kanal "default" dipakai
+// Telegram dan in-app, kanal "email" dirender dengan html/template sehingga data otomatis di-escape.
+
+// Jenis template notifikasi
+const (
+	TemplateReminder      = "reminder"
+	TemplateLewatDeadline = "lewat_deadline"
+)
+
+// KanalTemplateDefault - Template untuk kanal yang tidak punya varian sendiri
+const KanalTemplateDefault = "default"
+
+var (
+	JenisTemplateNotifikasi = []string{TemplateReminder, TemplateLewatDeadline}
+	KanalTemplateNotifikasi = []string{KanalTemplateDefault, KanalWhatsApp, KanalEmail}
+	BahasaNotifikasi        = []string{"id", "en"}
+)
+
+// ErrTemplateTidakDikenal - Kombinasi jenis/kanal/bahasa tidak didukung
+var ErrTemplateTidakDikenal = errors.New("jenis, kanal atau bahasa template tidak dikenal")
+
+// DataTemplateNotifikasi - Data yang tersedia di template, misal {{.NamaSiswa}}, {{.Deadline}}, {{.URL}}
+type DataTemplateNotifikasi struct {
+	TugasWithSiswa
+	Jenis     string // 3_hari, 1_hari, 2_jam atau lewat_deadline
+	Deadline  string // deadline terformat "02 Jan 2006 15:04" di zona waktu sekolah
+	SisaWaktu string // sisa waktu ke deadline dalam bahasa penerima
+	URL       string // halaman tugas di frontend (FRONTEND_URL)
+}
+
+// VariabelTemplateNotifikasi - Daftar variabel template untuk ditampilkan di API admin
+var VariabelTemplateNotifikasi = map[string]string{
+	"NamaSiswa":           "Nama lengkap siswa",
+	"JudulTugas":          "Judul tugas",
+	"NamaMapel":           "Nama mata pelajaran",
+	"NamaKelas":           "Nama kelas",
+	"PoinMaksimal":        "Poin maksimal tugas",
+	"Deadline":            "Deadline terformat (02 Jan 2006 15:04) di zona waktu sekolah",
+	"DeadlinePengumpulan": "Deadline (time.Time, zona waktu sekolah), misal {{tanggal .DeadlinePengumpulan \"02/01/2006\"}}",
+	"SisaWaktu":           "Sisa waktu ke deadline, misal \"2 jam lagi\"",
+	"Jenis":               "Tingkat reminder: 3_hari, 1_hari, 2_jam atau lewat_deadline",
+	"URL":                 "Tautan halaman tugas di frontend",
+}
+
+// fungsiTemplate - Fungsi tambahan yang bisa dipakai di template
+var fungsiTemplate = map[string]any{
+	"tanggal": func(t time.Time, format string) string { return t.Format(format) },
+	"upper":   strings.ToUpper,
+}
+
+// TemplateBawaan - Judul dan isi template
+type TemplateBawaan struct {
+	Judul string
+	Isi   string
+}
+
+func kunciTemplate(jenis, kanal, bahasa string) string {
+	return jenis + "|" + kanal + "|" + bahasa
+}
+
+// templateBawaan - Template bawaan aplikasi
+var templateBawaan = map[string]TemplateBawaan{
+	kunciTemplate(TemplateReminder, KanalWhatsApp, "id"): {
+		Judul: "Reminder tugas: {{.JudulTugas}}",
+		Isi: `🔔 *REMINDER TUGAS* 🔔
+
+Halo {{.NamaSiswa}}!
+
+📚 *Tugas:* {{.JudulTugas}}
+📖 *Mata Pelajaran:* {{.NamaMapel}}
+🏫 *Kelas:* {{.NamaKelas}}
+⏰ *Deadline:* {{.Deadline}} ({{.SisaWaktu}})
+
+⚠️ Jangan lupa untuk mengumpulkan tugas ya!
+
+Akses Tugas Disini yaa!:
+🌐 {{.URL}}
+
+---
+📱 Pesan otomatis dari Sistem PASTI`,
+	},
+	kunciTemplate(TemplateReminder, KanalWhatsApp, "en"): {
+		Judul: "Assignment reminder: {{.JudulTugas}}",
+		Isi: `🔔 *ASSIGNMENT REMINDER* 🔔
+
+Hi {{.NamaSiswa}}!
+
+📚 *Assignment:* {{.JudulTugas}}
+📖 *Subject:* {{.NamaMapel}}
+🏫 *Class:* {{.NamaKelas}}
+⏰ *Deadline:* {{.Deadline}} ({{.SisaWaktu}})
+
+⚠️ Don't forget to submit your assignment!
+
+Open your assignments here:
+🌐 {{.URL}}
+
+---
+📱 Automated message from PASTI`,
+	},
+	kunciTemplate(TemplateReminder, KanalTemplateDefault, "id"): {
+		Judul: "Reminder tugas: {{.JudulTugas}}",
+		Isi: `Halo {{.NamaSiswa}}, tugas "{{.JudulTugas}}" ({{.NamaMapel}}, {{.NamaKelas}}) harus dikumpulkan {{.SisaWaktu}}, paling lambat {{.Deadline}}.
+
+{{.URL}}`,
+	},
+	kunciTemplate(TemplateReminder, KanalTemplateDefault, "en"): {
+		Judul: "Assignment reminder: {{.JudulTugas}}",
+		Isi: `Hi {{.NamaSiswa}}, the assignment "{{.JudulTugas}}" ({{.NamaMapel}}, {{.NamaKelas}}) is due {{.SisaWaktu}}, no later than {{.Deadline}}.
+
+{{.URL}}`,
+	},
+	kunciTemplate(TemplateReminder, KanalEmail, "id"): {
+		Judul: "Reminder tugas: {{.JudulTugas}}",
+		Isi: `<p>Halo {{.NamaSiswa}},</p>
+<p>Tugas berikut belum Anda kumpulkan:</p>
+<table>
+<tr><td>Tugas</td><td><b>{{.JudulTugas}}</b></td></tr>
+<tr><td>Mata pelajaran</td><td>{{.NamaMapel}}</td></tr>
+<tr><td>Kelas</td><td>{{.NamaKelas}}</td></tr>
+<tr><td>Deadline</td><td>{{.Deadline}} ({{.SisaWaktu}})</td></tr>
+</table>
+<p><a href="{{.URL}}">Buka tugas di PASTI</a></p>
+<p><small>Pesan otomatis dari Sistem PASTI</small></p>`,
+	},
+	kunciTemplate(TemplateReminder, KanalEmail, "en"): {
+		Judul: "Assignment reminder: {{.JudulTugas}}",
+		Isi: `<p>Hi {{.NamaSiswa}},</p>
+<p>You have not submitted the following assignment yet:</p>
+<table>
+<tr><td>Assignment</td><td><b>{{.JudulTugas}}</b></td></tr>
+<tr><td>Subject</td><td>{{.NamaMapel}}</td></tr>
+<tr><td>Class</td><td>{{.NamaKelas}}</td></tr>
+<tr><td>Deadline</td><td>{{.Deadline}} ({{.SisaWaktu}})</td></tr>
+</table>
+<p><a href="{{.URL}}">Open the assignment in PASTI</a></p>
+<p><small>Automated message from PASTI</small></p>`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalWhatsApp, "id"): {
+		Judul: "Tugas terlambat: {{.JudulTugas}}",
+		Isi: `⚠️ *TUGAS TERLAMBAT* ⚠️
+
+Halo {{.NamaSiswa}}!
+
+📚 *Tugas:* {{.JudulTugas}}
+📖 *Mata Pelajaran:* {{.NamaMapel}}
+🏫 *Kelas:* {{.NamaKelas}}
+⏰ *Deadline:* {{.Deadline}} (SUDAH LEWAT)
+
+🚨 Tugas ini sudah melewati deadline. Segera hubungi guru pengampu untuk menanyakan apakah masih bisa dikumpulkan terlambat.
+
+Akses sistem PASTI:
+🌐 {{.URL}}
+
+---
+📱 Pesan otomatis dari Sistem PASTI`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalWhatsApp, "en"): {
+		Judul: "Overdue assignment: {{.JudulTugas}}",
+		Isi: `⚠️ *OVERDUE ASSIGNMENT* ⚠️
+
+Hi {{.NamaSiswa}}!
+
+📚 *Assignment:* {{.JudulTugas}}
+📖 *Subject:* {{.NamaMapel}}
+🏫 *Class:* {{.NamaKelas}}
+⏰ *Deadline:* {{.Deadline}} (PASSED)
+
+🚨 This assignment is past its deadline. Please contact your teacher to ask whether a late submission is still possible.
+
+Open PASTI:
+🌐 {{.URL}}
+
+---
+📱 Automated message from PASTI`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalTemplateDefault, "id"): {
+		Judul: "Tugas terlambat: {{.JudulTugas}}",
+		Isi: `Halo {{.NamaSiswa}}, tugas "{{.JudulTugas}}" ({{.NamaMapel}}, {{.NamaKelas}}) sudah melewati deadline {{.Deadline}} dan belum dikumpulkan. Segera hubungi guru pengampu.
+
+{{.URL}}`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalTemplateDefault, "en"): {
+		Judul: "Overdue assignment: {{.JudulTugas}}",
+		Isi: `Hi {{.NamaSiswa}}, the assignment "{{.JudulTugas}}" ({{.NamaMapel}}, {{.NamaKelas}}) passed its deadline on {{.Deadline}} and has not been submitted. Please contact your teacher.
+
+{{.URL}}`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalEmail, "id"): {
+		Judul: "Tugas terlambat: {{.JudulTugas}}",
+		Isi: `<p>Halo {{.NamaSiswa}},</p>
+<p>Tugas <b>{{.JudulTugas}}</b> ({{.NamaMapel}}, {{.NamaKelas}}) sudah melewati deadline <b>{{.Deadline}}</b> dan belum dikumpulkan.</p>
+<p>Segera hubungi guru pengampu untuk menanyakan apakah masih bisa dikumpulkan terlambat.</p>
+<p><a href="{{.URL}}">Buka PASTI</a></p>
+<p><small>Pesan otomatis dari Sistem PASTI</small></p>`,
+	},
+	kunciTemplate(TemplateLewatDeadline, KanalEmail, "en"): {
+		Judul: "Overdue assignment: {{.JudulTugas}}",
+		Isi: `<p>Hi {{.NamaSiswa}},</p>
+<p>The assignment <b>{{.JudulTugas}}</b> ({{.NamaMapel}}, {{.NamaKelas}}) passed its deadline on <b>{{.Deadline}}</b> and has not been submitted.</p>
+<p>Please contact your teacher to ask whether a late submission is still possible.</p>
+<p><a href="{{.URL}}">Open PASTI</a></p>
+<p><small>Automated message from PASTI</small></p>`,
+	},
+}
+
+// AmbilTemplateBawaan - Template bawaan untuk kombinasi tertentu
+func AmbilTemplateBawaan(jenis, kanal, bahasa string) (TemplateBawaan, bool) {
+	t, ok := templateBawaan[kunciTemplate(jenis, kanal, bahasa)]
+	return t, ok
+}
+
+// BahasaValid - Kode bahasa yang didukung, "id" untuk nilai lain
+func BahasaValid(bahasa string) string {
+	for _, b := range BahasaNotifikasi {
+		if b == bahasa {
+			return b
+		}
+	}
+	return "id"
+}
+
+// TemplateDikenal - Apakah kombinasi jenis/kanal/bahasa didukung
+func TemplateDikenal(jenis, kanal, bahasa string) bool {
+	_, ok := templateBawaan[kunciTemplate(jenis, kanal, bahasa)]
+	return ok
+}
+
+// URLFrontend - FRONTEND_URL (default http://localhost:5174) tanpa garis miring di akhir
+func URLFrontend() string {
+	return strings.TrimRight(envDefault("FRONTEND_URL", "http://localhost:5174"), "/")
+}
+
+// SisaWaktu - Teks sisa waktu ke deadline dalam bahasa penerima
+func SisaWaktu(deadline time.Time, bahasa string) string {
+	sisa := time.Until(deadline)
+	en := bahasa == "en"
+	switch {
+	case sisa < 0:
+		if en {
+			return "passed"
+		}
+		return "sudah lewat"
+	case sisa < time.Hour:
+		if en {
+			return "in less than an hour"
+		}
+		return "kurang dari 1 jam lagi"
+	case sisa < 24*time.Hour:
+		if en {
+			return "in " + jumlahInggris(int(sisa.Hours()), "hour")
+		}
+		return fmt.Sprintf("%d jam lagi", int(sisa.Hours()))
+	default:
+		if en {
+			return "in " + jumlahInggris(int(sisa.Hours()/24), "day")
+		}
+		return fmt.Sprintf("%d hari lagi", int(sisa.Hours()/24))
+	}
+}
+
+// jumlahInggris - "1 day", "3 days"
+func jumlahInggris(n int, satuan string) string {
+	if n == 1 {
+		return "1 " + satuan
+	}
+	return fmt.Sprintf("%d %ss", n, satuan)
+}
+
+// DataTemplateDariTugas - Data template untuk satu siswa dan tugas. Deadline ditampilkan di zona waktu sekolah
+// (PengaturanReminder.ZonaWaktu), bukan zona waktu server
+func DataTemplateDariTugas(tugas TugasWithSiswa, jenis, bahasa string) DataTemplateNotifikasi {
+	lokasi, err := time.LoadLocation(AmbilPengaturanReminder().ZonaWaktu)
+	if err != nil {
+		lokasi = time.Local
+	}
+	return dataTemplate(tugas, jenis, bahasa, lokasi)
+}
+
+// dataTemplate - Data template dengan deadline di zona waktu lokasi
+func dataTemplate(tugas TugasWithSiswa, jenis, bahasa string, lokasi *time.Location) DataTemplateNotifikasi {
+	tugas.DeadlinePengumpulan = tugas.DeadlinePengumpulan.In(lokasi)
+	return DataTemplateNotifikasi{
+		TugasWithSiswa: tugas,
+		Jenis:          jenis,
+		Deadline:       tugas.DeadlinePengumpulan.Format("02 Jan 2006 15:04"),
+		SisaWaktu:      SisaWaktu(tugas.DeadlinePengumpulan, bahasa),
+		URL:            URLFrontend() + "/tugas",
+	}
+}
+
+// ContohTugasWithSiswa - Data contoh untuk preview template
+func ContohTugasWithSiswa() TugasWithSiswa {
+	return TugasWithSiswa{
+		TugasID:             1,
+		JudulTugas:          "Laporan Praktikum Fotosintesis",
+		DeadlinePengumpulan: time.Now().Add(26 * time.Hour).Truncate(time.Minute),
+		PoinMaksimal:        100,
+		NamaMapel:           "Biologi",
+		NamaKelas:           "XI IPA 1",
+		SiswaID:             1,
+		NamaSiswa:           "Budi Santoso",
+		NoTelepon:           "081234567890",
+		Email:               "budi@example.com",
+	}
+}
+
+// cacheTemplateDB - Template dari database, dimuat ulang paling lama setiap menit
+var (
+	cacheTemplateDB      map[string]models.TemplateNotifikasi
+	cacheTemplateDBWaktu time.Time
+	cacheTemplateDBMu    sync.Mutex
+)
+
+// ResetCacheTemplateNotifikasi - Dipanggil setelah admin mengubah template
+func ResetCacheTemplateNotifikasi() {
+	cacheTemplateDBMu.Lock()
+	cacheTemplateDB = nil
+	cacheTemplateDBMu.Unlock()
+}
+
+func templateDariDB(jenis, kanal, bahasa string) (models.TemplateNotifikasi, bool) {
+	cacheTemplateDBMu.Lock()
+	defer cacheTemplateDBMu.Unlock()
+
+	if cacheTemplateDB == nil || time.Since(cacheTemplateDBWaktu) > time.Minute {
+		var daftar []models.TemplateNotifikasi
+		if err := config.DB.Find(&daftar).Error; err != nil {
+			log.Printf("⚠️ Failed to load notification templates: %v", err)
+			return models.TemplateNotifikasi{}, false
+		}
+		cacheTemplateDB = make(map[string]models.TemplateNotifikasi, len(daftar))
+		for _, t := range daftar {
+			cacheTemplateDB[kunciTemplate(t.Jenis, t.Kanal, t.Bahasa)] = t
+		}
+		cacheTemplateDBWaktu = time.Now()
+	}
+	t, ok := cacheTemplateDB[kunciTemplate(jenis, kanal, bahasa)]
+	return t, ok
+}
+
+// TemplateEfektif - Template yang dipakai untuk kombinasi tertentu dan sumbernya ("database" atau "bawaan")
+func TemplateEfektif(jenis, kanal, bahasa string) (TemplateBawaan, string) {
+	if t, ok := templateDariDB(jenis, kanal, bahasa); ok {
+		return TemplateBawaan{Judul: t.Judul, Isi: t.Isi}, "database"
+	}
+	t, _ := AmbilTemplateBawaan(jenis, kanal, bahasa)
+	return t, "bawaan"
+}
+
+// RenderTemplate - Merender judul (text/template) dan isi; isi kanal email dirender dengan html/template
+func RenderTemplate(kanal string, t TemplateBawaan, data DataTemplateNotifikasi) (string, string, error) {
+	var judul, isi bytes.Buffer
+
+	tj, err := template.New("judul").Funcs(fungsiTemplate).Option("missingkey=error").Parse(t.Judul)
+	if err != nil {
+		return "", "", fmt.Errorf("judul: %w", err)
+	}
+	if err := tj.Execute(&judul, data); err != nil {
+		return "", "", fmt.Errorf("judul: %w", err)
+	}
+
+	if kanal == KanalEmail {
+		ti, err := htmltemplate.New("isi").Funcs(fungsiTemplate).Parse(t.Isi)
+		if err != nil {
+			return "", "", fmt.Errorf("isi: %w", err)
+		}
+		if err := ti.Execute(&isi, data); err != nil {
+			return "", "", fmt.Errorf("isi: %w", err)
+		}
+	} else {
+		ti, err := template.New("isi").Funcs(fungsiTemplate).Parse(t.Isi)
+		if err != nil {
+			return "", "", fmt.Errorf("isi: %w", err)
+		}
+		if err := ti.Execute(&isi, data); err != nil {
+			return "", "", fmt.Errorf("isi: %w", err)
+		}
+	}
+	return strings.TrimSpace(judul.String()), strings.TrimSpace(isi.String()), nil
+}
+
+// renderKanal - Render template efektif, kembali ke template bawaan jika template admin gagal dirender
+func renderKanal(jenis, kanal, bahasa string, data DataTemplateNotifikasi) (string, string) {
+	t, sumber := TemplateEfektif(jenis, kanal, bahasa)
+	judul, isi, err := RenderTemplate(kanal, t, data)
+	if err != nil && sumber == "database" {
+		log.Printf("⚠️ Template %s/%s/%s failed, using built-in: %v", jenis, kanal, bahasa, err)
+		bawaan, _ := AmbilTemplateBawaan(jenis, kanal, bahasa)
+		judul, isi, err = RenderTemplate(kanal, bawaan, data)
+	}
+	if err != nil {
+		log.Printf("❌ Built-in template %s/%s/%s failed: %v", jenis, kanal, bahasa, err)
+	}
+	return judul, isi
+}
+
+// RenderPesanNotifikasi - Pesan lengkap dengan varian WhatsApp dan email untuk satu penerima
+func RenderPesanNotifikasi(jenis, bahasa string, data DataTemplateNotifikasi, link string) PesanNotifikasi {
+	bahasa = BahasaValid(bahasa)
+	judul, isi := renderKanal(jenis, KanalTemplateDefault, bahasa, data)
+	pesan := PesanNotifikasi{Judul: judul, Isi: isi, Link: link, IsiLengkap: true}
+
+	waJudul, waIsi := renderKanal(jenis, KanalWhatsApp, bahasa, data)
+	emailJudul, emailHTML := renderKanal(jenis, KanalEmail, bahasa, data)
+	pesan.Varian = map[string]PesanNotifikasi{
+		KanalWhatsApp: {Judul: waJudul, Isi: waIsi, Link: link, IsiLengkap: true},
+		KanalEmail:    {Judul: emailJudul, Isi: isi, IsiHTML: emailHTML, Link: link, IsiLengkap: true},
+	}
+	return pesan
+}
+
+func init() {
+	// Template bawaan harus selalu bisa dirender; gagal di sini berarti bug di file ini. Database belum
+	// tersedia saat init, jadi zona waktu sekolah tidak dibaca
+	data := dataTemplate(ContohTugasWithSiswa(), "1_hari", "id", time.Local)
+	for kunci, t := range templateBawaan {
+		kanal := strings.Split(kunci, "|")[1]
+		if _, _, err := RenderTemplate(kanal, t, data); err != nil {
+			panic(fmt.Sprintf("template notifikasi bawaan %s tidak valid: %v", kunci, err))
+		}
+	}
+}
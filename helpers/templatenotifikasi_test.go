@@ -0,0 +1,141 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSisaWaktu(t *testing.T) {
+	// Selisih diberi sedikit kelebihan agar waktu berjalan selama test tidak menggeser pembulatan
+	tests := []struct {
+		sisa time.Duration
+		id   string
+		en   string
+	}{
+		{-time.Hour, "sudah lewat", "passed"},
+		{30 * time.Minute, "kurang dari 1 jam lagi", "in less than an hour"},
+		{90 * time.Minute, "1 jam lagi", "in 1 hour"},
+		{5*time.Hour + 30*time.Minute, "5 jam lagi", "in 5 hours"},
+		{25 * time.Hour, "1 hari lagi", "in 1 day"},
+		{73 * time.Hour, "3 hari lagi", "in 3 days"},
+	}
+	for _, tt := range tests {
+		deadline := time.Now().Add(tt.sisa)
+		if got := SisaWaktu(deadline, "id"); got != tt.id {
+			t.Errorf("SisaWaktu(%s, id) = %q, want %q", tt.sisa, got, tt.id)
+		}
+		if got := SisaWaktu(deadline, "en"); got != tt.en {
+			t.Errorf("SisaWaktu(%s, en) = %q, want %q", tt.sisa, got, tt.en)
+		}
+	}
+	if got := SisaWaktu(time.Now().Add(25*time.Hour), "fr"); got != "1 hari lagi" {
+		t.Errorf("bahasa tidak dikenal = %q, want bahasa Indonesia", got)
+	}
+}
+
+func TestDataTemplateZonaWaktu(t *testing.T) {
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+	tugas := ContohTugasWithSiswa()
+	tugas.DeadlinePengumpulan = time.Date(2026, 3, 10, 16, 30, 0, 0, time.UTC)
+
+	data := dataTemplate(tugas, "1_hari", "id", jakarta)
+	if data.Deadline != "10 Mar 2026 23:30" {
+		t.Errorf("Deadline = %q, want jam Jakarta", data.Deadline)
+	}
+	judul, _, err := RenderTemplate(KanalTemplateDefault, TemplateBawaan{Judul: `{{tanggal .DeadlinePengumpulan "02/01 15:04"}}`}, data)
+	if err != nil || judul != "10/03 23:30" {
+		t.Errorf("tanggal .DeadlinePengumpulan = %q, %v; want jam Jakarta", judul, err)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	data := dataTemplate(ContohTugasWithSiswa(), "2_jam", "id", time.UTC)
+	data.NamaSiswa = `Budi <b>"&"</b>`
+
+	tests := []struct {
+		nama      string
+		kanal     string
+		template  TemplateBawaan
+		wantJudul string
+		wantIsi   string
+		wantErr   bool
+	}{
+		{
+			nama:      "teks biasa tidak di-escape",
+			kanal:     KanalTemplateDefault,
+			template:  TemplateBawaan{Judul: "Halo {{.NamaSiswa}}", Isi: "  {{.NamaSiswa}} ({{.Jenis}})\n"},
+			wantJudul: `Halo Budi <b>"&"</b>`,
+			wantIsi:   `Budi <b>"&"</b> (2_jam)`,
+		},
+		{
+			nama:      "email di-escape, judul tidak",
+			kanal:     KanalEmail,
+			template:  TemplateBawaan{Judul: "Halo {{.NamaSiswa}}", Isi: "<p>{{.NamaSiswa}}</p>"},
+			wantJudul: `Halo Budi <b>"&"</b>`,
+			wantIsi:   `<p>Budi &lt;b&gt;&#34;&amp;&#34;&lt;/b&gt;</p>`,
+		},
+		{
+			nama:      "fungsi upper",
+			kanal:     KanalWhatsApp,
+			template:  TemplateBawaan{Judul: "{{upper .NamaMapel}}", Isi: "{{.PoinMaksimal}}"},
+			wantJudul: "BIOLOGI",
+			wantIsi:   "100",
+		},
+		{
+			nama:     "variabel tidak dikenal di judul",
+			kanal:    KanalTemplateDefault,
+			template: TemplateBawaan{Judul: "{{.TidakAda}}", Isi: "x"},
+			wantErr:  true,
+		},
+		{
+			nama:     "variabel tidak dikenal di isi email",
+			kanal:    KanalEmail,
+			template: TemplateBawaan{Judul: "x", Isi: "{{.TidakAda}}"},
+			wantErr:  true,
+		},
+		{
+			nama:     "sintaks rusak",
+			kanal:    KanalTemplateDefault,
+			template: TemplateBawaan{Judul: "x", Isi: "{{.NamaSiswa"},
+			wantErr:  true,
+		},
+		{
+			nama:     "fungsi tidak dikenal",
+			kanal:    KanalTemplateDefault,
+			template: TemplateBawaan{Judul: "{{lower .NamaSiswa}}", Isi: "x"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			judul, isi, err := RenderTemplate(tt.kanal, tt.template, data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("error tidak dikembalikan, judul %q isi %q", judul, isi)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if judul != tt.wantJudul || isi != tt.wantIsi {
+				t.Errorf("got (%q, %q), want (%q, %q)", judul, isi, tt.wantJudul, tt.wantIsi)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateBawaan(t *testing.T) {
+	data := dataTemplate(ContohTugasWithSiswa(), "1_hari", "en", time.UTC)
+	for kunci, tb := range templateBawaan {
+		kanal := strings.Split(kunci, "|")[1]
+		judul, isi, err := RenderTemplate(kanal, tb, data)
+		if err != nil {
+			t.Fatalf("%s: %v", kunci, err)
+		}
+		if !strings.Contains(judul, data.JudulTugas) || !strings.Contains(isi, data.NamaSiswa) || !strings.Contains(isi, data.Deadline) {
+			t.Errorf("%s tidak memuat judul, nama siswa atau deadline:\n%s\n%s", kunci, judul, isi)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package helpers
 import (
 	"Pasti/models"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -198,4 +199,29 @@ func ValidateTokenUniversal(tokenString string) (any, error) {
 	}
 
 	return claims, nil
-}
\ No newline at end of file
+}
+
+// ValidateTokenPengguna memvalidasi token siswa, guru maupun admin berdasarkan klaim role.
+// Berbeda dengan ValidateTokenUniversal, token guru/admin tidak akan terbaca sebagai siswa
+func ValidateTokenPengguna(tokenString string) (any, error) {
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return mySigningKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("unauthorized")
+	}
+
+	switch claims["role"] {
+	case "admin":
+		return ValidateTokenAdmin(tokenString)
+	case "guru":
+		return ValidateTokenGuru(tokenString)
+	case "siswa":
+		return ValidateToken(tokenString)
+	default:
+		return nil, fmt.Errorf("invalid token type")
+	}
+}
@@ -0,0 +1,401 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Validasi isi file upload: deteksi tipe dari magic bytes (bukan dari ekstensi) dan
+// pemeriksaan arsip .zip/.rar (jumlah entri, total ukuran hasil ekstrak, rasio kompresi,
+// entri terenkripsi dan nama entri dengan path traversal)
+
+var (
+	sigPDF  = []byte("%PDF-")
+	sigOLE  = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1} // .doc (Office 97-2003)
+	sigJPEG = []byte{0xFF, 0xD8, 0xFF}
+	sigPNG  = []byte{0x89, 'P', 'N', 'G', 0x0D, 0x0A, 0x1A, 0x0A}
+	sigZIP  = []byte("PK\x03\x04")
+	sigZIP0 = []byte("PK\x05\x06") // zip kosong
+	sigRAR4 = []byte("Rar!\x1A\x07\x00")
+	sigRAR5 = []byte("Rar!\x1A\x07\x01\x00")
+	sigEBML = []byte{0x1A, 0x45, 0xDF, 0xA3} // .webm/.mkv
+)
+
+// DeteksiTipeFile - Tipe file dari magic bytes: pdf, doc, zip, jpeg, png, rar, mp4, webm, atau "" jika tidak dikenal.
+// File .docx terdeteksi sebagai zip dan dibedakan lewat isi arsipnya
+func DeteksiTipeFile(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, sigPDF):
+		return "pdf"
+	case bytes.HasPrefix(header, sigOLE):
+		return "doc"
+	case bytes.HasPrefix(header, sigZIP), bytes.HasPrefix(header, sigZIP0):
+		return "zip"
+	case bytes.HasPrefix(header, sigJPEG):
+		return "jpeg"
+	case bytes.HasPrefix(header, sigPNG):
+		return "png"
+	case bytes.HasPrefix(header, sigRAR4), bytes.HasPrefix(header, sigRAR5):
+		return "rar"
+	case len(header) >= 12 && string(header[4:8]) == "ftyp": // ISO base media (.mp4/.mov)
+		return "mp4"
+	case bytes.HasPrefix(header, sigEBML):
+		return "webm"
+	}
+	return ""
+}
+
+// TipeFileEkstensi - Tipe isi yang wajib cocok untuk setiap ekstensi upload yang diizinkan
+var TipeFileEkstensi = map[string]string{
+	".pdf":  "pdf",
+	".doc":  "doc",
+	".docx": "zip",
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+	".zip":  "zip",
+	".rar":  "rar",
+	".mp4":  "mp4",
+	".mov":  "mp4",
+	".webm": "webm",
+	".mkv":  "webm",
+}
+
+// BatasArsip - Batas pemeriksaan arsip, dapat diatur lewat env
+type BatasArsip struct {
+	MaksEntri         int
+	MaksUkuranEkstrak int64
+	MaksRasio         float64
+}
+
+// BatasArsipDariEnv - ARSIP_MAKS_ENTRI (default 1000), ARSIP_MAKS_EKSTRAK_MB (default 500),
+// ARSIP_MAKS_RASIO (default 100)
+func BatasArsipDariEnv() BatasArsip {
+	batas := BatasArsip{MaksEntri: 1000, MaksUkuranEkstrak: 500 << 20, MaksRasio: 100}
+	if v, err := strconv.Atoi(os.Getenv("ARSIP_MAKS_ENTRI")); err == nil && v > 0 {
+		batas.MaksEntri = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("ARSIP_MAKS_EKSTRAK_MB"), 10, 64); err == nil && v > 0 {
+		batas.MaksUkuranEkstrak = v << 20
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ARSIP_MAKS_RASIO"), 64); err == nil && v > 0 {
+		batas.MaksRasio = v
+	}
+	return batas
+}
+
+// ErrArsipDitolak - Arsip melanggar batas pemeriksaan (kemungkinan zip bomb atau tidak dapat diperiksa)
+var ErrArsipDitolak = errors.New("arsip ditolak")
+
+// periksa - Membandingkan jumlah entri dan ukuran hasil ekstrak dengan batas
+func (b BatasArsip) periksa(jumlahEntri int, ukuranEkstrak, ukuranArsip int64) error {
+	if jumlahEntri > b.MaksEntri {
+		return fmt.Errorf("%w: berisi %d file (maksimal %d)", ErrArsipDitolak, jumlahEntri, b.MaksEntri)
+	}
+	if ukuranEkstrak > b.MaksUkuranEkstrak {
+		return fmt.Errorf("%w: ukuran setelah diekstrak %d MB (maksimal %d MB)", ErrArsipDitolak, ukuranEkstrak>>20, b.MaksUkuranEkstrak>>20)
+	}
+	if ukuranArsip > 0 && float64(ukuranEkstrak)/float64(ukuranArsip) > b.MaksRasio {
+		return fmt.Errorf("%w: rasio kompresi terlalu tinggi", ErrArsipDitolak)
+	}
+	return nil
+}
+
+// namaArsipAman - Nama entri arsip relatif tanpa komponen ".." (path traversal). Pemisah \ dari arsip
+// Windows diperlakukan sama dengan /
+func namaArsipAman(nama string) bool {
+	nama = strings.ReplaceAll(nama, `\`, "/")
+	if nama == "" || strings.HasPrefix(nama, "/") || (len(nama) >= 2 && nama[1] == ':') {
+		return false
+	}
+	for _, bagian := range strings.Split(nama, "/") {
+		if bagian == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// PeriksaZip - Memeriksa arsip zip (termasuk .docx). Untuk .docx wajib berisi word/document.xml
+func PeriksaZip(r io.ReaderAt, size int64, wajibDocx bool, batas BatasArsip) error {
+	arsip, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: zip tidak valid", ErrArsipDitolak)
+	}
+
+	var total uint64
+	adaDokumen := false
+	for _, f := range arsip.File {
+		if f.Name == "word/document.xml" {
+			adaDokumen = true
+		}
+		if !namaArsipAman(f.Name) {
+			return fmt.Errorf("%w: nama file %q tidak valid", ErrArsipDitolak, f.Name)
+		}
+		// Isi entri terenkripsi tidak dapat diperiksa pemindai
+		if f.Flags&0x1 != 0 {
+			return fmt.Errorf("%w: zip berisi file terenkripsi tidak dapat diperiksa", ErrArsipDitolak)
+		}
+		// Rasio per entri menangkap satu file kecil yang mengembang sangat besar
+		if f.CompressedSize64 > 0 && float64(f.UncompressedSize64)/float64(f.CompressedSize64) > batas.MaksRasio*10 {
+			return fmt.Errorf("%w: rasio kompresi %s terlalu tinggi", ErrArsipDitolak, f.Name)
+		}
+		total += f.UncompressedSize64
+	}
+
+	if wajibDocx && !adaDokumen {
+		return fmt.Errorf("%w: bukan dokumen Word yang valid", ErrArsipDitolak)
+	}
+	return batas.periksa(len(arsip.File), int64(min(total, uint64(1)<<62)), size)
+}
+
+// PeriksaRar - Membaca header blok RAR 4/5 untuk menghitung entri dan ukuran hasil ekstrak.
+// Arsip dengan header atau file terenkripsi ditolak karena isinya tidak dapat diperiksa, begitu juga
+// header yang terpotong dan nama file dengan path traversal
+func PeriksaRar(r io.ReaderAt, size int64, batas BatasArsip) error {
+	sig := make([]byte, 8)
+	if _, err := r.ReadAt(sig, 0); err != nil && err != io.EOF {
+		return fmt.Errorf("%w: rar tidak valid", ErrArsipDitolak)
+	}
+
+	var (
+		jumlah int
+		total  int64
+		err    error
+	)
+	switch {
+	case bytes.HasPrefix(sig, sigRAR5):
+		jumlah, total, err = bacaHeaderRar5(r, size)
+	case bytes.HasPrefix(sig, sigRAR4):
+		jumlah, total, err = bacaHeaderRar4(r, size)
+	default:
+		return fmt.Errorf("%w: rar tidak valid", ErrArsipDitolak)
+	}
+	if err != nil {
+		return err
+	}
+	return batas.periksa(jumlah, total, size)
+}
+
+// bacaHeaderRar4 - Blok RAR 1.5-4.x: CRC(2) TYPE(1) FLAGS(2) SIZE(2) [ADD_SIZE(4)]. Header file:
+// PACK_SIZE(4) UNP_SIZE(4) HOST_OS(1) FILE_CRC(4) FTIME(4) UNP_VER(1) METHOD(1) NAME_SIZE(2) ATTR(4)
+// [HIGH_PACK_SIZE(4) HIGH_UNP_SIZE(4)] FILE_NAME
+func bacaHeaderRar4(r io.ReaderAt, size int64) (int, int64, error) {
+	var jumlah int
+	var total int64
+	pos := int64(len(sigRAR4))
+	buf := make([]byte, 11)
+	rusak := fmt.Errorf("%w: header rar rusak", ErrArsipDitolak)
+
+	for pos+7 <= size {
+		n, _ := r.ReadAt(buf, pos)
+		if n < 7 {
+			break
+		}
+		tipe := buf[2]
+		flags := binary.LittleEndian.Uint16(buf[3:5])
+		ukuranHeader := int64(binary.LittleEndian.Uint16(buf[5:7]))
+		if ukuranHeader < 7 || pos+ukuranHeader > size {
+			return 0, 0, rusak
+		}
+
+		var tambahan int64
+		switch tipe {
+		case 0x73: // header arsip
+			if flags&0x0080 != 0 {
+				return 0, 0, fmt.Errorf("%w: rar dengan header terenkripsi tidak dapat diperiksa", ErrArsipDitolak)
+			}
+		case 0x74: // header file
+			if ukuranHeader < 32 {
+				return 0, 0, rusak
+			}
+			header := make([]byte, ukuranHeader)
+			if n, _ := r.ReadAt(header, pos); int64(n) < ukuranHeader {
+				return 0, 0, rusak
+			}
+			if flags&0x0004 != 0 {
+				return 0, 0, fmt.Errorf("%w: rar berisi file terenkripsi tidak dapat diperiksa", ErrArsipDitolak)
+			}
+
+			tambahan = int64(binary.LittleEndian.Uint32(header[7:11]))
+			unpacked := int64(binary.LittleEndian.Uint32(header[11:15]))
+			awalNama := int64(32)
+			if flags&0x0100 != 0 {
+				if ukuranHeader < 40 {
+					return 0, 0, rusak
+				}
+				tambahan |= int64(binary.LittleEndian.Uint32(header[32:36])) << 32
+				unpacked |= int64(binary.LittleEndian.Uint32(header[36:40])) << 32
+				awalNama = 40
+			}
+
+			panjangNama := int64(binary.LittleEndian.Uint16(header[26:28]))
+			if awalNama+panjangNama > ukuranHeader {
+				return 0, 0, rusak
+			}
+			// Nama Unicode (flag 0x200) diawali nama ASCII yang diakhiri byte 0
+			nama, _, _ := bytes.Cut(header[awalNama:awalNama+panjangNama], []byte{0})
+			if !namaArsipAman(string(nama)) {
+				return 0, 0, fmt.Errorf("%w: nama file %q tidak valid", ErrArsipDitolak, nama)
+			}
+
+			// Entri direktori tidak dihitung
+			if flags&0x00E0 != 0x00E0 {
+				jumlah++
+				total += unpacked
+			}
+		case 0x7B: // akhir arsip
+			return jumlah, total, nil
+		default:
+			if flags&0x8000 != 0 {
+				if n < 11 {
+					return 0, 0, rusak
+				}
+				tambahan = int64(binary.LittleEndian.Uint32(buf[7:11]))
+			}
+		}
+
+		pos += ukuranHeader + tambahan
+	}
+	return jumlah, total, nil
+}
+
+// bacaVint - Integer panjang variabel RAR5 (7 bit per byte, bit 8 = lanjut)
+func bacaVint(b []byte) (uint64, int) {
+	var hasil uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		hasil |= uint64(b[i]&0x7F) << (7 * i)
+		if b[i]&0x80 == 0 {
+			return hasil, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// pembacaVint - Membaca vint RAR5 berurutan dari satu header; ok menjadi false setelah data habis
+type pembacaVint struct {
+	b  []byte
+	p  int
+	ok bool
+}
+
+func (v *pembacaVint) vint() uint64 {
+	if !v.ok {
+		return 0
+	}
+	nilai, k := bacaVint(v.b[v.p:])
+	if k == 0 {
+		v.ok = false
+		return 0
+	}
+	v.p += k
+	return nilai
+}
+
+func (v *pembacaVint) lewati(n uint64) []byte {
+	if !v.ok || n > uint64(len(v.b)-v.p) {
+		v.ok = false
+		return nil
+	}
+	awal := v.p
+	v.p += int(n)
+	return v.b[awal:v.p]
+}
+
+// bacaHeaderRar5 - Blok RAR 5: CRC(4) HEADER_SIZE(vint) TYPE(vint) FLAGS(vint) [EXTRA_SIZE(vint)]
+// [DATA_SIZE(vint)] ... [EXTRA_AREA]. Header file: FILE_FLAGS UNPACKED_SIZE ATTRIBUTES [MTIME(4)]
+// [CRC32(4)] COMPRESSION HOST_OS NAME_LENGTH NAME; record extra bertipe 1 menandai file terenkripsi
+func bacaHeaderRar5(r io.ReaderAt, size int64) (int, int64, error) {
+	var jumlah int
+	var total int64
+	pos := int64(len(sigRAR5))
+	buf := make([]byte, 16)
+	rusak := fmt.Errorf("%w: header rar rusak", ErrArsipDitolak)
+
+	for pos+7 <= size {
+		n, _ := r.ReadAt(buf, pos)
+		if n < 7 {
+			break
+		}
+
+		ukuranHeader, k := bacaVint(buf[4:n])
+		if k == 0 || ukuranHeader == 0 || ukuranHeader > 2<<20 {
+			return 0, 0, rusak
+		}
+		awalHeader := int64(4 + k)
+		if pos+awalHeader+int64(ukuranHeader) > size {
+			return 0, 0, rusak
+		}
+		header := make([]byte, ukuranHeader)
+		if n, _ := r.ReadAt(header, pos+awalHeader); uint64(n) < ukuranHeader {
+			return 0, 0, rusak
+		}
+
+		v := &pembacaVint{b: header, ok: true}
+		tipe := v.vint()
+		flags := v.vint()
+		var ukuranExtra, ukuranData uint64
+		if flags&0x01 != 0 { // extra area
+			ukuranExtra = v.vint()
+		}
+		if flags&0x02 != 0 { // data area
+			ukuranData = v.vint()
+		}
+		if !v.ok || ukuranExtra > ukuranHeader {
+			return 0, 0, rusak
+		}
+
+		switch tipe {
+		case 4: // header enkripsi arsip
+			return 0, 0, fmt.Errorf("%w: rar dengan header terenkripsi tidak dapat diperiksa", ErrArsipDitolak)
+		case 2: // header file
+			fileFlags := v.vint()
+			unpacked := v.vint()
+			v.vint() // atribut
+			if fileFlags&0x02 != 0 {
+				v.lewati(4) // mtime
+			}
+			if fileFlags&0x04 != 0 {
+				v.lewati(4) // crc32
+			}
+			v.vint() // metode kompresi
+			v.vint() // host OS
+			nama := v.lewati(v.vint())
+			if !v.ok {
+				return 0, 0, rusak
+			}
+			if !namaArsipAman(string(nama)) {
+				return 0, 0, fmt.Errorf("%w: nama file %q tidak valid", ErrArsipDitolak, nama)
+			}
+
+			// Extra area berada di akhir header: SIZE(vint) TYPE(vint) DATA
+			extra := &pembacaVint{b: header[ukuranHeader-ukuranExtra:], ok: true}
+			for extra.ok && extra.p < len(extra.b) {
+				record := extra.lewati(extra.vint())
+				if !extra.ok {
+					return 0, 0, rusak
+				}
+				if tipeRecord, k := bacaVint(record); k > 0 && tipeRecord == 1 {
+					return 0, 0, fmt.Errorf("%w: rar berisi file terenkripsi tidak dapat diperiksa", ErrArsipDitolak)
+				}
+			}
+
+			if fileFlags&0x01 == 0 { // bukan direktori
+				jumlah++
+				total += int64(min(unpacked, uint64(1)<<40))
+			}
+		case 5: // akhir arsip
+			return jumlah, total, nil
+		}
+
+		pos += awalHeader + int64(ukuranHeader) + int64(min(ukuranData, uint64(size)))
+	}
+	return jumlah, total, nil
+}
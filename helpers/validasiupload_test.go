@@ -0,0 +1,267 @@
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeteksiTipeFile(t *testing.T) {
+	tests := []struct {
+		nama   string
+		header []byte
+		want   string
+	}{
+		{"pdf", []byte("%PDF-1.7\n"), "pdf"},
+		{"doc", append(append([]byte{}, sigOLE...), 0, 0), "doc"},
+		{"zip", []byte("PK\x03\x04\x14\x00"), "zip"},
+		{"zip kosong", []byte("PK\x05\x06\x00\x00"), "zip"},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "jpeg"},
+		{"png", append(append([]byte{}, sigPNG...), 0, 0, 0, 13), "png"},
+		{"rar4", []byte("Rar!\x1A\x07\x00\xCF"), "rar"},
+		{"rar5", []byte("Rar!\x1A\x07\x01\x00"), "rar"},
+		{"mp4", []byte("\x00\x00\x00\x18ftypmp42"), "mp4"},
+		{"webm", append(append([]byte{}, sigEBML...), 0x9F), "webm"},
+		// Magic bytes palsu: signature tidak di awal file, terpotong, atau tipe lain
+		{"exe", []byte("MZ\x90\x00\x03\x00"), ""},
+		{"html", []byte("<html><script>alert(1)</script>"), ""},
+		{"pdf tidak di awal", []byte(" %PDF-1.7"), ""},
+		{"png terpotong", sigPNG[:4], ""},
+		{"rar versi lain", []byte("Rar!\x1A\x07\x02\x00"), ""},
+		{"ftyp terlalu pendek", []byte("\x00\x00\x00\x18ftyp"), ""},
+		{"kosong", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := DeteksiTipeFile(tt.header); got != tt.want {
+				t.Fatalf("DeteksiTipeFile = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTipeFileEkstensiMenolakIsiLain(t *testing.T) {
+	// File PNG yang diberi ekstensi .jpg atau .pdf tidak lolos pencocokan
+	tipe := DeteksiTipeFile(sigPNG)
+	for _, ext := range []string{".jpg", ".pdf", ".docx"} {
+		if TipeFileEkstensi[ext] == tipe {
+			t.Fatalf("%s menerima isi %s", ext, tipe)
+		}
+	}
+}
+
+// ==================== ZIP ====================
+
+type entriZip struct {
+	nama     string
+	isi      []byte
+	simpan   bool // tanpa kompresi
+	enkripsi bool
+}
+
+func buatZip(t *testing.T, entri ...entriZip) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, e := range entri {
+		header := &zip.FileHeader{Name: e.nama, Method: zip.Deflate}
+		if e.simpan {
+			header.Method = zip.Store
+		}
+		if e.enkripsi {
+			header.Flags |= 0x1
+		}
+		f, err := w.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(e.isi)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func batasUji() BatasArsip {
+	return BatasArsip{MaksEntri: 10, MaksUkuranEkstrak: 10 << 20, MaksRasio: 100}
+}
+
+func TestPeriksaZip(t *testing.T) {
+	teks := []byte("jawaban tugas siswa")
+	tests := []struct {
+		nama      string
+		data      []byte
+		wajibDocx bool
+		ditolak   bool
+	}{
+		{"zip biasa", buatZip(t, entriZip{nama: "a.txt", isi: teks}, entriZip{nama: "folder/b.txt", isi: teks}), false, false},
+		{"docx", buatZip(t, entriZip{nama: "[Content_Types].xml", isi: teks}, entriZip{nama: "word/document.xml", isi: teks}), true, false},
+		{"docx tanpa document.xml", buatZip(t, entriZip{nama: "a.txt", isi: teks}), true, true},
+		{"zip bomb", buatZip(t, entriZip{nama: "nol.bin", isi: make([]byte, 2<<20)}), false, true},
+		{"terlalu banyak entri", buatZip(t, func() []entriZip {
+			var e []entriZip
+			for i := 0; i < 11; i++ {
+				e = append(e, entriZip{nama: strings.Repeat("x", i+1), isi: teks, simpan: true})
+			}
+			return e
+		}()...), false, true},
+		{"entri terenkripsi", buatZip(t, entriZip{nama: "rahasia.txt", isi: teks, enkripsi: true}), false, true},
+		{"path traversal", buatZip(t, entriZip{nama: "../../etc/cron.d/x", isi: teks}), false, true},
+		{"path traversal backslash", buatZip(t, entriZip{nama: `..\..\windows\x.dll`, isi: teks}), false, true},
+		{"path absolut", buatZip(t, entriZip{nama: "/etc/passwd", isi: teks}), false, true},
+		{"drive windows", buatZip(t, entriZip{nama: "C:/x.bat", isi: teks}), false, true},
+		{"bukan zip", []byte("PK\x03\x04 bukan zip"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			err := PeriksaZip(bytes.NewReader(tt.data), int64(len(tt.data)), tt.wajibDocx, batasUji())
+			if tt.ditolak != (err != nil) {
+				t.Fatalf("PeriksaZip = %v, ditolak = %v", err, tt.ditolak)
+			}
+			if err != nil && !errors.Is(err, ErrArsipDitolak) {
+				t.Fatalf("error %v bukan ErrArsipDitolak", err)
+			}
+		})
+	}
+}
+
+// ==================== RAR 4 ====================
+
+type entriRar4 struct {
+	nama     string
+	packed   []byte
+	unpacked uint32
+	flags    uint16
+}
+
+func blokRar4(tipe byte, flags uint16, isi []byte) []byte {
+	b := make([]byte, 7, 7+len(isi))
+	b[2] = tipe
+	binary.LittleEndian.PutUint16(b[3:5], flags)
+	binary.LittleEndian.PutUint16(b[5:7], uint16(7+len(isi)))
+	return append(b, isi...)
+}
+
+func buatRar4(flagsArsip uint16, entri ...entriRar4) []byte {
+	data := append([]byte{}, sigRAR4...)
+	data = append(data, blokRar4(0x73, flagsArsip, make([]byte, 6))...)
+	for _, e := range entri {
+		isi := make([]byte, 25)
+		binary.LittleEndian.PutUint32(isi[0:4], uint32(len(e.packed)))
+		binary.LittleEndian.PutUint32(isi[4:8], e.unpacked)
+		binary.LittleEndian.PutUint16(isi[19:21], uint16(len(e.nama)))
+		isi = append(isi, e.nama...)
+		data = append(data, blokRar4(0x74, 0x8000|e.flags, isi)...)
+		data = append(data, e.packed...)
+	}
+	return append(data, blokRar4(0x7B, 0, nil)...)
+}
+
+// ==================== RAR 5 ====================
+
+func vint(n uint64) []byte {
+	var b []byte
+	for n >= 0x80 {
+		b = append(b, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(b, byte(n))
+}
+
+func gabung(bagian ...[]byte) []byte {
+	return bytes.Join(bagian, nil)
+}
+
+func blokRar5(isi []byte) []byte {
+	return gabung(make([]byte, 4), vint(uint64(len(isi))), isi)
+}
+
+type entriRar5 struct {
+	nama     string
+	packed   []byte
+	unpacked uint64
+	extra    []byte // record extra area
+}
+
+func buatRar5(blokAwal []byte, entri ...entriRar5) []byte {
+	data := append([]byte{}, sigRAR5...)
+	if blokAwal != nil {
+		data = append(data, blokAwal...)
+	}
+	data = append(data, blokRar5(gabung(vint(1), vint(0), vint(0)))...)
+	for _, e := range entri {
+		flags, ukuran := uint64(0x02), vint(uint64(len(e.packed)))
+		if e.extra != nil {
+			flags |= 0x01
+			ukuran = gabung(vint(uint64(len(e.extra))), ukuran)
+		}
+		isi := gabung(vint(2), vint(flags), ukuran,
+			vint(0), vint(e.unpacked), vint(0), vint(0), vint(0), vint(uint64(len(e.nama))), []byte(e.nama), e.extra)
+		data = append(data, blokRar5(isi)...)
+		data = append(data, e.packed...)
+	}
+	return append(data, blokRar5(gabung(vint(5), vint(0), vint(0)))...)
+}
+
+func TestPeriksaRar(t *testing.T) {
+	packed := bytes.Repeat([]byte{0xAB}, 100)
+	rar4 := buatRar4(0, entriRar4{nama: "a.txt", packed: packed, unpacked: 500}, entriRar4{nama: `folder\b.txt`, packed: packed, unpacked: 500})
+	rar5 := buatRar5(nil, entriRar5{nama: "a.txt", packed: packed, unpacked: 500}, entriRar5{nama: "folder/b.txt", packed: packed, unpacked: 500})
+
+	tests := []struct {
+		nama    string
+		data    []byte
+		ditolak bool
+	}{
+		{"rar4", rar4, false},
+		{"rar4 header terpotong", rar4[:len(sigRAR4)+13+20], true},
+		{"rar4 ukuran header kurang dari 7", append(append([]byte{}, sigRAR4...), 0, 0, 0x73, 0, 0, 3, 0, 0, 0), true},
+		{"rar4 header terenkripsi", buatRar4(0x0080), true},
+		{"rar4 file terenkripsi", buatRar4(0, entriRar4{nama: "a.txt", packed: packed, unpacked: 500, flags: 0x0004}), true},
+		{"rar4 path traversal", buatRar4(0, entriRar4{nama: `..\..\x.exe`, packed: packed, unpacked: 500}), true},
+		{"rar4 rasio terlalu tinggi", buatRar4(0, entriRar4{nama: "nol.bin", packed: packed, unpacked: 1 << 20}), true},
+
+		{"rar5", rar5, false},
+		{"rar5 header terpotong", rar5[:len(sigRAR5)+5+12], true},
+		{"rar5 header terenkripsi", buatRar5(blokRar5(gabung(vint(4), vint(0), vint(0)))), true},
+		{"rar5 file terenkripsi", buatRar5(nil, entriRar5{nama: "a.txt", packed: packed, unpacked: 500, extra: gabung(vint(3), vint(1), []byte{0, 0})}), true},
+		{"rar5 extra bukan enkripsi", buatRar5(nil, entriRar5{nama: "a.txt", packed: packed, unpacked: 500, extra: gabung(vint(3), vint(2), []byte{0, 0})}), false},
+		{"rar5 path traversal", buatRar5(nil, entriRar5{nama: "../../x.sh", packed: packed, unpacked: 500}), true},
+		{"rar5 ukuran header terlalu besar", append(append([]byte{}, sigRAR5...), gabung(make([]byte, 4), vint(3<<20), make([]byte, 8))...), true},
+		{"rar5 rasio terlalu tinggi", buatRar5(nil, entriRar5{nama: "nol.bin", packed: packed, unpacked: 1 << 20}), true},
+
+		{"signature palsu", []byte("Rar!\x1A\x07\x02\x00aaaaaaaaaaaa"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			err := PeriksaRar(bytes.NewReader(tt.data), int64(len(tt.data)), batasUji())
+			if tt.ditolak != (err != nil) {
+				t.Fatalf("PeriksaRar = %v, ditolak = %v", err, tt.ditolak)
+			}
+			if err != nil && !errors.Is(err, ErrArsipDitolak) {
+				t.Fatalf("error %v bukan ErrArsipDitolak", err)
+			}
+		})
+	}
+}
+
+func TestPeriksaRarMenghitungEntri(t *testing.T) {
+	packed := []byte("data")
+	var entri []entriRar5
+	for i := 0; i < 11; i++ {
+		entri = append(entri, entriRar5{nama: strings.Repeat("f", i+1), packed: packed, unpacked: 4})
+	}
+	data := buatRar5(nil, entri...)
+	if err := PeriksaRar(bytes.NewReader(data), int64(len(data)), batasUji()); !errors.Is(err, ErrArsipDitolak) {
+		t.Fatalf("PeriksaRar 11 entri = %v, want ErrArsipDitolak", err)
+	}
+
+	n, total, err := bacaHeaderRar5(bytes.NewReader(data), int64(len(data)))
+	if err != nil || n != 11 || total != 44 {
+		t.Fatalf("bacaHeaderRar5 = %d, %d, %v", n, total, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"Pasti/cron"
 	"Pasti/middleware"
 	"Pasti/routes"
+	"Pasti/storage"
 	"log"
 	"net/http"
 
@@ -21,14 +22,33 @@ func main() {
 
 
 	config.ConnectDB()
+	storage.Init()
 
 	cron.StartCronJobs()
 	r := mux.NewRouter()
 		// Upload endpoint - dengan authentication middleware  
-	r.Handle("/api/upload/tugas", middleware.AuthUniversal(http.HandlerFunc(controllers.UploadFileHandler))).Methods("POST")
+	r.Handle("/api/upload/tugas", middleware.AuthPengguna(http.HandlerFunc(controllers.UploadFileHandler))).Methods("POST")
+	// Upload bertahap (resumable) untuk file besar
+	r.Handle("/api/upload/bertahap", middleware.AuthPengguna(http.HandlerFunc(controllers.BuatUploadBertahap))).Methods("POST")
+	r.Handle("/api/upload/bertahap/{upload_id}", middleware.AuthPengguna(http.HandlerFunc(controllers.StatusUploadBertahap))).Methods("GET", "HEAD")
+	r.Handle("/api/upload/bertahap/{upload_id}", middleware.AuthPengguna(http.HandlerFunc(controllers.KirimPotonganUpload))).Methods("PATCH")
+	r.Handle("/api/upload/bertahap/{upload_id}", middleware.AuthPengguna(http.HandlerFunc(controllers.BatalkanUploadBertahap))).Methods("DELETE")
 	
-	// File serving - TANPA authentication untuk akses publik
+	// File serving - header Authorization atau tautan bertanda tangan (dicek di controller)
 	r.HandleFunc("/uploads/tugas/{filename}", controllers.ServeProtectedFile).Methods("GET")
+	r.HandleFunc("/uploads/thumb/{filename}", controllers.ServeProtectedFile).Methods("GET")
+	r.HandleFunc("/uploads/profil/{filename}", controllers.ServeProtectedFile).Methods("GET")
+	r.Handle("/api/files/tautan", middleware.AuthPengguna(http.HandlerFunc(controllers.BuatTautanFile))).Methods("POST")
+
+	// Kotak masuk notifikasi in-app (siswa & guru)
+	r.Handle("/api/notifikasi", middleware.AuthPengguna(http.HandlerFunc(controllers.GetNotifikasi))).Methods("GET")
+	r.Handle("/api/notifikasi/belum-dibaca", middleware.AuthPengguna(http.HandlerFunc(controllers.GetJumlahNotifikasiBelumDibaca))).Methods("GET")
+	r.Handle("/api/notifikasi/baca-semua", middleware.AuthPengguna(http.HandlerFunc(controllers.BacaSemuaNotifikasi))).Methods("PUT")
+	r.Handle("/api/notifikasi/{notifikasi_id:[0-9]+}/baca", middleware.AuthPengguna(http.HandlerFunc(controllers.BacaNotifikasi))).Methods("PUT")
+	r.Handle("/api/notifikasi/{notifikasi_id:[0-9]+}", middleware.AuthPengguna(http.HandlerFunc(controllers.DeleteNotifikasi))).Methods("DELETE")
+	r.Handle("/api/notifikasi/stream", middleware.AuthPengguna(http.HandlerFunc(controllers.StreamNotifikasi))).Methods("GET")
+	r.Handle("/api/notifikasi/stream/tautan", middleware.AuthPengguna(http.HandlerFunc(controllers.BuatTautanStreamNotifikasi))).Methods("POST")
+	r.HandleFunc("/api/notifikasi/stream/{tipe_user:Siswa|Guru}/{user_id:[0-9]+}", controllers.StreamNotifikasi).Methods("GET")
 	
 	router := r.PathPrefix("/api").Subrouter()
 		routes.AuthRoutes(router)
@@ -59,8 +79,9 @@ func enableCORS(h http.Handler) http.Handler {
 		} else {
 			w.Header().Set("Access-Control-Allow-Origin", "*") // atau bisa ditolak
 		}
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Upload-Offset, Upload-Checksum")
+		w.Header().Set("Access-Control-Expose-Headers", "Upload-Offset, Upload-Length, Location")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Untuk request preflight (OPTIONS)
@@ -128,4 +128,28 @@ func AuthAdmin(next http.Handler) http.Handler {
 		ctx := context.WithValue(r.Context(), "admininfo", admin)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+// AuthPengguna middleware untuk autentikasi siswa, guru atau admin (dibedakan dari klaim role)
+func AuthPengguna(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := helpers.ValidateTokenPengguna(r.Header.Get("Authorization"))
+		if err != nil {
+			helpers.Response(w, 401, err.Error(), nil)
+			return
+		}
+
+		var ctx context.Context
+		switch u := user.(type) {
+		case *helpers.MyCustomClaims:
+			ctx = context.WithValue(r.Context(), "siswainfo", u)
+		case *helpers.GuruCustomClaims:
+			ctx = context.WithValue(r.Context(), "guruinfo", u)
+		case *helpers.AdminCustomClaims:
+			ctx = context.WithValue(r.Context(), "admininfo", u)
+		default:
+			helpers.Response(w, 401, "invalid token type", nil)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
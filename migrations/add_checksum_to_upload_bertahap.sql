@@ -0,0 +1,7 @@
+-- Migration: Add checksum & dikunci_sampai to uploadbertahap
+-- Checksum seluruh file yang dicek sebelum file disimpan, dan kunci sesi di database agar potongan
+-- yang masuk ke instance server berbeda tidak diterima dua kali pada offset yang sama
+
+ALTER TABLE `uploadbertahap`
+  ADD COLUMN `checksum` varchar(150) NOT NULL DEFAULT '' AFTER `diterima`,
+  ADD COLUMN `dikunci_sampai` timestamp NULL DEFAULT NULL AFTER `kedaluwarsa`;
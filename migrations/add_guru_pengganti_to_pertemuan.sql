@@ -0,0 +1,13 @@
+-- Migration script untuk menambahkan guru pengganti per pertemuan
+-- Guru pengganti hanya mendapat akses ke pertemuan tersebut setelah disetujui admin
+
+ALTER TABLE `pertemuan`
+ADD COLUMN `guru_pengganti_id` INT NULL AFTER `is_active`,
+ADD COLUMN `alasan_pengganti` VARCHAR(255) NULL AFTER `guru_pengganti_id`,
+ADD COLUMN `pengganti_disetujui_oleh` VARCHAR(100) NULL AFTER `alasan_pengganti`,
+ADD COLUMN `pengganti_disetujui_pada` TIMESTAMP NULL AFTER `pengganti_disetujui_oleh`,
+ADD KEY `idx_guru_pengganti_id` (`guru_pengganti_id`),
+ADD CONSTRAINT `fk_pertemuan_guru_pengganti` FOREIGN KEY (`guru_pengganti_id`) REFERENCES `guru` (`guru_id`) ON DELETE SET NULL;
+
+-- Verify the changes
+DESCRIBE `pertemuan`;
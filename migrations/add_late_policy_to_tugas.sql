@@ -0,0 +1,30 @@
+-- Migration: Kebijakan keterlambatan tugas
+-- Masa tenggang, tanggal tutup mutlak, penalti per hari, perpanjangan deadline per siswa
+
+ALTER TABLE `tugas`
+ADD COLUMN `grace_period_menit` INT NOT NULL DEFAULT 0 AFTER `tolak_setelah_dinilai`,
+ADD COLUMN `tanggal_tutup` DATETIME NULL AFTER `grace_period_menit`,
+ADD COLUMN `penalti_persen_per_hari` DECIMAL(5,2) NOT NULL DEFAULT 0 AFTER `tanggal_tutup`,
+ADD COLUMN `penalti_maksimal_persen` DECIMAL(5,2) NOT NULL DEFAULT 100 AFTER `penalti_persen_per_hari`;
+
+-- Poin mentah (sebelum penalti) dan penalti yang diterapkan
+ALTER TABLE `pengumpulantugas`
+ADD COLUMN `poin_mentah` INT NULL AFTER `versi_dinilai_id`,
+ADD COLUMN `persen_penalti` DECIMAL(5,2) NOT NULL DEFAULT 0 AFTER `poin_mentah`,
+ADD COLUMN `hari_terlambat` INT NOT NULL DEFAULT 0 AFTER `persen_penalti`;
+
+CREATE TABLE IF NOT EXISTS `perpanjangandeadline` (
+  `perpanjangan_id` int NOT NULL AUTO_INCREMENT,
+  `tugas_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  `deadline_baru` datetime NOT NULL,
+  `alasan` varchar(255) DEFAULT NULL,
+  `diberikan_oleh` int NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`perpanjangan_id`),
+  UNIQUE KEY `uk_perpanjangan_tugas_siswa` (`tugas_id`, `siswa_id`),
+  CONSTRAINT `fk_perpanjangan_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_perpanjangan_siswa` FOREIGN KEY (`siswa_id`) REFERENCES `siswa` (`siswa_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_perpanjangan_guru` FOREIGN KEY (`diberikan_oleh`) REFERENCES `guru` (`guru_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
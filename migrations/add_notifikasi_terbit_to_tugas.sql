@@ -0,0 +1,10 @@
+-- Migration: Add notifikasi_terbit_at to tugas
+-- Menandai tugas yang notifikasi "tugas baru"-nya sudah masuk ke kotak masuk siswa. Tugas yang sudah
+-- terbit sebelum migrasi ditandai terkirim agar tidak diumumkan ulang
+
+ALTER TABLE `tugas`
+  ADD COLUMN `notifikasi_terbit_at` timestamp NULL DEFAULT NULL AFTER `maks_ukuran_file_mb`;
+
+UPDATE `tugas`
+  SET `notifikasi_terbit_at` = NOW()
+  WHERE `tanggal_terbit` IS NULL OR `tanggal_terbit` <= NOW();
@@ -0,0 +1,28 @@
+-- Migration: Publikasi terjadwal tugas & template tugas
+-- Tugas dengan tanggal_terbit di masa depan tidak terlihat siswa dan tidak masuk reminder
+
+ALTER TABLE `tugas`
+ADD COLUMN `tanggal_terbit` timestamp NULL DEFAULT NULL AFTER `penalti_maksimal_persen`,
+ADD KEY `idx_tugas_tanggal_terbit` (`tanggal_terbit`);
+
+CREATE TABLE IF NOT EXISTS `templatetugas` (
+  `template_id` int NOT NULL AUTO_INCREMENT,
+  `guru_id` int NOT NULL,
+  `nama_template` varchar(255) NOT NULL,
+  `judul_tugas` varchar(255) NOT NULL,
+  `deskripsi_tugas` text,
+  `file_tugas_guru` varchar(255) DEFAULT NULL,
+  `poin_maksimal` int DEFAULT 100,
+  `tipe_tugas` enum('Individu','Kelompok') DEFAULT 'Individu',
+  `maks_pengumpulan_ulang` int NULL,
+  `tolak_setelah_dinilai` tinyint(1) NOT NULL DEFAULT 0,
+  `grace_period_menit` int NOT NULL DEFAULT 0,
+  `penalti_persen_per_hari` decimal(5,2) NOT NULL DEFAULT 0,
+  `penalti_maksimal_persen` decimal(5,2) NOT NULL DEFAULT 100,
+  `rubrik_json` text,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`template_id`),
+  KEY `idx_templatetugas_guru_id` (`guru_id`),
+  CONSTRAINT `fk_templatetugas_guru` FOREIGN KEY (`guru_id`) REFERENCES `guru` (`guru_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
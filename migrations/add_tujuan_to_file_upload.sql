@@ -0,0 +1,5 @@
+-- Migration: Add tujuan to fileupload
+-- Keperluan file upload (jawaban, lampiran tugas, komentar, materi) untuk laporan dan pembersihan file yatim
+
+ALTER TABLE `fileupload`
+  ADD COLUMN `tujuan` enum('JawabanTugas','LampiranTugas','LampiranKomentar','Materi','Lainnya') NOT NULL DEFAULT 'Lainnya' AFTER `tugas_id`;
@@ -0,0 +1,11 @@
+-- Migration: Add 'usang' status & MEDIUMTEXT response_api to outboxnotifikasi
+-- Reminder yang tidak berlaku lagi saat akan dikirim (siswa sudah mengumpulkan, deadline lewat atau diundur)
+-- ditutup sebagai 'usang', bukan dicatat gagal. Riwayat percobaan di response_api bisa melebihi 64 KB
+-- setelah pesan dead letter diantrekan ulang beberapa kali
+
+ALTER TABLE `outboxnotifikasi`
+  MODIFY COLUMN `status` enum('menunggu','diproses','terkirim','dead_letter','usang') NOT NULL DEFAULT 'menunggu',
+  MODIFY COLUMN `response_api` mediumtext;
+
+ALTER TABLE `notifikasi_tugas`
+  MODIFY COLUMN `response_api` mediumtext;
@@ -0,0 +1,32 @@
+-- Migration: Create banksoal & opsibanksoal tables
+-- Bank soal per mata pelajaran, dipakai bersama oleh semua guru pengampu mapel tersebut.
+-- Soal diberi tag topik, tingkat kesulitan dan level taksonomi Bloom untuk pengambilan sampel kuis
+
+CREATE TABLE IF NOT EXISTS `banksoal` (
+  `bank_soal_id` int NOT NULL AUTO_INCREMENT,
+  `mapel_id` int NOT NULL,
+  `guru_id` int NOT NULL,
+  `tipe_soal` enum('PilihanGanda','PilihanJamak','BenarSalah','IsianSingkat') NOT NULL,
+  `pertanyaan` text NOT NULL,
+  `poin` int NOT NULL DEFAULT 1,
+  `topik` varchar(100) DEFAULT NULL,
+  `tingkat_kesulitan` varchar(20) DEFAULT NULL, -- Mudah | Sedang | Sulit
+  `level_bloom` varchar(2) DEFAULT NULL, -- C1 - C6
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`bank_soal_id`),
+  KEY `idx_banksoal_tag` (`mapel_id`, `topik`, `tingkat_kesulitan`, `level_bloom`),
+  CONSTRAINT `fk_banksoal_mapel` FOREIGN KEY (`mapel_id`) REFERENCES `matapelajaran` (`mapel_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_banksoal_guru` FOREIGN KEY (`guru_id`) REFERENCES `guru` (`guru_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `opsibanksoal` (
+  `opsi_bank_id` int NOT NULL AUTO_INCREMENT,
+  `bank_soal_id` int NOT NULL,
+  `teks` text NOT NULL,
+  `is_benar` tinyint(1) NOT NULL DEFAULT 0,
+  `urutan` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`opsi_bank_id`),
+  KEY `idx_opsibanksoal_bank_soal_id` (`bank_soal_id`),
+  CONSTRAINT `fk_opsibanksoal_banksoal` FOREIGN KEY (`bank_soal_id`) REFERENCES `banksoal` (`bank_soal_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,23 @@
+-- Migration: Create fileupload table
+-- Catatan setiap file upload (pemilik, ukuran, hash) untuk kuota penyimpanan per user dan per tugas,
+-- serta status karantina untuk file yang gagal pemindaian malware
+
+CREATE TABLE IF NOT EXISTS `fileupload` (
+  `file_id` int NOT NULL AUTO_INCREMENT,
+  `kunci` varchar(255) NOT NULL,
+  `nama_asli` varchar(255) DEFAULT NULL,
+  `ukuran` bigint NOT NULL,
+  `content_type` varchar(100) DEFAULT NULL,
+  `hash_sha256` char(64) DEFAULT NULL,
+  `tipe_user` enum('Siswa','Guru','Admin') NOT NULL,
+  `user_id` int NOT NULL,
+  `tugas_id` int DEFAULT NULL,
+  `status` enum('Aktif','Karantina') NOT NULL DEFAULT 'Aktif',
+  `alasan_karantina` varchar(255) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`file_id`),
+  UNIQUE KEY `uk_fileupload_kunci` (`kunci`),
+  KEY `idx_fileupload_user` (`tipe_user`, `user_id`, `status`),
+  KEY `idx_fileupload_tugas` (`tugas_id`, `status`),
+  CONSTRAINT `fk_fileupload_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
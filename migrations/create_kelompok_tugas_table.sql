@@ -0,0 +1,34 @@
+-- Migration: Create kelompoktugas & anggotakelompok tables
+-- Kelompok siswa untuk tugas bertipe 'Kelompok'. Satu pengumpulan dipakai bersama
+-- oleh seluruh anggota (baris pengumpulantugas per anggota dengan kelompok_id yang sama)
+
+CREATE TABLE IF NOT EXISTS `kelompoktugas` (
+  `kelompok_id` int NOT NULL AUTO_INCREMENT,
+  `tugas_id` int NOT NULL,
+  `nama_kelompok` varchar(100) NOT NULL,
+  `poin_kelompok` int NULL,
+  `catatan_guru` text,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`kelompok_id`),
+  KEY `idx_kelompoktugas_tugas_id` (`tugas_id`),
+  CONSTRAINT `fk_kelompoktugas_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `anggotakelompok` (
+  `anggota_id` int NOT NULL AUTO_INCREMENT,
+  `kelompok_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  PRIMARY KEY (`anggota_id`),
+  UNIQUE KEY `uk_anggotakelompok` (`kelompok_id`, `siswa_id`),
+  KEY `idx_anggotakelompok_siswa_id` (`siswa_id`),
+  CONSTRAINT `fk_anggotakelompok_kelompok` FOREIGN KEY (`kelompok_id`) REFERENCES `kelompoktugas` (`kelompok_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_anggotakelompok_siswa` FOREIGN KEY (`siswa_id`) REFERENCES `siswa` (`siswa_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- Pengumpulan kelompok: kelompok_id sama untuk semua anggota, penyesuaian_poin per anggota
+ALTER TABLE `pengumpulantugas`
+ADD COLUMN `kelompok_id` INT NULL AFTER `poin_didapat`,
+ADD COLUMN `penyesuaian_poin` INT NOT NULL DEFAULT 0 AFTER `kelompok_id`,
+ADD KEY `idx_pengumpulantugas_kelompok_id` (`kelompok_id`),
+ADD CONSTRAINT `fk_pengumpulantugas_kelompok` FOREIGN KEY (`kelompok_id`) REFERENCES `kelompoktugas` (`kelompok_id`) ON DELETE SET NULL;
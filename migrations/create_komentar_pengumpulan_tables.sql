@@ -0,0 +1,27 @@
+-- Migration: Create komentarpengumpulan & bacakomentar tables
+-- Thread komentar per pengumpulan tugas antara guru dan siswa (dengan lampiran opsional),
+-- serta penanda komentar terakhir yang dibaca tiap user untuk menghitung komentar belum dibaca
+
+CREATE TABLE IF NOT EXISTS `komentarpengumpulan` (
+  `komentar_id` int NOT NULL AUTO_INCREMENT,
+  `pengumpulan_id` int NOT NULL,
+  `pengirim_id` int NOT NULL,
+  `tipe_pengirim` enum('Siswa','Guru') NOT NULL,
+  `isi` text,
+  `file_lampiran` varchar(255) DEFAULT NULL,
+  `nama_lampiran` varchar(255) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`komentar_id`),
+  KEY `idx_komentarpengumpulan_pengumpulan` (`pengumpulan_id`, `komentar_id`),
+  CONSTRAINT `fk_komentarpengumpulan_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `bacakomentar` (
+  `pengumpulan_id` int NOT NULL,
+  `tipe_user` enum('Siswa','Guru') NOT NULL,
+  `user_id` int NOT NULL,
+  `terakhir_dibaca_id` int NOT NULL DEFAULT 0,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`pengumpulan_id`, `tipe_user`, `user_id`),
+  CONSTRAINT `fk_bacakomentar_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,78 @@
+-- Migration: Create kuis, soalkuis, opsisoal, percobaankuis & jawabankuis tables
+-- Kuis online pada jadwal pelajaran. Setiap kuis memiliki satu tugas pendamping sehingga
+-- skor percobaan siswa disimpan ke pengumpulantugas seperti tugas biasa
+
+CREATE TABLE IF NOT EXISTS `kuis` (
+  `kuis_id` int NOT NULL AUTO_INCREMENT,
+  `tugas_id` int NOT NULL,
+  `jadwal_id` int NOT NULL,
+  `waktu_mulai` datetime NOT NULL,
+  `waktu_selesai` datetime NOT NULL,
+  `durasi_menit` int NOT NULL,
+  `acak_soal` tinyint(1) NOT NULL DEFAULT 0,
+  `acak_opsi` tinyint(1) NOT NULL DEFAULT 0,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`kuis_id`),
+  UNIQUE KEY `uk_kuis_tugas_id` (`tugas_id`),
+  KEY `idx_kuis_jadwal_id` (`jadwal_id`),
+  CONSTRAINT `fk_kuis_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_kuis_jadwal` FOREIGN KEY (`jadwal_id`) REFERENCES `jadwalpelajaran` (`jadwal_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `soalkuis` (
+  `soal_id` int NOT NULL AUTO_INCREMENT,
+  `kuis_id` int NOT NULL,
+  `tipe_soal` enum('PilihanGanda','PilihanJamak','BenarSalah','IsianSingkat') NOT NULL,
+  `pertanyaan` text NOT NULL,
+  `poin` int NOT NULL DEFAULT 1,
+  `urutan` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`soal_id`),
+  KEY `idx_soalkuis_kuis_id` (`kuis_id`),
+  CONSTRAINT `fk_soalkuis_kuis` FOREIGN KEY (`kuis_id`) REFERENCES `kuis` (`kuis_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- Untuk soal IsianSingkat, opsi berisi jawaban yang diterima (tidak pernah dikirim ke siswa)
+CREATE TABLE IF NOT EXISTS `opsisoal` (
+  `opsi_id` int NOT NULL AUTO_INCREMENT,
+  `soal_id` int NOT NULL,
+  `teks` text NOT NULL,
+  `is_benar` tinyint(1) NOT NULL DEFAULT 0,
+  `urutan` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`opsi_id`),
+  KEY `idx_opsisoal_soal_id` (`soal_id`),
+  CONSTRAINT `fk_opsisoal_soal` FOREIGN KEY (`soal_id`) REFERENCES `soalkuis` (`soal_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `percobaankuis` (
+  `percobaan_id` int NOT NULL AUTO_INCREMENT,
+  `kuis_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  `mulai_pada` datetime NOT NULL,
+  `batas_waktu` datetime NOT NULL,
+  `selesai_pada` datetime NULL,
+  `urutan_soal` text,
+  `urutan_opsi` text,
+  `skor` int NOT NULL DEFAULT 0,
+  `status` enum('Berlangsung','Menunggu Review','Selesai') DEFAULT 'Berlangsung',
+  PRIMARY KEY (`percobaan_id`),
+  UNIQUE KEY `uk_percobaankuis` (`kuis_id`, `siswa_id`),
+  KEY `idx_percobaankuis_status` (`status`, `batas_waktu`),
+  CONSTRAINT `fk_percobaankuis_kuis` FOREIGN KEY (`kuis_id`) REFERENCES `kuis` (`kuis_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_percobaankuis_siswa` FOREIGN KEY (`siswa_id`) REFERENCES `siswa` (`siswa_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `jawabankuis` (
+  `jawaban_id` int NOT NULL AUTO_INCREMENT,
+  `percobaan_id` int NOT NULL,
+  `soal_id` int NOT NULL,
+  `opsi_dipilih` varchar(255) DEFAULT NULL,
+  `jawaban_teks` text,
+  `poin` int NULL,
+  `dinilai_manual` tinyint(1) NOT NULL DEFAULT 0,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`jawaban_id`),
+  UNIQUE KEY `uk_jawabankuis` (`percobaan_id`, `soal_id`),
+  CONSTRAINT `fk_jawabankuis_percobaan` FOREIGN KEY (`percobaan_id`) REFERENCES `percobaankuis` (`percobaan_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_jawabankuis_soal` FOREIGN KEY (`soal_id`) REFERENCES `soalkuis` (`soal_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
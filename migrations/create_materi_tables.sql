@@ -0,0 +1,45 @@
+-- Migration: Create seksimateri, itemmateri & aksesmateri tables
+-- Repositori materi pembelajaran per pertemuan: seksi berurutan berisi file, tautan atau video,
+-- serta catatan siswa yang membuka tiap item materi
+
+CREATE TABLE IF NOT EXISTS `seksimateri` (
+  `seksi_id` int NOT NULL AUTO_INCREMENT,
+  `pertemuan_id` int NOT NULL,
+  `judul` varchar(255) NOT NULL,
+  `deskripsi` text,
+  `urutan` int DEFAULT 0,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`seksi_id`),
+  KEY `idx_seksimateri_pertemuan` (`pertemuan_id`, `urutan`),
+  CONSTRAINT `fk_seksimateri_pertemuan` FOREIGN KEY (`pertemuan_id`) REFERENCES `pertemuan` (`id_pertemuan`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `itemmateri` (
+  `item_id` int NOT NULL AUTO_INCREMENT,
+  `seksi_id` int NOT NULL,
+  `tipe` enum('File','Tautan','Video') NOT NULL,
+  `judul` varchar(255) NOT NULL,
+  `deskripsi` text,
+  `url` varchar(500) NOT NULL,
+  `nama_file` varchar(255) DEFAULT NULL,
+  `urutan` int DEFAULT 0,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`item_id`),
+  KEY `idx_itemmateri_seksi` (`seksi_id`, `urutan`),
+  KEY `idx_itemmateri_url` (`url`(191)),
+  CONSTRAINT `fk_itemmateri_seksi` FOREIGN KEY (`seksi_id`) REFERENCES `seksimateri` (`seksi_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `aksesmateri` (
+  `item_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  `jumlah_dibuka` int NOT NULL DEFAULT 1,
+  `pertama_dibuka` datetime NOT NULL,
+  `terakhir_dibuka` datetime NOT NULL,
+  PRIMARY KEY (`item_id`, `siswa_id`),
+  KEY `idx_aksesmateri_siswa` (`siswa_id`),
+  CONSTRAINT `fk_aksesmateri_item` FOREIGN KEY (`item_id`) REFERENCES `itemmateri` (`item_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_aksesmateri_siswa` FOREIGN KEY (`siswa_id`) REFERENCES `siswa` (`siswa_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
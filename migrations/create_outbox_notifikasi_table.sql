@@ -0,0 +1,29 @@
+-- Migration: Create outboxnotifikasi table
+-- Antrean pesan reminder deadline. Worker mengambil baris 'menunggu' yang berikutnya_at-nya sudah lewat,
+-- mencoba ulang dengan backoff eksponensial dan memindahkannya ke 'dead_letter' setelah batas percobaan.
+-- Baris 'diproses' dengan dikunci_sampai yang lewat (worker mati di tengah pengiriman) diambil ulang
+
+CREATE TABLE IF NOT EXISTS `outboxnotifikasi` (
+  `outbox_id` int NOT NULL AUTO_INCREMENT,
+  `kunci_idempotensi` varchar(100) NOT NULL,
+  `tugas_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  `jenis_notifikasi` varchar(20) NOT NULL,
+  `jenis_template` varchar(30) NOT NULL,
+  `data` text NOT NULL,
+  `status` enum('menunggu','diproses','terkirim','dead_letter') NOT NULL DEFAULT 'menunggu',
+  `percobaan` int NOT NULL DEFAULT 0,
+  `berikutnya_at` datetime NOT NULL,
+  `dikunci_sampai` datetime DEFAULT NULL,
+  `kanal_terkirim` varchar(20) DEFAULT NULL,
+  `error_terakhir` text,
+  `response_api` text,
+  `selesai_at` datetime DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`outbox_id`),
+  UNIQUE KEY `uk_outboxnotifikasi_kunci` (`kunci_idempotensi`),
+  KEY `idx_outboxnotifikasi_antrean` (`status`, `berikutnya_at`),
+  CONSTRAINT `fk_outboxnotifikasi_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_outboxnotifikasi_siswa` FOREIGN KEY (`siswa_id`) REFERENCES `siswa` (`siswa_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,20 @@
+-- Migration: Create pengaturanreminder table
+-- Pengaturan reminder deadline sekolah: tingkat reminder yang aktif, reminder lewat deadline dan jam tenang
+-- (tidak ada pesan yang dikirim di antara jam_tenang_mulai dan jam_tenang_selesai)
+
+CREATE TABLE IF NOT EXISTS `pengaturanreminder` (
+  `id` int NOT NULL,
+  `reminder_3_hari` tinyint(1) NOT NULL DEFAULT 1,
+  `reminder_1_hari` tinyint(1) NOT NULL DEFAULT 1,
+  `reminder_2_jam` tinyint(1) NOT NULL DEFAULT 1,
+  `reminder_lewat_deadline` tinyint(1) NOT NULL DEFAULT 1,
+  `lewat_deadline_maks_hari` int NOT NULL DEFAULT 7,
+  `jam_tenang_mulai` varchar(5) DEFAULT '21:00',
+  `jam_tenang_selesai` varchar(5) DEFAULT '06:00',
+  `zona_waktu` varchar(50) NOT NULL DEFAULT 'Asia/Jakarta',
+  `diubah_oleh` varchar(100) DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT IGNORE INTO `pengaturanreminder` (`id`) VALUES (1);
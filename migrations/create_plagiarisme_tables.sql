@@ -0,0 +1,35 @@
+-- Migration: Create sidikfile & kemiripanpengumpulan tables
+-- Deteksi plagiarisme: hash dan tanda tangan MinHash per file jawaban, serta
+-- pasangan pengumpulan antar siswa yang identik (hash sama) atau mirip (MinHash)
+
+CREATE TABLE IF NOT EXISTS `sidikfile` (
+  `pengumpulan_id` int NOT NULL,
+  `tugas_id` int NOT NULL,
+  `siswa_id` int NOT NULL,
+  `file_jawaban_siswa` varchar(255) NOT NULL,
+  `hash_sha256` char(64) DEFAULT NULL,
+  `jumlah_kata` int NOT NULL DEFAULT 0,
+  `status_ekstraksi` varchar(20) NOT NULL COMMENT 'Berhasil, TidakDidukung, Gagal',
+  `tanda_tangan` text COMMENT 'MinHash 128 x uint64 (hex)',
+  `dianalisis_pada` timestamp NOT NULL,
+  PRIMARY KEY (`pengumpulan_id`),
+  KEY `idx_sidikfile_tugas_hash` (`tugas_id`, `hash_sha256`),
+  CONSTRAINT `fk_sidikfile_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `kemiripanpengumpulan` (
+  `kemiripan_id` int NOT NULL AUTO_INCREMENT,
+  `tugas_id` int NOT NULL,
+  `pengumpulan_id` int NOT NULL,
+  `pengumpulan_pembanding_id` int NOT NULL,
+  `jenis` enum('Identik','Mirip') NOT NULL,
+  `skor` decimal(5,2) NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`kemiripan_id`),
+  UNIQUE KEY `uk_kemiripan_pasangan` (`pengumpulan_id`, `pengumpulan_pembanding_id`),
+  KEY `idx_kemiripan_tugas` (`tugas_id`),
+  KEY `idx_kemiripan_pembanding` (`pengumpulan_pembanding_id`),
+  CONSTRAINT `fk_kemiripan_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_kemiripan_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_kemiripan_pembanding` FOREIGN KEY (`pengumpulan_pembanding_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,13 @@
+-- Migration: Create preferensinotifikasi table
+-- Urutan kanal notifikasi (whatsapp, email, telegram) pilihan setiap siswa/guru. Kanal berikutnya
+-- dicoba jika pengiriman di kanal sebelumnya gagal; salinan in-app disimpan jika simpan_inapp = 1
+
+CREATE TABLE IF NOT EXISTS `preferensinotifikasi` (
+  `tipe_user` enum('Siswa','Guru') NOT NULL,
+  `user_id` int NOT NULL,
+  `urutan_kanal` varchar(100) NOT NULL DEFAULT 'whatsapp,email,telegram',
+  `simpan_inapp` tinyint(1) NOT NULL DEFAULT 1,
+  `telegram_chat_id` varchar(64) DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`tipe_user`, `user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
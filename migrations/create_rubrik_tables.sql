@@ -0,0 +1,53 @@
+-- Migration: Create rubrik, kriteriarubrik, levelrubrik & penilaianrubrik tables
+-- Rubrik penilaian per tugas: kriteria dengan beberapa level poin. Guru menilai
+-- pengumpulan dengan memilih satu level per kriteria
+
+CREATE TABLE IF NOT EXISTS `rubrik` (
+  `rubrik_id` int NOT NULL AUTO_INCREMENT,
+  `tugas_id` int NOT NULL,
+  `judul` varchar(255) DEFAULT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`rubrik_id`),
+  UNIQUE KEY `uk_rubrik_tugas_id` (`tugas_id`),
+  CONSTRAINT `fk_rubrik_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `kriteriarubrik` (
+  `kriteria_id` int NOT NULL AUTO_INCREMENT,
+  `rubrik_id` int NOT NULL,
+  `nama_kriteria` varchar(255) NOT NULL,
+  `deskripsi` text,
+  `urutan` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`kriteria_id`),
+  KEY `idx_kriteriarubrik_rubrik_id` (`rubrik_id`),
+  CONSTRAINT `fk_kriteriarubrik_rubrik` FOREIGN KEY (`rubrik_id`) REFERENCES `rubrik` (`rubrik_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `levelrubrik` (
+  `level_id` int NOT NULL AUTO_INCREMENT,
+  `kriteria_id` int NOT NULL,
+  `nama_level` varchar(100) NOT NULL,
+  `deskripsi` text,
+  `poin` int NOT NULL,
+  `urutan` int NOT NULL DEFAULT 0,
+  PRIMARY KEY (`level_id`),
+  KEY `idx_levelrubrik_kriteria_id` (`kriteria_id`),
+  CONSTRAINT `fk_levelrubrik_kriteria` FOREIGN KEY (`kriteria_id`) REFERENCES `kriteriarubrik` (`kriteria_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `penilaianrubrik` (
+  `penilaian_id` int NOT NULL AUTO_INCREMENT,
+  `pengumpulan_id` int NOT NULL,
+  `kriteria_id` int NOT NULL,
+  `level_id` int NOT NULL,
+  `poin` int NOT NULL,
+  `komentar` text,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`penilaian_id`),
+  UNIQUE KEY `uk_penilaianrubrik` (`pengumpulan_id`, `kriteria_id`),
+  CONSTRAINT `fk_penilaianrubrik_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_penilaianrubrik_kriteria` FOREIGN KEY (`kriteria_id`) REFERENCES `kriteriarubrik` (`kriteria_id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_penilaianrubrik_level` FOREIGN KEY (`level_id`) REFERENCES `levelrubrik` (`level_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,19 @@
+-- Migration: Create templatenotifikasi table & add bahasa to preferensinotifikasi
+-- Template pesan notifikasi (Go text/template, html/template untuk email) yang diubah admin per jenis,
+-- kanal dan bahasa. Kombinasi tanpa baris di tabel ini memakai template bawaan aplikasi
+
+CREATE TABLE IF NOT EXISTS `templatenotifikasi` (
+  `template_id` int NOT NULL AUTO_INCREMENT,
+  `jenis` varchar(30) NOT NULL,
+  `kanal` varchar(20) NOT NULL,
+  `bahasa` varchar(5) NOT NULL,
+  `judul` varchar(255) NOT NULL,
+  `isi` text NOT NULL,
+  `diubah_oleh` varchar(100) DEFAULT NULL,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`template_id`),
+  UNIQUE KEY `uk_templatenotifikasi` (`jenis`, `kanal`, `bahasa`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+ALTER TABLE `preferensinotifikasi`
+  ADD COLUMN `bahasa` varchar(5) NOT NULL DEFAULT 'id' AFTER `telegram_chat_id`;
@@ -0,0 +1,27 @@
+-- Migration: Create uploadbertahap table & add maks_ukuran_file_mb to tugas
+-- Sesi upload bertahap (chunk + offset, dapat dilanjutkan) untuk file jawaban besar,
+-- dengan batas ukuran file yang dapat diatur per tugas
+
+CREATE TABLE IF NOT EXISTS `uploadbertahap` (
+  `upload_id` char(36) NOT NULL,
+  `tipe_user` enum('Siswa','Guru','Admin') NOT NULL,
+  `user_id` int NOT NULL,
+  `nama_file` varchar(255) NOT NULL,
+  `ukuran` bigint NOT NULL,
+  `diterima` bigint NOT NULL DEFAULT 0,
+  `tugas_id` int DEFAULT NULL,
+  `tujuan` varchar(30) NOT NULL DEFAULT 'Lainnya',
+  `status` enum('Berjalan','Selesai','Gagal') NOT NULL DEFAULT 'Berjalan',
+  `url_hasil` varchar(255) DEFAULT NULL,
+  `pesan` varchar(255) DEFAULT NULL,
+  `kedaluwarsa` timestamp NOT NULL,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`upload_id`),
+  KEY `idx_uploadbertahap_user` (`tipe_user`, `user_id`, `status`),
+  KEY `idx_uploadbertahap_kedaluwarsa` (`status`, `kedaluwarsa`),
+  CONSTRAINT `fk_uploadbertahap_tugas` FOREIGN KEY (`tugas_id`) REFERENCES `tugas` (`tugas_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+ALTER TABLE `tugas`
+  ADD COLUMN `maks_ukuran_file_mb` int DEFAULT NULL AFTER `tanggal_terbit`;
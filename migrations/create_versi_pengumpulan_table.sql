@@ -0,0 +1,34 @@
+-- Migration: Create versipengumpulan table
+-- Setiap percobaan pengumpulan tugas disimpan sebagai versi, versi terbaru ditandai is_current
+
+CREATE TABLE IF NOT EXISTS `versipengumpulan` (
+  `versi_id` int NOT NULL AUTO_INCREMENT,
+  `pengumpulan_id` int NOT NULL,
+  `nomor_versi` int NOT NULL,
+  `file_jawaban_siswa` varchar(255) DEFAULT NULL,
+  `catatan_siswa` text,
+  `tanggal_pengumpulan` timestamp NOT NULL,
+  `status_pengumpulan` enum('Mengerjakan','Terlambat') DEFAULT 'Mengerjakan',
+  `is_current` tinyint(1) NOT NULL DEFAULT 0,
+  `created_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`versi_id`),
+  UNIQUE KEY `uk_versipengumpulan_nomor` (`pengumpulan_id`, `nomor_versi`),
+  KEY `idx_versipengumpulan_current` (`pengumpulan_id`, `is_current`),
+  CONSTRAINT `fk_versipengumpulan_pengumpulan` FOREIGN KEY (`pengumpulan_id`) REFERENCES `pengumpulantugas` (`pengumpulan_id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+-- Versi yang dinilai guru
+ALTER TABLE `pengumpulantugas`
+ADD COLUMN `versi_dinilai_id` INT NULL AFTER `penyesuaian_poin`;
+
+-- Kebijakan pengumpulan ulang per tugas (NULL = tanpa batas)
+ALTER TABLE `tugas`
+ADD COLUMN `maks_pengumpulan_ulang` INT NULL AFTER `tipe_tugas`,
+ADD COLUMN `tolak_setelah_dinilai` tinyint(1) NOT NULL DEFAULT 0 AFTER `maks_pengumpulan_ulang`;
+
+-- Backfill: pengumpulan yang sudah ada menjadi versi pertama
+INSERT INTO `versipengumpulan` (`pengumpulan_id`, `nomor_versi`, `file_jawaban_siswa`, `catatan_siswa`, `tanggal_pengumpulan`, `status_pengumpulan`, `is_current`)
+SELECT `pengumpulan_id`, 1, `file_jawaban_siswa`, `catatan_siswa`, `tanggal_pengumpulan`,
+       IF(`status_pengumpulan` = 'Terlambat', 'Terlambat', 'Mengerjakan'), 1
+FROM `pengumpulantugas`
+WHERE `pengumpulan_id` NOT IN (SELECT `pengumpulan_id` FROM `versipengumpulan`);
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// BankSoal model - soal bersama untuk semua guru pengampu mata pelajaran yang sama
+type BankSoal struct {
+	BankSoalID       int       `gorm:"column:bank_soal_id;primaryKey;autoIncrement" json:"bank_soal_id"`
+	MapelID          int       `gorm:"column:mapel_id;not null" json:"mapel_id"`
+	GuruID           int       `gorm:"column:guru_id;not null" json:"guru_id"`
+	TipeSoal         string    `gorm:"column:tipe_soal;type:enum('PilihanGanda','PilihanJamak','BenarSalah','IsianSingkat');not null" json:"tipe_soal"`
+	Pertanyaan       string    `gorm:"column:pertanyaan;type:text;not null" json:"pertanyaan"`
+	Poin             int       `gorm:"column:poin;not null;default:1" json:"poin"`
+	Topik            string    `gorm:"column:topik;size:100" json:"topik"`
+	TingkatKesulitan string    `gorm:"column:tingkat_kesulitan;size:20" json:"tingkat_kesulitan"`
+	LevelBloom       string    `gorm:"column:level_bloom;size:2" json:"level_bloom"`
+	CreatedAt        time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi - untuk IsianSingkat, opsi berisi daftar jawaban yang diterima
+	Opsi []OpsiBankSoal `gorm:"foreignKey:BankSoalID;references:BankSoalID" json:"opsi,omitempty"`
+}
+
+// OpsiBankSoal model - pilihan jawaban soal pada bank soal
+type OpsiBankSoal struct {
+	OpsiBankID int    `gorm:"column:opsi_bank_id;primaryKey;autoIncrement" json:"opsi_bank_id"`
+	BankSoalID int    `gorm:"column:bank_soal_id;not null" json:"bank_soal_id"`
+	Teks       string `gorm:"column:teks;type:text;not null" json:"teks"`
+	IsBenar    bool   `gorm:"column:is_benar;default:false" json:"is_benar"`
+	Urutan     int    `gorm:"column:urutan;default:0" json:"urutan"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (BankSoal) TableName() string {
+	return "banksoal"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (OpsiBankSoal) TableName() string {
+	return "opsibanksoal"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// FileUpload model - catatan setiap file yang diupload (pemilik dan keperluan), dipakai untuk kuota,
+// karantina dan pembersihan file yang tidak pernah dipakai
+type FileUpload struct {
+	FileID          int       `gorm:"column:file_id;primaryKey;autoIncrement" json:"file_id"`
+	Kunci           string    `gorm:"column:kunci;size:255;uniqueIndex;not null" json:"kunci"`
+	NamaAsli        string    `gorm:"column:nama_asli;size:255" json:"nama_asli"`
+	Ukuran          int64     `gorm:"column:ukuran;not null" json:"ukuran"`
+	ContentType     string    `gorm:"column:content_type;size:100" json:"content_type"`
+	HashSHA256      string    `gorm:"column:hash_sha256;size:64" json:"hash_sha256"`
+	TipeUser        string    `gorm:"column:tipe_user;type:enum('Siswa','Guru','Admin');not null" json:"tipe_user"`
+	UserID          int       `gorm:"column:user_id;not null" json:"user_id"`
+	TugasID         *int      `gorm:"column:tugas_id" json:"tugas_id"`
+	Tujuan          string    `gorm:"column:tujuan;type:enum('JawabanTugas','LampiranTugas','LampiranKomentar','Materi','Lainnya');default:Lainnya" json:"tujuan"`
+	Status          string    `gorm:"column:status;type:enum('Aktif','Karantina');default:Aktif" json:"status"`
+	AlasanKarantina string    `gorm:"column:alasan_karantina;size:255" json:"alasan_karantina,omitempty"`
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (FileUpload) TableName() string {
+	return "fileupload"
+}
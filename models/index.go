@@ -19,12 +19,32 @@ package models
 
 // Assignments and achievements
 // - Tugas: Assignment model
+// - TemplateTugas: Reusable assignment template owned by a teacher
 // - PengumpulanTugas: Assignment submission model
+// - VersiPengumpulan: Every submission attempt, latest marked current
+// - PerpanjanganDeadline: Per-student deadline extension for a tugas
+// - KelompokTugas / AnggotaKelompok: Groups and members for group assignments
+// - Rubrik / KriteriaRubrik / LevelRubrik: Grading rubric attached to a tugas
+// - PenilaianRubrik: Selected rubric level per criterion for a submission
+// - Kuis / SoalKuis / OpsiSoal: Online quiz on a jadwal with its questions and options
+// - PercobaanKuis / JawabanKuis: Student quiz attempt and its answers
+// - BankSoal / OpsiBankSoal: Tagged question bank shared per mata pelajaran
+// - SidikFile / KemiripanPengumpulan: File fingerprints and flagged duplicate/similar submission pairs
+// - KomentarPengumpulan / BacaKomentar: Teacher-student comment thread per submission and read markers
+// - SeksiMateri / ItemMateri / AksesMateri: Learning materials per pertemuan and student view tracking
 // - Achievement: Achievement/badge model
 // - SiswaAchievement: Student achievement junction model
 
+// Files
+// - FileUpload: Uploaded file registry (owner, purpose) for quotas, quarantine and orphan cleanup
+// - UploadBertahap: Resumable chunked upload session (offset, expiry) for large files
+
 // Notifications
 // - Notifikasi: Notification model for students and teachers
+// - PreferensiNotifikasi: Per-user notification channel order (WhatsApp/email/Telegram) with fallback
+// - PengaturanReminder: School-wide deadline reminder tiers and quiet hours (single row)
+// - TemplateNotifikasi: Admin-edited notification message template per type, channel and language
+// - OutboxNotifikasi: Queued deadline reminder with retry state, idempotency key and dead-letter status
 
 // Usage example:
 // import "Pasti/models"
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// KelompokTugas model - kelompok siswa untuk tugas bertipe Kelompok
+type KelompokTugas struct {
+	KelompokID   int       `gorm:"column:kelompok_id;primaryKey;autoIncrement" json:"kelompok_id"`
+	TugasID      int       `gorm:"column:tugas_id;not null" json:"tugas_id"`
+	NamaKelompok string    `gorm:"column:nama_kelompok;size:100;not null" json:"nama_kelompok"`
+	PoinKelompok *int      `gorm:"column:poin_kelompok" json:"poin_kelompok"`
+	CatatanGuru  string    `gorm:"column:catatan_guru;type:text" json:"catatan_guru"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Anggota []AnggotaKelompok `gorm:"foreignKey:KelompokID;references:KelompokID" json:"anggota,omitempty"`
+}
+
+// AnggotaKelompok model - keanggotaan siswa dalam kelompok tugas
+type AnggotaKelompok struct {
+	AnggotaID  int `gorm:"column:anggota_id;primaryKey;autoIncrement" json:"anggota_id"`
+	KelompokID int `gorm:"column:kelompok_id;not null" json:"kelompok_id"`
+	SiswaID    int `gorm:"column:siswa_id;not null" json:"siswa_id"`
+
+	// Relasi
+	Siswa Siswa `gorm:"foreignKey:SiswaID;references:SiswaID" json:"siswa,omitempty"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (KelompokTugas) TableName() string {
+	return "kelompoktugas"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (AnggotaKelompok) TableName() string {
+	return "anggotakelompok"
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// KomentarPengumpulan model - pesan pada thread diskusi sebuah pengumpulan tugas antara guru dan siswa
+type KomentarPengumpulan struct {
+	KomentarID    int       `gorm:"column:komentar_id;primaryKey;autoIncrement" json:"komentar_id"`
+	PengumpulanID int       `gorm:"column:pengumpulan_id;not null" json:"pengumpulan_id"`
+	PengirimID    int       `gorm:"column:pengirim_id;not null" json:"pengirim_id"`
+	TipePengirim  string    `gorm:"column:tipe_pengirim;type:enum('Siswa','Guru');not null" json:"tipe_pengirim"`
+	Isi           string    `gorm:"column:isi;type:text" json:"isi"`
+	FileLampiran  string    `gorm:"column:file_lampiran;size:255" json:"file_lampiran"`
+	NamaLampiran  string    `gorm:"column:nama_lampiran;size:255" json:"nama_lampiran"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+
+	// Nama guru/siswa pengirim, diisi saat thread diambil
+	NamaPengirim string `gorm:"-" json:"nama_pengirim"`
+}
+
+// BacaKomentar model - komentar terakhir yang sudah dibaca seorang user pada sebuah thread
+type BacaKomentar struct {
+	PengumpulanID    int       `gorm:"column:pengumpulan_id;primaryKey;autoIncrement:false" json:"pengumpulan_id"`
+	TipeUser         string    `gorm:"column:tipe_user;primaryKey;type:enum('Siswa','Guru')" json:"tipe_user"`
+	UserID           int       `gorm:"column:user_id;primaryKey;autoIncrement:false" json:"user_id"`
+	TerakhirDibacaID int       `gorm:"column:terakhir_dibaca_id;default:0" json:"terakhir_dibaca_id"`
+	UpdatedAt        time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (KomentarPengumpulan) TableName() string {
+	return "komentarpengumpulan"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (BacaKomentar) TableName() string {
+	return "bacakomentar"
+}
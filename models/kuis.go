@@ -0,0 +1,101 @@
+package models
+
+import "time"
+
+// Kuis model - kuis/ujian online pada sebuah jadwal pelajaran.
+// Setiap kuis memiliki satu Tugas pendamping sehingga skor masuk ke PengumpulanTugas
+type Kuis struct {
+	KuisID       int       `gorm:"column:kuis_id;primaryKey;autoIncrement" json:"kuis_id"`
+	TugasID      int       `gorm:"column:tugas_id;not null;unique" json:"tugas_id"`
+	JadwalID     int       `gorm:"column:jadwal_id;not null" json:"jadwal_id"`
+	WaktuMulai   time.Time `gorm:"column:waktu_mulai;not null" json:"waktu_mulai"`
+	WaktuSelesai time.Time `gorm:"column:waktu_selesai;not null" json:"waktu_selesai"`
+	DurasiMenit  int       `gorm:"column:durasi_menit;not null" json:"durasi_menit"`
+	AcakSoal     bool      `gorm:"column:acak_soal;default:false" json:"acak_soal"`
+	AcakOpsi     bool      `gorm:"column:acak_opsi;default:false" json:"acak_opsi"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Tugas Tugas      `gorm:"foreignKey:TugasID;references:TugasID" json:"tugas,omitempty"`
+	Soal  []SoalKuis `gorm:"foreignKey:KuisID;references:KuisID" json:"soal,omitempty"`
+}
+
+// SoalKuis model - satu soal kuis
+type SoalKuis struct {
+	SoalID     int    `gorm:"column:soal_id;primaryKey;autoIncrement" json:"soal_id"`
+	KuisID     int    `gorm:"column:kuis_id;not null" json:"kuis_id"`
+	TipeSoal   string `gorm:"column:tipe_soal;type:enum('PilihanGanda','PilihanJamak','BenarSalah','IsianSingkat');not null" json:"tipe_soal"`
+	Pertanyaan string `gorm:"column:pertanyaan;type:text;not null" json:"pertanyaan"`
+	Poin       int    `gorm:"column:poin;not null;default:1" json:"poin"`
+	Urutan     int    `gorm:"column:urutan;default:0" json:"urutan"`
+
+	// Relasi - untuk IsianSingkat, opsi berisi daftar jawaban yang diterima
+	Opsi []OpsiSoal `gorm:"foreignKey:SoalID;references:SoalID" json:"opsi,omitempty"`
+}
+
+// OpsiSoal model - pilihan jawaban (atau jawaban yang diterima untuk isian singkat)
+type OpsiSoal struct {
+	OpsiID  int    `gorm:"column:opsi_id;primaryKey;autoIncrement" json:"opsi_id"`
+	SoalID  int    `gorm:"column:soal_id;not null" json:"soal_id"`
+	Teks    string `gorm:"column:teks;type:text;not null" json:"teks"`
+	IsBenar bool   `gorm:"column:is_benar;default:false" json:"is_benar"`
+	Urutan  int    `gorm:"column:urutan;default:0" json:"urutan"`
+}
+
+// PercobaanKuis model - satu percobaan siswa mengerjakan kuis
+type PercobaanKuis struct {
+	PercobaanID int        `gorm:"column:percobaan_id;primaryKey;autoIncrement" json:"percobaan_id"`
+	KuisID      int        `gorm:"column:kuis_id;not null" json:"kuis_id"`
+	SiswaID     int        `gorm:"column:siswa_id;not null" json:"siswa_id"`
+	MulaiPada   time.Time  `gorm:"column:mulai_pada;not null" json:"mulai_pada"`
+	BatasWaktu  time.Time  `gorm:"column:batas_waktu;not null" json:"batas_waktu"`
+	SelesaiPada *time.Time `gorm:"column:selesai_pada" json:"selesai_pada"`
+	// Urutan soal dan opsi hasil pengacakan per siswa (JSON), tidak dikirim ke klien
+	UrutanSoal string `gorm:"column:urutan_soal;type:text" json:"-"`
+	UrutanOpsi string `gorm:"column:urutan_opsi;type:text" json:"-"`
+	Skor       int    `gorm:"column:skor;default:0" json:"skor"`
+	Status     string `gorm:"column:status;type:enum('Berlangsung','Menunggu Review','Selesai');default:'Berlangsung'" json:"status"`
+
+	// Relasi
+	Siswa   Siswa         `gorm:"foreignKey:SiswaID;references:SiswaID" json:"siswa,omitempty"`
+	Jawaban []JawabanKuis `gorm:"foreignKey:PercobaanID;references:PercobaanID" json:"jawaban,omitempty"`
+}
+
+// JawabanKuis model - jawaban siswa untuk satu soal dalam sebuah percobaan
+type JawabanKuis struct {
+	JawabanID   int    `gorm:"column:jawaban_id;primaryKey;autoIncrement" json:"jawaban_id"`
+	PercobaanID int    `gorm:"column:percobaan_id;not null" json:"percobaan_id"`
+	SoalID      int    `gorm:"column:soal_id;not null" json:"soal_id"`
+	OpsiDipilih string `gorm:"column:opsi_dipilih;size:255" json:"opsi_dipilih"` // ID opsi dipisah koma
+	JawabanTeks string `gorm:"column:jawaban_teks;type:text" json:"jawaban_teks"`
+	// Poin nil berarti menunggu review guru (isian singkat yang tidak cocok otomatis)
+	Poin          *int      `gorm:"column:poin" json:"poin"`
+	DinilaiManual bool      `gorm:"column:dinilai_manual;default:false" json:"dinilai_manual"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (Kuis) TableName() string {
+	return "kuis"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (SoalKuis) TableName() string {
+	return "soalkuis"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (OpsiSoal) TableName() string {
+	return "opsisoal"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (PercobaanKuis) TableName() string {
+	return "percobaankuis"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (JawabanKuis) TableName() string {
+	return "jawabankuis"
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// SeksiMateri model - bagian berurutan dari materi sebuah pertemuan (misal "Pendahuluan", "Latihan")
+type SeksiMateri struct {
+	SeksiID     int       `gorm:"column:seksi_id;primaryKey;autoIncrement" json:"seksi_id"`
+	PertemuanID int       `gorm:"column:pertemuan_id;not null" json:"pertemuan_id"`
+	Judul       string    `gorm:"column:judul;size:255;not null" json:"judul"`
+	Deskripsi   string    `gorm:"column:deskripsi;type:text" json:"deskripsi"`
+	Urutan      int       `gorm:"column:urutan;default:0" json:"urutan"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Item []ItemMateri `gorm:"foreignKey:SeksiID;references:SeksiID" json:"item"`
+}
+
+// ItemMateri model - satu materi dalam seksi: file hasil upload, tautan, atau video
+type ItemMateri struct {
+	ItemID    int       `gorm:"column:item_id;primaryKey;autoIncrement" json:"item_id"`
+	SeksiID   int       `gorm:"column:seksi_id;not null" json:"seksi_id"`
+	Tipe      string    `gorm:"column:tipe;type:enum('File','Tautan','Video');not null" json:"tipe"`
+	Judul     string    `gorm:"column:judul;size:255;not null" json:"judul"`
+	Deskripsi string    `gorm:"column:deskripsi;type:text" json:"deskripsi"`
+	URL       string    `gorm:"column:url;size:500;not null" json:"url"`
+	NamaFile  string    `gorm:"column:nama_file;size:255" json:"nama_file"`
+	Urutan    int       `gorm:"column:urutan;default:0" json:"urutan"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// URL embed untuk item video (YouTube/Vimeo), diisi saat materi diambil
+	EmbedURL string `gorm:"-" json:"embed_url,omitempty"`
+	// Statistik akses: jumlah siswa yang membuka (untuk guru) atau waktu dibuka (untuk siswa)
+	JumlahDibuka int        `gorm:"-" json:"jumlah_dibuka,omitempty"`
+	DibukaPada   *time.Time `gorm:"-" json:"dibuka_pada,omitempty"`
+}
+
+// AksesMateri model - catatan siswa membuka sebuah item materi
+type AksesMateri struct {
+	ItemID         int       `gorm:"column:item_id;primaryKey;autoIncrement:false" json:"item_id"`
+	SiswaID        int       `gorm:"column:siswa_id;primaryKey;autoIncrement:false" json:"siswa_id"`
+	JumlahDibuka   int       `gorm:"column:jumlah_dibuka;default:1" json:"jumlah_dibuka"`
+	PertamaDibuka  time.Time `gorm:"column:pertama_dibuka" json:"pertama_dibuka"`
+	TerakhirDibuka time.Time `gorm:"column:terakhir_dibuka" json:"terakhir_dibuka"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (SeksiMateri) TableName() string {
+	return "seksimateri"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (ItemMateri) TableName() string {
+	return "itemmateri"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (AksesMateri) TableName() string {
+	return "aksesmateri"
+}
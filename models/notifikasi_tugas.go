@@ -10,7 +10,7 @@ type NotifikasiTugas struct {
     JenisNotifikasi  string    `gorm:"column:jenis_notifikasi;type:enum('3_hari','1_hari','2_jam','lewat_deadline');not null" json:"jenis_notifikasi"`
     TanggalKirim     time.Time `gorm:"column:tanggal_kirim;default:CURRENT_TIMESTAMP" json:"tanggal_kirim"`
     Status           string    `gorm:"column:status;type:enum('terkirim','gagal');default:'terkirim'" json:"status"`
-    ResponseAPI      string    `gorm:"column:response_api;type:text" json:"response_api"`
+    ResponseAPI      string    `gorm:"column:response_api;type:mediumtext" json:"response_api"`
     CreatedAt        time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP" json:"created_at"`
     
     // Relations
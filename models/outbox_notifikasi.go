@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// OutboxNotifikasi model - antrean pesan reminder deadline yang dikirim worker dengan retry.
+// KunciIdempotensi (jenis:tugas:siswa) mencegah pesan yang sama diantrekan dua kali, termasuk setelah restart
+type OutboxNotifikasi struct {
+	OutboxID         int        `gorm:"column:outbox_id;primaryKey;autoIncrement" json:"outbox_id"`
+	KunciIdempotensi string     `gorm:"column:kunci_idempotensi;size:100;not null;uniqueIndex" json:"kunci_idempotensi"`
+	TugasID          int        `gorm:"column:tugas_id;not null" json:"tugas_id"`
+	SiswaID          int        `gorm:"column:siswa_id;not null" json:"siswa_id"`
+	JenisNotifikasi  string     `gorm:"column:jenis_notifikasi;size:20;not null" json:"jenis_notifikasi"`
+	JenisTemplate    string     `gorm:"column:jenis_template;size:30;not null" json:"jenis_template"`
+	Data             string     `gorm:"column:data;type:text;not null" json:"-"`
+	Status           string     `gorm:"column:status;type:enum('menunggu','diproses','terkirim','dead_letter','usang');not null" json:"status"`
+	Percobaan        int        `gorm:"column:percobaan;not null" json:"percobaan"`
+	BerikutnyaAt     time.Time  `gorm:"column:berikutnya_at;not null" json:"berikutnya_at"`
+	DikunciSampai    *time.Time `gorm:"column:dikunci_sampai" json:"dikunci_sampai,omitempty"`
+	KanalTerkirim    string     `gorm:"column:kanal_terkirim;size:20" json:"kanal_terkirim,omitempty"`
+	ErrorTerakhir    string     `gorm:"column:error_terakhir;type:text" json:"error_terakhir,omitempty"`
+	ResponseAPI      string     `gorm:"column:response_api;type:mediumtext" json:"response_api,omitempty"`
+	SelesaiAt        *time.Time `gorm:"column:selesai_at" json:"selesai_at,omitempty"`
+	CreatedAt        time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (OutboxNotifikasi) TableName() string {
+	return "outboxnotifikasi"
+}
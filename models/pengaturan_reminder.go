@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PengaturanReminder model - pengaturan reminder deadline sekolah (satu baris, id = 1)
+type PengaturanReminder struct {
+	ID                    int       `gorm:"column:id;primaryKey" json:"-"`
+	Reminder3Hari         bool      `gorm:"column:reminder_3_hari;not null" json:"reminder_3_hari"`
+	Reminder1Hari         bool      `gorm:"column:reminder_1_hari;not null" json:"reminder_1_hari"`
+	Reminder2Jam          bool      `gorm:"column:reminder_2_jam;not null" json:"reminder_2_jam"`
+	ReminderLewatDeadline bool      `gorm:"column:reminder_lewat_deadline;not null" json:"reminder_lewat_deadline"`
+	LewatDeadlineMaksHari int       `gorm:"column:lewat_deadline_maks_hari;not null" json:"lewat_deadline_maks_hari"`
+	JamTenangMulai        string    `gorm:"column:jam_tenang_mulai;size:5" json:"jam_tenang_mulai"`     // "HH:MM", kosong = tanpa jam tenang
+	JamTenangSelesai      string    `gorm:"column:jam_tenang_selesai;size:5" json:"jam_tenang_selesai"` // "HH:MM"
+	ZonaWaktu             string    `gorm:"column:zona_waktu;size:50;not null" json:"zona_waktu"`
+	DiubahOleh            string    `gorm:"column:diubah_oleh;size:100" json:"diubah_oleh"`
+	UpdatedAt             time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (PengaturanReminder) TableName() string {
+	return "pengaturanreminder"
+}
@@ -14,12 +14,24 @@ type PengumpulanTugas struct {
 	CatatanGuru        string    `gorm:"column:catatan_guru;type:text" json:"catatan_guru"`
 	StatusPengumpulan  string    `gorm:"column:status_pengumpulan;type:enum('Belum Mengerjakan','Mengerjakan','Terlambat','Dinilai');default:'Belum Mengerjakan'" json:"status_pengumpulan"`
 	PoinDidapat        int       `gorm:"column:poin_didapat;default:0" json:"poin_didapat"`
+	KelompokID         *int      `gorm:"column:kelompok_id" json:"kelompok_id"`
+	PenyesuaianPoin    int       `gorm:"column:penyesuaian_poin;default:0" json:"penyesuaian_poin"`
+	VersiDinilaiID     *int      `gorm:"column:versi_dinilai_id" json:"versi_dinilai_id"`
+	PoinMentah         *int      `gorm:"column:poin_mentah" json:"poin_mentah"`
+	PersenPenalti      float64   `gorm:"column:persen_penalti;type:decimal(5,2);default:0" json:"persen_penalti"`
+	HariTerlambat      int       `gorm:"column:hari_terlambat;default:0" json:"hari_terlambat"`
 	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
 	// Relasi
 	Tugas Tugas `gorm:"foreignKey:TugasID;references:TugasID" json:"tugas,omitempty"`
 	Siswa Siswa `gorm:"foreignKey:SiswaID;references:SiswaID" json:"siswa,omitempty"`
+
+	// Riwayat versi pengumpulan (tidak mereferensikan balik ke pengumpulan, aman dari circular)
+	Versi []VersiPengumpulan `gorm:"foreignKey:PengumpulanID;references:PengumpulanID" json:"versi,omitempty"`
+
+	// Hasil penilaian rubrik per kriteria
+	PenilaianRubrik []PenilaianRubrik `gorm:"foreignKey:PengumpulanID;references:PengumpulanID" json:"penilaian_rubrik,omitempty"`
 }
 
 // TableName method untuk menentukan nama tabel yang benar
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PerpanjanganDeadline model - perpanjangan deadline tugas untuk siswa tertentu
+type PerpanjanganDeadline struct {
+	PerpanjanganID int       `gorm:"column:perpanjangan_id;primaryKey;autoIncrement" json:"perpanjangan_id"`
+	TugasID        int       `gorm:"column:tugas_id;not null" json:"tugas_id"`
+	SiswaID        int       `gorm:"column:siswa_id;not null" json:"siswa_id"`
+	DeadlineBaru   time.Time `gorm:"column:deadline_baru;not null" json:"deadline_baru"`
+	Alasan         string    `gorm:"column:alasan;size:255" json:"alasan"`
+	DiberikanOleh  int       `gorm:"column:diberikan_oleh;not null" json:"diberikan_oleh"`
+	CreatedAt      time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Siswa Siswa `gorm:"foreignKey:SiswaID;references:SiswaID" json:"siswa,omitempty"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (PerpanjanganDeadline) TableName() string {
+	return "perpanjangandeadline"
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // Pertemuan model
 type Pertemuan struct {
 	IDPertemuan int       `gorm:"column:id_pertemuan;primaryKey;autoIncrement" json:"id_pertemuan"`
@@ -9,10 +11,39 @@ type Pertemuan struct {
 	Tanggal     string    `gorm:"column:tanggal;type:date;not null" json:"tanggal"`
 	Materi      string    `gorm:"column:materi;size:3000" json:"materi"`
 	IsActive    bool      `gorm:"column:is_active;default:false" json:"is_active"`
+
+	// Guru pengganti untuk pertemuan ini saja (berlaku setelah disetujui admin)
+	GuruPenggantiID        *int       `gorm:"column:guru_pengganti_id" json:"guru_pengganti_id"`
+	AlasanPengganti        string     `gorm:"column:alasan_pengganti;size:255" json:"alasan_pengganti"`
+	PenggantiDisetujuiOleh string     `gorm:"column:pengganti_disetujui_oleh;size:100" json:"pengganti_disetujui_oleh"`
+	PenggantiDisetujuiPada *time.Time `gorm:"column:pengganti_disetujui_pada" json:"pengganti_disetujui_pada"`
+
 	// Relasi
 	Jadwal Jadwal `gorm:"foreignKey:IDJadwal;references:IDJadwal" json:"jadwal,omitempty"`
 }
 
+// JadwalPengganti - pertemuan yang melibatkan guru pengganti, dilihat dari sisi guru asli maupun pengganti
+type JadwalPengganti struct {
+	IDPertemuan            int        `json:"id_pertemuan"`
+	JadwalID               int        `json:"jadwal_id"`
+	PertemuanKe            int        `json:"pertemuan_ke"`
+	Tanggal                string     `json:"tanggal"`
+	NamaMapel              string     `json:"nama_mapel"`
+	NamaKelas              string     `json:"nama_kelas"`
+	Hari                   string     `json:"hari"`
+	WaktuMulai             string     `json:"waktu_mulai"`
+	WaktuSelesai           string     `json:"waktu_selesai"`
+	Ruang                  string     `json:"ruang"`
+	GuruID                 int        `json:"guru_id"`
+	NamaGuru               string     `json:"nama_guru"`
+	GuruPenggantiID        int        `json:"guru_pengganti_id"`
+	NamaGuruPengganti      string     `json:"nama_guru_pengganti"`
+	AlasanPengganti        string     `json:"alasan_pengganti"`
+	PenggantiDisetujuiOleh string     `json:"pengganti_disetujui_oleh"`
+	PenggantiDisetujuiPada *time.Time `json:"pengganti_disetujui_pada"`
+	Peran                  string     `json:"peran"`
+}
+
 // TableName method untuk menentukan nama tabel yang benar
 func (Pertemuan) TableName() string {
 	return "pertemuan"
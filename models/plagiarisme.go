@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// SidikFile model - hasil analisis file jawaban sebuah pengumpulan (hash dan tanda tangan MinHash)
+type SidikFile struct {
+	PengumpulanID    int    `gorm:"column:pengumpulan_id;primaryKey;autoIncrement:false" json:"pengumpulan_id"`
+	TugasID          int    `gorm:"column:tugas_id;not null" json:"tugas_id"`
+	SiswaID          int    `gorm:"column:siswa_id;not null" json:"siswa_id"`
+	FileJawabanSiswa string `gorm:"column:file_jawaban_siswa;size:255;not null" json:"file_jawaban_siswa"`
+	HashSHA256       string `gorm:"column:hash_sha256;size:64" json:"hash_sha256"`
+	JumlahKata       int    `gorm:"column:jumlah_kata;default:0" json:"jumlah_kata"`
+	// Berhasil, TidakDidukung (gambar/arsip), Gagal (file hilang atau rusak)
+	StatusEkstraksi string    `gorm:"column:status_ekstraksi;size:20;not null" json:"status_ekstraksi"`
+	TandaTangan     string    `gorm:"column:tanda_tangan;type:text" json:"-"` // MinHash (hex)
+	DianalisisPada  time.Time `gorm:"column:dianalisis_pada;not null" json:"dianalisis_pada"`
+}
+
+// KemiripanPengumpulan model - pasangan pengumpulan antar siswa berbeda yang identik atau mirip.
+// Satu baris per pasangan dengan pengumpulan_id < pengumpulan_pembanding_id
+type KemiripanPengumpulan struct {
+	KemiripanID             int       `gorm:"column:kemiripan_id;primaryKey;autoIncrement" json:"kemiripan_id"`
+	TugasID                 int       `gorm:"column:tugas_id;not null" json:"tugas_id"`
+	PengumpulanID           int       `gorm:"column:pengumpulan_id;not null" json:"pengumpulan_id"`
+	PengumpulanPembandingID int       `gorm:"column:pengumpulan_pembanding_id;not null" json:"pengumpulan_pembanding_id"`
+	Jenis                   string    `gorm:"column:jenis;type:enum('Identik','Mirip');not null" json:"jenis"`
+	Skor                    float64   `gorm:"column:skor;type:decimal(5,2);not null" json:"skor"` // persen kemiripan
+	CreatedAt               time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (SidikFile) TableName() string {
+	return "sidikfile"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (KemiripanPengumpulan) TableName() string {
+	return "kemiripanpengumpulan"
+}
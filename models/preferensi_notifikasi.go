@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// PreferensiNotifikasi model - urutan kanal notifikasi pilihan siswa/guru beserta alamat tambahan
+type PreferensiNotifikasi struct {
+	TipeUser       string    `gorm:"column:tipe_user;primaryKey;type:enum('Siswa','Guru')" json:"tipe_user"`
+	UserID         int       `gorm:"column:user_id;primaryKey;autoIncrement:false" json:"user_id"`
+	UrutanKanal    string    `gorm:"column:urutan_kanal;size:100;not null" json:"urutan_kanal"` // Kanal dipisah koma, misal "whatsapp,email"
+	SimpanInApp    bool      `gorm:"column:simpan_inapp;not null" json:"simpan_inapp"`
+	TelegramChatID string    `gorm:"column:telegram_chat_id;size:64" json:"telegram_chat_id"`
+	Bahasa         string    `gorm:"column:bahasa;size:5;not null" json:"bahasa"` // Bahasa template pesan (id/en)
+	UpdatedAt      time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (PreferensiNotifikasi) TableName() string {
+	return "preferensinotifikasi"
+}
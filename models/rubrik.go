@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Rubrik model - rubrik penilaian yang melekat pada satu tugas
+type Rubrik struct {
+	RubrikID  int       `gorm:"column:rubrik_id;primaryKey;autoIncrement" json:"rubrik_id"`
+	TugasID   int       `gorm:"column:tugas_id;not null;unique" json:"tugas_id"`
+	Judul     string    `gorm:"column:judul;size:255" json:"judul"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Kriteria []KriteriaRubrik `gorm:"foreignKey:RubrikID;references:RubrikID" json:"kriteria,omitempty"`
+}
+
+// KriteriaRubrik model - satu kriteria (baris) dalam rubrik
+type KriteriaRubrik struct {
+	KriteriaID   int    `gorm:"column:kriteria_id;primaryKey;autoIncrement" json:"kriteria_id"`
+	RubrikID     int    `gorm:"column:rubrik_id;not null" json:"rubrik_id"`
+	NamaKriteria string `gorm:"column:nama_kriteria;size:255;not null" json:"nama_kriteria"`
+	Deskripsi    string `gorm:"column:deskripsi;type:text" json:"deskripsi"`
+	Urutan       int    `gorm:"column:urutan;default:0" json:"urutan"`
+
+	// Relasi
+	Level []LevelRubrik `gorm:"foreignKey:KriteriaID;references:KriteriaID" json:"level,omitempty"`
+}
+
+// LevelRubrik model - tingkat capaian pada sebuah kriteria beserta poinnya
+type LevelRubrik struct {
+	LevelID    int    `gorm:"column:level_id;primaryKey;autoIncrement" json:"level_id"`
+	KriteriaID int    `gorm:"column:kriteria_id;not null" json:"kriteria_id"`
+	NamaLevel  string `gorm:"column:nama_level;size:100;not null" json:"nama_level"`
+	Deskripsi  string `gorm:"column:deskripsi;type:text" json:"deskripsi"`
+	Poin       int    `gorm:"column:poin;not null" json:"poin"`
+	Urutan     int    `gorm:"column:urutan;default:0" json:"urutan"`
+}
+
+// PenilaianRubrik model - level yang dipilih guru per kriteria untuk satu pengumpulan
+type PenilaianRubrik struct {
+	PenilaianID   int       `gorm:"column:penilaian_id;primaryKey;autoIncrement" json:"penilaian_id"`
+	PengumpulanID int       `gorm:"column:pengumpulan_id;not null" json:"pengumpulan_id"`
+	KriteriaID    int       `gorm:"column:kriteria_id;not null" json:"kriteria_id"`
+	LevelID       int       `gorm:"column:level_id;not null" json:"level_id"`
+	Poin          int       `gorm:"column:poin;not null" json:"poin"`
+	Komentar      string    `gorm:"column:komentar;type:text" json:"komentar"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Relasi
+	Kriteria KriteriaRubrik `gorm:"foreignKey:KriteriaID;references:KriteriaID" json:"kriteria,omitempty"`
+	Level    LevelRubrik    `gorm:"foreignKey:LevelID;references:LevelID" json:"level,omitempty"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (Rubrik) TableName() string {
+	return "rubrik"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (KriteriaRubrik) TableName() string {
+	return "kriteriarubrik"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (LevelRubrik) TableName() string {
+	return "levelrubrik"
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (PenilaianRubrik) TableName() string {
+	return "penilaianrubrik"
+}
@@ -52,6 +52,8 @@ type SiswaProfile struct {
 	PoinMotivasi    int       `json:"poin_motivasi"`
 	TingkatDisiplin string    `json:"tingkat_disiplin"`
 	FotoProfil      string    `json:"foto_profil"`
+	// URL avatar per ukuran ("256", "128", "64")
+	FotoProfilUkuran map[string]string `json:"foto_profil_ukuran,omitempty"`
 
 	// Relasi
 	Kelas Kelas `json:"kelas,omitempty"`
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TemplateNotifikasi model - template pesan notifikasi (Go text/template) yang diubah admin untuk satu
+// jenis notifikasi, kanal dan bahasa; menggantikan template bawaan
+type TemplateNotifikasi struct {
+	TemplateID int       `gorm:"column:template_id;primaryKey;autoIncrement" json:"template_id"`
+	Jenis      string    `gorm:"column:jenis;size:30;not null" json:"jenis"`
+	Kanal      string    `gorm:"column:kanal;size:20;not null" json:"kanal"`
+	Bahasa     string    `gorm:"column:bahasa;size:5;not null" json:"bahasa"`
+	Judul      string    `gorm:"column:judul;size:255;not null" json:"judul"`
+	Isi        string    `gorm:"column:isi;type:text;not null" json:"isi"`
+	DiubahOleh string    `gorm:"column:diubah_oleh;size:100" json:"diubah_oleh"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (TemplateNotifikasi) TableName() string {
+	return "templatenotifikasi"
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// TemplateTugas model - tugas tersimpan milik guru yang dapat dipakai ulang pada jadwal mana pun.
+// Tanggal (deadline, tutup, terbit) ditentukan saat template dipakai
+type TemplateTugas struct {
+	TemplateID            int     `gorm:"column:template_id;primaryKey;autoIncrement" json:"template_id"`
+	GuruID                int     `gorm:"column:guru_id;not null" json:"guru_id"`
+	NamaTemplate          string  `gorm:"column:nama_template;size:255;not null" json:"nama_template"`
+	JudulTugas            string  `gorm:"column:judul_tugas;size:255;not null" json:"judul_tugas"`
+	DeskripsiTugas        string  `gorm:"column:deskripsi_tugas;type:text" json:"deskripsi_tugas"`
+	FileTugasGuru         string  `gorm:"column:file_tugas_guru;size:255" json:"file_tugas_guru"`
+	PoinMaksimal          int     `gorm:"column:poin_maksimal;default:100" json:"poin_maksimal"`
+	TipeTugas             string  `gorm:"column:tipe_tugas;type:enum('Individu','Kelompok');default:'Individu'" json:"tipe_tugas"`
+	MaksPengumpulanUlang  *int    `gorm:"column:maks_pengumpulan_ulang" json:"maks_pengumpulan_ulang"`
+	TolakSetelahDinilai   bool    `gorm:"column:tolak_setelah_dinilai;default:false" json:"tolak_setelah_dinilai"`
+	GracePeriodMenit      int     `gorm:"column:grace_period_menit;default:0" json:"grace_period_menit"`
+	PenaltiPersenPerHari  float64 `gorm:"column:penalti_persen_per_hari;type:decimal(5,2);default:0" json:"penalti_persen_per_hari"`
+	PenaltiMaksimalPersen float64 `gorm:"column:penalti_maksimal_persen;type:decimal(5,2);default:100" json:"penalti_maksimal_persen"`
+	// Salinan rubrik (JSON) yang ikut dibuat saat template dipakai
+	RubrikJSON string    `gorm:"column:rubrik_json;type:text" json:"-"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Rubrik hasil decode RubrikJSON, diisi saat template diambil
+	Rubrik *Rubrik `gorm:"-" json:"rubrik,omitempty"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (TemplateTugas) TableName() string {
+	return "templatetugas"
+}
@@ -4,20 +4,37 @@ import "time"
 
 // Tugas model
 type Tugas struct {
-    TugasID              int       `gorm:"column:tugas_id;primaryKey;autoIncrement" json:"tugas_id"`
-    JadwalID             int       `gorm:"column:jadwal_id;not null" json:"jadwal_id"`
-    JudulTugas           string    `gorm:"column:judul_tugas;size:255;not null" json:"judul_tugas"`
-    DeskripsiTugas       string    `gorm:"column:deskripsi_tugas;type:text" json:"deskripsi_tugas"`
-    FileTugasGuru        string    `gorm:"column:file_tugas_guru;size:255" json:"file_tugas_guru"`
-    TanggalDibuat        time.Time `gorm:"column:tanggal_dibuat;autoCreateTime" json:"tanggal_dibuat"`
-    DeadlinePengumpulan  time.Time `gorm:"column:deadline_pengumpulan;not null" json:"deadline_pengumpulan"`
-    PoinMaksimal         int       `gorm:"column:poin_maksimal;default:100" json:"poin_maksimal"`
-    TipeTugas            string    `gorm:"column:tipe_tugas;type:enum('Individu','Kelompok');default:'Individu'" json:"tipe_tugas"`
-    CreatedAt            time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-    UpdatedAt            time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+    TugasID               int        `gorm:"column:tugas_id;primaryKey;autoIncrement" json:"tugas_id"`
+    JadwalID              int        `gorm:"column:jadwal_id;not null" json:"jadwal_id"`
+    JudulTugas            string     `gorm:"column:judul_tugas;size:255;not null" json:"judul_tugas"`
+    DeskripsiTugas        string     `gorm:"column:deskripsi_tugas;type:text" json:"deskripsi_tugas"`
+    FileTugasGuru         string     `gorm:"column:file_tugas_guru;size:255" json:"file_tugas_guru"`
+    TanggalDibuat         time.Time  `gorm:"column:tanggal_dibuat;autoCreateTime" json:"tanggal_dibuat"`
+    DeadlinePengumpulan   time.Time  `gorm:"column:deadline_pengumpulan;not null" json:"deadline_pengumpulan"`
+    PoinMaksimal          int        `gorm:"column:poin_maksimal;default:100" json:"poin_maksimal"`
+    TipeTugas             string     `gorm:"column:tipe_tugas;type:enum('Individu','Kelompok');default:'Individu'" json:"tipe_tugas"`
+    // Kebijakan pengumpulan ulang: nil = tanpa batas, 0 = tidak boleh mengumpulkan ulang
+    MaksPengumpulanUlang  *int       `gorm:"column:maks_pengumpulan_ulang" json:"maks_pengumpulan_ulang"`
+    TolakSetelahDinilai   bool       `gorm:"column:tolak_setelah_dinilai;default:false" json:"tolak_setelah_dinilai"`
+    // Kebijakan keterlambatan: masa tenggang, batas akhir mutlak dan penalti per hari
+    GracePeriodMenit      int        `gorm:"column:grace_period_menit;default:0" json:"grace_period_menit"`
+    TanggalTutup          *time.Time `gorm:"column:tanggal_tutup" json:"tanggal_tutup"`
+    PenaltiPersenPerHari  float64    `gorm:"column:penalti_persen_per_hari;type:decimal(5,2);default:0" json:"penalti_persen_per_hari"`
+    PenaltiMaksimalPersen float64    `gorm:"column:penalti_maksimal_persen;type:decimal(5,2);default:100" json:"penalti_maksimal_persen"`
+    // Publikasi terjadwal: nil = langsung terlihat, selain itu tersembunyi dari siswa sampai waktu ini
+    TanggalTerbit         *time.Time `gorm:"column:tanggal_terbit" json:"tanggal_terbit"`
+    // Batas ukuran file jawaban (MB) untuk upload bertahap: nil = batas default 10 MB
+    MaksUkuranFileMB      *int       `gorm:"column:maks_ukuran_file_mb" json:"maks_ukuran_file_mb"`
+    // Waktu notifikasi "tugas baru" dikirim ke kelas: nil = belum (tugas terjadwal yang belum terbit)
+    NotifikasiTerbitAt    *time.Time `gorm:"column:notifikasi_terbit_at" json:"-"`
+    CreatedAt             time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+    UpdatedAt             time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
 
     // Relasi belongs to - HANYA INI YANG BOLEH
     JadwalPelajaran JadwalPelajaran `gorm:"foreignKey:JadwalID;references:JadwalID" json:"jadwal_pelajaran,omitempty"`
+
+    // Rubrik penilaian (opsional, has-one)
+    Rubrik *Rubrik `gorm:"foreignKey:TugasID;references:TugasID" json:"rubrik,omitempty"`
     
     // HAPUS atau JANGAN PAKAI foreignKey di relasi has-many ini:
     // PengumpulanTugas []PengumpulanTugas `gorm:"foreignKey:TugasID" json:"pengumpulan_tugas,omitempty"`
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// UploadBertahap model - sesi upload file besar yang dikirim per potongan (chunk) dan dapat dilanjutkan
+type UploadBertahap struct {
+	UploadID      string     `gorm:"column:upload_id;primaryKey;size:36" json:"upload_id"`
+	TipeUser      string     `gorm:"column:tipe_user;type:enum('Siswa','Guru','Admin');not null" json:"tipe_user"`
+	UserID        int        `gorm:"column:user_id;not null" json:"user_id"`
+	NamaFile      string     `gorm:"column:nama_file;size:255;not null" json:"nama_file"`
+	Ukuran        int64      `gorm:"column:ukuran;not null" json:"ukuran"`
+	Diterima      int64      `gorm:"column:diterima;default:0" json:"diterima"`
+	Checksum      string     `gorm:"column:checksum;size:150;not null" json:"checksum"`
+	TugasID       *int       `gorm:"column:tugas_id" json:"tugas_id"`
+	Tujuan        string     `gorm:"column:tujuan;size:30;default:Lainnya" json:"tujuan"`
+	Status        string     `gorm:"column:status;type:enum('Berjalan','Selesai','Gagal');default:Berjalan" json:"status"`
+	URLHasil      string     `gorm:"column:url_hasil;size:255" json:"url_hasil,omitempty"`
+	Pesan         string     `gorm:"column:pesan;size:255" json:"pesan,omitempty"`
+	Kedaluwarsa   time.Time  `gorm:"column:kedaluwarsa;not null" json:"kedaluwarsa"`
+	DikunciSampai *time.Time `gorm:"column:dikunci_sampai" json:"-"`
+	CreatedAt     time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (UploadBertahap) TableName() string {
+	return "uploadbertahap"
+}
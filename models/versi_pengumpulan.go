@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// VersiPengumpulan model - setiap percobaan pengumpulan disimpan sebagai versi tersendiri
+type VersiPengumpulan struct {
+	VersiID            int       `gorm:"column:versi_id;primaryKey;autoIncrement" json:"versi_id"`
+	PengumpulanID      int       `gorm:"column:pengumpulan_id;not null" json:"pengumpulan_id"`
+	NomorVersi         int       `gorm:"column:nomor_versi;not null" json:"nomor_versi"`
+	FileJawabanSiswa   string    `gorm:"column:file_jawaban_siswa;size:255" json:"file_jawaban_siswa"`
+	CatatanSiswa       string    `gorm:"column:catatan_siswa;type:text" json:"catatan_siswa"`
+	TanggalPengumpulan time.Time `gorm:"column:tanggal_pengumpulan;not null" json:"tanggal_pengumpulan"`
+	StatusPengumpulan  string    `gorm:"column:status_pengumpulan;type:enum('Mengerjakan','Terlambat');default:'Mengerjakan'" json:"status_pengumpulan"`
+	IsCurrent          bool      `gorm:"column:is_current;default:false" json:"is_current"`
+	CreatedAt          time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// TableName method untuk menentukan nama tabel yang benar
+func (VersiPengumpulan) TableName() string {
+	return "versipengumpulan"
+}
@@ -47,6 +47,36 @@ func AdminRoutes(r *mux.Router) {
 	adminProtected.HandleFunc("/upload-jadwal", controllers.UploadJadwalData).Methods("POST")
 		// Admin upload jadwal pelajaran (CSV)
 	adminProtected.HandleFunc("/upload-jadwal-csv", controllers.UploadJadwalCSV).Methods("POST")
+	// Guru pengganti per pertemuan
+	adminProtected.HandleFunc("/pertemuan/pengganti", controllers.GetDaftarPengganti).Methods("GET")
+	adminProtected.HandleFunc("/pertemuan/{id}/pengganti", controllers.SetGuruPengganti).Methods("PUT")
+	adminProtected.HandleFunc("/pertemuan/{id}/pengganti", controllers.DeleteGuruPengganti).Methods("DELETE")
+	
+	// File upload yang dikarantina oleh pemindai malware
+	adminProtected.HandleFunc("/uploads/karantina", controllers.GetFileKarantina).Methods("GET")
+	adminProtected.HandleFunc("/uploads/karantina/{file_id}", controllers.DeleteFileKarantina).Methods("DELETE")
+	adminProtected.HandleFunc("/uploads/karantina/{file_id}/lepas", controllers.LepasFileKarantina).Methods("POST")
+	// Laporan file upload yatim yang akan dihapus cron pembersihan (dry run)
+	adminProtected.HandleFunc("/uploads/yatim", controllers.GetLaporanUploadYatim).Methods("GET")
+	
+	// Pengaturan reminder deadline (tingkat aktif, lewat deadline, jam tenang)
+	adminProtected.HandleFunc("/pengaturan/reminder", controllers.GetPengaturanReminder).Methods("GET")
+	adminProtected.HandleFunc("/pengaturan/reminder", controllers.UpdatePengaturanReminder).Methods("PUT")
+	
+	// Template pesan reminder per jenis, kanal dan bahasa
+	adminProtected.HandleFunc("/template-notifikasi", controllers.GetTemplateNotifikasi).Methods("GET")
+	adminProtected.HandleFunc("/template-notifikasi/preview", controllers.PreviewTemplateNotifikasi).Methods("POST")
+	adminProtected.HandleFunc("/template-notifikasi/{jenis}/{kanal}/{bahasa}", controllers.UpdateTemplateNotifikasi).Methods("PUT")
+	adminProtected.HandleFunc("/template-notifikasi/{jenis}/{kanal}/{bahasa}", controllers.DeleteTemplateNotifikasi).Methods("DELETE")
+	
+	// Outbox reminder: antrean, percobaan ulang dan dead letter
+	adminProtected.HandleFunc("/notifikasi/outbox", controllers.GetOutboxNotifikasi).Methods("GET")
+	adminProtected.HandleFunc("/notifikasi/outbox/{outbox_id}/ulang", controllers.UlangOutboxNotifikasi).Methods("POST")
+	
+	// Statistik pengiriman reminder dan trigger manual cron notifikasi
+	adminProtected.HandleFunc("/notifications/stats", controllers.GetNotificationStats).Methods("GET")
+	adminProtected.HandleFunc("/notifications/trigger", controllers.TriggerNotificationTest).Methods("POST")
+	
 	// Analytics dashboard
 	adminProtected.HandleFunc("/analytics/dashboard", controllers.GetAnalyticsDashboard).Methods("GET")
 	
@@ -15,12 +15,29 @@ func GuruRoutes(r *mux.Router) {
 		// Profile routes
 	router.HandleFunc("/profile", controllers.GetGuruProfile).Methods("GET")
 	router.HandleFunc("/profile/password", controllers.UpdateGuruPassword).Methods("PUT")
+	router.HandleFunc("/notifikasi/preferensi", controllers.GetPreferensiNotifikasi).Methods("GET")
+	router.HandleFunc("/notifikasi/preferensi", controllers.UpdatePreferensiNotifikasi).Methods("PUT")
+	router.HandleFunc("/notifikasi/preferensi/tes", controllers.TesPreferensiNotifikasi).Methods("POST")
 	router.HandleFunc("/jadwalMengajar", controllers.GetDaftarMengajar).Methods("GET")
+	router.HandleFunc("/jadwalPengganti", controllers.GetJadwalPengganti).Methods("GET")
 	router.HandleFunc("/pertemuan", controllers.GetAbsensiSiswaPertemuan).Methods("GET")
 	router.HandleFunc("/absensi", controllers.GetDetailAbsensiSiswa).Methods("GET")
 		// Pertemuan management routes
 	router.HandleFunc("/pertemuan/{id}", controllers.UpdatePertemuan).Methods("PUT")
 	router.HandleFunc("/pertemuan/{id}/status", controllers.UpdateStatusPertemuan).Methods("PUT")
+	router.HandleFunc("/pertemuan/{id}/pengganti", controllers.AjukanGuruPengganti).Methods("POST")
+
+	// Materi pembelajaran per pertemuan
+	router.HandleFunc("/pertemuan/{id}/materi", controllers.GetMateriPertemuanGuru).Methods("GET")
+	router.HandleFunc("/pertemuan/{id}/materi/seksi", controllers.CreateSeksiMateri).Methods("POST")
+	router.HandleFunc("/pertemuan/{id}/materi/urutan", controllers.UrutkanSeksiMateri).Methods("PUT")
+	router.HandleFunc("/materi/seksi/{seksi_id}", controllers.UpdateSeksiMateri).Methods("PUT")
+	router.HandleFunc("/materi/seksi/{seksi_id}", controllers.DeleteSeksiMateri).Methods("DELETE")
+	router.HandleFunc("/materi/seksi/{seksi_id}/urutan", controllers.UrutkanItemMateri).Methods("PUT")
+	router.HandleFunc("/materi/seksi/{seksi_id}/item", controllers.CreateItemMateri).Methods("POST")
+	router.HandleFunc("/materi/item/{item_id}", controllers.UpdateItemMateri).Methods("PUT")
+	router.HandleFunc("/materi/item/{item_id}", controllers.DeleteItemMateri).Methods("DELETE")
+	router.HandleFunc("/materi/item/{item_id}/akses", controllers.GetAksesItemMateri).Methods("GET")
 	
 	// Absensi management routes
 	router.HandleFunc("/absensi/{id}/status", controllers.UpdateStatusAbsensi).Methods("PUT")
@@ -37,9 +54,55 @@ func GuruRoutes(r *mux.Router) {
 	router.HandleFunc("/tugas/{tugas_id}", controllers.DeleteTugas).Methods("DELETE")
 	router.HandleFunc("/tugas/{tugas_id}/detail", controllers.GetTugasDetail).Methods("GET")
 	router.HandleFunc("/tugas/{tugas_id}/pengumpulan", controllers.GetPengumpulanByTugas).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/pengumpulan", controllers.BulkUpdatePoints).Methods("PUT")
+	router.HandleFunc("/tugas/{tugas_id}/pengumpulan/archive", controllers.DownloadArsipPengumpulan).Methods("GET")
 	router.HandleFunc("/tugas/pengumpulan/{pengumpulan_id}/poin", controllers.UpdateStudentPoints).Methods("PUT")
+	router.HandleFunc("/tugas/pengumpulan/{pengumpulan_id}/versi", controllers.GetVersiPengumpulanGuru).Methods("GET")
+	router.HandleFunc("/tugas/pengumpulan/{pengumpulan_id}/rubrik", controllers.NilaiDenganRubrik).Methods("PUT")
+	router.HandleFunc("/tugas/{tugas_id}/rubrik", controllers.GetRubrikTugas).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/rubrik", controllers.SetRubrikTugas).Methods("PUT")
+	router.HandleFunc("/tugas/{tugas_id}/rubrik", controllers.DeleteRubrikTugas).Methods("DELETE")
+	router.HandleFunc("/tugas/{tugas_id}/perpanjangan", controllers.GetPerpanjanganDeadline).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/perpanjangan", controllers.SetPerpanjanganDeadline).Methods("POST")
+	router.HandleFunc("/tugas/{tugas_id}/perpanjangan/{siswa_id}", controllers.DeletePerpanjanganDeadline).Methods("DELETE")
+
+	// Salin tugas dan template tugas
+	router.HandleFunc("/tugas/{tugas_id}/salin", controllers.SalinTugas).Methods("POST")
+	router.HandleFunc("/template-tugas", controllers.GetTemplateTugas).Methods("GET")
+	router.HandleFunc("/template-tugas", controllers.CreateTemplateTugas).Methods("POST")
+	router.HandleFunc("/template-tugas/{template_id}", controllers.DeleteTemplateTugas).Methods("DELETE")
+	router.HandleFunc("/template-tugas/{template_id}/gunakan", controllers.GunakanTemplateTugas).Methods("POST")
+
+	// Thread komentar pengumpulan
+	router.HandleFunc("/tugas/pengumpulan/{pengumpulan_id}/komentar", controllers.GetKomentarPengumpulanGuru).Methods("GET")
+	router.HandleFunc("/tugas/pengumpulan/{pengumpulan_id}/komentar", controllers.KirimKomentarGuru).Methods("POST")
+	router.HandleFunc("/komentar/belum-dibaca", controllers.GetKomentarBelumDibacaGuru).Methods("GET")
+	router.HandleFunc("/komentar/{komentar_id}", controllers.DeleteKomentarGuru).Methods("DELETE")
+
+	// Deteksi plagiarisme
+	router.HandleFunc("/tugas/{tugas_id}/plagiarisme", controllers.GetLaporanPlagiarisme).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/plagiarisme/analisis", controllers.AnalisisPlagiarisme).Methods("POST")
 	
-	// Notification routes (admin only - untuk testing)
-	router.HandleFunc("/notifications/trigger", controllers.TriggerNotificationTest).Methods("POST")
-	router.HandleFunc("/notifications/stats", controllers.GetNotificationStats).Methods("GET")
+	// Kuis online routes
+	router.HandleFunc("/kuis", controllers.CreateKuis).Methods("POST")
+	router.HandleFunc("/kuis/jadwal/{jadwal_id}", controllers.GetKuisByJadwal).Methods("GET")
+	router.HandleFunc("/kuis/{kuis_id}", controllers.GetKuisDetailGuru).Methods("GET")
+	router.HandleFunc("/kuis/{kuis_id}", controllers.DeleteKuis).Methods("DELETE")
+	router.HandleFunc("/kuis/{kuis_id}/soal", controllers.UpdateSoalKuis).Methods("PUT")
+	router.HandleFunc("/kuis/{kuis_id}/percobaan", controllers.GetPercobaanKuis).Methods("GET")
+	router.HandleFunc("/kuis/jawaban/{jawaban_id}/nilai", controllers.NilaiJawabanKuis).Methods("PUT")
+
+	// Bank soal per mata pelajaran
+	router.HandleFunc("/bank-soal/{mapel_id}", controllers.GetBankSoal).Methods("GET")
+	router.HandleFunc("/bank-soal/{mapel_id}", controllers.CreateBankSoal).Methods("POST")
+	router.HandleFunc("/bank-soal/{mapel_id}/export", controllers.ExportBankSoal).Methods("GET")
+	router.HandleFunc("/bank-soal/{mapel_id}/import", controllers.ImportBankSoal).Methods("POST")
+	router.HandleFunc("/bank-soal/soal/{bank_soal_id}", controllers.UpdateBankSoal).Methods("PUT")
+	router.HandleFunc("/bank-soal/soal/{bank_soal_id}", controllers.DeleteBankSoal).Methods("DELETE")
+
+	// Kelompok tugas routes
+	router.HandleFunc("/tugas/{tugas_id}/kelompok", controllers.GetKelompokTugas).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/kelompok", controllers.SetKelompokTugas).Methods("POST")
+	router.HandleFunc("/tugas/{tugas_id}/kelompok/acak", controllers.GenerateKelompokAcak).Methods("POST")
+	router.HandleFunc("/tugas/kelompok/{kelompok_id}/poin", controllers.NilaiKelompok).Methods("PUT")
 }
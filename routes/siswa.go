@@ -12,6 +12,11 @@ func SiswaRoutes(r *mux.Router) {
 	router.Use(middleware.Auth)	// Profile endpoints
 	router.HandleFunc("/me", controllers.Me).Methods("GET")
 	router.HandleFunc("/profile", controllers.UpdateSiswaProfile).Methods("PUT")
+	router.HandleFunc("/profile/foto", controllers.UploadFotoProfilSiswa).Methods("POST")
+	router.HandleFunc("/profile/foto", controllers.DeleteFotoProfilSiswa).Methods("DELETE")
+	router.HandleFunc("/notifikasi/preferensi", controllers.GetPreferensiNotifikasi).Methods("GET")
+	router.HandleFunc("/notifikasi/preferensi", controllers.UpdatePreferensiNotifikasi).Methods("PUT")
+	router.HandleFunc("/notifikasi/preferensi/tes", controllers.TesPreferensiNotifikasi).Methods("POST")
 	
 	// Dashboard endpoints
 	router.HandleFunc("/tugas/mendekati-deadline", controllers.GetTugasMendekatiDeadline).Methods("GET")
@@ -23,4 +28,21 @@ func SiswaRoutes(r *mux.Router) {
 	router.HandleFunc("/tugas/{tugas_id}/submit", controllers.SubmitTugas).Methods("POST")
 	router.HandleFunc("/tugas/{tugas_id}/detail", controllers.GetDetailPengumpulan).Methods("GET")
 	router.HandleFunc("/tugas/{tugas_id}/submit", controllers.DeletePengumpulan).Methods("DELETE")
+	router.HandleFunc("/tugas/{tugas_id}/kelompok", controllers.GetKelompokSaya).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/versi", controllers.GetVersiPengumpulanSiswa).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/komentar", controllers.GetKomentarPengumpulanSiswa).Methods("GET")
+	router.HandleFunc("/tugas/{tugas_id}/komentar", controllers.KirimKomentarSiswa).Methods("POST")
+	router.HandleFunc("/komentar/belum-dibaca", controllers.GetKomentarBelumDibacaSiswa).Methods("GET")
+	router.HandleFunc("/komentar/{komentar_id}", controllers.DeleteKomentarSiswa).Methods("DELETE")
+
+	// Materi pembelajaran endpoints for siswa
+	router.HandleFunc("/materi", controllers.GetMateriSiswa).Methods("GET")
+	router.HandleFunc("/materi/pertemuan/{pertemuan_id}", controllers.GetMateriPertemuanSiswa).Methods("GET")
+	router.HandleFunc("/materi/item/{item_id}/buka", controllers.BukaItemMateri).Methods("POST")
+
+	// Kuis online endpoints for siswa
+	router.HandleFunc("/kuis/{kuis_id}", controllers.GetKuisSiswa).Methods("GET")
+	router.HandleFunc("/kuis/{kuis_id}/mulai", controllers.MulaiKuis).Methods("POST")
+	router.HandleFunc("/kuis/{kuis_id}/jawaban", controllers.SimpanJawabanKuis).Methods("PUT")
+	router.HandleFunc("/kuis/{kuis_id}/selesai", controllers.SelesaiKuis).Methods("POST")
 }
\ No newline at end of file
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"Pasti/helpers"
+)
+
+// LocalStorage - File disimpan di disk lokal di bawah direktori root (default "uploads")
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage - Storage disk lokal dengan root dir
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{root: root}
+}
+
+func (s *LocalStorage) path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	tujuan := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(tujuan), 0755); err != nil {
+		return err
+	}
+
+	// Tulis ke file sementara lalu rename agar pembaca tidak melihat file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(tujuan), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), tujuan)
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	if err := validKey(key); err != nil {
+		return nil, Info{}, err
+	}
+
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, Info{}, ErrNotFound
+		}
+		return nil, Info{}, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, Info{}, err
+	}
+	return file, infoLokal(key, stat), nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (Info, error) {
+	if err := validKey(key); err != nil {
+		return Info{}, err
+	}
+
+	stat, err := os.Stat(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Info{}, ErrNotFound
+		}
+		return Info{}, err
+	}
+	return infoLokal(key, stat), nil
+}
+
+// SignedURL - Tautan /uploads/<key> bertanda tangan yang diverifikasi oleh ServeProtectedFile
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, durasi time.Duration) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return helpers.TautanFileBertandaTangan("/uploads/"+key, durasi), nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	return filepath.WalkDir(s.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == s.root {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		stat, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(infoLokal(key, stat))
+	})
+}
+
+func infoLokal(key string, stat fs.FileInfo) Info {
+	return Info{
+		Key:         key,
+		Size:        stat.Size(),
+		ModTime:     stat.ModTime(),
+		ContentType: mime.TypeByExtension(strings.ToLower(path.Ext(key))),
+	}
+}
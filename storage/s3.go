@@ -0,0 +1,400 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Config - Konfigurasi storage S3-compatible (AWS S3, MinIO, dll)
+type S3Config struct {
+	Endpoint       string // misal http://minio:9000 atau https://s3.ap-southeast-1.amazonaws.com
+	PublicEndpoint string // host yang dapat diakses browser untuk SignedURL, default = Endpoint
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool // true: endpoint/bucket/key (MinIO), false: bucket.endpoint/key
+}
+
+// S3Storage - Implementasi Storage dengan REST API S3 dan tanda tangan AWS Signature V4
+type S3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	publik   *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage - Storage S3-compatible; endpoint, bucket dan kredensial wajib diisi
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY dan S3_SECRET_KEY wajib diisi")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.PublicEndpoint == "" {
+		cfg.PublicEndpoint = cfg.Endpoint
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3_ENDPOINT tidak valid: %q", cfg.Endpoint)
+	}
+	publik, err := url.Parse(strings.TrimRight(cfg.PublicEndpoint, "/"))
+	if err != nil || publik.Host == "" {
+		return nil, fmt.Errorf("S3_PUBLIC_ENDPOINT tidak valid: %q", cfg.PublicEndpoint)
+	}
+
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		publik:   publik,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// objectURL - URL objek (atau bucket jika key kosong) sesuai gaya path/virtual host
+func (s *S3Storage) objectURL(base *url.URL, key string, query url.Values) *url.URL {
+	u := *base
+	if s.cfg.PathStyle {
+		u.Path = base.Path + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + base.Host
+		u.Path = base.Path + "/" + key
+	}
+	u.RawPath = ""
+	if query != nil {
+		u.RawQuery = encodeQuery(query)
+	}
+	return &u
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(s.endpoint, key, nil).String(), r)
+	if err != nil {
+		return err
+	}
+	// Body tanpa panjang akan dikirim chunked, yang tidak diterima S3
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	if err := validKey(key); err != nil {
+		return nil, Info{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(s.endpoint, key, nil).String(), nil)
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	return resp.Body, infoS3(key, resp.Header), nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(s.endpoint, key, nil).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (Info, error) {
+	if err := validKey(key); err != nil {
+		return Info{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(s.endpoint, key, nil).String(), nil)
+	if err != nil {
+		return Info{}, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return Info{}, err
+	}
+	resp.Body.Close()
+	return infoS3(key, resp.Header), nil
+}
+
+// SignedURL - Presigned GET URL (query string SigV4) pada S3_PUBLIC_ENDPOINT
+func (s *S3Storage) SignedURL(ctx context.Context, key string, durasi time.Duration) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+
+	detik := int(durasi / time.Second)
+	if detik < 1 || detik > 7*24*3600 {
+		return "", fmt.Errorf("durasi signed URL harus antara 1 detik dan 7 hari")
+	}
+	return s.presign(key, detik, time.Now().UTC()), nil
+}
+
+// presign - Query string SigV4 untuk GET key yang berlaku selama detik sejak now
+func (s *S3Storage) presign(key string, detik int, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(detik))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	u := s.objectURL(s.publik, key, query)
+	canonical := strings.Join([]string{
+		http.MethodGet,
+		encodePath(u.Path),
+		encodeQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, amzDate, scope, canonical))
+	u.RawQuery = encodeQuery(query)
+	return u.String()
+}
+
+// hasilListS3 - Respon XML ListObjectsV2
+type hasilListS3 struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string, fn func(Info) error) error {
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		u := s.objectURL(s.endpoint, "", query)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := s.do(req)
+		if err != nil {
+			return err
+		}
+		var hasil hasilListS3
+		err = xml.NewDecoder(resp.Body).Decode(&hasil)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, c := range hasil.Contents {
+			if err := fn(Info{Key: c.Key, Size: c.Size, ModTime: c.LastModified}); err != nil {
+				return err
+			}
+		}
+
+		if !hasil.IsTruncated || hasil.NextContinuationToken == "" {
+			return nil
+		}
+		token = hasil.NextContinuationToken
+	}
+}
+
+// errorS3 - Badan respon error S3
+type errorS3 struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do - Menandatangani dan mengirim request; status 404 menjadi ErrNotFound, status lain >= 300 menjadi error
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.signRequest(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var e errorS3
+	if body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); len(body) > 0 {
+		xml.Unmarshal(body, &e)
+	}
+	if e.Code == "NoSuchKey" {
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("s3 %s %s: %d %s %s", req.Method, req.URL.Path, resp.StatusCode, e.Code, e.Message)
+}
+
+// signRequest - Header Authorization AWS Signature V4; payload tidak di-hash (UNSIGNED-PAYLOAD)
+// agar upload dapat di-stream tanpa dibaca dua kali
+func (s *S3Storage) signRequest(req *http.Request) {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": "UNSIGNED-PAYLOAD",
+		"x-amz-date":           amzDate,
+	}
+	if ct := req.Header.Get("Content-Type"); ct != "" {
+		headers["content-type"] = ct
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		encodeQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, s.signature(now, amzDate, scope, canonical)))
+}
+
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature - HMAC string-to-sign dengan kunci turunan tanggal/region/layanan
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonical string) string {
+	hash := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	kunci := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format("20060102"))
+	kunci = hmacSHA256(kunci, s.cfg.Region)
+	kunci = hmacSHA256(kunci, "s3")
+	kunci = hmacSHA256(kunci, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(kunci, stringToSign))
+}
+
+func hmacSHA256(kunci []byte, data string) []byte {
+	mac := hmac.New(sha256.New, kunci)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode - Encoding RFC 3986 sesuai aturan SigV4 (hanya karakter unreserved yang tidak di-encode)
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func encodePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return uriEncode(p, false)
+}
+
+// encodeQuery - Query string kanonik: key terurut, key dan value di-encode
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var bagian []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			bagian = append(bagian, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(bagian, "&")
+}
+
+func infoS3(key string, header http.Header) Info {
+	size, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	modTime, _ := http.ParseTime(header.Get("Last-Modified"))
+	return Info{Key: key, Size: size, ModTime: modTime, ContentType: header.Get("Content-Type")}
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Penyimpanan file upload yang dapat diganti (disk lokal atau S3/MinIO).
+// Key berbentuk "<kategori>/<nama file>", misal "tugas/20240101_120000_<uuid>.pdf",
+// sehingga URL /uploads/<key> yang tersimpan di database tidak berubah saat backend diganti.
+
+// ErrNotFound - File dengan key tersebut tidak ada di storage
+var ErrNotFound = errors.New("file tidak ditemukan di storage")
+
+// Info - Metadata file di storage
+type Info struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+}
+
+// Storage - Operasi dasar penyimpanan file
+type Storage interface {
+	// Put menyimpan isi r (sebesar size byte) ke key, menimpa jika sudah ada
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get membuka file; pemanggil wajib menutup reader. Reader disk lokal juga io.ReadSeeker
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	// Delete menghapus file, tidak error jika file memang tidak ada
+	Delete(ctx context.Context, key string) error
+	// Stat mengambil metadata tanpa membaca isi file
+	Stat(ctx context.Context, key string) (Info, error)
+	// SignedURL membuat URL unduhan sementara yang dapat dibuka tanpa header Authorization
+	SignedURL(ctx context.Context, key string, durasi time.Duration) (string, error)
+	// List memanggil fn untuk setiap file dengan awalan prefix (dipakai perintah migrasi)
+	List(ctx context.Context, prefix string, fn func(Info) error) error
+}
+
+var (
+	defaultStorage Storage
+	defaultOnce    sync.Once
+)
+
+// Init - Menyiapkan storage default dari env STORAGE_BACKEND (local|s3); berhenti jika konfigurasi salah
+func Init() {
+	defaultOnce.Do(func() {
+		s, err := NewFromEnv(os.Getenv("STORAGE_BACKEND"))
+		if err != nil {
+			log.Fatalf("❌ Storage tidak dapat disiapkan: %v", err)
+		}
+		defaultStorage = s
+	})
+}
+
+// Default - Storage yang dipakai aplikasi (upload, serving, arsip)
+func Default() Storage {
+	Init()
+	return defaultStorage
+}
+
+// NewFromEnv - Membuat storage berdasarkan nama backend dan konfigurasi env.
+//
+//	local: STORAGE_LOCAL_DIR (default "uploads")
+//	s3:    S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY, S3_REGION (default us-east-1),
+//	       S3_PATH_STYLE (default true, untuk MinIO), S3_PUBLIC_ENDPOINT (opsional, host untuk SignedURL)
+func NewFromEnv(backend string) (Storage, error) {
+	switch strings.ToLower(backend) {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocalStorage(dir), nil
+	case "s3", "minio":
+		return NewS3Storage(S3Config{
+			Endpoint:       os.Getenv("S3_ENDPOINT"),
+			PublicEndpoint: os.Getenv("S3_PUBLIC_ENDPOINT"),
+			Region:         os.Getenv("S3_REGION"),
+			Bucket:         os.Getenv("S3_BUCKET"),
+			AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:      os.Getenv("S3_SECRET_KEY"),
+			PathStyle:      os.Getenv("S3_PATH_STYLE") != "false",
+		})
+	default:
+		return nil, fmt.Errorf("STORAGE_BACKEND tidak dikenal: %q", backend)
+	}
+}
+
+// validKey - Key tidak boleh kosong, absolut, atau keluar dari root storage
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("key storage tidak valid: %q", key)
+	}
+	for _, bagian := range strings.Split(key, "/") {
+		if bagian == "" || bagian == "." || bagian == ".." {
+			return fmt.Errorf("key storage tidak valid: %q", key)
+		}
+	}
+	return nil
+}